@@ -1,7 +1,40 @@
 package dto
 
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"time"
+)
+
 type TopUsedExcerciseDTO struct {
 	ExcerciseID   string
 	ExcerciseName string
 	Count         int
 }
+
+type SessionResponseDTO struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created"`
+	ExpiresAt time.Time `json:"expires"`
+	IsActive  bool      `json:"status"`
+}
+
+func NewSessionResponseDTO(session models.Session) *SessionResponseDTO {
+	return &SessionResponseDTO{
+		ID:        utils.GetStringIDFromObjectID(session.ID),
+		UserID:    utils.GetStringIDFromObjectID(session.UserID),
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		IsActive:  session.IsActive,
+	}
+}
+
+// SessionListFields son los campos que GET /api/admin/sessions permite ordenar y seleccionar
+var SessionListFields = PageFields{
+	"id":      {BSON: "_id", Sortable: true},
+	"user_id": {BSON: "user_id", Sortable: true},
+	"created": {BSON: "created", Sortable: true},
+	"expires": {BSON: "expires", Sortable: true},
+	"status":  {BSON: "estatus", Sortable: true},
+}
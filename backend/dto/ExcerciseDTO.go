@@ -4,6 +4,8 @@ import (
 	"AppFitness/models"
 	"AppFitness/utils"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // ExcerciseRegisterDTO
@@ -12,110 +14,299 @@ import (
 
 type ExcerciseRegisterDTO struct {
 	CreatorUserID   string
-	Name            string `json:"name" bson:"name" binding:"required"`
-	Description     string `json:"description" bson:"description" binding:"required"`
-	Category        string `json:"category" bson:"category" binding:"required"`
-	MainMuscleGroup string `json:"main_muscle_group" bson:"main_muscle_group" binding:"required"`
-	DifficultLevel  string `json:"difficult_level" bson:"difficult_level" binding:"required"`
-	Example         string `json:"example" bson:"example" binding:"required"`
-	Instructions    string `json:"instructions" bson:"instructions" binding:"required"`
+	Name            string   `json:"name" bson:"name" binding:"required"`
+	Description     string   `json:"description" bson:"description" binding:"required"`
+	Category        string   `json:"category" bson:"category" binding:"required"`
+	MainMuscleGroup string   `json:"main_muscle_group" bson:"main_muscle_group" binding:"required"`
+	DifficultLevel  string   `json:"difficult_level" bson:"difficult_level" binding:"required"`
+	Example         string   `json:"example" bson:"example" binding:"required"`
+	Instructions    string   `json:"instructions" bson:"instructions" binding:"required"`
+	Aliases         []string `json:"aliases" bson:"aliases" binding:"max=20,dive,min=2,max=60"`
+	// clasificacion opcional; los valores son claves o nombres de los vocabularios de GET /api/taxonomy
+	SecondaryMuscles []models.MuscleInvolvement `json:"secondary_muscles" bson:"secondary_muscles" binding:"max=10"`
+	Equipment        []string                   `json:"equipment" bson:"equipment" binding:"max=5"`
+	Mechanics        string                     `json:"mechanics" bson:"mechanics"`
+	Force            string                     `json:"force" bson:"force"`
+	Laterality       string                     `json:"laterality" bson:"laterality"`
 }
 
 func GetModelExcerciseRegister(excercise *ExcerciseRegisterDTO) *models.Excercise {
 	return &models.Excercise{
-		Name:            excercise.Name,
-		Description:     excercise.Description,
-		Category:        models.CategoryLevel(excercise.Category),
-		MainMuscleGroup: excercise.MainMuscleGroup,
-		DifficultLevel:  excercise.DifficultLevel,
-		Example:         excercise.Example,
-		Instructions:    excercise.Instructions,
+		Name:             excercise.Name,
+		Description:      excercise.Description,
+		Category:         models.CategoryLevel(excercise.Category),
+		MainMuscleGroup:  excercise.MainMuscleGroup,
+		DifficultLevel:   excercise.DifficultLevel,
+		Example:          excercise.Example,
+		Instructions:     excercise.Instructions,
+		Aliases:          excercise.Aliases,
+		SecondaryMuscles: excercise.SecondaryMuscles,
+		Equipment:        excercise.Equipment,
+		Mechanics:        excercise.Mechanics,
+		Force:            excercise.Force,
+		Laterality:       excercise.Laterality,
 	}
 }
 
 type ExcerciseResponseDTO struct {
-	ID              string `json:"id"`
-	Name            string
-	Description     string
-	CreatorUserID   string
-	Category        string
-	MainMuscleGroup string
-	DifficultLevel  string
-	Example         string
-	Instructions    string
+	ID               string `json:"id"`
+	Name             string
+	Description      string
+	CreatorUserID    string
+	Category         string
+	MainMuscleGroup  string
+	DifficultLevel   string
+	Example          string
+	Instructions     string
+	Aliases          []string                   `json:",omitempty"`
+	SecondaryMuscles []models.MuscleInvolvement `json:",omitempty"`
+	Equipment        []string                   `json:",omitempty"`
+	Mechanics        string                     `json:",omitempty"`
+	Force            string                     `json:",omitempty"`
+	Laterality       string                     `json:",omitempty"`
+	ExcerciseLabelsDTO
 	EditionDate     time.Time
 	EliminationDate time.Time
 	CreationDate    time.Time
+	Score           float64 `json:",omitempty"` // relevancia en las busquedas, de 0 a 1
+}
+
+// ExcerciseLabelsDTO son los nombres en el vocabulario de las claves de clasificacion, para mostrarlos sin pedir
+// GET /api/taxonomy. Un nombre queda vacio si su clave no esta en el vocabulario
+type ExcerciseLabelsDTO struct {
+	MainMuscleGroupName  string   `json:",omitempty"`
+	SecondaryMuscleNames []string `json:",omitempty"` // en el orden de SecondaryMuscles
+	EquipmentNames       []string `json:",omitempty"` // en el orden de Equipment
+	MechanicsName        string   `json:",omitempty"`
+	ForceName            string   `json:",omitempty"`
+	LateralityName       string   `json:",omitempty"`
+}
+
+// NewExcerciseLabelsDTO nombra cada clave de clasificacion del ejercicio con label
+func NewExcerciseLabelsDTO(excercise models.Excercise, label func(kind models.TaxonomyKind, key string) string) ExcerciseLabelsDTO {
+	labels := ExcerciseLabelsDTO{
+		MainMuscleGroupName: label(models.TaxonomyMuscle, excercise.MainMuscleGroup),
+		MechanicsName:       label(models.TaxonomyMechanics, excercise.Mechanics),
+		ForceName:           label(models.TaxonomyForce, excercise.Force),
+		LateralityName:      label(models.TaxonomyLaterality, excercise.Laterality),
+	}
+	for _, secondary := range excercise.SecondaryMuscles {
+		labels.SecondaryMuscleNames = append(labels.SecondaryMuscleNames, label(models.TaxonomyMuscle, secondary.Muscle))
+	}
+	for _, key := range excercise.Equipment {
+		labels.EquipmentNames = append(labels.EquipmentNames, label(models.TaxonomyEquipment, key))
+	}
+	return labels
 }
 
 func NewExcerciseResponseDTO(excercise models.Excercise) *ExcerciseResponseDTO {
 	return &ExcerciseResponseDTO{
-		ID:              utils.GetStringIDFromObjectID(excercise.ID),
-		Name:            excercise.Name,
-		Description:     excercise.Description,
-		CreatorUserID:   utils.GetStringIDFromObjectID(excercise.CreatorUserID),
-		Category:        string(excercise.Category),
-		MainMuscleGroup: excercise.MainMuscleGroup,
-		DifficultLevel:  excercise.DifficultLevel,
-		Example:         excercise.Example,
-		Instructions:    excercise.Instructions,
-		EditionDate:     excercise.EditionDate,
-		EliminationDate: excercise.EliminationDate,
-		CreationDate:    excercise.CreationDate,
+		ID:               utils.GetStringIDFromObjectID(excercise.ID),
+		Name:             excercise.Name,
+		Description:      excercise.Description,
+		CreatorUserID:    utils.GetStringIDFromObjectID(excercise.CreatorUserID),
+		Category:         string(excercise.Category),
+		MainMuscleGroup:  excercise.MainMuscleGroup,
+		DifficultLevel:   excercise.DifficultLevel,
+		Example:          excercise.Example,
+		Instructions:     excercise.Instructions,
+		Aliases:          excercise.Aliases,
+		SecondaryMuscles: excercise.SecondaryMuscles,
+		Equipment:        excercise.Equipment,
+		Mechanics:        excercise.Mechanics,
+		Force:            excercise.Force,
+		Laterality:       excercise.Laterality,
+		EditionDate:      excercise.EditionDate,
+		EliminationDate:  excercise.EliminationDate,
+		CreationDate:     excercise.CreationDate,
 	}
 }
 
 type ExcerciseModifyDTO struct {
 	ID              string
-	Name            string `json:"name" binding:"required"`
-	Description     string `json:"description" binding:"required"`
-	Category        string `json:"category" binding:"required"`
-	MainMuscleGroup string `json:"main_muscle_group" binding:"required"`
-	DifficultLevel  string `json:"difficult_level" binding:"required"`
-	Example         string `json:"example" binding:"required"`
-	Instructions    string `json:"instructions" binding:"required"`
+	Name            string   `json:"name" binding:"required"`
+	Description     string   `json:"description" binding:"required"`
+	Category        string   `json:"category" binding:"required"`
+	MainMuscleGroup string   `json:"main_muscle_group" binding:"required"`
+	DifficultLevel  string   `json:"difficult_level" binding:"required"`
+	Example         string   `json:"example" binding:"required"`
+	Instructions    string   `json:"instructions" binding:"required"`
+	Aliases         []string `json:"aliases" binding:"max=20,dive,min=2,max=60"`
+	// clasificacion opcional; los valores son claves o nombres de los vocabularios de GET /api/taxonomy
+	SecondaryMuscles []models.MuscleInvolvement `json:"secondary_muscles" binding:"max=10"`
+	Equipment        []string                   `json:"equipment" binding:"max=5"`
+	Mechanics        string                     `json:"mechanics"`
+	Force            string                     `json:"force"`
+	Laterality       string                     `json:"laterality"`
 }
 
 func GetModelExcerciseModify(excercise *ExcerciseModifyDTO) *models.Excercise {
 	return &models.Excercise{
-		Name:            excercise.Name,
-		Description:     excercise.Description,
-		Category:        models.CategoryLevel(excercise.Category),
-		MainMuscleGroup: excercise.MainMuscleGroup,
-		DifficultLevel:  excercise.DifficultLevel,
-		Example:         excercise.Example,
-		Instructions:    excercise.Instructions,
+		Name:             excercise.Name,
+		Description:      excercise.Description,
+		Category:         models.CategoryLevel(excercise.Category),
+		MainMuscleGroup:  excercise.MainMuscleGroup,
+		DifficultLevel:   excercise.DifficultLevel,
+		Example:          excercise.Example,
+		Instructions:     excercise.Instructions,
+		Aliases:          excercise.Aliases,
+		SecondaryMuscles: excercise.SecondaryMuscles,
+		Equipment:        excercise.Equipment,
+		Mechanics:        excercise.Mechanics,
+		Force:            excercise.Force,
+		Laterality:       excercise.Laterality,
 	}
 }
 
 type ExcerciseModifyResponseDTO struct {
-	Name            string
-	Description     string
-	CreatorUserID   string
-	Category        string
-	MainMuscleGroup string
-	DifficultLevel  string
-	Example         string
-	Instructions    string
-	EditionDate     time.Time
+	Name             string
+	Description      string
+	CreatorUserID    string
+	Category         string
+	MainMuscleGroup  string
+	DifficultLevel   string
+	Example          string
+	Instructions     string
+	Aliases          []string                   `json:",omitempty"`
+	SecondaryMuscles []models.MuscleInvolvement `json:",omitempty"`
+	Equipment        []string                   `json:",omitempty"`
+	Mechanics        string                     `json:",omitempty"`
+	Force            string                     `json:",omitempty"`
+	Laterality       string                     `json:",omitempty"`
+	ExcerciseLabelsDTO
+	EditionDate time.Time
 }
 
 func NewExcerciseModifyResponseDTO(excercise models.Excercise) *ExcerciseModifyResponseDTO {
 	return &ExcerciseModifyResponseDTO{
-		Name:            excercise.Name,
-		Description:     excercise.Description,
-		CreatorUserID:   utils.GetStringIDFromObjectID(excercise.CreatorUserID),
-		Category:        string(excercise.Category),
-		MainMuscleGroup: excercise.MainMuscleGroup,
-		DifficultLevel:  excercise.DifficultLevel,
-		Example:         excercise.Example,
-		Instructions:    excercise.Instructions,
-		EditionDate:     excercise.EditionDate,
+		Name:             excercise.Name,
+		Description:      excercise.Description,
+		CreatorUserID:    utils.GetStringIDFromObjectID(excercise.CreatorUserID),
+		Category:         string(excercise.Category),
+		MainMuscleGroup:  excercise.MainMuscleGroup,
+		DifficultLevel:   excercise.DifficultLevel,
+		Example:          excercise.Example,
+		Instructions:     excercise.Instructions,
+		Aliases:          excercise.Aliases,
+		SecondaryMuscles: excercise.SecondaryMuscles,
+		Equipment:        excercise.Equipment,
+		Mechanics:        excercise.Mechanics,
+		Force:            excercise.Force,
+		Laterality:       excercise.Laterality,
+		EditionDate:      excercise.EditionDate,
 	}
 }
 
+// ExerciseFilterDTO son los query params de GET /api/exercises/filter; q busca por relevancia en nombre, alias,
+// descripcion e instrucciones y se combina con los filtros de categoria y de clasificacion. Los filtros de
+// clasificacion aceptan la clave o el nombre del vocabulario y el servicio los reemplaza por la clave
 type ExerciseFilterDTO struct {
-	Name        string `json:"name,omitempty"`
-	Category    string `json:"category,omitempty"`
-	MuscleGroup string `json:"muscle_group,omitempty"`
+	Query       string `form:"q" json:"q,omitempty"`
+	Name        string `form:"name" json:"name,omitempty"` // se busca igual que q, se mantiene por compatibilidad
+	Category    string `form:"category" json:"category,omitempty"`
+	MuscleGroup string `form:"muscle_group" json:"muscle_group,omitempty"` // solo el musculo principal
+	Muscle      string `form:"muscle" json:"muscle,omitempty"`             // principal o secundario
+	Equipment   string `form:"equipment" json:"equipment,omitempty"`
+	Mechanics   string `form:"mechanics" json:"mechanics,omitempty"`
+	Force       string `form:"force" json:"force,omitempty"`
+	Laterality  string `form:"laterality" json:"laterality,omitempty"`
+}
+
+// Empty indica que no se pidio ningun filtro de categoria ni de clasificacion
+func (f ExerciseFilterDTO) Empty() bool {
+	return f.Category == "" && f.MuscleGroup == "" && f.Muscle == "" && f.Equipment == "" && f.Mechanics == "" &&
+		f.Force == "" && f.Laterality == ""
+}
+
+// ExcerciseListFields son los campos que GET /api/exercises permite ordenar y seleccionar
+var ExcerciseListFields = PageFields{
+	"id":               {BSON: "_id", Sortable: true},
+	"Name":             {BSON: "name", Sortable: true},
+	"Description":      {BSON: "description"},
+	"CreatorUserID":    {BSON: "creator_user_id"},
+	"Category":         {BSON: "category", Sortable: true},
+	"MainMuscleGroup":  {BSON: "main_muscle_group", Sortable: true},
+	"DifficultLevel":   {BSON: "difficult_level", Sortable: true},
+	"Example":          {BSON: "example"},
+	"Instructions":     {BSON: "instructions"},
+	"Aliases":          {BSON: "aliases"},
+	"SecondaryMuscles": {BSON: "secondary_muscles"},
+	"Equipment":        {BSON: "equipment"},
+	"Mechanics":        {BSON: "mechanics"},
+	"Force":            {BSON: "force"},
+	"Laterality":       {BSON: "laterality"},
+	// los nombres se arman con las claves, por eso traen el campo de la clave
+	"MainMuscleGroupName":  {BSON: "main_muscle_group"},
+	"SecondaryMuscleNames": {BSON: "secondary_muscles"},
+	"EquipmentNames":       {BSON: "equipment"},
+	"MechanicsName":        {BSON: "mechanics"},
+	"ForceName":            {BSON: "force"},
+	"LateralityName":       {BSON: "laterality"},
+	"EditionDate":          {BSON: "edition_date", Sortable: true},
+	"EliminationDate":      {BSON: "elimination_date"},
+	"CreationDate":         {BSON: "creation_date", Sortable: true},
+}
+
+// modos de DELETE /api/exercises/:id cuando el ejercicio esta en rutinas
+const (
+	ExcerciseDeleteBlock   = "block"   // no se elimina mientras alguna rutina lo use (por defecto)
+	ExcerciseDeleteCascade = "cascade" // se quita de las rutinas que lo usan
+	ExcerciseDeleteReplace = "replace" // se reemplaza en las rutinas por replace_with
+)
+
+// ExcerciseDeleteDTO son los query params de DELETE /api/exercises/:id
+type ExcerciseDeleteDTO struct {
+	ExcerciseID string
+	AdminID     string
+	Mode        string `form:"mode" binding:"omitempty,oneof=block cascade replace"`
+	ReplaceWith string `form:"replace_with"` // obligatorio con mode=replace, de la misma categoria
+}
+
+// ExcerciseDeleteResultDTO informa que se hizo con las rutinas que usaban el ejercicio
+type ExcerciseDeleteResultDTO struct {
+	Deleted         bool   `json:"deleted"`
+	Mode            string `json:"mode"`
+	RoutinesUpdated int    `json:"routines_updated"`
+	ReplacedWith    string `json:"replaced_with,omitempty"`
+	WorkoutCount    int64  `json:"workout_count"`         // workouts que lo registraron, quedan sin cambios
+	WorkoutsBlock   bool   `json:"workouts_block_delete"` // siempre false, ver ExcerciseDependenciesDTO
+}
+
+// ExcerciseDependenciesDTO indica donde se usa un ejercicio. Solo las rutinas (incluidas las de la papelera) impiden
+// eliminarlo en modo block; los workouts solo se informan: guardan el nombre del ejercicio en su snapshot, asi que
+// el historial no cambia al eliminarlo
+type ExcerciseDependenciesDTO struct {
+	ExcerciseID   string                      `json:"exercise_id"`
+	Routines      []*ExcerciseRoutineUsageDTO `json:"routines"`
+	WorkoutCount  int64                       `json:"workout_count"`
+	BlocksDelete  bool                        `json:"blocks_delete"`         // true si hay rutinas: mode=block falla
+	WorkoutsBlock bool                        `json:"workouts_block_delete"` // siempre false
+}
+
+type ExcerciseRoutineUsageDTO struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	CreatorUserID string `json:"creator_user_id"`
+	Visibility    string `json:"visibility"`
+	Entries       int    `json:"entries"`  // veces que aparece el ejercicio en la rutina
+	InTrash       bool   `json:"in_trash"` // la rutina esta en la papelera
+}
+
+func NewExcerciseRoutineUsageDTO(routine models.Routine, excerciseID primitive.ObjectID) *ExcerciseRoutineUsageDTO {
+	usage := &ExcerciseRoutineUsageDTO{
+		ID:            utils.GetStringIDFromObjectID(routine.ID),
+		Name:          routine.Name,
+		CreatorUserID: utils.GetStringIDFromObjectID(routine.CreatorUserID),
+		Visibility:    string(routine.Visibility),
+		InTrash:       !routine.EliminationDate.IsZero(),
+	}
+	if usage.Visibility == "" {
+		usage.Visibility = string(models.RoutinePrivate)
+	}
+	for _, e := range routine.ExcerciseList {
+		if e.ExcerciseID == excerciseID {
+			usage.Entries++
+		}
+	}
+	return usage
 }
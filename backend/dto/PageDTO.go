@@ -0,0 +1,138 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// PageQueryDTO son los query params comunes de los listados paginados
+type PageQueryDTO struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"` // por defecto 20
+	Cursor string `form:"cursor"`                                  // el next o prev de la pagina anterior
+	Sort   string `form:"sort"`                                    // campo de la respuesta, con "-" adelante = descendente
+	Fields string `form:"fields"`                                  // campos de la respuesta separados por coma, vacio = todos
+	Total  bool   `form:"total"`                                   // incluir la cantidad total (hace un conteo aparte)
+}
+
+// PageField relaciona un campo de la respuesta con su campo en MongoDB
+type PageField struct {
+	BSON     string
+	Sortable bool
+}
+
+// PageFields son los campos que un listado permite ordenar y seleccionar, por su clave JSON en la respuesta
+type PageFields map[string]PageField
+
+// PageDTO es la respuesta de todos los listados paginados; next y prev son los enlaces a las paginas vecinas
+// (el servicio deja el cursor y el handler lo convierte en enlace), null en los extremos
+type PageDTO struct {
+	Data  interface{} `json:"data"`
+	Next  *string     `json:"next"`
+	Prev  *string     `json:"prev"`
+	Total *int64      `json:"total,omitempty"`
+}
+
+// Resolve valida la consulta contra los campos del listado; defaultSort se usa si no se pide orden
+func (q PageQueryDTO) Resolve(fields PageFields, defaultSort string) (models.PageRequest, error) {
+	request := models.PageRequest{Limit: q.Limit, Total: q.Total}
+	if request.Limit <= 0 {
+		request.Limit = defaultPageLimit
+	}
+	request.Limit = min(request.Limit, maxPageLimit)
+
+	sort := strings.TrimSpace(q.Sort)
+	if sort == "" {
+		sort = defaultSort
+	}
+	key := strings.TrimPrefix(sort, "-")
+	name, field, ok := fields.lookup(key)
+	if !ok || !field.Sortable {
+		return models.PageRequest{}, fmt.Errorf("campo inválido para ordenar: %s", key)
+	}
+	request.SortField = field.BSON
+	request.SortDesc = strings.HasPrefix(sort, "-")
+	sort = strings.Replace(sort, key, name, 1)
+
+	if q.Fields != "" {
+		for _, key := range strings.Split(q.Fields, ",") {
+			key = strings.TrimSpace(key)
+			if key == "" {
+				continue
+			}
+			name, field, ok := fields.lookup(key)
+			if !ok {
+				return models.PageRequest{}, fmt.Errorf("campo inválido para seleccionar: %s", key)
+			}
+			request.Fields = append(request.Fields, name)
+			request.Projection = append(request.Projection, field.BSON)
+		}
+	}
+
+	if q.Cursor != "" {
+		cursor, err := models.DecodePageCursor(q.Cursor)
+		if err != nil || cursor.Sort != sort {
+			return models.PageRequest{}, fmt.Errorf("cursor inválido: pida de nuevo la primera pagina")
+		}
+		request.Cursor = cursor
+	}
+	request.Sort = sort
+	return request, nil
+}
+
+// lookup busca el campo sin distinguir mayusculas y devuelve su clave tal como aparece en la respuesta
+func (fields PageFields) lookup(key string) (string, PageField, bool) {
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return name, field, true
+		}
+	}
+	return "", PageField{}, false
+}
+
+// NewPageDTO arma la respuesta paginada; si se pidieron campos, cada elemento se reduce a esas claves
+func NewPageDTO[T any](items []T, info models.PageInfo, fields []string) (*PageDTO, error) {
+	page := &PageDTO{Data: items, Total: info.Total}
+	if items == nil {
+		page.Data = []T{}
+	}
+	if len(fields) > 0 {
+		selected := make([]map[string]json.RawMessage, 0, len(items))
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			var all map[string]json.RawMessage
+			if err := json.Unmarshal(data, &all); err != nil {
+				return nil, err
+			}
+			reduced := make(map[string]json.RawMessage, len(fields)+1)
+			for _, key := range []string{"id", "ID"} { // el id se devuelve siempre
+				if id, ok := all[key]; ok {
+					reduced[key] = id
+				}
+			}
+			for _, field := range fields {
+				if value, ok := all[field]; ok {
+					reduced[field] = value
+				}
+			}
+			selected = append(selected, reduced)
+		}
+		page.Data = selected
+	}
+	if info.Next != "" {
+		page.Next = &info.Next
+	}
+	if info.Prev != "" {
+		page.Prev = &info.Prev
+	}
+	return page, nil
+}
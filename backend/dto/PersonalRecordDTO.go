@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"time"
+)
+
+type PersonalRecordResponseDTO struct {
+	ID            string    `json:"id"`
+	ExcerciseID   string    `json:"exercise_id"`
+	ExcerciseName string    `json:"exercise_name"`
+	WorkoutID     string    `json:"workout_id"`
+	Type          string    `json:"type"`
+	Value         float64   `json:"value"`
+	Weight        float64   `json:"weight"`
+	Repetitions   int       `json:"repetitions"`
+	PreviousValue float64   `json:"previous_value"`
+	Date          time.Time `json:"date"`
+}
+
+func NewPersonalRecordResponseDTO(record models.PersonalRecord, excerciseName string) *PersonalRecordResponseDTO {
+	return &PersonalRecordResponseDTO{
+		ID:            utils.GetStringIDFromObjectID(record.ID),
+		ExcerciseID:   utils.GetStringIDFromObjectID(record.ExcerciseID),
+		ExcerciseName: excerciseName,
+		WorkoutID:     utils.GetStringIDFromObjectID(record.WorkoutID),
+		Type:          string(record.Type),
+		Value:         record.Value,
+		Weight:        record.Weight,
+		Repetitions:   record.Repetitions,
+		PreviousValue: record.PreviousValue,
+		Date:          record.Date,
+	}
+}
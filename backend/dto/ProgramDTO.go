@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"time"
+)
+
+// ProgramRegisterDTO se usa para crear y para reemplazar un programa; las semanas se numeran en el orden enviado
+type ProgramRegisterDTO struct {
+	UserID      string
+	ProgramID   string             // solo al modificar
+	Name        string             `json:"name" binding:"required,min=3,max=100"`
+	Description string             `json:"description" binding:"max=500"`
+	Weeks       []ProgramWeekDTO   `json:"weeks" binding:"required,min=1,max=52,dive"`
+	Progression ProgressionRuleDTO `json:"progression"`
+	Visibility  string             `json:"visibility" binding:"omitempty,oneof=private unlisted public"` // default private
+}
+
+type ProgramWeekDTO struct {
+	Number int             `json:"number,omitempty"` // solo en respuestas
+	Deload bool            `json:"deload"`
+	Days   []ProgramDayDTO `json:"days" binding:"max=7,dive"` // sin dias = semana de descanso
+}
+
+type ProgramDayDTO struct {
+	Weekday     int    `json:"weekday" binding:"min=0,max=6"` // 0=domingo ... 6=sabado
+	RoutineID   string `json:"routine_id" binding:"required"`
+	RoutineName string `json:"routine_name,omitempty"` // solo en respuestas
+}
+
+type ProgressionRuleDTO struct {
+	Type            string    `json:"type" binding:"omitempty,oneof=none linear percentage wave"` // vacio = none
+	WeightIncrement float64   `json:"weight_increment" binding:"gte=0,lte=50"`
+	RepsIncrement   int       `json:"reps_increment" binding:"gte=0,lte=10"`
+	Percentages     []float64 `json:"percentages" binding:"omitempty,dive,gt=0,lte=1.2"`
+	Pattern         []float64 `json:"pattern" binding:"omitempty,dive,gt=0,lte=2"`
+	DeloadFactor    float64   `json:"deload_factor" binding:"omitempty,gt=0,lt=1"`
+}
+
+func GetModelProgressionRuleDTO(rule ProgressionRuleDTO) models.ProgressionRule {
+	progression := models.ProgressionRule{
+		Type:            models.ProgressionType(rule.Type),
+		WeightIncrement: rule.WeightIncrement,
+		RepsIncrement:   rule.RepsIncrement,
+		Percentages:     rule.Percentages,
+		Pattern:         rule.Pattern,
+		DeloadFactor:    rule.DeloadFactor,
+	}
+	if progression.Type == "" {
+		progression.Type = models.NoProgression
+	}
+	return progression
+}
+
+type ProgramResponseDTO struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Description   string             `json:"description,omitempty"`
+	CreatorUserID string             `json:"creator_user_id"`
+	Weeks         []ProgramWeekDTO   `json:"weeks"`
+	Progression   ProgressionRuleDTO `json:"progression"`
+	Visibility    string             `json:"visibility"`
+	CreationDate  time.Time          `json:"creation_date"`
+	EditionDate   time.Time          `json:"edition_date"`
+}
+
+// NewProgramResponseDTO arma la respuesta; routineNames resuelve el nombre de cada rutina por su ID
+func NewProgramResponseDTO(program models.Program, routineNames map[string]string) *ProgramResponseDTO {
+	weeks := make([]ProgramWeekDTO, 0, len(program.Weeks))
+	for _, week := range program.Weeks {
+		days := make([]ProgramDayDTO, 0, len(week.Days))
+		for _, day := range week.Days {
+			routineID := utils.GetStringIDFromObjectID(day.RoutineID)
+			days = append(days, ProgramDayDTO{
+				Weekday:     day.Weekday,
+				RoutineID:   routineID,
+				RoutineName: routineNames[routineID],
+			})
+		}
+		weeks = append(weeks, ProgramWeekDTO{Number: week.Number, Deload: week.Deload, Days: days})
+	}
+
+	return &ProgramResponseDTO{
+		ID:            utils.GetStringIDFromObjectID(program.ID),
+		Name:          program.Name,
+		Description:   program.Description,
+		CreatorUserID: utils.GetStringIDFromObjectID(program.CreatorUserID),
+		Weeks:         weeks,
+		Progression: ProgressionRuleDTO{
+			Type:            string(program.Progression.Type),
+			WeightIncrement: program.Progression.WeightIncrement,
+			RepsIncrement:   program.Progression.RepsIncrement,
+			Percentages:     program.Progression.Percentages,
+			Pattern:         program.Progression.Pattern,
+			DeloadFactor:    program.Progression.DeloadFactor,
+		},
+		Visibility:   string(program.Visibility),
+		CreationDate: program.CreationDate,
+		EditionDate:  program.EditionDate,
+	}
+}
+
+type ProgramEnrollDTO struct {
+	UserID    string
+	ProgramID string
+	StartDate string `json:"start_date"` // YYYY-MM-DD, por defecto hoy
+}
+
+type EnrollmentResponseDTO struct {
+	ID          string     `json:"id"`
+	ProgramID   string     `json:"program_id"`
+	ProgramName string     `json:"program_name"`
+	StartDate   string     `json:"start_date"`
+	Status      string     `json:"status"`
+	CurrentWeek int        `json:"current_week"` // 0 = todavia no empezo
+	TotalWeeks  int        `json:"total_weeks"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+}
+
+func NewEnrollmentResponseDTO(enrollment models.ProgramEnrollment, program models.Program, currentWeek int) *EnrollmentResponseDTO {
+	response := &EnrollmentResponseDTO{
+		ID:          utils.GetStringIDFromObjectID(enrollment.ID),
+		ProgramID:   utils.GetStringIDFromObjectID(enrollment.ProgramID),
+		ProgramName: program.Name,
+		StartDate:   enrollment.StartDate,
+		Status:      string(enrollment.Status),
+		CurrentWeek: currentWeek,
+		TotalWeeks:  len(program.Weeks),
+	}
+	if !enrollment.EndDate.IsZero() {
+		response.EndDate = &enrollment.EndDate
+	}
+	return response
+}
+
+// ProgramTodayFilterDTO son los query params de GET /api/programs/today
+type ProgramTodayFilterDTO struct {
+	UserID   string
+	TimeZone string `form:"tz"`
+}
+
+// ProgramTodayDTO indica que toca hoy dentro del programa activo, con los pesos y repeticiones ya progresados
+type ProgramTodayDTO struct {
+	Date          string                  `json:"date"`
+	Status        string                  `json:"status"` // training, rest, not_started, completed, unavailable (la rutina del dia ya no existe o dejo de estar compartida)
+	ProgramID     string                  `json:"program_id"`
+	ProgramName   string                  `json:"program_name"`
+	Week          int                     `json:"week"`
+	TotalWeeks    int                     `json:"total_weeks"`
+	Deload        bool                    `json:"deload"`
+	RoutineID     string                  `json:"routine_id,omitempty"`
+	RoutineName   string                  `json:"routine_name,omitempty"`
+	ExcerciseList []ExcerciseInRoutineDTO `json:"exercise_list,omitempty"`
+	Groups        []ExcerciseGroupDTO     `json:"groups,omitempty"`
+}
+
+// SetRoutine completa la rutina del dia con las entradas ya ajustadas a la semana
+func (today *ProgramTodayDTO) SetRoutine(routine models.Routine) {
+	today.RoutineID = utils.GetStringIDFromObjectID(routine.ID)
+	today.RoutineName = routine.Name
+	today.ExcerciseList = newExcerciseInRoutineResponseDTO(routine.ExcerciseList)
+	today.Groups = newExcerciseGroupDTOs(routine.Groups, routine.ExcerciseList)
+}
@@ -4,18 +4,198 @@ import (
 	"AppFitness/models"
 	"AppFitness/utils"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type RoutineRegisterDTO struct {
 	Name          string `json:"name"`
 	CreatorUserID string
+	Visibility    string `json:"visibility" binding:"omitempty,oneof=private unlisted public"` // default private
 }
 type ExcerciseInRoutineDTO struct {
+	EntryID     string  `json:"entry_id,omitempty"` // solo en respuestas
 	ExcerciseID string  `json:"exercise_id" binding:"required"`
-	Repetitions int     `json:"repetitions" binding:"required,gt=0,lte=100"`
-	Series      int     `json:"series" binding:"required,gt=0,lte=20"`
+	GroupID     string  `json:"group_id,omitempty"`                           // solo en respuestas
+	Repetitions int     `json:"repetitions" binding:"omitempty,gt=0,lte=100"` // obligatorio si no se envian sets
+	Series      int     `json:"series" binding:"omitempty,gt=0,lte=20"`       // obligatorio si no se envian sets
+	Weight      float64 `json:"weight" binding:"gte=0,lte=1000"`
+	Position    *int    `json:"position,omitempty" binding:"omitempty,gte=0"` // al agregar: donde insertarlo (sin valor = al final)
+	PrescriptionDTO
+}
+
+// PrescriptionDTO es lo que se prescribe ademas de series x repeticiones; lo comparten el alta y la modificacion.
+// Con sets, repetitions/series/weight se calculan a partir de las series de trabajo. Cardio y hold_seconds
+// reemplazan a las repeticiones segun la categoria del ejercicio
+type PrescriptionDTO struct {
+	Sets        []SetPrescriptionDTO `json:"sets,omitempty" binding:"omitempty,max=10,dive"`
+	RestSeconds int                  `json:"rest_seconds,omitempty" binding:"omitempty,gte=0,lte=900"`
+	Tempo       string               `json:"tempo,omitempty"`
+	Cardio      *CardioTargetDTO     `json:"cardio,omitempty"`
+	HoldSeconds int                  `json:"hold_seconds,omitempty" binding:"omitempty,gte=1,lte=600"` // flexibilidad
+}
+
+// CardioTargetDTO es la prescripcion de un ejercicio de cardio: duracion y/o distancia, con ritmo y zona opcionales
+type CardioTargetDTO struct {
+	DurationSeconds  int     `json:"duration_seconds,omitempty" binding:"omitempty,gte=1,lte=86400"`
+	DistanceMeters   float64 `json:"distance_meters,omitempty" binding:"omitempty,gt=0,lte=500000"`
+	PaceSecondsPerKm int     `json:"pace_seconds_per_km,omitempty" binding:"omitempty,gte=60,lte=3600"`
+	HeartRateZone    int     `json:"heart_rate_zone,omitempty" binding:"omitempty,gte=1,lte=5"`
+}
+
+// SetPrescriptionDTO es un bloque de series iguales dentro de la prescripcion de un ejercicio
+type SetPrescriptionDTO struct {
+	Type        string  `json:"type" binding:"required,oneof=warmup working drop"`
+	Count       int     `json:"count" binding:"omitempty,gte=1,lte=20"` // default 1
+	MinReps     int     `json:"min_reps" binding:"required,gte=1,lte=100"`
+	MaxReps     int     `json:"max_reps" binding:"omitempty,gte=1,lte=100"` // sin valor = min_reps
 	Weight      float64 `json:"weight" binding:"gte=0,lte=1000"`
+	RPE         float64 `json:"rpe,omitempty" binding:"omitempty,gte=1,lte=10"`
+	RestSeconds int     `json:"rest_seconds,omitempty" binding:"omitempty,gte=0,lte=900"`
+	Drops       int     `json:"drops,omitempty" binding:"omitempty,gte=1,lte=5"` // solo drop sets, default 1
+}
+
+// tempoPattern acepta 3 o 4 fases de segundos, con X para una fase explosiva: 3-1-1, 3-1-X-0
+var tempoPattern = regexp.MustCompile(`^[0-9X](-[0-9X]){2,3}$`)
+
+// applyPrescription valida la prescripcion segun la categoria del ejercicio y la copia al modelo. Con series
+// detalladas los campos simples se derivan de ellas; sin ellas siguen siendo obligatorios, como antes
+func applyPrescription(model *models.ExcerciseInRoutine, category models.CategoryLevel, prescription PrescriptionDTO) error {
+	sets := prescription.Sets
+	tempo := strings.ToUpper(strings.TrimSpace(prescription.Tempo))
+	if tempo != "" && !tempoPattern.MatchString(tempo) {
+		return fmt.Errorf("tempo inválido: use el formato 3-1-1 o 3-1-1-0")
+	}
+	model.Tempo = tempo
+	model.RestSeconds = prescription.RestSeconds
+	model.Sets = nil
+	model.Cardio = nil
+	model.HoldSeconds = 0
+
+	switch category {
+	case models.Cardio:
+		return applyCardioPrescription(model, prescription)
+	case models.Flexibility:
+		return applyFlexibilityPrescription(model, prescription)
+	}
+	if prescription.Cardio != nil || prescription.HoldSeconds > 0 {
+		return fmt.Errorf("prescripción inválida: cardio y hold_seconds solo aplican a ejercicios de cardio o flexibilidad")
+	}
+
+	if len(sets) == 0 {
+		if model.Repetitions <= 0 || model.Series <= 0 {
+			return fmt.Errorf("repeticiones y series inválidas: son obligatorias si no se detallan las series")
+		}
+		model.Sets = nil
+		return nil
+	}
+
+	model.Sets = make([]models.SetPrescription, 0, len(sets))
+	warmupsDone := false
+	for _, s := range sets {
+		set := models.SetPrescription{
+			Type:        models.SetType(s.Type),
+			Count:       max(s.Count, 1),
+			MinReps:     s.MinReps,
+			MaxReps:     s.MaxReps,
+			Weight:      s.Weight,
+			RPE:         s.RPE,
+			RestSeconds: s.RestSeconds,
+			Drops:       s.Drops,
+		}
+		if set.MaxReps == 0 {
+			set.MaxReps = set.MinReps
+		}
+		if set.MaxReps < set.MinReps {
+			return fmt.Errorf("rango de repeticiones inválido: max_reps no puede ser menor que min_reps")
+		}
+		switch set.Type {
+		case models.WarmupSet:
+			if warmupsDone {
+				return fmt.Errorf("orden de series inválido: el calentamiento va antes de las series de trabajo")
+			}
+		case models.DropSet:
+			set.Drops = max(set.Drops, 1)
+			warmupsDone = true
+		default:
+			if set.Drops > 0 {
+				return fmt.Errorf("drops inválido: solo aplica a drop sets")
+			}
+			warmupsDone = true
+		}
+		model.Sets = append(model.Sets, set)
+	}
+	if !warmupsDone {
+		return fmt.Errorf("series inválidas: debe haber al menos una serie de trabajo")
+	}
+	model.Series, model.Repetitions, model.Weight = models.SummarizeSets(model.Sets)
+	return nil
+}
+
+// applyCardioPrescription: un bloque continuo (Series 1) de duracion y/o distancia
+func applyCardioPrescription(model *models.ExcerciseInRoutine, prescription PrescriptionDTO) error {
+	cardio := prescription.Cardio
+	if cardio == nil || (cardio.DurationSeconds == 0 && cardio.DistanceMeters == 0) {
+		return fmt.Errorf("prescripción inválida: un ejercicio de cardio necesita duration_seconds o distance_meters")
+	}
+	if len(prescription.Sets) > 0 || prescription.HoldSeconds > 0 || prescription.Tempo != "" {
+		return fmt.Errorf("prescripción inválida: sets, tempo y hold_seconds no aplican a ejercicios de cardio")
+	}
+	model.Cardio = &models.CardioTarget{
+		DurationSeconds:  cardio.DurationSeconds,
+		DistanceMeters:   cardio.DistanceMeters,
+		PaceSecondsPerKm: cardio.PaceSecondsPerKm,
+		HeartRateZone:    cardio.HeartRateZone,
+	}
+	model.Series = max(model.Series, 1)
+	model.Repetitions = 0
+	model.Weight = 0
+	return nil
+}
+
+// applyFlexibilityPrescription: Series posiciones sostenidas HoldSeconds cada una (Repetitions = por lado, opcional)
+func applyFlexibilityPrescription(model *models.ExcerciseInRoutine, prescription PrescriptionDTO) error {
+	if prescription.HoldSeconds == 0 {
+		return fmt.Errorf("prescripción inválida: un ejercicio de flexibilidad necesita hold_seconds")
+	}
+	if len(prescription.Sets) > 0 || prescription.Cardio != nil || prescription.Tempo != "" {
+		return fmt.Errorf("prescripción inválida: sets, tempo y cardio no aplican a ejercicios de flexibilidad")
+	}
+	model.HoldSeconds = prescription.HoldSeconds
+	model.Series = max(model.Series, 1)
+	return nil
+}
+
+func newCardioTargetDTO(cardio *models.CardioTarget) *CardioTargetDTO {
+	if cardio == nil {
+		return nil
+	}
+	return &CardioTargetDTO{
+		DurationSeconds:  cardio.DurationSeconds,
+		DistanceMeters:   cardio.DistanceMeters,
+		PaceSecondsPerKm: cardio.PaceSecondsPerKm,
+		HeartRateZone:    cardio.HeartRateZone,
+	}
+}
+
+func newSetPrescriptionDTOs(sets []models.SetPrescription) []SetPrescriptionDTO {
+	setsDTO := make([]SetPrescriptionDTO, 0, len(sets))
+	for _, s := range sets {
+		setsDTO = append(setsDTO, SetPrescriptionDTO{
+			Type:        string(s.Type),
+			Count:       s.Count,
+			MinReps:     s.MinReps,
+			MaxReps:     s.MaxReps,
+			Weight:      s.Weight,
+			RPE:         s.RPE,
+			RestSeconds: s.RestSeconds,
+			Drops:       s.Drops,
+		})
+	}
+	return setsDTO
 }
 
 func GetModelRoutineRegisterDTO(routine *RoutineRegisterDTO) (*models.Routine, error) {
@@ -26,47 +206,155 @@ func GetModelRoutineRegisterDTO(routine *RoutineRegisterDTO) (*models.Routine, e
 		return nil, fmt.Errorf("ID de creador con formato inválido: %w", err)
 	}
 
+	visibility := models.RoutineVisibility(routine.Visibility)
+	if visibility == "" {
+		visibility = models.RoutinePrivate
+	}
 	return &models.Routine{
 		Name:          routine.Name,
 		CreatorUserID: creatorOID,
+		Visibility:    visibility,
 	}, nil
 }
-func GetModelExerciseInRoutineDTO(excercise *ExcerciseInRoutineDTO) (models.ExcerciseInRoutine, error) {
+
+// GetModelExerciseInRoutineDTO convierte la entrada validando la prescripcion segun la categoria del ejercicio
+func GetModelExerciseInRoutineDTO(excercise *ExcerciseInRoutineDTO, category models.CategoryLevel) (models.ExcerciseInRoutine, error) {
 
 	// Capturamos el ObjectID y el error
 	excerciseOID, err := utils.GetObjectIDFromStringID(excercise.ExcerciseID)
 	if err != nil {
 		return models.ExcerciseInRoutine{}, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
 	}
-	return models.ExcerciseInRoutine{
+	model := models.ExcerciseInRoutine{
 		ExcerciseID: excerciseOID,
 		Repetitions: excercise.Repetitions,
 		Series:      excercise.Series,
 		Weight:      excercise.Weight,
-	}, nil
+	}
+	if err := applyPrescription(&model, category, excercise.PrescriptionDTO); err != nil {
+		return models.ExcerciseInRoutine{}, err
+	}
+	return model, nil
 }
 func newExcerciseInRoutineResponseDTO(excerciseList []models.ExcerciseInRoutine) []ExcerciseInRoutineDTO {
 	var excerciseInRoutineDTOList []ExcerciseInRoutineDTO
-	for _, excercise := range excerciseList {
+	for i, excercise := range excerciseList {
+		position := i
 		excerciseDTO := ExcerciseInRoutineDTO{
+			EntryID:     optionalID(excercise.EntryID),
 			ExcerciseID: utils.GetStringIDFromObjectID(excercise.ExcerciseID),
+			GroupID:     optionalID(excercise.GroupID),
 			Repetitions: excercise.Repetitions,
 			Series:      excercise.Series,
 			Weight:      excercise.Weight,
+			Position:    &position,
+			PrescriptionDTO: PrescriptionDTO{
+				Sets:        newSetPrescriptionDTOs(excercise.Prescription()),
+				RestSeconds: excercise.RestSeconds,
+				Tempo:       excercise.Tempo,
+				Cardio:      newCardioTargetDTO(excercise.Cardio),
+				HoldSeconds: excercise.HoldSeconds,
+			},
 		}
 		excerciseInRoutineDTOList = append(excerciseInRoutineDTOList, excerciseDTO)
 	}
 	return excerciseInRoutineDTOList
 }
 
+// optionalID devuelve "" para los IDs vacios en vez de una cadena de ceros
+func optionalID(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return utils.GetStringIDFromObjectID(id)
+}
+
+// ExcerciseGroupDTO es una superserie, serie gigante o circuito con sus entradas en orden
+type ExcerciseGroupDTO struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	RestSeconds int      `json:"rest_seconds"`
+	Rounds      int      `json:"rounds,omitempty"`
+	EntryIDs    []string `json:"entry_ids"`
+}
+
+func newExcerciseGroupDTOs(groups []models.ExcerciseGroup, entries []models.ExcerciseInRoutine) []ExcerciseGroupDTO {
+	groupsDTO := []ExcerciseGroupDTO{}
+	for _, g := range groups {
+		groupDTO := ExcerciseGroupDTO{
+			ID:          utils.GetStringIDFromObjectID(g.ID),
+			Type:        string(g.Type),
+			RestSeconds: g.RestSeconds,
+			Rounds:      g.Rounds,
+			EntryIDs:    []string{},
+		}
+		for _, e := range entries {
+			if e.GroupID == g.ID {
+				groupDTO.EntryIDs = append(groupDTO.EntryIDs, optionalID(e.EntryID))
+			}
+		}
+		groupsDTO = append(groupsDTO, groupDTO)
+	}
+	return groupsDTO
+}
+
+// RoutineGroupRegisterDTO agrupa entradas de la rutina; quedan seguidas en el orden indicado, desde la posicion de la primera
+type RoutineGroupRegisterDTO struct {
+	RoutineID   string
+	EditorID    string
+	Type        string   `json:"type" binding:"required,oneof=superset giant_set circuit"`
+	RestSeconds int      `json:"rest_seconds" binding:"gte=0,lte=600"`
+	Rounds      int      `json:"rounds" binding:"omitempty,gte=1,lte=20"` // solo circuitos, default 1
+	EntryIDs    []string `json:"entry_ids" binding:"required,min=2,dive,required"`
+}
+
+// RoutineReorderDTO es el orden completo de las entradas de la rutina
+type RoutineReorderDTO struct {
+	RoutineID string
+	EditorID  string
+	EntryIDs  []string `json:"entry_ids" binding:"required,min=1,dive,required"`
+}
+
 type RoutineResponseDTO struct {
 	ID              string
 	Name            string
 	CreatorUserID   string
 	ExcerciseList   []ExcerciseInRoutineDTO
+	Groups          []ExcerciseGroupDTO
 	EditionDate     time.Time
 	EliminationDate time.Time
 	CreationDate    time.Time
+	Version         int
+	ForkedFrom      string `json:"ForkedFrom,omitempty"`
+	Visibility      string
+	Featured        bool
+	Suggestions     []ProgressionSuggestionDTO `json:"Suggestions,omitempty"` // solo para el creador
+}
+
+// ProgressionSuggestionDTO es el objetivo sugerido para la proxima sesion de una entrada segun lo registrado en los
+// ultimos workouts; se acepta con PUT /api/routines/:id/exercises/:entry_id y {"accept_suggestion": true}
+type ProgressionSuggestionDTO struct {
+	EntryID     string  `json:"entry_id"`
+	ExcerciseID string  `json:"exercise_id"`
+	Action      string  `json:"action"` // increase_weight, increase_reps, deload
+	Reason      string  `json:"reason"`
+	Current     string  `json:"current"`   // prescripcion actual, ej. 3x8-12 @60kg
+	Suggested   string  `json:"suggested"` // prescripcion sugerida, ej. 3x8-12 @62.5kg
+	Weight      float64 `json:"weight"`
+	Repetitions int     `json:"repetitions"`
+}
+
+func NewProgressionSuggestionDTO(current models.ExcerciseInRoutine, suggested models.ExcerciseInRoutine, action string, reason string) ProgressionSuggestionDTO {
+	return ProgressionSuggestionDTO{
+		EntryID:     optionalID(current.EntryID),
+		ExcerciseID: utils.GetStringIDFromObjectID(current.ExcerciseID),
+		Action:      action,
+		Reason:      reason,
+		Current:     current.Describe(),
+		Suggested:   suggested.Describe(),
+		Weight:      suggested.Weight,
+		Repetitions: suggested.Repetitions,
+	}
 }
 
 func NewRoutineResponseDTO(routine models.Routine) *RoutineResponseDTO {
@@ -76,34 +364,227 @@ func NewRoutineResponseDTO(routine models.Routine) *RoutineResponseDTO {
 		Name:            routine.Name,
 		CreatorUserID:   utils.GetStringIDFromObjectID(routine.CreatorUserID), //check
 		ExcerciseList:   newExcerciseInRoutineResponseDTO(routine.ExcerciseList),
+		Groups:          newExcerciseGroupDTOs(routine.Groups, routine.ExcerciseList),
 		EditionDate:     routine.EditionDate,
 		EliminationDate: routine.EliminationDate,
 		CreationDate:    routine.CreationDate,
+		Version:         routine.Version,
+		ForkedFrom:      forkedFrom(routine),
+		Visibility:      string(routineVisibility(routine)),
+		Featured:        routine.Featured,
+	}
+}
+
+// routineVisibility trata como privadas a las rutinas creadas antes de que existiera la visibilidad
+func routineVisibility(routine models.Routine) models.RoutineVisibility {
+	if routine.Visibility == "" {
+		return models.RoutinePrivate
+	}
+	return routine.Visibility
+}
+
+type RoutineVisibilityDTO struct {
+	RoutineID  string
+	EditorID   string
+	Visibility string `json:"visibility" binding:"required,oneof=private unlisted public"`
+}
+
+type RoutineFeaturedDTO struct {
+	RoutineID string
+	Featured  *bool `json:"featured" binding:"required"`
+}
+
+// PublicRoutineFilterDTO son los query params de GET /api/routines/public
+type PublicRoutineFilterDTO struct {
+	Query       string `form:"q"` // comienzo del nombre
+	MuscleGroup string `form:"muscle_group"`
+	Category    string `form:"category" binding:"omitempty,oneof=strength cardio flexibility balance"`
+	Difficulty  string `form:"difficulty"`
+	Featured    bool   `form:"featured"`
+	Limit       int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+}
+
+func GetModelPublicRoutineFilterDTO(filter PublicRoutineFilterDTO) models.PublicRoutineFilter {
+	return models.PublicRoutineFilter{
+		Query:       filter.Query,
+		MuscleGroup: filter.MuscleGroup,
+		Category:    filter.Category,
+		Difficulty:  filter.Difficulty,
+		Featured:    filter.Featured,
+		Limit:       filter.Limit,
+	}
+}
+
+// PublicRoutineDTO es una rutina de la biblioteca con el resumen de lo que trabaja
+type PublicRoutineDTO struct {
+	RoutineResponseDTO
+	MuscleGroups []string `json:"MuscleGroups"`
+	Categories   []string `json:"Categories"`
+	Difficulties []string `json:"Difficulties"`
+}
+
+func NewPublicRoutineDTO(routine models.PublicRoutine) *PublicRoutineDTO {
+	publicDTO := &PublicRoutineDTO{
+		RoutineResponseDTO: *NewRoutineResponseDTO(routine.Routine),
+		MuscleGroups:       []string{},
+		Categories:         []string{},
+		Difficulties:       []string{},
+	}
+	seen := make(map[string]bool)
+	add := func(list *[]string, kind string, value string) {
+		if value == "" || seen[kind+value] {
+			return
+		}
+		seen[kind+value] = true
+		*list = append(*list, value)
+	}
+	for _, e := range routine.Excercises {
+		add(&publicDTO.MuscleGroups, "muscle", e.MainMuscleGroup)
+		add(&publicDTO.Categories, "category", string(e.Category))
+		add(&publicDTO.Difficulties, "difficulty", e.DifficultLevel)
 	}
+	return publicDTO
+}
+
+func forkedFrom(routine models.Routine) string {
+	if routine.ForkedFrom.IsZero() {
+		return ""
+	}
+	return utils.GetStringIDFromObjectID(routine.ForkedFrom)
+}
+
+// RoutineCloneDTO copia una rutina ajena a la cuenta del usuario; sin nombre se usa "<original> (copia)"
+type RoutineCloneDTO struct {
+	RoutineID string
+	UserID    string
+	Name      string `json:"name"`
 }
 
 type RoutineModifyDTO struct {
 	IDRoutine string
+	IDEditor  string
 	Name      string `json:"name"`
 }
 
+// RoutineRemoveDTO identifica la entrada a quitar; el exercise_id alcanza si el ejercicio esta una sola vez
 type RoutineRemoveDTO struct {
-	IDExercise string `json:"exercise_id" binding:"required"`
+	IDEntry    string `json:"entry_id" binding:"required_without=IDExercise"`
+	IDExercise string `json:"exercise_id" binding:"required_without=IDEntry"`
 	IDRoutine  string `json:"routine_id" binding:"required"`
 }
 
 type ExcerciseInRoutineModifyDTO struct {
 	RoutineID   string
-	ExcerciseID string
-	Repetitions int     `json:"repetitions" binding:"required,gt=0,lte=100"`
-	Series      int     `json:"series" binding:"required,gt=0,lte=20"`
+	ExcerciseID string  // entry_id, o el ID del ejercicio si esta una sola vez en la rutina
+	Repetitions int     `json:"repetitions" binding:"omitempty,gt=0,lte=100"` // obligatorio si no se envian sets
+	Series      int     `json:"series" binding:"omitempty,gt=0,lte=20"`       // obligatorio si no se envian sets
 	Weight      float64 `json:"weight" binding:"gte=0,lte=1000"`
+
+	// aplica la sugerencia de progresion vigente e ignora el resto de los campos
+	AcceptSuggestion bool `json:"accept_suggestion"`
+
+	// reemplaza la prescripcion completa: sin sets la entrada vuelve a ser series x repeticiones
+	PrescriptionDTO
+}
+
+// NewExcerciseInRoutineModifyDTO arma la modificacion equivalente a una entrada, para que una sugerencia aceptada
+// pase por las mismas validaciones que una modificacion enviada por el usuario
+func NewExcerciseInRoutineModifyDTO(routineID string, entryRef string, entry models.ExcerciseInRoutine) *ExcerciseInRoutineModifyDTO {
+	modify := &ExcerciseInRoutineModifyDTO{
+		RoutineID:   routineID,
+		ExcerciseID: entryRef,
+		Repetitions: entry.Repetitions,
+		Series:      entry.Series,
+		Weight:      entry.Weight,
+		PrescriptionDTO: PrescriptionDTO{
+			RestSeconds: entry.RestSeconds,
+			Tempo:       entry.Tempo,
+			Cardio:      newCardioTargetDTO(entry.Cardio),
+			HoldSeconds: entry.HoldSeconds,
+		},
+	}
+	if len(entry.Sets) > 0 {
+		modify.Sets = newSetPrescriptionDTOs(entry.Sets)
+	}
+	return modify
 }
 
-func GetModelFromExerciseInRoutineModifyDTO(excercise *ExcerciseInRoutineModifyDTO) models.ExcerciseInRoutine {
-	return models.ExcerciseInRoutine{
+func GetModelFromExerciseInRoutineModifyDTO(excercise *ExcerciseInRoutineModifyDTO, category models.CategoryLevel) (models.ExcerciseInRoutine, error) {
+	model := models.ExcerciseInRoutine{
 		Repetitions: excercise.Repetitions,
 		Series:      excercise.Series,
 		Weight:      excercise.Weight,
 	}
+	if err := applyPrescription(&model, category, excercise.PrescriptionDTO); err != nil {
+		return models.ExcerciseInRoutine{}, err
+	}
+	return model, nil
+}
+
+// RoutineVersionDTO es una version del historial de la rutina con los cambios respecto de la anterior
+type RoutineVersionDTO struct {
+	Version       int                     `json:"version"`
+	Change        string                  `json:"change"`
+	RestoredFrom  int                     `json:"restored_from,omitempty"`
+	Name          string                  `json:"name"`
+	ExcerciseList []ExcerciseInRoutineDTO `json:"exercise_list"`
+	Groups        []ExcerciseGroupDTO     `json:"groups"`
+	EditorUserID  string                  `json:"editor_user_id"`
+	CreationDate  time.Time               `json:"creation_date"`
+	Diff          []RoutineDiffDTO        `json:"diff"`
+}
+
+// RoutineDiffDTO describe un cambio puntual: name_changed, exercise_added, exercise_removed, exercise_changed,
+// order_changed, group_added o group_removed
+type RoutineDiffDTO struct {
+	Type        string `json:"type"`
+	EntryID     string `json:"entry_id,omitempty"`
+	ExcerciseID string `json:"exercise_id,omitempty"`
+	GroupID     string `json:"group_id,omitempty"`
+	Field       string `json:"field,omitempty"` // name, repetitions, series o weight
+	From        any    `json:"from,omitempty"`
+	To          any    `json:"to,omitempty"`
+}
+
+func NewRoutineVersionDTO(version models.RoutineVersion, diff []RoutineDiffDTO) *RoutineVersionDTO {
+	exercises := newExcerciseInRoutineResponseDTO(version.ExcerciseList)
+	if exercises == nil {
+		exercises = []ExcerciseInRoutineDTO{}
+	}
+	if diff == nil {
+		diff = []RoutineDiffDTO{}
+	}
+	return &RoutineVersionDTO{
+		Version:       version.Version,
+		Change:        string(version.Change),
+		RestoredFrom:  version.RestoredFrom,
+		Name:          version.Name,
+		ExcerciseList: exercises,
+		Groups:        newExcerciseGroupDTOs(version.Groups, version.ExcerciseList),
+		EditorUserID:  utils.GetStringIDFromObjectID(version.EditorUserID),
+		CreationDate:  version.CreationDate,
+		Diff:          diff,
+	}
+}
+
+type RoutineRollbackDTO struct {
+	RoutineID string
+	Version   int
+	EditorID  string
+}
+
+// RoutineListFields son los campos que GET /api/routines permite ordenar y seleccionar
+var RoutineListFields = PageFields{
+	"ID":              {BSON: "_id", Sortable: true},
+	"Name":            {BSON: "name", Sortable: true},
+	"CreatorUserID":   {BSON: "creator_user_id"},
+	"ExcerciseList":   {BSON: "exercise_list"},
+	"Groups":          {BSON: "groups"},
+	"EditionDate":     {BSON: "edition_date", Sortable: true},
+	"EliminationDate": {BSON: "elimination_date"},
+	"CreationDate":    {BSON: "creation_date", Sortable: true},
+	"Version":         {BSON: "version", Sortable: true},
+	"ForkedFrom":      {BSON: "forked_from"},
+	"Visibility":      {BSON: "visibility", Sortable: true},
+	"Featured":        {BSON: "featured"},
 }
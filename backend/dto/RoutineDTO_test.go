@@ -0,0 +1,132 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"strings"
+	"testing"
+)
+
+func TestApplyPrescription(t *testing.T) {
+	cases := []struct {
+		name     string
+		category models.CategoryLevel
+		simple   models.ExcerciseInRoutine
+		in       PrescriptionDTO
+		wantErr  string
+		check    func(t *testing.T, m models.ExcerciseInRoutine)
+	}{
+		{
+			name:     "sin series detalladas usa los campos simples",
+			category: models.Strength,
+			simple:   models.ExcerciseInRoutine{Series: 3, Repetitions: 10, Weight: 40},
+			in:       PrescriptionDTO{Tempo: " 3-1-x ", RestSeconds: 90},
+			check: func(t *testing.T, m models.ExcerciseInRoutine) {
+				if m.Sets != nil || m.Tempo != "3-1-X" || m.RestSeconds != 90 {
+					t.Fatalf("prescripcion inesperada: %+v", m)
+				}
+			},
+		},
+		{
+			name:     "sin series detalladas ni campos simples",
+			category: models.Strength,
+			wantErr:  "repeticiones y series inválidas",
+		},
+		{
+			name:     "series detalladas derivan los campos simples",
+			category: models.Strength,
+			in: PrescriptionDTO{Sets: []SetPrescriptionDTO{
+				{Type: "warmup", Count: 2, MinReps: 10, Weight: 20},
+				{Type: "working", Count: 3, MinReps: 8, MaxReps: 12, Weight: 60},
+				{Type: "drop", MinReps: 10, Weight: 40},
+			}},
+			check: func(t *testing.T, m models.ExcerciseInRoutine) {
+				if m.Series != 4 || m.Repetitions != 8 || m.Weight != 60 {
+					t.Fatalf("resumen %dx%d @%g, se esperaba 4x8 @60", m.Series, m.Repetitions, m.Weight)
+				}
+				if m.Sets[2].Count != 1 || m.Sets[2].Drops != 1 || m.Sets[0].MaxReps != 10 {
+					t.Fatalf("valores por defecto no aplicados: %+v", m.Sets)
+				}
+			},
+		},
+		{
+			name:     "calentamiento despues de una serie de trabajo",
+			category: models.Strength,
+			in: PrescriptionDTO{Sets: []SetPrescriptionDTO{
+				{Type: "working", MinReps: 5, Weight: 100},
+				{Type: "warmup", MinReps: 5, Weight: 50},
+			}},
+			wantErr: "orden de series inválido",
+		},
+		{
+			name:     "solo calentamiento",
+			category: models.Strength,
+			in:       PrescriptionDTO{Sets: []SetPrescriptionDTO{{Type: "warmup", MinReps: 5}}},
+			wantErr:  "debe haber al menos una serie de trabajo",
+		},
+		{
+			name:     "rango de repeticiones invertido",
+			category: models.Strength,
+			in:       PrescriptionDTO{Sets: []SetPrescriptionDTO{{Type: "working", MinReps: 12, MaxReps: 8}}},
+			wantErr:  "rango de repeticiones inválido",
+		},
+		{
+			name:     "drops en una serie de trabajo",
+			category: models.Strength,
+			in:       PrescriptionDTO{Sets: []SetPrescriptionDTO{{Type: "working", MinReps: 8, Drops: 2}}},
+			wantErr:  "drops inválido",
+		},
+		{
+			name:     "tempo mal formado",
+			category: models.Strength,
+			simple:   models.ExcerciseInRoutine{Series: 3, Repetitions: 10},
+			in:       PrescriptionDTO{Tempo: "lento"},
+			wantErr:  "tempo inválido",
+		},
+		{
+			name:     "cardio en un ejercicio de fuerza",
+			category: models.Strength,
+			simple:   models.ExcerciseInRoutine{Series: 3, Repetitions: 10},
+			in:       PrescriptionDTO{Cardio: &CardioTargetDTO{DurationSeconds: 600}},
+			wantErr:  "solo aplican a ejercicios de cardio o flexibilidad",
+		},
+		{
+			name:     "cardio por duracion",
+			category: models.Cardio,
+			simple:   models.ExcerciseInRoutine{Repetitions: 10, Weight: 20},
+			in:       PrescriptionDTO{Cardio: &CardioTargetDTO{DurationSeconds: 1200, HeartRateZone: 2}},
+			check: func(t *testing.T, m models.ExcerciseInRoutine) {
+				if m.Cardio == nil || m.Cardio.DurationSeconds != 1200 || m.Series != 1 || m.Repetitions != 0 || m.Weight != 0 {
+					t.Fatalf("prescripcion de cardio inesperada: %+v", m)
+				}
+			},
+		},
+		{
+			name:     "cardio sin duracion ni distancia",
+			category: models.Cardio,
+			in:       PrescriptionDTO{Cardio: &CardioTargetDTO{HeartRateZone: 2}},
+			wantErr:  "necesita duration_seconds o distance_meters",
+		},
+		{
+			name:     "flexibilidad sin hold_seconds",
+			category: models.Flexibility,
+			in:       PrescriptionDTO{Tempo: "3-1-1"},
+			wantErr:  "necesita hold_seconds",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := tc.simple
+			err := applyPrescription(&model, tc.category, tc.in)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("se esperaba un error con %q, se obtuvo %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			tc.check(t, model)
+		})
+	}
+}
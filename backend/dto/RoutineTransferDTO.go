@@ -0,0 +1,374 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoutineExportFormat identifica los archivos de rutinas exportados por la aplicacion
+const RoutineExportFormat = "appfitness-routines"
+
+// RoutineExportFileDTO es el formato de intercambio de rutinas: lo que devuelve la exportacion en JSON y lo que recibe
+// la importacion. Cada ejercicio lleva su ID y su nombre para poder importarlo en otra cuenta o instalacion
+type RoutineExportFileDTO struct {
+	Format     string             `json:"format"`
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Routines   []RoutineExportDTO `json:"routines" binding:"required,min=1,max=50,dive"`
+}
+
+type RoutineExportDTO struct {
+	Name      string                  `json:"name" binding:"required,max=100"`
+	Exercises []RoutineExportEntryDTO `json:"exercises" binding:"max=100,dive"`
+	Groups    []RoutineExportGroupDTO `json:"groups,omitempty" binding:"omitempty,dive"`
+}
+
+// RoutineExportEntryDTO es una entrada de la rutina; Group es la etiqueta (A, B, ...) del grupo al que pertenece
+type RoutineExportEntryDTO struct {
+	Position      int     `json:"position"`
+	ExcerciseID   string  `json:"exercise_id,omitempty"`   // se intenta primero
+	ExcerciseName string  `json:"exercise_name,omitempty"` // si el ID no existe en esta instalacion
+	Group         string  `json:"group,omitempty"`
+	Repetitions   int     `json:"repetitions,omitempty" binding:"omitempty,gt=0,lte=100"`
+	Series        int     `json:"series,omitempty" binding:"omitempty,gt=0,lte=20"`
+	Weight        float64 `json:"weight,omitempty" binding:"gte=0,lte=1000"`
+	PrescriptionDTO
+}
+
+type RoutineExportGroupDTO struct {
+	Label       string `json:"label" binding:"required"`
+	Type        string `json:"type" binding:"required,oneof=superset giant_set circuit"`
+	RestSeconds int    `json:"rest_seconds" binding:"gte=0,lte=600"`
+	Rounds      int    `json:"rounds,omitempty" binding:"omitempty,gte=1,lte=20"`
+}
+
+// NewRoutineExportDTO arma la rutina exportada; names resuelve el nombre de cada ejercicio
+func NewRoutineExportDTO(routine models.Routine, names map[primitive.ObjectID]string) RoutineExportDTO {
+	labels := make(map[primitive.ObjectID]string, len(routine.Groups))
+	groups := []RoutineExportGroupDTO{}
+	for i, g := range routine.Groups {
+		labels[g.ID] = groupLabel(i)
+		groups = append(groups, RoutineExportGroupDTO{
+			Label:       labels[g.ID],
+			Type:        string(g.Type),
+			RestSeconds: g.RestSeconds,
+			Rounds:      g.Rounds,
+		})
+	}
+
+	exercises := []RoutineExportEntryDTO{}
+	for _, e := range routine.ExcerciseList {
+		if !e.EliminationDate.IsZero() {
+			continue
+		}
+		entry := RoutineExportEntryDTO{
+			Position:      len(exercises),
+			ExcerciseID:   utils.GetStringIDFromObjectID(e.ExcerciseID),
+			ExcerciseName: names[e.ExcerciseID],
+			Group:         labels[e.GroupID],
+			Repetitions:   e.Repetitions,
+			Series:        e.Series,
+			Weight:        e.Weight,
+			PrescriptionDTO: PrescriptionDTO{
+				RestSeconds: e.RestSeconds,
+				Tempo:       e.Tempo,
+				Cardio:      newCardioTargetDTO(e.Cardio),
+				HoldSeconds: e.HoldSeconds,
+			},
+		}
+		if len(e.Sets) > 0 {
+			entry.Sets = newSetPrescriptionDTOs(e.Sets)
+		}
+		exercises = append(exercises, entry)
+	}
+
+	return RoutineExportDTO{Name: routine.Name, Exercises: exercises, Groups: groups}
+}
+
+// groupLabel devuelve A, B, ... Z, AA, AB, ...
+func groupLabel(i int) string {
+	label := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = string(rune('A'+(i-1)%26)) + label
+	}
+	return label
+}
+
+// routineCSVColumns son las columnas del CSV, una fila por entrada. sets lleva el detalle de series como JSON
+var routineCSVColumns = []string{
+	"routine", "position", "exercise_id", "exercise_name", "series", "repetitions", "weight", "rest_seconds", "tempo",
+	"hold_seconds", "duration_seconds", "distance_meters", "pace_seconds_per_km", "heart_rate_zone",
+	"group", "group_type", "group_rest_seconds", "group_rounds", "sets",
+}
+
+// WriteRoutinesCSV escribe las rutinas en CSV con encabezado
+func WriteRoutinesCSV(w io.Writer, routines []RoutineExportDTO) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(routineCSVColumns); err != nil {
+		return err
+	}
+	for _, routine := range routines {
+		groups := make(map[string]RoutineExportGroupDTO, len(routine.Groups))
+		for _, g := range routine.Groups {
+			groups[g.Label] = g
+		}
+		for _, e := range routine.Exercises {
+			row := map[string]string{
+				"routine":       routine.Name,
+				"position":      strconv.Itoa(e.Position),
+				"exercise_id":   e.ExcerciseID,
+				"exercise_name": e.ExcerciseName,
+				"series":        optionalInt(e.Series),
+				"repetitions":   optionalInt(e.Repetitions),
+				"weight":        strconv.FormatFloat(e.Weight, 'f', -1, 64),
+				"rest_seconds":  optionalInt(e.RestSeconds),
+				"tempo":         e.Tempo,
+				"hold_seconds":  optionalInt(e.HoldSeconds),
+				"group":         e.Group,
+			}
+			if e.Cardio != nil {
+				row["duration_seconds"] = optionalInt(e.Cardio.DurationSeconds)
+				if e.Cardio.DistanceMeters > 0 {
+					row["distance_meters"] = strconv.FormatFloat(e.Cardio.DistanceMeters, 'f', -1, 64)
+				}
+				row["pace_seconds_per_km"] = optionalInt(e.Cardio.PaceSecondsPerKm)
+				row["heart_rate_zone"] = optionalInt(e.Cardio.HeartRateZone)
+			}
+			if g, ok := groups[e.Group]; ok {
+				row["group_type"] = g.Type
+				row["group_rest_seconds"] = strconv.Itoa(g.RestSeconds)
+				row["group_rounds"] = optionalInt(g.Rounds)
+			}
+			if len(e.Sets) > 0 {
+				sets, err := json.Marshal(e.Sets)
+				if err != nil {
+					return err
+				}
+				row["sets"] = string(sets)
+			}
+
+			record := make([]string, len(routineCSVColumns))
+			for i, column := range routineCSVColumns {
+				record[i] = row[column]
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func optionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+// ReadRoutinesCSV lee el CSV de rutinas. Las columnas se buscan por nombre, asi que pueden venir en otro orden o
+// faltar las opcionales; las filas de una misma rutina se juntan por el nombre, en el orden de position
+func ReadRoutinesCSV(r io.Reader) (*RoutineExportFileDTO, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // las planillas suelen omitir las celdas vacias del final
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: no se pudo leer el encabezado: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["routine"]; !ok {
+		return nil, fmt.Errorf("CSV inválido: falta la columna routine")
+	}
+	_, hasID := columns["exercise_id"]
+	_, hasName := columns["exercise_name"]
+	if !hasID && !hasName {
+		return nil, fmt.Errorf("CSV inválido: falta la columna exercise_id o exercise_name")
+	}
+
+	file := &RoutineExportFileDTO{Format: RoutineExportFormat, Version: 1}
+	index := make(map[string]int) // nombre de rutina -> posicion en file.Routines
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido en la linea %d: %v", line, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		name := field("routine")
+		if name == "" {
+			continue
+		}
+		i, ok := index[strings.ToLower(name)]
+		if !ok {
+			i = len(file.Routines)
+			index[strings.ToLower(name)] = i
+			file.Routines = append(file.Routines, RoutineExportDTO{Name: name})
+		}
+		routine := &file.Routines[i]
+
+		var numbers csvNumbers
+		entry := RoutineExportEntryDTO{
+			ExcerciseID:   field("exercise_id"),
+			ExcerciseName: field("exercise_name"),
+			Group:         field("group"),
+			Repetitions:   numbers.int(field("repetitions"), "repetitions"),
+			Series:        numbers.int(field("series"), "series"),
+			Weight:        numbers.float(field("weight"), "weight"),
+			PrescriptionDTO: PrescriptionDTO{
+				RestSeconds: numbers.int(field("rest_seconds"), "rest_seconds"),
+				Tempo:       field("tempo"),
+				HoldSeconds: numbers.int(field("hold_seconds"), "hold_seconds"),
+			},
+		}
+		entry.Position = len(routine.Exercises)
+		if position := field("position"); position != "" {
+			entry.Position = numbers.int(position, "position")
+		}
+		cardio := CardioTargetDTO{
+			DurationSeconds:  numbers.int(field("duration_seconds"), "duration_seconds"),
+			DistanceMeters:   numbers.float(field("distance_meters"), "distance_meters"),
+			PaceSecondsPerKm: numbers.int(field("pace_seconds_per_km"), "pace_seconds_per_km"),
+			HeartRateZone:    numbers.int(field("heart_rate_zone"), "heart_rate_zone"),
+		}
+		if cardio != (CardioTargetDTO{}) {
+			entry.Cardio = &cardio
+		}
+		if sets := field("sets"); sets != "" {
+			if err := json.Unmarshal([]byte(sets), &entry.Sets); err != nil {
+				return nil, fmt.Errorf("CSV inválido en la linea %d: sets debe ser una lista JSON de series", line)
+			}
+		}
+		if numbers.err != "" {
+			return nil, fmt.Errorf("CSV inválido en la linea %d: %s no es un numero", line, numbers.err)
+		}
+
+		// la primera fila de cada grupo define su tipo, descanso y vueltas
+		if entry.Group != "" && field("group_type") != "" && !hasGroup(routine.Groups, entry.Group) {
+			routine.Groups = append(routine.Groups, RoutineExportGroupDTO{
+				Label:       entry.Group,
+				Type:        field("group_type"),
+				RestSeconds: numbers.int(field("group_rest_seconds"), "group_rest_seconds"),
+				Rounds:      numbers.int(field("group_rounds"), "group_rounds"),
+			})
+			if numbers.err != "" {
+				return nil, fmt.Errorf("CSV inválido en la linea %d: %s no es un numero", line, numbers.err)
+			}
+		}
+		routine.Exercises = append(routine.Exercises, entry)
+	}
+	if len(file.Routines) == 0 {
+		return nil, fmt.Errorf("CSV inválido: no tiene filas de rutinas")
+	}
+	return file, nil
+}
+
+func hasGroup(groups []RoutineExportGroupDTO, label string) bool {
+	for _, g := range groups {
+		if g.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+// csvNumbers convierte las celdas numericas y guarda la primera columna que no se pudo leer
+type csvNumbers struct {
+	err string
+}
+
+func (n *csvNumbers) int(value string, column string) int {
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil && n.err == "" {
+		n.err = column
+	}
+	return parsed
+}
+
+func (n *csvNumbers) float(value string, column string) float64 {
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64) // planillas con coma decimal
+	if err != nil && n.err == "" {
+		n.err = column
+	}
+	return parsed
+}
+
+// RoutineExportFilterDTO son los query params de GET /api/routines/export
+type RoutineExportFilterDTO struct {
+	Format    string `form:"format" binding:"omitempty,oneof=json csv"` // por defecto json
+	RoutineID string `form:"id"`                                        // sin id se exportan todas las rutinas del usuario
+}
+
+// RoutineImportQueryDTO son los query params de POST /api/routines/import; el archivo va en el cuerpo
+type RoutineImportQueryDTO struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv"` // por defecto segun el Content-Type
+	DryRun bool   `form:"dry_run"`
+}
+
+// RoutineImportDTO es el pedido de importacion ya leido, en JSON o CSV
+type RoutineImportDTO struct {
+	UserID   string
+	DryRun   bool
+	Routines []RoutineExportDTO
+}
+
+// RoutineImportResultDTO resume la importacion; en dry_run nada se guarda y Created indica lo que se crearia
+type RoutineImportResultDTO struct {
+	DryRun   bool                     `json:"dry_run"`
+	Created  int                      `json:"created"`
+	Skipped  int                      `json:"skipped"`
+	Routines []RoutineImportReportDTO `json:"routines"`
+}
+
+type RoutineImportReportDTO struct {
+	Name      string                  `json:"name"`
+	Status    string                  `json:"status"` // created, ready (dry_run) o skipped
+	RoutineID string                  `json:"routine_id,omitempty"`
+	Exercises int                     `json:"exercises"`
+	Errors    []string                `json:"errors,omitempty"`    // motivo por el que la rutina no se importa
+	Unmatched []RoutineImportRowDTO   `json:"unmatched,omitempty"` // filas cuyo ejercicio no se encontro o no es valido
+	Matched   []RoutineImportMatchDTO `json:"matched,omitempty"`   // ejercicio encontrado para cada fila
+}
+
+type RoutineImportRowDTO struct {
+	Position      int    `json:"position"`
+	ExcerciseID   string `json:"exercise_id,omitempty"`
+	ExcerciseName string `json:"exercise_name,omitempty"`
+	Reason        string `json:"reason"`
+}
+
+type RoutineImportMatchDTO struct {
+	Position      int    `json:"position"`
+	ExcerciseID   string `json:"exercise_id"`
+	ExcerciseName string `json:"exercise_name"`
+	MatchedBy     string `json:"matched_by"` // id o name
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"time"
+)
+
+// ScheduleRegisterDTO planifica una rutina en una fecha puntual (date) o en dias de la semana recurrentes (weekdays)
+type ScheduleRegisterDTO struct {
+	UserID    string
+	RoutineID string `json:"routine_id" binding:"required"`
+	Date      string `json:"date"`                                          // YYYY-MM-DD
+	Weekdays  []int  `json:"weekdays" binding:"omitempty,dive,min=0,max=6"` // 0=domingo ... 6=sabado
+	StartDate string `json:"start_date"`                                    // YYYY-MM-DD, por defecto hoy
+	EndDate   string `json:"end_date"`                                      // YYYY-MM-DD, opcional
+}
+
+type ScheduleResponseDTO struct {
+	ID          string    `json:"id"`
+	RoutineID   string    `json:"routine_id"`
+	RoutineName string    `json:"routine_name"`
+	Date        string    `json:"date,omitempty"`
+	Weekdays    []int     `json:"weekdays,omitempty"`
+	StartDate   string    `json:"start_date,omitempty"`
+	EndDate     string    `json:"end_date,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewScheduleResponseDTO(schedule models.ScheduledWorkout) *ScheduleResponseDTO {
+	return &ScheduleResponseDTO{
+		ID:          utils.GetStringIDFromObjectID(schedule.ID),
+		RoutineID:   utils.GetStringIDFromObjectID(schedule.RoutineID),
+		RoutineName: schedule.RoutineName,
+		Date:        schedule.Date,
+		Weekdays:    schedule.Weekdays,
+		StartDate:   schedule.StartDate,
+		EndDate:     schedule.EndDate,
+		CreatedAt:   schedule.CreationDate,
+	}
+}
+
+// CalendarFilterDTO son los query params de GET /api/schedules/calendar
+type CalendarFilterDTO struct {
+	UserID   string
+	From     string `form:"from"` // YYYY-MM-DD, por defecto el primer dia del mes actual
+	To       string `form:"to"`   // YYYY-MM-DD inclusive, por defecto el ultimo dia del mes actual
+	TimeZone string `form:"tz"`
+}
+
+type CalendarDTO struct {
+	From             string           `json:"from"`
+	To               string           `json:"to"`
+	PlannedSessions  int              `json:"planned_sessions"`  // sesiones planificadas hasta hoy
+	CompletedPlanned int              `json:"completed_planned"` // de esas, cuantas se hicieron
+	Adherence        float64          `json:"adherence"`         // completed_planned / planned_sessions
+	Days             []CalendarDayDTO `json:"days"`
+}
+
+type CalendarDayDTO struct {
+	Date      string                 `json:"date"`
+	Status    string                 `json:"status"` // done, missed, upcoming
+	Planned   []CalendarPlannedDTO   `json:"planned"`
+	Completed []CalendarCompletedDTO `json:"completed"`
+}
+
+type CalendarPlannedDTO struct {
+	ScheduleID  string `json:"schedule_id"`
+	RoutineID   string `json:"routine_id"`
+	RoutineName string `json:"routine_name"`
+	Done        bool   `json:"done"`
+}
+
+type CalendarCompletedDTO struct {
+	WorkoutID   string `json:"workout_id"`
+	RoutineID   string `json:"routine_id"`
+	RoutineName string `json:"routine_name"`
+}
+
+type ScheduleDeleteDTO struct {
+	ScheduleID string
+	UserID     string
+}
+
+// CalendarFeedDTO expone el token secreto del feed .ics y la ruta para suscribirse
+type CalendarFeedDTO struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func NewCalendarFeedDTO(token string) *CalendarFeedDTO {
+	return &CalendarFeedDTO{
+		Token: token,
+		URL:   "/calendar/" + token + ".ics",
+	}
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+)
+
+// TaxonomyTermRegisterDTO es el cuerpo de POST /api/admin/taxonomy/:kind; la clave no se puede cambiar despues
+// porque es lo que guardan los ejercicios
+type TaxonomyTermRegisterDTO struct {
+	Kind    string
+	Key     string   `json:"key" binding:"required,min=2,max=40"`
+	Name    string   `json:"name" binding:"required,min=2,max=60"`
+	Aliases []string `json:"aliases" binding:"max=20,dive,min=2,max=60"`
+}
+
+// TaxonomyTermModifyDTO es el cuerpo de PUT /api/admin/taxonomy/:kind/:key
+type TaxonomyTermModifyDTO struct {
+	Kind    string
+	Key     string
+	Name    string   `json:"name" binding:"required,min=2,max=60"`
+	Aliases []string `json:"aliases" binding:"max=20,dive,min=2,max=60"`
+}
+
+type TaxonomyTermDTO struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Key     string   `json:"key"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+func NewTaxonomyTermDTO(term models.TaxonomyTerm) *TaxonomyTermDTO {
+	return &TaxonomyTermDTO{
+		ID:      utils.GetStringIDFromObjectID(term.ID),
+		Kind:    string(term.Kind),
+		Key:     term.Key,
+		Name:    term.Name,
+		Aliases: term.Aliases,
+	}
+}
+
+// TaxonomyDTO son todos los vocabularios, por tipo, como los devuelve GET /api/taxonomy
+type TaxonomyDTO map[string][]*TaxonomyTermDTO
+
+// MuscleGroupMigrationDTO es el resultado de POST /api/admin/migrations/muscle-groups: cuantos ejercicios y
+// workouts pasaron su grupo muscular de texto libre a la clave del vocabulario
+type MuscleGroupMigrationDTO struct {
+	Excercises int64 `json:"exercises"`
+	Workouts   int64 `json:"workouts"`
+}
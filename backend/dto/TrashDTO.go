@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"AppFitness/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// tipos de documento de la papelera, tal como aparecen en /api/admin/trash/:type
+const (
+	TrashExcercises = "exercises"
+	TrashRoutines   = "routines"
+	TrashUsers      = "users"
+	TrashWorkouts   = "workouts"
+)
+
+// TrashItemDTO es un documento de la papelera; purge_at es cuando el purgado periodico lo borra definitivamente
+type TrashItemDTO struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	OwnerID   string    `json:"owner_id,omitempty"` // creador del ejercicio o la rutina, dueño del workout
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+func NewTrashItemDTO(kind string, id primitive.ObjectID, name string, owner primitive.ObjectID, deletedAt time.Time, retention time.Duration) *TrashItemDTO {
+	item := &TrashItemDTO{
+		ID:        utils.GetStringIDFromObjectID(id),
+		Type:      kind,
+		Name:      name,
+		DeletedAt: deletedAt,
+		PurgeAt:   deletedAt.Add(retention),
+	}
+	if !owner.IsZero() {
+		item.OwnerID = utils.GetStringIDFromObjectID(owner)
+	}
+	return item
+}
+
+// TrashListFields devuelve los campos que la papelera de cada tipo permite ordenar y seleccionar; el nombre y el
+// dueño se guardan en campos distintos segun la coleccion (los usuarios no tienen dueño)
+func TrashListFields(kind string) (PageFields, bool) {
+	var name, owner string
+	switch kind {
+	case TrashExcercises, TrashRoutines:
+		name, owner = "name", "creator_user_id"
+	case TrashUsers:
+		name = "user_name"
+	case TrashWorkouts:
+		name, owner = "routine_name", "user_id"
+	default:
+		return nil, false
+	}
+	fields := PageFields{
+		"id":         {BSON: "_id", Sortable: true},
+		"name":       {BSON: name, Sortable: true},
+		"deleted_at": {BSON: "elimination_date", Sortable: true},
+		"purge_at":   {BSON: "elimination_date"},
+	}
+	if owner != "" {
+		fields["owner_id"] = PageField{BSON: owner}
+	}
+	return fields, true
+}
+
+// TrashPurgeDTO cuenta lo que borro definitivamente una pasada del purgado
+type TrashPurgeDTO struct {
+	Excercises int `json:"exercises"`
+	Routines   int `json:"routines"`
+	Users      int `json:"users"`
+	Workouts   int `json:"workouts"`
+}
+
+// Total suma todos los documentos purgados
+func (p TrashPurgeDTO) Total() int {
+	return p.Excercises + p.Routines + p.Users + p.Workouts
+}
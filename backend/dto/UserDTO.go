@@ -19,6 +19,7 @@ type UserRegisterDTO struct {
 	Height     float32   `json:"height"`
 	Experience string    `json:"experience"`
 	Objetive   string    `json:"objetive"`
+	WeeklyGoal int       `json:"weekly_goal" binding:"gte=0,lte=14"`
 }
 
 func (user UserRegisterDTO) GetModelUserRegister() models.User {
@@ -34,6 +35,7 @@ func (user UserRegisterDTO) GetModelUserRegister() models.User {
 		Height:     user.Height,
 		Experience: models.ExperienceLevel(user.Experience),
 		Objetive:   models.ObjetiveLevel(user.Objetive),
+		WeeklyGoal: user.WeeklyGoal,
 	}
 }
 
@@ -48,6 +50,7 @@ type UserResponseDTO struct {
 	Height     float32
 	Experience string
 	Objetive   string
+	WeeklyGoal int    `json:"weekly_goal"`
 	IsActive   bool   `json:"is_active"`
 	Role       string `json:"role"`
 }
@@ -64,6 +67,7 @@ func NewUserResponseDTO(user models.User) *UserResponseDTO {
 		Height:     user.Height,
 		Experience: string(user.Experience),
 		Objetive:   string(user.Objetive),
+		WeeklyGoal: user.WeeklyGoal,
 		Role:       string(user.Role),
 	}
 }
@@ -77,6 +81,7 @@ type UserModifyDTO struct {
 	Height     float32 `json:"height" binding:"gte=0"`
 	Experience string  `json:"experience"`
 	Objetive   string  `json:"objetive"`
+	WeeklyGoal *int    `json:"weekly_goal" binding:"omitempty,gte=0,lte=14"` // nil: no se toca el objetivo guardado
 }
 
 func GetModelUserModify(user *UserModifyDTO) (models.User, error) {
@@ -86,6 +91,10 @@ func GetModelUserModify(user *UserModifyDTO) (models.User, error) {
 	if err != nil {
 		return models.User{}, fmt.Errorf("ID de usuario con formato inválido: %w", err)
 	}
+	weeklyGoal := 0
+	if user.WeeklyGoal != nil {
+		weeklyGoal = *user.WeeklyGoal
+	}
 	return models.User{
 		ID:         objectID,
 		UserName:   user.UserName,
@@ -95,6 +104,7 @@ func GetModelUserModify(user *UserModifyDTO) (models.User, error) {
 		Height:     user.Height,
 		Experience: models.ExperienceLevel(user.Experience),
 		Objetive:   models.ObjetiveLevel(user.Objetive),
+		WeeklyGoal: weeklyGoal,
 	}, nil
 }
 
@@ -106,6 +116,7 @@ type UserModifyResponseDTO struct {
 	Height     float32
 	Experience string
 	Objetive   string
+	WeeklyGoal int
 }
 
 func NewUserModifyResponseDTO(user models.User) *UserModifyResponseDTO {
@@ -117,6 +128,7 @@ func NewUserModifyResponseDTO(user models.User) *UserModifyResponseDTO {
 		Height:     user.Height,
 		Experience: string(user.Experience),
 		Objetive:   string(user.Objetive),
+		WeeklyGoal: user.WeeklyGoal,
 	}
 }
 
@@ -129,5 +141,22 @@ func GetModelUserModifyResponse(user UserModifyResponseDTO) *models.User {
 		Height:     user.Height,
 		Experience: models.ExperienceLevel(user.Experience),
 		Objetive:   models.ObjetiveLevel(user.Objetive),
+		WeeklyGoal: user.WeeklyGoal,
 	}
 }
+
+// UserListFields son los campos que los listados de usuarios permiten ordenar y seleccionar
+var UserListFields = PageFields{
+	"id":          {BSON: "_id", Sortable: true},
+	"Name":        {BSON: "name", Sortable: true},
+	"LastName":    {BSON: "last_name", Sortable: true},
+	"UserName":    {BSON: "user_name", Sortable: true},
+	"Email":       {BSON: "email", Sortable: true},
+	"BirthDate":   {BSON: "birth_date", Sortable: true},
+	"Weight":      {BSON: "weight", Sortable: true},
+	"Height":      {BSON: "height", Sortable: true},
+	"Experience":  {BSON: "experience", Sortable: true},
+	"Objetive":    {BSON: "objetive", Sortable: true},
+	"weekly_goal": {BSON: "weekly_goal", Sortable: true},
+	"role":        {BSON: "role", Sortable: true},
+}
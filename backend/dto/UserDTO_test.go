@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetModelUserModifyWeeklyGoal(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	goal := 4
+
+	user, err := GetModelUserModify(&UserModifyDTO{ID: id, WeeklyGoal: &goal})
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if user.WeeklyGoal != 4 {
+		t.Fatalf("weekly_goal = %d, se esperaba 4", user.WeeklyGoal)
+	}
+
+	user, err = GetModelUserModify(&UserModifyDTO{ID: id})
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if user.WeeklyGoal != 0 {
+		t.Fatalf("sin weekly_goal el modelo deberia quedar en cero, se obtuvo %d", user.WeeklyGoal)
+	}
+}
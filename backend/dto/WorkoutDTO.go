@@ -5,19 +5,142 @@ import (
 	"AppFitness/utils"
 	"fmt"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WorkoutRegisterDTO struct {
-	RoutineID   string `json:"routine_id" binding:"required"`
+	RoutineID   string // se setea en handler desde la URL
 	RoutineName string
 	UserID      string
+	Exercises   []ExcerciseInWorkoutDTO `json:"exercises" binding:"dive"`
+	Date        *time.Time              `json:"date"` // opcional, para registrar un workout pasado (RFC 3339)
+	Notes       string                  `json:"notes" binding:"max=1000"`
+}
+
+// WorkoutModifyDTO edita un workout terminado; los campos nulos no se modifican
+type WorkoutModifyDTO struct {
+	WorkoutID string
+	UserID    string
+	Date      *time.Time               `json:"date"`
+	Notes     *string                  `json:"notes" binding:"omitempty,max=1000"`
+	Exercises *[]ExcerciseInWorkoutDTO `json:"exercises" binding:"omitempty,dive"`
+}
+
+// ExcerciseInWorkoutDTO es un ejercicio realizado dentro de un workout, con sus series
+type ExcerciseInWorkoutDTO struct {
+	EntryID     string          `json:"entry_id,omitempty"` // entrada de la rutina, necesaria si el ejercicio esta dos veces
+	ExcerciseID string          `json:"exercise_id" binding:"required"`
+	Sets        []WorkoutSetDTO `json:"sets" binding:"required,min=1,dive"`
+}
+
+type WorkoutSetDTO struct {
+	Repetitions int     `json:"repetitions" binding:"gte=0,lte=100"`
+	Weight      float64 `json:"weight" binding:"gte=0,lte=1000"`
+	RPE         float64 `json:"rpe,omitempty" binding:"omitempty,gte=1,lte=10"`
+	Completed   bool    `json:"completed"`
+
+	// cardio y flexibilidad: tiempo sostenido o recorrido, distancia y pulso promedio
+	DurationSeconds int     `json:"duration_seconds,omitempty" binding:"omitempty,gte=0,lte=86400"`
+	DistanceMeters  float64 `json:"distance_meters,omitempty" binding:"omitempty,gte=0,lte=500000"`
+	HeartRate       int     `json:"heart_rate,omitempty" binding:"omitempty,gte=30,lte=250"`
+}
+
+// WorkoutSetRegisterDTO es una serie que se agrega a un workout en curso
+type WorkoutSetRegisterDTO struct {
+	UserID      string
+	EntryID     string  `json:"entry_id"` // entrada de la rutina, necesaria si el ejercicio esta dos veces
+	ExcerciseID string  `json:"exercise_id" binding:"required"`
+	Repetitions int     `json:"repetitions" binding:"gte=0,lte=100"`
+	Weight      float64 `json:"weight" binding:"gte=0,lte=1000"`
+	RPE         float64 `json:"rpe,omitempty" binding:"omitempty,gte=1,lte=10"`
+	Completed   bool    `json:"completed"`
+
+	DurationSeconds int     `json:"duration_seconds,omitempty" binding:"omitempty,gte=0,lte=86400"`
+	DistanceMeters  float64 `json:"distance_meters,omitempty" binding:"omitempty,gte=0,lte=500000"`
+	HeartRate       int     `json:"heart_rate,omitempty" binding:"omitempty,gte=30,lte=250"`
 }
+
 type WorkoutResponseDTO struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	RoutineID   string    `json:"routine_id"`
-	RoutineName string    `json:"RoutineName"`
-	DoneAt      time.Time `json:"DoneAt"`
+	ID              string                  `json:"id"`
+	UserID          string                  `json:"user_id"`
+	RoutineID       string                  `json:"routine_id"`
+	RoutineName     string                  `json:"RoutineName"`
+	DoneAt          time.Time               `json:"DoneAt"`
+	Exercises       []ExcerciseInWorkoutDTO `json:"exercises"`
+	Status          string                  `json:"status"`
+	Paused          bool                    `json:"paused"`
+	StartTime       time.Time               `json:"start_time"`
+	EndTime         time.Time               `json:"end_time"`
+	DurationSeconds int64                   `json:"duration_seconds"`
+	Notes           string                  `json:"notes"`
+	Routine         *RoutineSnapshotDTO     `json:"routine,omitempty"` // rutina tal como estaba al hacer el workout
+	Source          string                  `json:"source,omitempty"`  // import = historial importado
+}
+
+type RoutineSnapshotDTO struct {
+	Name      string                 `json:"name"`
+	TakenAt   time.Time              `json:"taken_at"`
+	Exercises []ExcerciseSnapshotDTO `json:"exercises"`
+	Groups    []ExcerciseGroupDTO    `json:"groups,omitempty"`
+}
+
+type ExcerciseSnapshotDTO struct {
+	EntryID         string  `json:"entry_id,omitempty"`
+	ExcerciseID     string  `json:"exercise_id"`
+	GroupID         string  `json:"group_id,omitempty"`
+	Name            string  `json:"name"`
+	Category        string  `json:"category,omitempty"`
+	MainMuscleGroup string  `json:"main_muscle_group"`
+	Repetitions     int     `json:"repetitions"`
+	Series          int     `json:"series"`
+	Weight          float64 `json:"weight"`
+
+	Sets        []SetPrescriptionDTO `json:"sets"`
+	RestSeconds int                  `json:"rest_seconds,omitempty"`
+	Tempo       string               `json:"tempo,omitempty"`
+	Cardio      *CardioTargetDTO     `json:"cardio,omitempty"`
+	HoldSeconds int                  `json:"hold_seconds,omitempty"`
+}
+
+func newRoutineSnapshotDTO(snapshot *models.RoutineSnapshot) *RoutineSnapshotDTO {
+	if snapshot == nil {
+		return nil
+	}
+	snapshotDTO := &RoutineSnapshotDTO{
+		Name:      snapshot.Name,
+		TakenAt:   snapshot.TakenAt,
+		Exercises: []ExcerciseSnapshotDTO{},
+	}
+	for _, e := range snapshot.Exercises {
+		snapshotDTO.Exercises = append(snapshotDTO.Exercises, ExcerciseSnapshotDTO{
+			EntryID:         optionalID(e.EntryID),
+			ExcerciseID:     utils.GetStringIDFromObjectID(e.ExcerciseID),
+			GroupID:         optionalID(e.GroupID),
+			Name:            e.Name,
+			Category:        string(e.Category),
+			MainMuscleGroup: e.MainMuscleGroup,
+			Repetitions:     e.Repetitions,
+			Series:          e.Series,
+			Weight:          e.Weight,
+			Sets:            newSetPrescriptionDTOs(e.Entry().Prescription()),
+			RestSeconds:     e.RestSeconds,
+			Tempo:           e.Tempo,
+			Cardio:          newCardioTargetDTO(e.Cardio),
+			HoldSeconds:     e.HoldSeconds,
+		})
+	}
+	snapshotDTO.Groups = newExcerciseGroupDTOs(snapshot.Groups, entryGroups(snapshot))
+	return snapshotDTO
+}
+
+// entryGroups arma la lista de entradas de la foto para ubicar los miembros de cada grupo
+func entryGroups(snapshot *models.RoutineSnapshot) []models.ExcerciseInRoutine {
+	entries := make([]models.ExcerciseInRoutine, 0, len(snapshot.Exercises))
+	for _, e := range snapshot.Exercises {
+		entries = append(entries, models.ExcerciseInRoutine{EntryID: e.EntryID, ExcerciseID: e.ExcerciseID, GroupID: e.GroupID})
+	}
+	return entries
 }
 
 func GetModelWorkoutRegisterDTO(dto *WorkoutRegisterDTO) (models.Workout, error) {
@@ -32,27 +155,145 @@ func GetModelWorkoutRegisterDTO(dto *WorkoutRegisterDTO) (models.Workout, error)
 		return models.Workout{}, fmt.Errorf("ID de usuario con formato inválido: %w", err)
 	}
 
+	exercises, err := GetModelExcercisesInWorkoutDTO(dto.Exercises)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
 	return models.Workout{
 		RoutineID:   routineOID,
 		UserID:      userOID,
 		RoutineName: dto.RoutineName,
+		Exercises:   exercises,
+		Notes:       dto.Notes,
 	}, nil // <--- 4. Devuelve nil como error
 }
 
+func GetModelExcercisesInWorkoutDTO(exercisesDTO []ExcerciseInWorkoutDTO) ([]models.ExcerciseInWorkout, error) {
+	var exercises []models.ExcerciseInWorkout
+	for _, e := range exercisesDTO {
+		excerciseOID, err := utils.GetObjectIDFromStringID(e.ExcerciseID)
+		if err != nil {
+			return nil, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
+		}
+		var entryOID primitive.ObjectID
+		if e.EntryID != "" {
+			if entryOID, err = utils.GetObjectIDFromStringID(e.EntryID); err != nil {
+				return nil, fmt.Errorf("ID de entrada con formato inválido: %w", err)
+			}
+		}
+		sets := make([]models.WorkoutSet, 0, len(e.Sets))
+		for _, s := range e.Sets {
+			sets = append(sets, models.WorkoutSet{
+				Repetitions:     s.Repetitions,
+				Weight:          s.Weight,
+				RPE:             s.RPE,
+				Completed:       s.Completed,
+				DurationSeconds: s.DurationSeconds,
+				DistanceMeters:  s.DistanceMeters,
+				HeartRate:       s.HeartRate,
+			})
+		}
+		exercises = append(exercises, models.ExcerciseInWorkout{
+			EntryID:     entryOID,
+			ExcerciseID: excerciseOID,
+			Sets:        sets,
+		})
+	}
+	return exercises, nil
+}
+
+func newExcercisesInWorkoutResponseDTO(exercises []models.ExcerciseInWorkout) []ExcerciseInWorkoutDTO {
+	exercisesDTO := []ExcerciseInWorkoutDTO{}
+	for _, e := range exercises {
+		sets := make([]WorkoutSetDTO, 0, len(e.Sets))
+		for _, s := range e.Sets {
+			sets = append(sets, WorkoutSetDTO{
+				Repetitions:     s.Repetitions,
+				Weight:          s.Weight,
+				RPE:             s.RPE,
+				Completed:       s.Completed,
+				DurationSeconds: s.DurationSeconds,
+				DistanceMeters:  s.DistanceMeters,
+				HeartRate:       s.HeartRate,
+			})
+		}
+		exercisesDTO = append(exercisesDTO, ExcerciseInWorkoutDTO{
+			EntryID:     optionalID(e.EntryID),
+			ExcerciseID: utils.GetStringIDFromObjectID(e.ExcerciseID),
+			Sets:        sets,
+		})
+	}
+	return exercisesDTO
+}
+
+func GetModelWorkoutSetRegisterDTO(set *WorkoutSetRegisterDTO) models.WorkoutSet {
+	return models.WorkoutSet{
+		Repetitions:     set.Repetitions,
+		Weight:          set.Weight,
+		RPE:             set.RPE,
+		Completed:       set.Completed,
+		DurationSeconds: set.DurationSeconds,
+		DistanceMeters:  set.DistanceMeters,
+		HeartRate:       set.HeartRate,
+	}
+}
+
 func NewWorkoutResponseDTO(workout models.Workout) *WorkoutResponseDTO {
+	status := workout.Status
+	if status == "" {
+		status = models.WorkoutFinished // workouts registrados antes del ciclo de vida
+	}
 	return &WorkoutResponseDTO{
-		UserID:      utils.GetStringIDFromObjectID(workout.UserID),
-		RoutineID:   utils.GetStringIDFromObjectID(workout.RoutineID),
-		RoutineName: workout.RoutineName,
-		DoneAt:      workout.Date,
+		ID:              utils.GetStringIDFromObjectID(workout.ID),
+		UserID:          utils.GetStringIDFromObjectID(workout.UserID),
+		RoutineID:       optionalID(workout.RoutineID), // vacio en el historial importado
+		RoutineName:     workout.RoutineName,
+		DoneAt:          workout.Date,
+		Exercises:       newExcercisesInWorkoutResponseDTO(workout.Exercises),
+		Status:          string(status),
+		Paused:          !workout.PausedAt.IsZero(),
+		StartTime:       workout.StartTime,
+		EndTime:         workout.EndTime,
+		DurationSeconds: workout.DurationSeconds,
+		Notes:           workout.Notes,
+		Routine:         newRoutineSnapshotDTO(workout.Routine),
+		Source:          string(workout.Source),
 	}
 }
 
+// WorkoutStatsFilterDTO son los query params de GET /api/workouts/stats
+type WorkoutStatsFilterDTO struct {
+	UserID      string
+	From        string `form:"from"`        // YYYY-MM-DD o RFC3339, inclusive
+	To          string `form:"to"`          // YYYY-MM-DD o RFC3339, inclusive
+	Granularity string `form:"granularity"` // day, week, month (default) o year
+	TimeZone    string `form:"tz"`          // zona IANA del usuario, ej. America/Argentina/Buenos_Aires
+}
+
 type WorkoutStatsDTO struct {
 	TotalWorkouts    int                //cantidad total de workouts del user
 	WeeklyFrequency  float64            // promedio de entrenamientos desde que se realizop el primero (ir contando la cantidad de dias que hay entre entrenamientos (desde el primero hasta el ult) y dividir por la cantidad de entrenamientos)
 	MostUsedRoutines []RoutineUsageDTO  //ranking de rutinas mas usadas
 	ProgressOverTime []ProgressPointDTO //para grafica entrenamientos-dias
+
+	// cardio y flexibilidad: suma de las series completadas por tiempo o distancia
+	TotalDurationSeconds int
+	TotalDistanceMeters  float64
+
+	// constancia respecto del objetivo semanal del usuario (si no tiene objetivo se toma 1 por semana)
+	WeeklyGoal      int
+	CurrentStreak   int                  // semanas consecutivas cumpliendo el objetivo hasta hoy
+	LongestStreak   int                  // mejor racha de semanas cumpliendo el objetivo
+	GoalMetRatio    float64              // semanas cumplidas / semanas del periodo (0 a 1)
+	WeeklyAdherence []WeeklyAdherenceDTO // por defecto las ultimas 12 semanas o el rango from/to
+}
+
+type WeeklyAdherenceDTO struct {
+	Week     string // semana ISO, ej. 2024-W05
+	Sessions int
+	Goal     int
+	Met      bool
 }
 
 type RoutineUsageDTO struct {
@@ -61,11 +302,58 @@ type RoutineUsageDTO struct {
 }
 
 type ProgressPointDTO struct {
-	Date  string
-	Count int
+	Date            string
+	Count           int
+	DurationSeconds int
+	DistanceMeters  float64
+}
+
+// ExcerciseProgressDTO son las curvas de fuerza de un ejercicio agrupadas por dia, semana o mes
+type ExcerciseProgressDTO struct {
+	ExcerciseID        string             `json:"exercise_id"`
+	ExcerciseName      string             `json:"exercise_name"`
+	Formula            string             `json:"formula"`
+	Granularity        string             `json:"granularity"`
+	EstimatedOneRepMax []ProgressValueDTO `json:"estimated_one_rep_max"` // mejor 1RM estimado del periodo
+	BestSet            []BestSetPointDTO  `json:"best_set"`
+	TotalVolume        []ProgressValueDTO `json:"total_volume"` // peso x repeticiones sumado en el periodo
+
+	// ejercicios por tiempo o distancia; el ritmo es el mejor del periodo en segundos por km
+	TotalDuration []ProgressValueDTO `json:"total_duration_seconds"`
+	TotalDistance []ProgressValueDTO `json:"total_distance_meters"`
+	BestPace      []ProgressValueDTO `json:"best_pace_seconds_per_km"`
+}
+
+type ProgressValueDTO struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+type BestSetPointDTO struct {
+	Date        string  `json:"date"`
+	Weight      float64 `json:"weight"`
+	Repetitions int     `json:"repetitions"`
 }
 
 type WorkoutDeleteDTO struct {
 	RoutineID string
 	UserID    string
 }
+
+// WorkoutListFields son los campos que GET /api/workouts permite ordenar y seleccionar
+var WorkoutListFields = PageFields{
+	"id":               {BSON: "_id", Sortable: true},
+	"user_id":          {BSON: "user_id"},
+	"routine_id":       {BSON: "routine_id"},
+	"RoutineName":      {BSON: "routine_name", Sortable: true},
+	"DoneAt":           {BSON: "date_and_hours", Sortable: true},
+	"exercises":        {BSON: "exercises"},
+	"status":           {BSON: "status", Sortable: true},
+	"paused":           {BSON: "paused_at"},
+	"start_time":       {BSON: "start_time", Sortable: true},
+	"end_time":         {BSON: "end_time", Sortable: true},
+	"duration_seconds": {BSON: "duration_seconds", Sortable: true},
+	"notes":            {BSON: "notes"},
+	"routine":          {BSON: "routine_snapshot"},
+	"source":           {BSON: "source"},
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetModelExcercisesInWorkoutDTO(t *testing.T) {
+	id := primitive.NewObjectID()
+	exercises, err := GetModelExcercisesInWorkoutDTO([]ExcerciseInWorkoutDTO{{
+		ExcerciseID: id.Hex(),
+		Sets:        []WorkoutSetDTO{{Repetitions: 8, Weight: 60, Completed: true}, {Repetitions: 6, Weight: 65}},
+	}})
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if len(exercises) != 1 || exercises[0].ExcerciseID != id || len(exercises[0].Sets) != 2 {
+		t.Fatalf("conversion incorrecta: %+v", exercises)
+	}
+	if exercises[0].Sets[1].Weight != 65 || !exercises[0].Sets[0].Completed {
+		t.Fatalf("series mal copiadas: %+v", exercises[0].Sets)
+	}
+
+	if _, err := GetModelExcercisesInWorkoutDTO([]ExcerciseInWorkoutDTO{{ExcerciseID: "nope"}}); err == nil {
+		t.Fatal("se esperaba error por ID inválido")
+	}
+}
@@ -0,0 +1,361 @@
+package dto
+
+import (
+	"AppFitness/utils"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkoutImportQueryDTO son los query params de POST /api/workouts/import; igual que al importar rutinas, el
+// archivo va en el cuerpo. La asignacion manual de los nombres que no se pudieron reconocer se envia en un segundo
+// paso como mapping[nombre en el CSV]=ID de ejercicio ("" = ignorar esas filas)
+type WorkoutImportQueryDTO struct {
+	DryRun     bool   `form:"dry_run"`
+	Unit       string `form:"unit" binding:"omitempty,oneof=kg lb"`          // unidad del peso si el CSV no la indica, por defecto kg
+	TimeZone   string `form:"tz"`                                            // zona de las fechas sin zona horaria, por defecto UTC
+	DateFormat string `form:"date_format" binding:"omitempty,oneof=dmy mdy"` // orden de las fechas con barras, ej. 03/04/2024
+}
+
+// WorkoutImportDTO es el pedido de importacion ya leido: el CSV exportado de otra aplicacion o planilla
+type WorkoutImportDTO struct {
+	UserID     string
+	CSV        string
+	Mapping    map[string]string // nombre en el CSV -> ID de ejercicio; "" = ignorar esas filas
+	DryRun     bool
+	Unit       string
+	TimeZone   string
+	DateFormat string
+}
+
+// WorkoutLogRowDTO es una serie leida del CSV, con el peso ya pasado a kg y la distancia a metros
+type WorkoutLogRowDTO struct {
+	Line            int
+	Date            time.Time
+	Workout         string
+	Excercise       string
+	SetOrder        int // 0 = sin numero, se respeta el orden de las filas
+	Weight          float64
+	Repetitions     int
+	DurationSeconds int
+	DistanceMeters  float64
+	RPE             float64
+	Notes           string
+}
+
+// WorkoutImportResultDTO resume la importacion; en dry_run nada se guarda y los totales indican lo que se crearia
+type WorkoutImportResultDTO struct {
+	DryRun        bool                        `json:"dry_run"`
+	Workouts      int                         `json:"workouts"`
+	Sets          int                         `json:"sets"`
+	Duplicates    int                         `json:"duplicates"` // workouts ya importados antes con la misma fecha y nombre
+	From          *time.Time                  `json:"from,omitempty"`
+	To            *time.Time                  `json:"to,omitempty"`
+	Exercises     []WorkoutImportExcerciseDTO `json:"exercises"`
+	Errors        []WorkoutImportRowErrorDTO  `json:"errors,omitempty"`
+	ErrorsOmitted int                         `json:"errors_omitted,omitempty"` // filas con error que no entran en Errors
+}
+
+// WorkoutImportExcerciseDTO indica a que ejercicio del catalogo se asigno cada nombre del CSV
+type WorkoutImportExcerciseDTO struct {
+	Name          string                      `json:"name"`
+	Rows          int                         `json:"rows"`
+	ExcerciseID   string                      `json:"exercise_id,omitempty"`
+	ExcerciseName string                      `json:"exercise_name,omitempty"`
+	MatchedBy     string                      `json:"matched_by"` // mapping, exact, fuzzy, skipped o unmatched
+	Score         float64                     `json:"score,omitempty"`
+	Candidates    []WorkoutImportCandidateDTO `json:"candidates,omitempty"` // sugerencias para los nombres sin asignar
+}
+
+type WorkoutImportCandidateDTO struct {
+	ExcerciseID string  `json:"exercise_id"`
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+}
+
+type WorkoutImportRowErrorDTO struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// workoutLogColumns son los encabezados reconocidos de cada dato, ya normalizados; cubre las exportaciones
+// de Strong, Hevy, FitNotes y planillas en español
+var workoutLogColumns = map[string][]string{
+	"date":          {"date", "fecha", "start time", "workout date", "dia"},
+	"workout":       {"workout name", "workout", "title", "entrenamiento", "rutina"},
+	"exercise":      {"exercise name", "exercise", "exercise title", "ejercicio"},
+	"set":           {"set order", "set", "set index", "serie"},
+	"weight":        {"weight", "peso"},
+	"weight_kg":     {"weight kg", "weight kgs", "peso kg"},
+	"weight_lb":     {"weight lb", "weight lbs", "peso lb"},
+	"unit":          {"unit", "units", "weight unit", "unidad"},
+	"reps":          {"reps", "repetitions", "repeticiones"},
+	"seconds":       {"seconds", "duration seconds", "segundos", "time"},
+	"distance":      {"distance", "distancia"},
+	"distance_km":   {"distance km"},
+	"distance_m":    {"distance m", "distance meters"},
+	"distance_unit": {"distance unit", "unidad distancia"},
+	"rpe":           {"rpe"},
+	"notes":         {"notes", "exercise notes", "notas"},
+}
+
+// workoutLogDateLayouts son los formatos de fecha sin ambiguedad, del mas al menos especifico
+var workoutLogDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2 Jan 2006, 15:04",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+}
+
+// workoutLogSlashLayouts son las fechas con barras, que se leen dia/mes o mes/dia segun date_format
+var workoutLogSlashLayouts = map[string][]string{
+	"dmy": {"2/1/2006 15:04:05", "2/1/2006 15:04", "2/1/2006"},
+	"mdy": {"1/2/2006 15:04:05", "1/2/2006 15:04", "1/2/2006"},
+}
+
+const poundsToKg = 0.45359237
+
+// ReadWorkoutLogCSV lee el historial de otra aplicacion; las fechas sin zona se toman en location, las fechas con
+// barras en el orden dateFormat (dmy o mdy, vacio = solo las que no son ambiguas) y el peso se interpreta en unit
+// salvo que el encabezado o la columna unit digan otra cosa. Las filas que no se pueden leer se devuelven aparte
+// para informarlas sin frenar el resto de la importacion
+func ReadWorkoutLogCSV(r io.Reader, location *time.Location, unit string, dateFormat string) ([]WorkoutLogRowDTO, []WorkoutImportRowErrorDTO, error) {
+	buffered := bufio.NewReader(r)
+	reader := csv.NewReader(buffered)
+	if first, _ := buffered.Peek(4096); delimiter(first) == ';' { // planillas exportadas con coma decimal
+		reader.Comma = ';'
+	}
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("CSV inválido: no se pudo leer el encabezado: %v", err)
+	}
+	return parseWorkoutLogRecords(reader, header, location, unit, dateFormat)
+}
+
+// delimiter elige entre coma y punto y coma segun cual aparece mas en la primera linea
+func delimiter(start []byte) rune {
+	line := string(start)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if strings.Count(line, ";") > strings.Count(line, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func parseWorkoutLogRecords(reader *csv.Reader, header []string, location *time.Location, unit string, dateFormat string) ([]WorkoutLogRowDTO, []WorkoutImportRowErrorDTO, error) {
+	aliases := make(map[string]string)
+	for column, names := range workoutLogColumns {
+		for _, name := range names {
+			aliases[name] = column
+		}
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		column, ok := aliases[utils.NormalizeText(strings.TrimPrefix(name, "\ufeff"))]
+		if _, taken := columns[column]; ok && !taken { // si se repite, vale la primera columna
+			columns[column] = i
+		}
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, nil, fmt.Errorf("CSV inválido: falta la columna de fecha (date)")
+	}
+	if _, ok := columns["exercise"]; !ok {
+		return nil, nil, fmt.Errorf("CSV inválido: falta la columna de ejercicio (exercise)")
+	}
+
+	var rows []WorkoutLogRowDTO
+	var rowErrors []WorkoutImportRowErrorDTO
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rowErrors = append(rowErrors, WorkoutImportRowErrorDTO{Line: line, Reason: err.Error()})
+			continue
+		}
+		line, _ = reader.FieldPos(0) // una celda entre comillas puede ocupar varias lineas
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row, err := parseWorkoutLogRow(field, location, unit, dateFormat)
+		if err != nil {
+			rowErrors = append(rowErrors, WorkoutImportRowErrorDTO{Line: line, Reason: err.Error()})
+			continue
+		}
+		if row == nil { // fila vacia
+			continue
+		}
+		row.Line = line
+		rows = append(rows, *row)
+	}
+	if len(rows) == 0 && len(rowErrors) == 0 {
+		return nil, nil, fmt.Errorf("CSV inválido: no tiene series para importar")
+	}
+	return rows, rowErrors, nil
+}
+
+func parseWorkoutLogRow(field func(string) string, location *time.Location, unit string, dateFormat string) (*WorkoutLogRowDTO, error) {
+	excercise := field("exercise")
+	date := field("date")
+	if excercise == "" && date == "" {
+		return nil, nil
+	}
+	if excercise == "" {
+		return nil, fmt.Errorf("falta el nombre del ejercicio")
+	}
+	parsedDate, err := parseWorkoutLogDate(date, location, dateFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	var numbers csvNumbers
+	row := &WorkoutLogRowDTO{
+		Date:        parsedDate,
+		Workout:     field("workout"),
+		Excercise:   excercise,
+		SetOrder:    numbers.int(field("set"), "set"),
+		Repetitions: int(numbers.float(field("reps"), "reps")),
+		RPE:         numbers.float(field("rpe"), "rpe"),
+		Notes:       field("notes"),
+	}
+
+	switch {
+	case field("weight_kg") != "":
+		row.Weight = numbers.float(field("weight_kg"), "weight")
+	case field("weight_lb") != "":
+		row.Weight = numbers.float(field("weight_lb"), "weight") * poundsToKg
+	default:
+		row.Weight = numbers.float(field("weight"), "weight")
+		weightUnit := strings.ToLower(field("unit"))
+		if weightUnit == "" {
+			weightUnit = unit
+		}
+		if strings.HasPrefix(weightUnit, "lb") {
+			row.Weight *= poundsToKg
+		}
+	}
+	row.Weight = float64(int(row.Weight*100+0.5)) / 100 // las conversiones de libras dejan decimales de mas
+
+	switch {
+	case field("distance_km") != "":
+		row.DistanceMeters = numbers.float(field("distance_km"), "distance") * 1000
+	case field("distance_m") != "":
+		row.DistanceMeters = numbers.float(field("distance_m"), "distance")
+	default:
+		row.DistanceMeters = numbers.float(field("distance"), "distance") * distanceFactor(field("distance_unit"))
+	}
+
+	if seconds := field("seconds"); seconds != "" {
+		parsed, ok := parseDurationCell(seconds)
+		if !ok {
+			return nil, fmt.Errorf("seconds no es un numero ni una duracion h:mm:ss")
+		}
+		row.DurationSeconds = parsed
+	}
+	if numbers.err != "" {
+		return nil, fmt.Errorf("%s no es un numero", numbers.err)
+	}
+	if row.Repetitions < 0 || row.Weight < 0 || row.DistanceMeters < 0 || row.RPE < 0 || row.RPE > 10 {
+		return nil, fmt.Errorf("valores fuera de rango")
+	}
+	if row.Repetitions == 0 && row.Weight == 0 && row.DurationSeconds == 0 && row.DistanceMeters == 0 {
+		return nil, fmt.Errorf("la serie no tiene repeticiones, peso, tiempo ni distancia")
+	}
+	return row, nil
+}
+
+// parseWorkoutLogDate prueba RFC3339 y luego los formatos de workoutLogDateLayouts en la zona del usuario. Una
+// fecha con barras como 03/04/2024 es el 3 de abril en una planilla europea y el 4 de marzo en una de Estados
+// Unidos: sin dateFormat solo se acepta si uno de los dos numeros pasa de 12 y deja claro el orden
+func parseWorkoutLogDate(value string, location *time.Location, dateFormat string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("falta la fecha")
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+	layouts := workoutLogDateLayouts
+	if strings.Contains(value, "/") {
+		if dateFormat == "" {
+			dateFormat = slashDateOrder(value)
+		}
+		if dateFormat == "" {
+			return time.Time{}, fmt.Errorf("fecha %q ambigua: indique date_format=dmy (dia/mes) o date_format=mdy (mes/dia)", value)
+		}
+		layouts = workoutLogSlashLayouts[dateFormat]
+	}
+	for _, layout := range layouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fecha %q con formato desconocido", value)
+}
+
+// slashDateOrder deduce el orden de una fecha con barras cuando no es ambigua: dmy si el primer numero pasa de 12,
+// mdy si lo hace el segundo, y cualquiera de los dos si son iguales. Devuelve "" si no se puede saber
+func slashDateOrder(value string) string {
+	parts := strings.SplitN(value, "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	first, err1 := strconv.Atoi(parts[0])
+	second, err2 := strconv.Atoi(parts[1])
+	switch {
+	case err1 != nil || err2 != nil:
+		return ""
+	case first > 12 || first == second:
+		return "dmy"
+	case second > 12:
+		return "mdy"
+	}
+	return ""
+}
+
+// distanceFactor pasa la unidad de distancia del CSV a metros; sin unidad se asume km
+func distanceFactor(unit string) float64 {
+	switch strings.ToLower(unit) {
+	case "m", "meters", "metros":
+		return 1
+	case "mi", "miles", "millas":
+		return 1609.344
+	case "ft", "feet":
+		return 0.3048
+	default:
+		return 1000
+	}
+}
+
+// parseDurationCell acepta segundos o una duracion mm:ss / h:mm:ss
+func parseDurationCell(value string) (int, bool) {
+	if seconds, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64); err == nil {
+		return int(seconds), seconds >= 0
+	}
+	total := 0
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	return total, true
+}
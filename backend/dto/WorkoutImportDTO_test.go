@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWorkoutLogDate(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("sin base de zonas horarias")
+	}
+	cases := []struct {
+		name       string
+		value      string
+		dateFormat string
+		want       time.Time
+		wantErr    string
+	}{
+		{"RFC3339 ignora la zona del usuario", "2024-04-03T18:30:00Z", "", time.Date(2024, 4, 3, 18, 30, 0, 0, time.UTC), ""},
+		{"ISO en la zona del usuario", "2024-04-03 18:30:00", "", time.Date(2024, 4, 3, 18, 30, 0, 0, madrid), ""},
+		{"mes en texto", "3 Apr 2024, 18:30", "", time.Date(2024, 4, 3, 18, 30, 0, 0, madrid), ""},
+		{"barras dia/mes", "03/04/2024 18:30", "dmy", time.Date(2024, 4, 3, 18, 30, 0, 0, madrid), ""},
+		{"barras mes/dia", "04/03/2024 18:30", "mdy", time.Date(2024, 4, 3, 18, 30, 0, 0, madrid), ""},
+		{"barras sin ceros", "4/3/2024", "mdy", time.Date(2024, 4, 3, 0, 0, 0, 0, madrid), ""},
+		{"ambigua sin date_format", "03/04/2024", "", time.Time{}, "ambigua"},
+		{"el dia pasa de 12", "23/04/2024", "", time.Date(2024, 4, 23, 0, 0, 0, 0, madrid), ""},
+		{"el dia pasa de 12 en formato americano", "04/23/2024", "", time.Date(2024, 4, 23, 0, 0, 0, 0, madrid), ""},
+		{"dia y mes iguales", "05/05/2024", "", time.Date(2024, 5, 5, 0, 0, 0, 0, madrid), ""},
+		{"contradice el date_format", "23/04/2024", "mdy", time.Time{}, "formato desconocido"},
+		{"vacia", "", "", time.Time{}, "falta la fecha"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseWorkoutLogDate(tc.value, madrid, tc.dateFormat)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("se esperaba un error con %q, se obtuvo %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("%s, se esperaba %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadWorkoutLogCSVReportsAmbiguousDates(t *testing.T) {
+	csv := "Date,Exercise Name,Weight,Reps\n" +
+		"03/04/2024,Bench Press,135,8\n" +
+		"23/04/2024,Bench Press,140,6\n"
+	rows, rowErrors, err := ReadWorkoutLogCSV(strings.NewReader(csv), time.UTC, "lb", "")
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Date.Day() != 23 || rows[0].Weight != 63.5 {
+		t.Fatalf("filas inesperadas: %+v", rows)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Line != 2 || !strings.Contains(rowErrors[0].Reason, "date_format") {
+		t.Fatalf("la fecha ambigua deberia informarse en la linea 2: %+v", rowErrors)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"AppFitness/dto"
 	"AppFitness/services"
 	"net/http"
 	"strings"
@@ -54,10 +55,20 @@ func (h *AdminHandler) GetLogs(c *gin.Context) {
 		return
 	}
 
-	result, count, err := h.AdminService.GetLogs()
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.AdminService.GetLogs(page)
 	if err != nil {
 		msg := err.Error()
 		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		case strings.Contains(msg, "vacio"):
 			// No hay usuarios registrados
 			c.Status(http.StatusNoContent) // 204
@@ -74,9 +85,124 @@ func (h *AdminHandler) GetLogs(c *gin.Context) {
 		}
 	}
 
-	// Éxito: devolvemos la lista de usuarios + cantidad
-	c.JSON(http.StatusOK, gin.H{
-		"total": count,
-		"users": result,
-	})
+	// Éxito: devolvemos la pagina de usuarios (con total si se pide ?total=true)
+	pageLinks(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) GetSessions(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.AdminService.GetSessions(page)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener sesiones"}) // 500
+			return
+		}
+	}
+
+	pageLinks(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	adminID, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	err := h.AdminService.DeleteUser(c.Param("id"), adminID.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+		case strings.Contains(msg, "su propio usuario"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+
+		case strings.Contains(msg, "no se encontró"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al eliminar el usuario"}) // 500
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+// GetTrash lista la papelera de un tipo: exercises, routines, users o workouts
+func (h *AdminHandler) GetTrash(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.AdminService.GetTrash(c.Param("type"), page)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener la papelera"}) // 500
+		}
+		return
+	}
+
+	pageLinks(c, result)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AdminHandler) RestoreFromTrash(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	err := h.AdminService.RestoreFromTrash(c.Param("type"), c.Param("id"))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+		case strings.Contains(msg, "no se encontró"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al restaurar"}) // 500
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"restored": true})
 }
@@ -36,7 +36,8 @@ func (h *ExerciseHandler) GetByFilters(c *gin.Context) {
 	if err != nil {
 		msg := err.Error()
 		switch {
-		case strings.Contains(msg, "al menos un filtro"):
+		case strings.Contains(msg, "al menos un filtro"),
+			strings.Contains(msg, "inválid"):
 			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
 			return
 		case strings.Contains(msg, "obtener ejercicios"):
@@ -88,12 +89,23 @@ func (h *ExerciseHandler) GetExcercises(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.ExerciseService.GetExcercises()
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.ExerciseService.GetExcercises(page)
 	if err != nil {
+		if strings.Contains(err.Error(), "inválid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}) //400
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "error al obtener ejercicios"}) //404
 		return
 	}
 
+	pageLinks(c, collection)
 	c.JSON(http.StatusOK, collection)
 }
 
@@ -117,7 +129,8 @@ func (h *ExerciseHandler) PostExcercise(c *gin.Context) {
 		msg := err.Error()
 		switch {
 		case strings.Contains(msg, "no puede estar vacío"),
-			strings.Contains(msg, "no puede estar vacía"):
+			strings.Contains(msg, "no puede estar vacía"),
+			strings.Contains(msg, "inválid"):
 			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) //400
 			return
 
@@ -191,7 +204,7 @@ func (h *ExerciseHandler) PutExcercise(c *gin.Context) {
 }
 
 func (h *ExerciseHandler) DeleteExcercise(c *gin.Context) {
-	_, exist := c.Get("user_id")
+	adminID, exist := c.Get("user_id")
 	if !exist {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
@@ -203,17 +216,63 @@ func (h *ExerciseHandler) DeleteExcercise(c *gin.Context) {
 		return
 	}
 
-	deleted, err := h.ExerciseService.DeleteExcercise(idExcercise)
+	var delete dto.ExcerciseDeleteDTO
+	if err := c.ShouldBindQuery(&delete); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "modo inválido: use block, cascade o replace"})
+		return
+	}
+	delete.ExcerciseID = idExcercise
+	delete.AdminID = adminID.(string)
+
+	result, err := h.ExerciseService.DeleteExcercise(delete)
 	if err != nil {
-		// ... (Maneja los errores como en tus otros handlers, ej. 404 si no existe, 500 si falla)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+		case strings.Contains(msg, "no existe un ejercicio"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+		case strings.Contains(msg, "en uso"):
+			// se devuelven las dependencias para que el admin elija cascade o replace
+			dependencies, _ := h.ExerciseService.GetDependencies(idExcercise)
+			c.JSON(http.StatusConflict, gin.H{"error": msg, "dependencies": dependencies}) // 409
+
+		case strings.Contains(msg, "cambió mientras se editaba"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg}) // 500
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ExerciseHandler) GetDependencies(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
 	}
 
-	if !deleted {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No se pudo eliminar el ejercicio o no fue encontrado"})
+	result, err := h.ExerciseService.GetDependencies(c.Param("id"))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+		case strings.Contains(msg, "no existe un ejercicio"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg}) // 500
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Ejercicio eliminado correctamente"})
+	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"AppFitness/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageLinks reemplaza los cursores de la pagina por enlaces a la misma URL con el cursor cambiado, asi el cliente
+// sigue next y prev sin tener que repetir limit, sort ni fields
+func pageLinks(c *gin.Context, page *dto.PageDTO) {
+	link := func(cursor *string) *string {
+		if cursor == nil {
+			return nil
+		}
+		url := *c.Request.URL
+		query := url.Query()
+		query.Set("cursor", *cursor)
+		url.RawQuery = query.Encode()
+		text := url.RequestURI()
+		return &text
+	}
+	page.Next = link(page.Next)
+	page.Prev = link(page.Prev)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"AppFitness/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalRecordHandler struct {
+	RecordService services.PersonalRecordInterface
+}
+
+func NewPersonalRecordHandler(recordService services.PersonalRecordInterface) *PersonalRecordHandler {
+	return &PersonalRecordHandler{
+		RecordService: recordService,
+	}
+}
+
+func (h *PersonalRecordHandler) GetRecords(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.RecordService.GetRecords(idUser.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener records"}) // 500
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *PersonalRecordHandler) GetRecordsByExcercise(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	idExcercise := c.Param("id")
+	if strings.TrimSpace(idExcercise) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "se requiere un ID de ejercicio"})
+		return
+	}
+
+	result, err := h.RecordService.GetRecordsByExcercise(idUser.(string), idExcercise)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ningún ejercicio"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "inválido"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener records"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"AppFitness/dto"
+	"AppFitness/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProgramHandler struct {
+	ProgramService services.ProgramInterface
+}
+
+func NewProgramHandler(programService services.ProgramInterface) *ProgramHandler {
+	return &ProgramHandler{
+		ProgramService: programService,
+	}
+}
+
+// programError traduce los errores del servicio de programas a respuestas HTTP
+func programError(c *gin.Context, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "inválid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+	case strings.Contains(msg, "programa no encontrado"),
+		strings.Contains(msg, "rutina no encontrada"),
+		strings.Contains(msg, "no tiene ningun programa activo"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+	case strings.Contains(msg, "Al no ser el creador de este programa"):
+		c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+
+	case strings.Contains(msg, "ya tiene un programa activo"):
+		c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback}) // 500
+	}
+}
+
+func (h *ProgramHandler) PostProgram(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var program dto.ProgramRegisterDTO
+	if err := c.ShouldBindJSON(&program); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	program.UserID = idUser.(string)
+
+	result, err := h.ProgramService.PostProgram(&program)
+	if err != nil {
+		programError(c, err, "error interno al crear el programa")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *ProgramHandler) GetPrograms(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ProgramService.GetPrograms(idUser.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener programas"}) // 500
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ProgramHandler) GetProgramByID(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ProgramService.GetProgramByID(c.Param("id"), idUser.(string))
+	if err != nil {
+		programError(c, err, "error interno al obtener el programa")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ProgramHandler) PutProgram(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var program dto.ProgramRegisterDTO
+	if err := c.ShouldBindJSON(&program); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	program.UserID = idUser.(string)
+	program.ProgramID = c.Param("id")
+
+	result, err := h.ProgramService.PutProgram(&program)
+	if err != nil {
+		programError(c, err, "error interno al modificar el programa")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ProgramHandler) DeleteProgram(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	if err := h.ProgramService.DeleteProgram(c.Param("id"), idUser.(string)); err != nil {
+		programError(c, err, "error interno al eliminar el programa")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Programa eliminado correctamente"})
+}
+
+func (h *ProgramHandler) Enroll(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var enroll dto.ProgramEnrollDTO
+	if c.Request.ContentLength > 0 { // el cuerpo es opcional, sin start_date empieza hoy
+		if err := c.ShouldBindJSON(&enroll); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	enroll.UserID = idUser.(string)
+	enroll.ProgramID = c.Param("id")
+
+	result, err := h.ProgramService.Enroll(enroll)
+	if err != nil {
+		programError(c, err, "error interno al inscribirse en el programa")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *ProgramHandler) GetEnrollment(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ProgramService.GetEnrollment(idUser.(string))
+	if err != nil {
+		programError(c, err, "error interno al obtener la inscripcion")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ProgramHandler) CancelEnrollment(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	if err := h.ProgramService.CancelEnrollment(idUser.(string)); err != nil {
+		programError(c, err, "error interno al cancelar la inscripcion")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Inscripcion cancelada correctamente"})
+}
+
+func (h *ProgramHandler) GetToday(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var filter dto.ProgramTodayFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = idUser.(string)
+
+	result, err := h.ProgramService.GetToday(filter)
+	if err != nil {
+		programError(c, err, "error interno al obtener el entrenamiento de hoy")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
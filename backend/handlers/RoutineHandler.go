@@ -3,10 +3,17 @@ package handlers
 import (
 	"AppFitness/dto"
 	"AppFitness/services"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type RoutineHandler struct {
@@ -64,16 +71,26 @@ func (h *RoutineHandler) PostRoutine(c *gin.Context) {
 }
 
 func (h *RoutineHandler) GetRoutines(c *gin.Context) {
-	_, exist := c.Get("user_id")
+	idUser, exist := c.Get("user_id")
 	if !exist {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"}) //401
 		return
 	}
 
-	result, err := h.RoutineService.GetRoutines()
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.RoutineService.GetRoutines(idUser.(string), page)
 	if err != nil {
 		msg := err.Error()
 		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) //400
+			return
+
 		case strings.Contains(msg, "no existen rutinas registradas"):
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) //404
 			return
@@ -88,11 +105,12 @@ func (h *RoutineHandler) GetRoutines(c *gin.Context) {
 		}
 	}
 
+	pageLinks(c, result)
 	c.JSON(http.StatusOK, result)
 }
 
 func (h *RoutineHandler) GetRoutineByID(c *gin.Context) {
-	_, exist := c.Get("user_id")
+	idUser, exist := c.Get("user_id")
 	if !exist {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"}) //401
 		return
@@ -104,7 +122,7 @@ func (h *RoutineHandler) GetRoutineByID(c *gin.Context) {
 		return
 	}
 
-	result, err := h.RoutineService.GetRoutineByID(id)
+	result, err := h.RoutineService.GetRoutineByID(id, idUser.(string))
 	if err != nil {
 		msg := err.Error()
 		switch {
@@ -126,7 +144,7 @@ func (h *RoutineHandler) GetRoutineByID(c *gin.Context) {
 }
 
 func (h *RoutineHandler) PutRoutine(c *gin.Context) {
-	_, exist := c.Get("user_id")
+	idEditor, exist := c.Get("user_id")
 	if !exist {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
@@ -140,10 +158,15 @@ func (h *RoutineHandler) PutRoutine(c *gin.Context) {
 	}
 
 	routineModify.IDRoutine = idRoutine
+	routineModify.IDEditor = idEditor.(string)
 	result, err := h.RoutineService.PutRoutine(routineModify)
 	if err != nil {
 		msg := err.Error()
 		switch {
+		case strings.Contains(msg, "Al no ser el creador"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) //403
+			return
+
 		case strings.Contains(msg, "no puede estar vacío"),
 			strings.Contains(msg, "no puede ser igual al anterior"):
 			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) //400
@@ -204,8 +227,15 @@ func (h *RoutineHandler) AddExcerciseToRoutine(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
 
+		// posicion invalida
+		case strings.Contains(msg, "inválid"),
+			strings.Contains(msg, "separa un grupo"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		//no se agregó nada
-		case strings.Contains(msg, "no se agregó ningún ejercicio a la rutina"):
+		case strings.Contains(msg, "no se agregó ningún ejercicio a la rutina"),
+			strings.Contains(msg, "cambió mientras se editaba"):
 			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409 (conflicto de negocio)
 			return
 
@@ -248,12 +278,24 @@ func (h *RoutineHandler) RemoveExerciseFromRoutine(c *gin.Context) {
 		// No se encontro
 		case strings.Contains(msg, "no existe ninguna rutina con ese ID"),
 			strings.Contains(msg, "no existe ningún ejercicio con ese ID"),
-			strings.Contains(msg, "no existe ninguna rutina con ese ID, error al eliminar ejercicio"):
+			strings.Contains(msg, "no existe ninguna rutina con ese ID, error al eliminar ejercicio"),
+			strings.Contains(msg, "no se encontró el ejercicio dentro de la rutina"):
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
 
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		// entrada ambigua o mal formada
+		case strings.Contains(msg, "aparece varias veces"),
+			strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		// no se eliminó nada
-		case strings.Contains(msg, "no se eliminó ningún ejercicio de la rutina"):
+		case strings.Contains(msg, "no se eliminó ningún ejercicio de la rutina"),
+			strings.Contains(msg, "cambió mientras se editaba"):
 			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
 			return
 
@@ -299,18 +341,32 @@ func (h *RoutineHandler) UpdateExerciseInRoutine(c *gin.Context) {
 		// No encontrados
 		case strings.Contains(msg, "no existe ninguna rutina con ese ID"),
 			strings.Contains(msg, "no existe ningún ejercicio con ese ID"),
-			strings.Contains(msg, "no existe ninguna rutina con ese ID, error al modificar ejercicio"):
+			strings.Contains(msg, "no existe ninguna rutina con ese ID, error al modificar ejercicio"),
+			strings.Contains(msg, "no se encontró el ejercicio dentro de la rutina"):
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
 
-		// Sin cambios
-		case strings.Contains(msg, "no se modificó ningún ejercicio de la rutina"):
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		// entrada ambigua o mal formada
+		case strings.Contains(msg, "aparece varias veces"),
+			strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		// Sin cambios o sin sugerencia para aceptar
+		case strings.Contains(msg, "no se modificó ningún ejercicio de la rutina"),
+			strings.Contains(msg, "no hay ninguna sugerencia de progresion"),
+			strings.Contains(msg, "cambió mientras se editaba"):
 			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
 			return
 
 		// Errores internos (repo/DB)
 		case strings.Contains(msg, "error al obtener la rutina a modificar"),
 			strings.Contains(msg, "error al obtener el ejercicio a modificar"),
+			strings.Contains(msg, "error al obtener los workouts de la rutina"),
 			strings.Contains(msg, "error al modificar el ejercicio de la rutina"),
 			strings.Contains(msg, "error al actualizar la fecha de edición de la rutina"),
 			strings.Contains(msg, "error al obtener la rutina modificada"):
@@ -364,3 +420,438 @@ func (h *RoutineHandler) DeleteRoutine(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
 }
+
+// RestoreRoutine saca de la papelera una rutina eliminada por su creador
+func (h *RoutineHandler) RestoreRoutine(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.RoutineService.RestoreRoutine(c.Param("id"), idEditor.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		case strings.Contains(msg, "no existe ninguna rutina eliminada"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al restaurar la rutina"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RoutineHandler) GetRoutineVersions(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.RoutineService.GetRoutineVersions(c.Param("id"), idEditor.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener el historial de la rutina"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RoutineHandler) RollbackRoutine(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "numero de version inválido"})
+		return
+	}
+	rollback := dto.RoutineRollbackDTO{
+		RoutineID: c.Param("id"),
+		Version:   version,
+		EditorID:  idEditor.(string),
+	}
+
+	result, err := h.RoutineService.RollbackRoutine(rollback)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"),
+			strings.Contains(msg, "no existe la version"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		case strings.Contains(msg, "ya está en la version"),
+			strings.Contains(msg, "dicho nombre de rutina ya existe"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al restaurar la rutina"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RoutineHandler) CloneRoutine(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var clone dto.RoutineCloneDTO
+	// el body es opcional: sin nombre se genera uno a partir de la rutina original
+	if err := c.ShouldBindJSON(&clone); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	clone.RoutineID = c.Param("id")
+	clone.UserID = idUser.(string)
+
+	result, err := h.RoutineService.CloneRoutine(clone)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "dicho nombre de rutina ya existe"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+			return
+
+		case strings.Contains(msg, "inválido"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al copiar la rutina"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// routineImportMaxBytes limita el tamaño del archivo a importar
+const routineImportMaxBytes = 5 << 20
+
+func (h *RoutineHandler) ExportRoutines(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var filter dto.RoutineExportFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	routines, err := h.RoutineService.ExportRoutines(idUser.(string), filter.RoutineID)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al exportar rutinas"}) // 500
+			return
+		}
+	}
+
+	filename := "rutinas-" + time.Now().Format("2006-01-02")
+	if filter.Format == "csv" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := dto.WriteRoutinesCSV(c.Writer, routines); err != nil {
+			log.Printf("Error al escribir el CSV de rutinas: %v", err)
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+	c.JSON(http.StatusOK, dto.RoutineExportFileDTO{
+		Format:     dto.RoutineExportFormat,
+		Version:    1,
+		ExportedAt: time.Now(),
+		Routines:   routines,
+	})
+}
+
+func (h *RoutineHandler) ImportRoutines(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var query dto.RoutineImportQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.Format == "" && strings.Contains(c.ContentType(), "csv") {
+		query.Format = "csv"
+	}
+
+	// el CSV se lee a mano y se valida con las mismas reglas que el JSON
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, routineImportMaxBytes)
+	file := &dto.RoutineExportFileDTO{}
+	if query.Format == "csv" {
+		parsed, err := dto.ReadRoutinesCSV(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file = parsed
+		if err := binding.Validator.ValidateStruct(file); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else if err := c.ShouldBindJSON(file); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.RoutineService.ImportRoutines(dto.RoutineImportDTO{
+		UserID:   idUser.(string),
+		DryRun:   query.DryRun,
+		Routines: file.Routines,
+	})
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválido"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al importar rutinas"}) // 500
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if !result.DryRun && result.Created > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+func (h *RoutineHandler) SetRoutineVisibility(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var visibility dto.RoutineVisibilityDTO
+	if err := c.ShouldBindJSON(&visibility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	visibility.RoutineID = c.Param("id")
+	visibility.EditorID = idEditor.(string)
+
+	result, err := h.RoutineService.SetRoutineVisibility(visibility)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al cambiar la visibilidad"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SearchPublicRoutines es la biblioteca de rutinas publicas: ?q=&muscle_group=&category=&difficulty=&featured=true&limit=
+func (h *RoutineHandler) SearchPublicRoutines(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var filter dto.PublicRoutineFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.RoutineService.SearchPublicRoutines(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al buscar rutinas publicas"}) // 500
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SetRoutineFeatured es solo para admins: marca o desmarca una rutina publica como destacada
+func (h *RoutineHandler) SetRoutineFeatured(c *gin.Context) {
+	var featured dto.RoutineFeaturedDTO
+	if err := c.ShouldBindJSON(&featured); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	featured.RoutineID = c.Param("id")
+
+	result, err := h.RoutineService.SetRoutineFeatured(featured)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no existe ninguna rutina"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "solo las rutinas publicas"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al destacar la rutina"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ReorderExercises recibe el orden completo de la rutina: {"entry_ids": [...]}
+func (h *RoutineHandler) ReorderExercises(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var reorder dto.RoutineReorderDTO
+	if err := c.ShouldBindJSON(&reorder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reorder.RoutineID = c.Param("id")
+	reorder.EditorID = idEditor.(string)
+
+	result, err := h.RoutineService.ReorderExercises(reorder)
+	if err != nil {
+		h.routineContentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// PostExcerciseGroup arma una superserie, serie gigante o circuito con entradas de la rutina
+func (h *RoutineHandler) PostExcerciseGroup(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var group dto.RoutineGroupRegisterDTO
+	if err := c.ShouldBindJSON(&group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	group.RoutineID = c.Param("id")
+	group.EditorID = idEditor.(string)
+
+	result, err := h.RoutineService.PostExcerciseGroup(group)
+	if err != nil {
+		h.routineContentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *RoutineHandler) DeleteExcerciseGroup(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.RoutineService.DeleteExcerciseGroup(c.Param("id"), c.Param("group_id"), idEditor.(string))
+	if err != nil {
+		h.routineContentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// routineContentError traduce los errores de reordenar y agrupar, que comparten validaciones
+func (h *RoutineHandler) routineContentError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no existe ninguna rutina"),
+		strings.Contains(msg, "no existe el grupo"),
+		strings.Contains(msg, "no se encontró el ejercicio dentro de la rutina"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+	case strings.Contains(msg, "Al no ser el creador de esta rutina"):
+		c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+	case strings.Contains(msg, "ya pertenece a un grupo"),
+		strings.Contains(msg, "ya tiene ese orden"),
+		strings.Contains(msg, "cambió mientras se editaba"):
+		c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+	case strings.Contains(msg, "inválid"),
+		strings.Contains(msg, "debe incluir cada entrada"),
+		strings.Contains(msg, "deben quedar seguidos"),
+		strings.Contains(msg, "debe tener"),
+		strings.Contains(msg, "aparece varias veces"),
+		strings.Contains(msg, "no puede repetirse"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al modificar la rutina"})
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"AppFitness/dto"
+	"AppFitness/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	ScheduleService services.ScheduleInterface
+}
+
+func NewScheduleHandler(scheduleService services.ScheduleInterface) *ScheduleHandler {
+	return &ScheduleHandler{
+		ScheduleService: scheduleService,
+	}
+}
+
+func (h *ScheduleHandler) PostSchedule(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var schedule dto.ScheduleRegisterDTO
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule.UserID = idUser.(string)
+
+	result, err := h.ScheduleService.PostSchedule(&schedule)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"),
+			strings.Contains(msg, "debe indicar"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		case strings.Contains(msg, "rutina no encontrada"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al crear la planificacion"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ScheduleService.GetSchedules(idUser.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener planificaciones"}) // 500
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var delete dto.ScheduleDeleteDTO
+	delete.ScheduleID = c.Param("id")
+	delete.UserID = idUser.(string)
+
+	err := h.ScheduleService.DeleteSchedule(delete)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "planificacion no encontrada"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "al no ser el creador"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		case strings.Contains(msg, "no se pudo eliminar"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Planificacion eliminada correctamente"})
+}
+
+func (h *ScheduleHandler) GetCalendar(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var filter dto.CalendarFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = idUser.(string)
+
+	result, err := h.ScheduleService.GetCalendar(filter)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener el calendario"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ScheduleHandler) GetCalendarFeed(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ScheduleService.GetCalendarFeed(idUser.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "no encontrado"),
+			strings.Contains(msg, "no se encontró"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener el feed de calendario"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ScheduleHandler) RotateCalendarFeed(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.ScheduleService.RotateCalendarFeed(idUser.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "usuario no encontrado"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al generar el feed de calendario"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *ScheduleHandler) RevokeCalendarFeed(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	err := h.ScheduleService.RevokeCalendarFeed(idUser.(string))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "usuario no encontrado"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al revocar el feed de calendario"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Feed de calendario revocado correctamente"})
+}
+
+// GetICalendar es publico: el token secreto de la URL hace de autenticacion para los clientes de calendario
+func (h *ScheduleHandler) GetICalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	result, err := h.ScheduleService.GetICalendar(token)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "feed de calendario no encontrado"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al generar el calendario"}) // 500
+			return
+		}
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(result))
+}
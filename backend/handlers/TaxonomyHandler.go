@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"AppFitness/dto"
+	"AppFitness/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxonomyHandler struct {
+	TaxonomyService services.TaxonomyInterface
+}
+
+func NewTaxonomyHandler(taxonomyService services.TaxonomyInterface) *TaxonomyHandler {
+	return &TaxonomyHandler{
+		TaxonomyService: taxonomyService,
+	}
+}
+
+// taxonomyError traduce los errores del servicio de vocabularios a respuestas HTTP
+func taxonomyError(c *gin.Context, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "inválid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+	case strings.Contains(msg, "no existe el término"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+	case strings.Contains(msg, "ya existe un término"),
+		strings.Contains(msg, "en uso"):
+		c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback}) // 500
+	}
+}
+
+func (h *TaxonomyHandler) GetTaxonomy(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.TaxonomyService.GetTaxonomy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener los vocabularios"}) // 500
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *TaxonomyHandler) PostTerm(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var term dto.TaxonomyTermRegisterDTO
+	if err := c.ShouldBindJSON(&term); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	term.Kind = c.Param("kind")
+
+	result, err := h.TaxonomyService.PostTerm(term)
+	if err != nil {
+		taxonomyError(c, err, "error interno al crear el término")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *TaxonomyHandler) PutTerm(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var term dto.TaxonomyTermModifyDTO
+	if err := c.ShouldBindJSON(&term); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	term.Kind = c.Param("kind")
+	term.Key = c.Param("key")
+
+	result, err := h.TaxonomyService.PutTerm(term)
+	if err != nil {
+		taxonomyError(c, err, "error interno al modificar el término")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *TaxonomyHandler) DeleteTerm(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	if err := h.TaxonomyService.DeleteTerm(c.Param("kind"), c.Param("key")); err != nil {
+		taxonomyError(c, err, "error interno al eliminar el término")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Término eliminado correctamente"})
+}
+
+// NormalizeMuscleGroups lanza la migracion de grupos musculares en texto libre a claves del vocabulario
+func (h *TaxonomyHandler) NormalizeMuscleGroups(c *gin.Context) {
+	_, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.TaxonomyService.NormalizeMuscleGroups()
+	if err != nil {
+		taxonomyError(c, err, "error interno al normalizar los grupos musculares")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -26,12 +26,23 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		return
 	}
 
-	collection, err := h.userService.GetUsers()
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection, err := h.userService.GetUsers(page)
 	if err != nil {
+		if strings.Contains(err.Error(), "inválid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()}) //400
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"mensaje": "error al obtener usuarios"}) //Error 404
 		return
 	}
 
+	pageLinks(c, collection)
 	c.JSON(http.StatusOK, collection)
 }
 
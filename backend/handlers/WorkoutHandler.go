@@ -3,6 +3,8 @@ package handlers
 import (
 	"AppFitness/dto"
 	"AppFitness/services"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
@@ -28,6 +30,11 @@ func (h *WorkoutHandler) PostWorkout(c *gin.Context) {
 
 	idRoutine := c.Param("id_routine")
 	newWorkout := &dto.WorkoutRegisterDTO{}
+	// el body es opcional: sin body se registra solo que la rutina fue realizada
+	if err := c.ShouldBindJSON(newWorkout); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	newWorkout.RoutineID = idRoutine
 	newWorkout.UserID = idEditor.(string)
@@ -39,6 +46,12 @@ func (h *WorkoutHandler) PostWorkout(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
 
+		case strings.Contains(msg, "no pertenece a la rutina"),
+			strings.Contains(msg, "al menos una serie"),
+			strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		case strings.Contains(msg, "error al crear el workout"),
 			strings.Contains(msg, "no se pudo crear el workout"),
 			strings.Contains(msg, "error al obtener el workout creado"),
@@ -55,6 +68,52 @@ func (h *WorkoutHandler) PostWorkout(c *gin.Context) {
 	c.JSON(http.StatusCreated, result)
 }
 
+func (h *WorkoutHandler) PutWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var modify dto.WorkoutModifyDTO
+	if err := c.ShouldBindJSON(&modify); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	modify.WorkoutID = c.Param("id")
+	modify.UserID = idEditor.(string)
+
+	result, err := h.WorkoutService.PutWorkout(&modify)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "workout no encontrado"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "no tiene permiso"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		case strings.Contains(msg, "workout en curso"):
+			c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+			return
+
+		case strings.Contains(msg, "no pertenece a la rutina"),
+			strings.Contains(msg, "al menos una serie"),
+			strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al modificar el workout"}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *WorkoutHandler) GetWorkouts(c *gin.Context) {
 	idEditor, exist := c.Get("user_id")
 	if !exist {
@@ -62,10 +121,20 @@ func (h *WorkoutHandler) GetWorkouts(c *gin.Context) {
 		return
 	}
 
-	result, err := h.WorkoutService.GetWorkouts(idEditor.(string))
+	var page dto.PageQueryDTO
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.WorkoutService.GetWorkouts(idEditor.(string), page)
 	if err != nil {
 		msg := err.Error()
 		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		case strings.Contains(msg, "usuario no encontrado"):
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
@@ -86,6 +155,7 @@ func (h *WorkoutHandler) GetWorkouts(c *gin.Context) {
 		}
 	}
 
+	pageLinks(c, result)
 	c.JSON(http.StatusOK, result)
 }
 
@@ -179,6 +249,43 @@ func (h *WorkoutHandler) DeleteWorkout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Workout eliminado correctamente"})
 }
 
+// RestoreWorkout saca de la papelera un workout eliminado por su dueño
+func (h *WorkoutHandler) RestoreWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var restore dto.WorkoutDeleteDTO
+	restore.RoutineID = c.Param("id")
+	restore.UserID = idEditor.(string)
+
+	result, err := h.WorkoutService.RestoreWorkout(restore)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		case strings.Contains(msg, "workout eliminado no encontrado"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "al no ser el creador"):
+			c.JSON(http.StatusForbidden, gin.H{"error": msg}) // 403
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg}) // 500
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *WorkoutHandler) GetWorkoutStats(c *gin.Context) {
 	idEditor, exist := c.Get("user_id")
 	if !exist {
@@ -186,10 +293,21 @@ func (h *WorkoutHandler) GetWorkoutStats(c *gin.Context) {
 		return
 	}
 
-	result, err := h.WorkoutService.GetWorkoutStats(idEditor.(string))
+	var filter dto.WorkoutStatsFilterDTO
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = idEditor.(string)
+
+	result, err := h.WorkoutService.GetWorkoutStats(filter)
 	if err != nil {
 		msg := err.Error()
 		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
 		case strings.Contains(msg, "No se encontro user"):
 			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
 			return
@@ -209,3 +327,237 @@ func (h *WorkoutHandler) GetWorkoutStats(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 
 }
+
+func (h *WorkoutHandler) GetExcerciseProgress(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	idExcercise := c.Param("id")
+	if strings.TrimSpace(idExcercise) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "se requiere un ID de ejercicio"})
+		return
+	}
+
+	result, err := h.WorkoutService.GetExcerciseProgress(idEditor.(string), idExcercise, c.Query("formula"), c.Query("granularity"))
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		case strings.Contains(msg, "no existe ningún ejercicio"):
+			c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+			return
+
+		case strings.Contains(msg, "Error al obtener workouts"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al obtener el progreso del ejercicio"}) // 500
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// --- Workouts en vivo ---
+
+func (h *WorkoutHandler) StartWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	newWorkout := &dto.WorkoutRegisterDTO{}
+	// el body es opcional: se pueden enviar series ya realizadas al iniciar
+	if err := c.ShouldBindJSON(newWorkout); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newWorkout.RoutineID = c.Param("id_routine")
+	newWorkout.UserID = idEditor.(string)
+
+	result, err := h.WorkoutService.StartWorkout(newWorkout)
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *WorkoutHandler) GetActiveWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.WorkoutService.GetActiveWorkout(idEditor.(string))
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WorkoutHandler) AddSetToActiveWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var set dto.WorkoutSetRegisterDTO
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	set.UserID = idEditor.(string)
+
+	result, err := h.WorkoutService.AddSetToActiveWorkout(&set)
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WorkoutHandler) PauseWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.WorkoutService.PauseActiveWorkout(idEditor.(string))
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WorkoutHandler) ResumeWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.WorkoutService.ResumeActiveWorkout(idEditor.(string))
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WorkoutHandler) FinishWorkout(c *gin.Context) {
+	idEditor, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	result, err := h.WorkoutService.FinishActiveWorkout(idEditor.(string))
+	if err != nil {
+		respondActiveWorkoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondActiveWorkoutError traduce los errores del ciclo de vida de un workout en curso a status HTTP
+func respondActiveWorkoutError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "rutina no encontrada"),
+		strings.Contains(msg, "no hay ningún workout en curso"),
+		strings.Contains(msg, "workout no encontrado"):
+		c.JSON(http.StatusNotFound, gin.H{"error": msg}) // 404
+
+	case strings.Contains(msg, "ya existe un workout en curso"),
+		strings.Contains(msg, "está pausado"),
+		strings.Contains(msg, "no está pausado"):
+		c.JSON(http.StatusConflict, gin.H{"error": msg}) // 409
+
+	case strings.Contains(msg, "no pertenece a la rutina"),
+		strings.Contains(msg, "al menos una serie"),
+		strings.Contains(msg, "ambiguo"),
+		strings.Contains(msg, "inválid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg}) // 500
+	}
+}
+
+// workoutImportMaxBytes limita el tamaño del CSV de historial; años de series ocupan bastante mas que unas rutinas
+const workoutImportMaxBytes = 20 << 20
+
+func (h *WorkoutHandler) ImportWorkouts(c *gin.Context) {
+	idUser, exist := c.Get("user_id")
+	if !exist {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var query dto.WorkoutImportQueryDTO
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, workoutImportMaxBytes)
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV inválido: " + err.Error()})
+		return
+	}
+	if len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV inválido: el cuerpo está vacío"})
+		return
+	}
+
+	result, err := h.WorkoutService.ImportWorkouts(dto.WorkoutImportDTO{
+		UserID:     idUser.(string),
+		CSV:        string(body),
+		Mapping:    c.QueryMap("mapping"),
+		DryRun:     query.DryRun,
+		Unit:       query.Unit,
+		TimeZone:   query.TimeZone,
+		DateFormat: query.DateFormat,
+	})
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "inválid"),
+			strings.Contains(msg, "sin asignar"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg}) // 400
+			return
+
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error interno al importar workouts"}) // 500
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if !result.DryRun && result.Workouts > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
@@ -2,14 +2,12 @@ package main
 
 import (
 	"AppFitness/handlers"
-	"AppFitness/middleware"
 	"AppFitness/repositories"
 	"AppFitness/services"
 	"fmt"
 	"log"
-	"net/http"
-
-	"github.com/gin-gonic/gin"
+	"os"
+	"time"
 )
 
 func main() {
@@ -34,180 +32,128 @@ func main() {
 	exerciseRepo := repositories.NewExcerciseRepository(db)
 	routineRepo := repositories.NewRoutineRepository(db)
 	workoutRepo := repositories.NewWorkoutRepository(db)
+	recordRepo := repositories.NewPersonalRecordRepository(db)
+	scheduleRepo := repositories.NewScheduleRepository(db)
+	routineVersionRepo := repositories.NewRoutineVersionRepository(db)
+	programRepo := repositories.NewProgramRepository(db)
+	taxonomyRepo := repositories.NewTaxonomyRepository(db)
+	if err := exerciseRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de busqueda de ejercicios: %v", err)
+	}
+	if err := routineRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de rutinas: %v", err)
+	}
+	if err := workoutRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de workouts: %v", err)
+	}
+	if err := routineVersionRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de versiones de rutinas: %v", err)
+	}
+	if err := programRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de inscripciones a programas: %v", err)
+	}
+	if err := taxonomyRepo.CreateIndexes(); err != nil {
+		log.Printf("No se pudieron crear los indices de vocabularios: %v", err)
+	}
 
 	// --- Servicios ---
 	authService := services.NewAuthService(userRepo, sessionRepo)
 	userService := services.NewUserService(userRepo)
-	exerciseService := services.NewExcerciseService(exerciseRepo)
-	routineService := services.NewRoutineService(routineRepo, exerciseRepo)
-	workoutService := services.NewWorkoutService(workoutRepo, routineRepo, userRepo)
-	adminService := services.NewAdminService(userRepo, exerciseRepo, routineRepo, sessionRepo)
-
-	// --- Handlers ---
-	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userService)
-	exerciseHandler := handlers.NewExerciseHandler(exerciseService)
-	routineHandler := handlers.NewRoutineHandler(routineService)
-	workoutHandler := handlers.NewWorkoutHadler(workoutService)
-	adminHandler := handlers.NewAdminHandler(adminService)
-
-	router := gin.Default()
-
-	// Configurar archivos státic y templates
-	router.Static("/statics", "./statics")
-	router.LoadHTMLGlob("templates/*")
-
-	router.GET("/", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "index.html", nil)
-	})
-	router.GET("/login", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "login.html", nil)
-	})
-	router.GET("/register", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "register1.html", nil)
-	})
-	router.GET("/register2", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "register2.html", nil)
-	})
-	router.GET("/dashboard-user", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-dashboard.html", nil)
-	})
-	router.GET("/dashboard-admin", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-dashboard.html", nil)
-	})
-
-	// Rutas de páginas de Usuario
-	router.GET("/user-exercise", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-exercise.html", nil)
-	})
-	router.GET("/user-routines", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-routines.html", nil)
-	})
-	router.GET("/user-progress", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-progress.html", nil)
-	})
-	router.GET("/user-record", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-record.html", nil)
-	})
-	router.GET("/user-routines-new", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-routines-new.html", nil)
-	})
-	router.GET("/user-routine-view.html", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-routine-view.html", nil)
-	})
-	router.GET("/user-routine-edit.html", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "user-routine-edit.html", nil)
-	})
-
-	// Rutas de Perfil
-	router.GET("/profile", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "profile.html", nil)
-	})
-	router.GET("/profile-edit.html", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "profile-edit.html", nil)
-	})
-	router.GET("/profile-edit-password.html", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "profile-edit-password.html", nil)
-	})
-
-	// Rutas de páginas de Admin
-	router.GET("/admin-exercises", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-exercises.html", nil)
-	})
-	router.GET("/admin-users", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-users.html", nil)
-	})
-	router.GET("/admin-stats", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-stats.html", nil)
-	})
-	router.GET("/admin-system-logs", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-system-logs.html", nil)
-	})
-	// Rutas para crear/editar ejercicios de admin
-	router.GET("/admin-excercise-new", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-excercise-new.html", nil)
-	})
-	router.GET("/admin-excercise-edit", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-excercise-edit.html", nil)
-	})
-	router.GET("/admin-exercise-ranking", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "admin-exercise-ranking.html", nil)
-	})
-
-	// Rutas Públicas (Autenticación y Registro)
-	router.POST("/register", userHandler.PostUser)
-	router.POST("/login", authHandler.PostLogin)
-	router.POST("/logout", authHandler.PostLogout)
-	router.POST("/refresh", authHandler.PostRefresh)
-
-	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware())
-
-	//  Rutas de Perfil de Usuario
-	userRoutes := api.Group("/users")
-	{
-		userRoutes.GET("/:id", userHandler.GetUserByID)
-		userRoutes.PUT("/:id", userHandler.PutUser)
-		userRoutes.POST("/:id/password", userHandler.PasswordModify)
+	taxonomyService := services.NewTaxonomyService(taxonomyRepo, exerciseRepo, workoutRepo)
+	routineService := services.NewRoutineService(routineRepo, exerciseRepo, routineVersionRepo, taxonomyService)
+	exerciseService := services.NewExcerciseService(exerciseRepo, routineRepo, workoutRepo, routineService, taxonomyService)
+	recordService := services.NewPersonalRecordService(recordRepo, exerciseRepo, workoutRepo)
+	workoutService := services.NewWorkoutService(workoutRepo, routineRepo, userRepo, exerciseRepo, recordService, workoutAbandonTimeout())
+	scheduleService := services.NewScheduleService(scheduleRepo, routineRepo, workoutRepo, userRepo, exerciseRepo)
+	programService := services.NewProgramService(programRepo, routineRepo, recordRepo)
+	adminService := services.NewAdminService(userRepo, exerciseRepo, routineRepo, sessionRepo, workoutRepo, routineVersionRepo, recordRepo,
+		scheduleRepo, programRepo, recordService, routineService, trashRetention())
+
+	// Vocabularios por defecto; los grupos musculares en texto libre se migran con POST /api/admin/migrations/muscle-groups
+	if err := taxonomyService.SeedDefaults(); err != nil {
+		log.Printf("No se pudieron cargar los vocabularios de ejercicios: %v", err)
 	}
-	exerciseRoutes := api.Group("/exercises")
-	{
-		exerciseRoutes.GET("/", exerciseHandler.GetExcercises)
-		exerciseRoutes.GET("/filter", exerciseHandler.GetByFilters) // Búsqueda y filtros
-		exerciseRoutes.GET("/:id", exerciseHandler.GetExcerciseByID)
 
-		adminExercise := exerciseRoutes.Group("/")
-		adminExercise.Use(middleware.CheckAdmin())
-		{
-			adminExercise.POST("/", exerciseHandler.PostExcercise)  // Alta
-			adminExercise.PUT("/:id", exerciseHandler.PutExcercise) // Edición
-			adminExercise.DELETE("/:id", exerciseHandler.DeleteExcercise)
+	// --- Handlers ---
+	router := newRouter(appHandlers{
+		auth:     handlers.NewAuthHandler(authService),
+		user:     handlers.NewUserHandler(userService),
+		exercise: handlers.NewExerciseHandler(exerciseService),
+		routine:  handlers.NewRoutineHandler(routineService),
+		workout:  handlers.NewWorkoutHadler(workoutService),
+		record:   handlers.NewPersonalRecordHandler(recordService),
+		schedule: handlers.NewScheduleHandler(scheduleService),
+		program:  handlers.NewProgramHandler(programService),
+		admin:    handlers.NewAdminHandler(adminService),
+		taxonomy: handlers.NewTaxonomyHandler(taxonomyService),
+	})
+
+	// Cierre periódico de workouts en curso abandonados
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			closed, err := workoutService.CloseAbandonedWorkouts()
+			if err != nil {
+				log.Printf("Error al cerrar workouts abandonados: %v", err)
+				continue
+			}
+			if closed > 0 {
+				log.Printf("Se cerraron %d workouts abandonados", closed)
+			}
 		}
-	}
+	}()
 
-	// Rutas de Rutinas
-	routineRoutes := api.Group("/routines")
-	routineRoutes.Use(middleware.CheckUser())
-	{
-		routineRoutes.POST("/", routineHandler.PostRoutine)
-		routineRoutes.GET("/", routineHandler.GetRoutines)
-		routineRoutes.GET("/:id", routineHandler.GetRoutineByID)
-		routineRoutes.PUT("/:id", routineHandler.PutRoutine)
-		routineRoutes.DELETE("/:id", routineHandler.DeleteRoutine)
+	// Purgado periódico de la papelera
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			purged, err := adminService.PurgeTrash()
+			if err != nil {
+				log.Printf("Error al purgar la papelera: %v", err)
+				continue
+			}
+			if purged.Total() > 0 {
+				log.Printf("Se purgaron de la papelera %d ejercicios, %d rutinas, %d usuarios y %d workouts",
+					purged.Excercises, purged.Routines, purged.Users, purged.Workouts)
+			}
+		}
+	}()
 
-		// Manejo de ejercicios dentro de una rutina
-		routineRoutes.POST("/:id/exercises", routineHandler.AddExcerciseToRoutine)
-		routineRoutes.PUT("/:id/exercises/:exercise_id", routineHandler.UpdateExerciseInRoutine) // handler espera un DTO en el body, así que no usamos params
-		routineRoutes.DELETE("/exercises", routineHandler.RemoveExerciseFromRoutine)
+	// 5. Iniciar Servidor
+	log.Println("Servidor escuchando en http://localhost:8080")
+	if err := router.Run(":8080"); err != nil {
+		log.Fatalf("Error al iniciar el servidor Gin: %v", err)
 	}
+}
 
-	// Rutas de Seguimiento (Workouts)
-	workoutRoutes := api.Group("/workouts")
-	workoutRoutes.Use(middleware.CheckUser())
-	{
-		workoutRoutes.GET("/", workoutHandler.GetWorkouts)
-
-		workoutRoutes.POST("/:id_routine", workoutHandler.PostWorkout)
-
-		workoutRoutes.GET("/stats", workoutHandler.GetWorkoutStats)
-
-		workoutRoutes.GET("/:id", workoutHandler.GetWorkoutByID) // Ver un workout específico
-
-		workoutRoutes.DELETE("/:id", workoutHandler.DeleteWorkout)
+// workoutAbandonTimeout lee WORKOUT_ABANDON_TIMEOUT (ej. "3h", "90m"); por defecto 4 horas
+func workoutAbandonTimeout() time.Duration {
+	const defaultTimeout = 4 * time.Hour
+	value := os.Getenv("WORKOUT_ABANDON_TIMEOUT")
+	if value == "" {
+		return defaultTimeout
 	}
-
-	// --- Rutas del Panel de Administración ---
-	adminRoutes := api.Group("/admin")
-	adminRoutes.Use(middleware.CheckAdmin()) // Protegido solo para Admins
-	{
-		adminRoutes.GET("/users", userHandler.GetUsers) // Gestión de usuarios
-		adminRoutes.GET("/stats/users", adminHandler.GetLogs)
-		adminRoutes.GET("/stats/exercises", adminHandler.GetGlobalStats)
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		log.Printf("WORKOUT_ABANDON_TIMEOUT inválido (%q), se usa %v", value, defaultTimeout)
+		return defaultTimeout
 	}
+	return timeout
+}
 
-	// 5. Iniciar Servidor
-	log.Println("Servidor escuchando en http://localhost:8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Error al iniciar el servidor Gin: %v", err)
+// trashRetention lee TRASH_RETENTION (ej. "720h"), el tiempo que lo eliminado queda en la papelera; por defecto 30 dias
+func trashRetention() time.Duration {
+	const defaultRetention = 30 * 24 * time.Hour
+	value := os.Getenv("TRASH_RETENTION")
+	if value == "" {
+		return defaultRetention
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		log.Printf("TRASH_RETENTION inválido (%q), se usa %v", value, defaultRetention)
+		return defaultRetention
 	}
+	return retention
 }
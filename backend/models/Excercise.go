@@ -16,9 +16,22 @@ type Excercise struct {
 	DifficultLevel  string             `bson:"difficult_level" json:"difficult_level" binding:"required"` //string or enum?
 	Example         string             `bson:"example" json:"example" binding:"required"`                 //url of video
 	Instructions    string             `bson:"instructions" json:"instructions" binding:"required"`
-	EditionDate     time.Time          `bson:"edition_date" json:"edition_date"`
-	EliminationDate time.Time          `bson:"elimination_date" json:"elimination_date"`
-	CreationDate    time.Time          `bson:"creation_date" json:"creation_date"`
+	Aliases         []string           `bson:"aliases,omitempty" json:"aliases,omitempty"` // otros nombres con los que se lo busca ("bench press", "pecho plano")
+	// clasificacion con los vocabularios de TaxonomyTerm; MainMuscleGroup es el musculo principal (participacion 1)
+	SecondaryMuscles []MuscleInvolvement `bson:"secondary_muscles,omitempty" json:"secondary_muscles,omitempty"`
+	Equipment        []string            `bson:"equipment,omitempty" json:"equipment,omitempty"`
+	Mechanics        string              `bson:"mechanics,omitempty" json:"mechanics,omitempty"`
+	Force            string              `bson:"force,omitempty" json:"force,omitempty"`
+	Laterality       string              `bson:"laterality,omitempty" json:"laterality,omitempty"`
+	EditionDate      time.Time           `bson:"edition_date" json:"edition_date"`
+	EliminationDate  time.Time           `bson:"elimination_date" json:"elimination_date"`
+	CreationDate     time.Time           `bson:"creation_date" json:"creation_date"`
+}
+
+// MuscleInvolvement es un musculo secundario y cuanto participa en el ejercicio, entre 0 y 1 sin incluirlos
+type MuscleInvolvement struct {
+	Muscle      string  `bson:"muscle" json:"muscle"`
+	Involvement float64 `bson:"involvement" json:"involvement"`
 }
 
 type CategoryLevel string
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PageRequest es la consulta de un listado ya validada contra sus campos, en terminos de MongoDB; la arma
+// dto.PageQueryDTO.Resolve y la recorren los repositorios
+type PageRequest struct {
+	Limit      int
+	Cursor     *PageCursor
+	SortField  string
+	SortDesc   bool
+	Projection []string // campos de MongoDB a traer, vacio = todos
+	Fields     []string // claves JSON a devolver, vacio = todas
+	Total      bool
+	Sort       string // orden normalizado ("-CreationDate"), se guarda en los cursores
+}
+
+// PageCursor marca donde termina una pagina: el valor del campo de orden y el _id del ultimo (o primer) documento.
+// Viaja en la URL como texto opaco
+type PageCursor struct {
+	Value    bson.RawValue      `bson:"v"`
+	ID       primitive.ObjectID `bson:"id"`
+	Backward bool               `bson:"b"` // true = pagina anterior
+	Sort     string             `bson:"s"` // orden con el que se genero, para rechazarlo si cambia
+}
+
+// PageInfo es lo que el repositorio devuelve ademas de los documentos; Next y Prev son cursores
+type PageInfo struct {
+	Next  string
+	Prev  string
+	Total *int64
+}
+
+// NewCursor arma el cursor opaco de la pagina siguiente (backward = false) o anterior
+func (r PageRequest) NewCursor(value bson.RawValue, id primitive.ObjectID, backward bool) string {
+	data, err := bson.Marshal(PageCursor{Value: value, ID: id, Backward: backward, Sort: r.Sort})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor lee el cursor opaco que armo NewCursor
+func DecodePageCursor(text string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, err
+	}
+	var cursor PageCursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID.IsZero() {
+		return nil, fmt.Errorf("cursor sin _id")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RecordType string
+
+const (
+	HeaviestWeight   RecordType = "heaviest_weight" // mayor peso levantado en una serie completada
+	MostRepsAtWeight RecordType = "most_reps"       // mas repeticiones con un mismo peso
+	BestEstimated1RM RecordType = "best_estimated_1rm"
+	HighestVolume    RecordType = "highest_volume" // peso x repeticiones sumado en una sesion
+)
+
+type PersonalRecord struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ExcerciseID   primitive.ObjectID `bson:"excercise_id" json:"exercise_id"`
+	WorkoutID     primitive.ObjectID `bson:"workout_id" json:"workout_id"`
+	Type          RecordType         `bson:"type" json:"type"`
+	Value         float64            `bson:"value" json:"value"`
+	Weight        float64            `bson:"weight" json:"weight"`           // peso de la serie que marco el record
+	Repetitions   int                `bson:"repetitions" json:"repetitions"` // repeticiones de la serie que marco el record
+	PreviousValue float64            `bson:"previous_value" json:"previous_value"`
+	Date          time.Time          `bson:"date" json:"date"` // fecha del workout
+	CreationDate  time.Time          `bson:"creation_date" json:"creation_date"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Program es un bloque de entrenamiento de varias semanas: cada semana asigna rutinas a dias y la progresion
+// ajusta pesos y repeticiones de esas rutinas semana a semana
+type Program struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name            string             `bson:"name" json:"name"`
+	Description     string             `bson:"description,omitempty" json:"description,omitempty"`
+	CreatorUserID   primitive.ObjectID `bson:"creator_user_id" json:"creator_user_id"`
+	Weeks           []ProgramWeek      `bson:"weeks" json:"weeks"`
+	Progression     ProgressionRule    `bson:"progression" json:"progression"`
+	Visibility      RoutineVisibility  `bson:"visibility,omitempty" json:"visibility"` // mismos valores que las rutinas; vacio = privado
+	CreationDate    time.Time          `bson:"creation_date" json:"creation_date"`
+	EditionDate     time.Time          `bson:"edition_date" json:"edition_date"`
+	EliminationDate time.Time          `bson:"elimination_date" json:"elimination_date"`
+}
+
+// VisibleTo indica si el usuario puede ver el programa e inscribirse; editarlo sigue siendo solo del creador
+func (p Program) VisibleTo(userID string) bool {
+	if p.CreatorUserID.Hex() == userID {
+		return true
+	}
+	return p.Visibility == RoutinePublic || p.Visibility == RoutineUnlisted
+}
+
+type ProgramWeek struct {
+	Number int          `bson:"number" json:"number"` // 1 a N
+	Deload bool         `bson:"deload,omitempty" json:"deload,omitempty"`
+	Days   []ProgramDay `bson:"days" json:"days"`
+}
+
+type ProgramDay struct {
+	Weekday   int                `bson:"weekday" json:"weekday"` // 0=domingo ... 6=sabado, igual que las planificaciones
+	RoutineID primitive.ObjectID `bson:"routine_id" json:"routine_id"`
+}
+
+type ProgressionType string
+
+const (
+	NoProgression         ProgressionType = "none"
+	LinearProgression     ProgressionType = "linear"     // suma un incremento fijo de peso y/o repeticiones por semana
+	PercentageProgression ProgressionType = "percentage" // porcentaje del 1RM estimado del usuario en cada semana
+	WaveProgression       ProgressionType = "wave"       // ondulante: multiplicadores del peso que se repiten en ciclo
+)
+
+type ProgressionRule struct {
+	Type            ProgressionType `bson:"type" json:"type"`
+	WeightIncrement float64         `bson:"weight_increment,omitempty" json:"weight_increment,omitempty"` // linear: kg por semana
+	RepsIncrement   int             `bson:"reps_increment,omitempty" json:"reps_increment,omitempty"`     // linear: repeticiones por semana
+	Percentages     []float64       `bson:"percentages,omitempty" json:"percentages,omitempty"`           // percentage: uno por semana, ej. 0.7
+	Pattern         []float64       `bson:"pattern,omitempty" json:"pattern,omitempty"`                   // wave: ej. 1, 1.05, 0.95
+	DeloadFactor    float64         `bson:"deload_factor,omitempty" json:"deload_factor,omitempty"`       // peso en semanas de descarga, default 0.6
+}
+
+type EnrollmentStatus string
+
+const (
+	EnrollmentActive    EnrollmentStatus = "active"
+	EnrollmentCompleted EnrollmentStatus = "completed"
+	EnrollmentCancelled EnrollmentStatus = "cancelled"
+)
+
+// ProgramEnrollment es la inscripcion de un usuario a un programa; solo puede tener una activa
+type ProgramEnrollment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProgramID    primitive.ObjectID `bson:"program_id" json:"program_id"`
+	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
+	StartDate    string             `bson:"start_date" json:"start_date"` // YYYY-MM-DD, la semana 1 empieza ese dia
+	Status       EnrollmentStatus   `bson:"status" json:"status"`
+	CreationDate time.Time          `bson:"creation_date" json:"creation_date"`
+	EndDate      time.Time          `bson:"end_date,omitempty" json:"end_date,omitempty"`
+}
@@ -1,26 +1,244 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Routine struct {
-	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Name            string               `bson:"name" json:"name" binding:"required"`
-	CreatorUserID   primitive.ObjectID   `bson:"creator_user_id" json:"creator_user_id"`
-	ExcerciseList   []ExcerciseInRoutine `bson:"exercise_list,omitempty" json:"exercise_list,omitempty"` // no required
-	EditionDate     time.Time            `bson:"edition_date" json:"edition_date"`
-	EliminationDate time.Time            `bson:"elimination_date" json:"elimination_date"`
-	CreationDate    time.Time            `bson:"creation_date" json:"creation_date"`
+	ID              primitive.ObjectID      `bson:"_id,omitempty" json:"id"`
+	Name            string                  `bson:"name" json:"name" binding:"required"`
+	CreatorUserID   primitive.ObjectID      `bson:"creator_user_id" json:"creator_user_id"`
+	ExcerciseList   []ExcerciseInRoutine    `bson:"exercise_list,omitempty" json:"exercise_list,omitempty"` // no required, en el orden en que se hacen
+	Groups          []ExcerciseGroup        `bson:"groups,omitempty" json:"groups,omitempty"`               // superseries y circuitos
+	EditionDate     time.Time               `bson:"edition_date" json:"edition_date"`
+	EliminationDate time.Time               `bson:"elimination_date" json:"elimination_date"`
+	CreationDate    time.Time               `bson:"creation_date" json:"creation_date"`
+	Version         int                     `bson:"version,omitempty" json:"version"`                         // 0 = rutina creada antes del versionado
+	ForkedFrom      primitive.ObjectID      `bson:"forked_from,omitempty" json:"forked_from,omitempty"`       // rutina original si es una copia
+	ForkedVersion   int                     `bson:"forked_version,omitempty" json:"forked_version,omitempty"` // version de la original al copiarla
+	Visibility      RoutineVisibility       `bson:"visibility,omitempty" json:"visibility"`                   // vacio = privada
+	Featured        bool                    `bson:"featured,omitempty" json:"featured"`                       // destacada por un admin en la biblioteca publica
+	Suggestions     []ProgressionSuggestion `bson:"suggestions,omitempty" json:"-"`                           // solo las ve el creador, en el detalle
+}
+
+// ProgressionSuggestion es el objetivo sugerido para la proxima sesion de una entrada. Se calcula cuando cambia el
+// historial de workouts de la rutina y deja de valer si la entrada se modifica despues (BasedOn ya no coincide)
+type ProgressionSuggestion struct {
+	EntryID      primitive.ObjectID `bson:"entry_id,omitempty"`
+	ExcerciseID  primitive.ObjectID `bson:"excercise_id"`
+	Suggested    ExcerciseInRoutine `bson:"suggested"`
+	Action       string             `bson:"action"`
+	Reason       string             `bson:"reason"`
+	BasedOn      string             `bson:"based_on"` // prescripcion de la entrada al calcularla, ej. 3x8-12 @60kg
+	CreationDate time.Time          `bson:"creation_date"`
+}
+
+// Matches indica si la sugerencia es de la entrada y sigue vigente para su prescripcion actual
+func (s ProgressionSuggestion) Matches(entry ExcerciseInRoutine) bool {
+	if !s.EntryID.IsZero() && s.EntryID != entry.EntryID {
+		return false
+	}
+	return s.ExcerciseID == entry.ExcerciseID && s.BasedOn == entry.Describe()
+}
+
+type RoutineVisibility string
+
+const (
+	RoutinePrivate  RoutineVisibility = "private"
+	RoutineUnlisted RoutineVisibility = "unlisted" // visible para quien tenga el enlace, no aparece en la biblioteca
+	RoutinePublic   RoutineVisibility = "public"
+)
+
+// VisibleTo indica si el usuario puede ver (y usar o copiar) la rutina
+func (r Routine) VisibleTo(userID string) bool {
+	if r.CreatorUserID.Hex() == userID {
+		return true
+	}
+	return r.Visibility == RoutinePublic || r.Visibility == RoutineUnlisted
+}
+
+// PublicRoutineFilter es la busqueda en la biblioteca publica; Query busca por el comienzo del nombre y el resto
+// se filtra sobre los ejercicios referenciados
+type PublicRoutineFilter struct {
+	Query       string
+	MuscleGroup string
+	Category    string
+	Difficulty  string
+	Featured    bool
+	Limit       int
+}
+
+// PublicRoutine es una rutina de la biblioteca junto con los ejercicios que referencia, resueltos con $lookup
+type PublicRoutine struct {
+	Routine    `bson:",inline"`
+	Excercises []Excercise `bson:"excercise_docs"`
 }
 
 type ExcerciseInRoutine struct {
-	ExcerciseID     primitive.ObjectID `bson:"excercise_id,omitempty" json:"excercise_id" binding:"required"`
+	EntryID         primitive.ObjectID `bson:"entry_id,omitempty" json:"entry_id"` // identifica la entrada, el mismo ejercicio puede estar dos veces
+	ExcerciseID     primitive.ObjectID `bson:"excercise_id,omitempty" json:"exercise_id" binding:"required"`
+	GroupID         primitive.ObjectID `bson:"group_id,omitempty" json:"group_id,omitempty"`
 	Repetitions     int                `bson:"repetitions"  json:"repetitions"  binding:"required,min=1"`
 	Series          int                `bson:"series"       json:"series"       binding:"required,min=1"`
 	Weight          float64            `bson:"weight"       json:"weight"       binding:"gte=0"`
+	Sets            []SetPrescription  `bson:"sets,omitempty" json:"sets,omitempty"`                 // detalle serie a serie; vacio = Series x Repetitions
+	RestSeconds     int                `bson:"rest_seconds,omitempty" json:"rest_seconds,omitempty"` // descanso entre series salvo que la serie indique otro
+	Tempo           string             `bson:"tempo,omitempty" json:"tempo,omitempty"`               // excentrica-pausa-concentrica[-pausa], ej. 3-1-1
+	Cardio          *CardioTarget      `bson:"cardio,omitempty" json:"cardio,omitempty"`             // solo ejercicios de cardio
+	HoldSeconds     int                `bson:"hold_seconds,omitempty" json:"hold_seconds,omitempty"` // flexibilidad: Series posiciones de HoldSeconds cada una
 	EliminationDate time.Time          `bson:"elimination_date" json:"elimination_date"`
 	CreationDate    time.Time          `bson:"creation_date" json:"creation_date"`
 }
+
+type GroupType string
+
+const (
+	Superset GroupType = "superset"  // dos ejercicios seguidos sin descanso
+	GiantSet GroupType = "giant_set" // tres o mas ejercicios seguidos sin descanso
+	Circuit  GroupType = "circuit"   // se repite la secuencia completa Rounds veces
+)
+
+// ExcerciseGroup agrupa entradas consecutivas de la rutina que se hacen seguidas y comparten el descanso
+type ExcerciseGroup struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        GroupType          `bson:"type" json:"type"`
+	RestSeconds int                `bson:"rest_seconds" json:"rest_seconds"` // descanso al terminar cada vuelta del grupo
+	Rounds      int                `bson:"rounds,omitempty" json:"rounds,omitempty"`
+}
+
+type SetType string
+
+const (
+	WarmupSet  SetType = "warmup"
+	WorkingSet SetType = "working"
+	DropSet    SetType = "drop" // al fallo se baja el peso Drops veces sin descanso
+)
+
+// SetPrescription es un bloque de series iguales, ej. 3 series de 8 a 12 repeticiones @ RPE 8
+type SetPrescription struct {
+	Type        SetType `bson:"type" json:"type"`
+	Count       int     `bson:"count" json:"count"`
+	MinReps     int     `bson:"min_reps" json:"min_reps"`
+	MaxReps     int     `bson:"max_reps" json:"max_reps"` // igual a MinReps si no es un rango
+	Weight      float64 `bson:"weight" json:"weight"`
+	RPE         float64 `bson:"rpe,omitempty" json:"rpe,omitempty"`
+	RestSeconds int     `bson:"rest_seconds,omitempty" json:"rest_seconds,omitempty"`
+	Drops       int     `bson:"drops,omitempty" json:"drops,omitempty"`
+}
+
+// CardioTarget es lo prescrito para un ejercicio de cardio; tiene al menos duracion o distancia
+type CardioTarget struct {
+	DurationSeconds  int     `bson:"duration_seconds,omitempty" json:"duration_seconds,omitempty"`
+	DistanceMeters   float64 `bson:"distance_meters,omitempty" json:"distance_meters,omitempty"`
+	PaceSecondsPerKm int     `bson:"pace_seconds_per_km,omitempty" json:"pace_seconds_per_km,omitempty"`
+	HeartRateZone    int     `bson:"heart_rate_zone,omitempty" json:"heart_rate_zone,omitempty"` // 1 a 5
+}
+
+// IsTimed indica si la entrada se prescribe por tiempo o distancia en vez de por repeticiones
+func (e ExcerciseInRoutine) IsTimed() bool {
+	return e.Cardio != nil || e.HoldSeconds > 0
+}
+
+// Prescription devuelve las series detalladas; las entradas anteriores se leen como un unico bloque de trabajo.
+// Las de cardio y flexibilidad no tienen series de repeticiones
+func (e ExcerciseInRoutine) Prescription() []SetPrescription {
+	if len(e.Sets) > 0 || e.IsTimed() {
+		return e.Sets
+	}
+	return []SetPrescription{{
+		Type:    WorkingSet,
+		Count:   e.Series,
+		MinReps: e.Repetitions,
+		MaxReps: e.Repetitions,
+		Weight:  e.Weight,
+	}}
+}
+
+// SummarizeSets resume las series de trabajo en los campos simples Series, Repetitions y Weight, que siguen
+// usando las estadisticas, el calendario y las rutinas anteriores
+func SummarizeSets(sets []SetPrescription) (series int, repetitions int, weight float64) {
+	for _, s := range sets {
+		if s.Type == WarmupSet {
+			continue
+		}
+		if series == 0 {
+			repetitions, weight = s.MinReps, s.Weight
+		}
+		series += s.Count
+	}
+	return series, repetitions, weight
+}
+
+// Describe resume lo prescrito en una linea, sea por repeticiones, tiempo o distancia
+func (e ExcerciseInRoutine) Describe() string {
+	switch {
+	case e.Cardio != nil:
+		var parts []string
+		if e.Cardio.DurationSeconds > 0 {
+			parts = append(parts, FormatSeconds(e.Cardio.DurationSeconds))
+		}
+		if e.Cardio.DistanceMeters > 0 {
+			parts = append(parts, FormatDistance(e.Cardio.DistanceMeters))
+		}
+		if e.Cardio.PaceSecondsPerKm > 0 {
+			parts = append(parts, "ritmo "+FormatSeconds(e.Cardio.PaceSecondsPerKm)+"/km")
+		}
+		if e.Cardio.HeartRateZone > 0 {
+			parts = append(parts, fmt.Sprintf("zona %d", e.Cardio.HeartRateZone))
+		}
+		return strings.Join(parts, " ")
+	case e.HoldSeconds > 0:
+		return fmt.Sprintf("%dx%ds", max(e.Series, 1), e.HoldSeconds)
+	default:
+		return DescribeSets(e.Prescription())
+	}
+}
+
+// FormatSeconds muestra una duracion como m:ss o h:mm:ss
+func FormatSeconds(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// FormatDistance muestra una distancia en kilometros
+func FormatDistance(meters float64) string {
+	return fmt.Sprintf("%gkm", meters/1000)
+}
+
+// DescribeSets arma el texto corto de una prescripcion, ej. "2x10 calentamiento @20kg + 3x8-12 @60kg RPE 8"
+func DescribeSets(sets []SetPrescription) string {
+	parts := make([]string, 0, len(sets))
+	for _, s := range sets {
+		reps := strconv.Itoa(s.MinReps)
+		if s.MaxReps > s.MinReps {
+			reps += "-" + strconv.Itoa(s.MaxReps)
+		}
+		part := fmt.Sprintf("%dx%s @%gkg", s.Count, reps, s.Weight)
+		if s.RPE > 0 {
+			part += fmt.Sprintf(" RPE %g", s.RPE)
+		}
+		switch s.Type {
+		case WarmupSet:
+			part += " calentamiento"
+		case DropSet:
+			part += fmt.Sprintf(" drop set (%d bajadas)", max(s.Drops, 1))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// EntryKey identifica una entrada para comparar versiones; las anteriores a entry_id se identifican por ejercicio
+func (e ExcerciseInRoutine) EntryKey() string {
+	if e.EntryID.IsZero() {
+		return e.ExcerciseID.Hex()
+	}
+	return e.EntryID.Hex()
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RoutineChange string
+
+const (
+	RoutineCreated          RoutineChange = "created"
+	RoutineCloned           RoutineChange = "cloned"
+	RoutineImported         RoutineChange = "imported"
+	RoutineBaseline         RoutineChange = "baseline" // estado de una rutina previa al versionado, antes de su primer cambio
+	RoutineRenamed          RoutineChange = "renamed"
+	RoutineExerciseAdded    RoutineChange = "exercise_added"
+	RoutineExerciseUpdated  RoutineChange = "exercise_updated"
+	RoutineExerciseRemoved  RoutineChange = "exercise_removed"
+	RoutineExerciseReplaced RoutineChange = "exercise_replaced" // un admin reemplazo un ejercicio del catalogo al eliminarlo
+	RoutineRolledBack       RoutineChange = "rolled_back"
+	RoutineReordered        RoutineChange = "reordered"
+	RoutineGrouped          RoutineChange = "grouped"
+	RoutineUngrouped        RoutineChange = "ungrouped"
+)
+
+// RoutineVersion es una copia completa de la rutina despues de cada cambio; la ultima coincide con la rutina actual
+type RoutineVersion struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	RoutineID     primitive.ObjectID   `bson:"routine_id" json:"routine_id"`
+	Version       int                  `bson:"version" json:"version"`
+	Name          string               `bson:"name" json:"name"`
+	ExcerciseList []ExcerciseInRoutine `bson:"exercise_list" json:"exercise_list"`
+	Groups        []ExcerciseGroup     `bson:"groups,omitempty" json:"groups,omitempty"`
+	Change        RoutineChange        `bson:"change" json:"change"`
+	RestoredFrom  int                  `bson:"restored_from,omitempty" json:"restored_from,omitempty"` // solo en rollbacks
+	EditorUserID  primitive.ObjectID   `bson:"editor_user_id" json:"editor_user_id"`
+	CreationDate  time.Time            `bson:"creation_date" json:"creation_date"`
+}
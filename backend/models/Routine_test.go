@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestSummarizeSets(t *testing.T) {
+	cases := []struct {
+		name   string
+		sets   []SetPrescription
+		series int
+		reps   int
+		weight float64
+	}{
+		{"vacio", nil, 0, 0, 0},
+		{"el calentamiento no cuenta", []SetPrescription{
+			{Type: WarmupSet, Count: 2, MinReps: 10, Weight: 20},
+			{Type: WorkingSet, Count: 3, MinReps: 8, MaxReps: 12, Weight: 60},
+		}, 3, 8, 60},
+		{"los drop sets suman series y la primera de trabajo fija reps y peso", []SetPrescription{
+			{Type: WorkingSet, Count: 3, MinReps: 5, Weight: 100},
+			{Type: DropSet, Count: 1, MinReps: 12, Weight: 70, Drops: 2},
+		}, 4, 5, 100},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			series, reps, weight := SummarizeSets(tc.sets)
+			if series != tc.series || reps != tc.reps || weight != tc.weight {
+				t.Fatalf("%dx%d @%g, se esperaba %dx%d @%g", series, reps, weight, tc.series, tc.reps, tc.weight)
+			}
+		})
+	}
+}
+
+func TestPrescriptionAndDescribe(t *testing.T) {
+	legacy := ExcerciseInRoutine{Series: 3, Repetitions: 10, Weight: 40}
+	if got := legacy.Describe(); got != "3x10 @40kg" {
+		t.Errorf("entrada anterior: %q", got)
+	}
+	detailed := ExcerciseInRoutine{Sets: []SetPrescription{
+		{Type: WarmupSet, Count: 2, MinReps: 10, MaxReps: 10, Weight: 20},
+		{Type: WorkingSet, Count: 3, MinReps: 8, MaxReps: 12, Weight: 60, RPE: 8},
+	}}
+	if got, want := detailed.Describe(), "2x10 @20kg calentamiento + 3x8-12 @60kg RPE 8"; got != want {
+		t.Errorf("%q, se esperaba %q", got, want)
+	}
+	cardio := ExcerciseInRoutine{Series: 1, Cardio: &CardioTarget{DurationSeconds: 1800, DistanceMeters: 5000}}
+	if len(cardio.Prescription()) != 0 || cardio.Describe() != "30:00 5km" {
+		t.Errorf("cardio: %v %q", cardio.Prescription(), cardio.Describe())
+	}
+}
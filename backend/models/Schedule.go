@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScheduledWorkout es una sesion planificada: en una fecha puntual (Date) o recurrente en dias de la semana (Weekdays)
+type ScheduledWorkout struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RoutineID       primitive.ObjectID `bson:"routine_id" json:"routine_id"`
+	RoutineName     string             `bson:"routine_name" json:"routine_name"`
+	Date            string             `bson:"date,omitempty" json:"date,omitempty"`         // YYYY-MM-DD, solo para sesiones puntuales
+	Weekdays        []int              `bson:"weekdays,omitempty" json:"weekdays,omitempty"` // 0=domingo ... 6=sabado, para recurrentes
+	StartDate       string             `bson:"start_date,omitempty" json:"start_date"`       // YYYY-MM-DD, desde cuando rige la recurrencia
+	EndDate         string             `bson:"end_date,omitempty" json:"end_date,omitempty"` // YYYY-MM-DD, opcional
+	CreationDate    time.Time          `bson:"creation_date" json:"creation_date"`
+	EliminationDate time.Time          `bson:"elimination_date" json:"elimination_date"`
+}
+
+// OccursOn indica si la sesion esta planificada para el dia (YYYY-MM-DD) y dia de semana dados
+func (s ScheduledWorkout) OccursOn(day string, weekday time.Weekday) bool {
+	if s.Date != "" {
+		return s.Date == day
+	}
+	if day < s.StartDate || (s.EndDate != "" && day > s.EndDate) {
+		return false
+	}
+	for _, w := range s.Weekdays {
+		if time.Weekday(w) == weekday {
+			return true
+		}
+	}
+	return false
+}
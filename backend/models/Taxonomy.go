@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaxonomyKind identifica cada vocabulario controlado con el que se clasifican los ejercicios
+type TaxonomyKind string
+
+const (
+	TaxonomyMuscle     TaxonomyKind = "muscle"     // grupos musculares, principal y secundarios
+	TaxonomyEquipment  TaxonomyKind = "equipment"  // barra, mancuerna, maquina, polea...
+	TaxonomyMechanics  TaxonomyKind = "mechanics"  // compuesto o aislamiento
+	TaxonomyForce      TaxonomyKind = "force"      // empuje, traccion o estatico
+	TaxonomyLaterality TaxonomyKind = "laterality" // bilateral, unilateral o alternado
+)
+
+// TaxonomyKinds son los vocabularios existentes, en el orden en que se muestran
+var TaxonomyKinds = []TaxonomyKind{TaxonomyMuscle, TaxonomyEquipment, TaxonomyMechanics, TaxonomyForce, TaxonomyLaterality}
+
+// TaxonomyTerm es un valor de un vocabulario. Los ejercicios guardan Key, que no cambia; Name y Aliases son lo que
+// se muestra y los otros nombres con los que se lo reconoce al cargar o filtrar ("Pecho", "pectorales" -> chest)
+type TaxonomyTerm struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind         TaxonomyKind       `bson:"kind" json:"kind"`
+	Key          string             `bson:"key" json:"key"`
+	Name         string             `bson:"name" json:"name"`
+	Aliases      []string           `bson:"aliases,omitempty" json:"aliases,omitempty"`
+	CreationDate time.Time          `bson:"creation_date" json:"creation_date"`
+	EditionDate  time.Time          `bson:"edition_date" json:"edition_date"`
+}
@@ -42,6 +42,8 @@ type User struct {
 	Height          float32            `bson:"height" json:"height"`
 	Experience      ExperienceLevel    `bson:"experience" json:"experience" binding:"required, oneof=beginner intermediate advanced"`
 	Objetive        ObjetiveLevel      `bson:"objetive" json:"objetive" binding:"required, oneof=lose_weight gain_weight maintain"`
+	WeeklyGoal      int                `bson:"weekly_goal" json:"weekly_goal"`    // entrenamientos por semana que se propone el usuario
+	CalendarToken   string             `bson:"calendar_token,omitempty" json:"-"` // token secreto del feed .ics, vacio = sin feed
 	EditionDate     time.Time          `bson:"edition_date" json:"edition_date"`
 	EliminationDate time.Time          `bson:"elimination_date" json:"elimination_date"`
 	CreationDate    time.Time          `bson:"creation_date" json:"creation_date"`
@@ -1,15 +1,166 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type WorkoutStatus string
+
+const (
+	WorkoutInProgress WorkoutStatus = "in_progress"
+	WorkoutFinished   WorkoutStatus = "finished"
+	WorkoutAbandoned  WorkoutStatus = "abandoned" // cerrado automaticamente por inactividad
+)
+
 type Workout struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID      primitive.ObjectID `bson:"user_id,omitempty" json:"user_id" binding:"required"`
-	RoutineID   primitive.ObjectID `bson:"routine_id,omitempty" json:"routine_id" binding:"required"`
-	RoutineName string             `bson:"routine_name,omitempty" json:"routine_name" binding:"required"`
-	Date        time.Time          `bson:"date_and_hours" json:"date_and_hours"`
+	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID          primitive.ObjectID   `bson:"user_id,omitempty" json:"user_id" binding:"required"`
+	RoutineID       primitive.ObjectID   `bson:"routine_id,omitempty" json:"routine_id" binding:"required"`
+	RoutineName     string               `bson:"routine_name,omitempty" json:"routine_name" binding:"required"`
+	Date            time.Time            `bson:"date_and_hours" json:"date_and_hours"`
+	Exercises       []ExcerciseInWorkout `bson:"exercises,omitempty" json:"exercises,omitempty"` // lo que realmente se hizo en el workout
+	Status          WorkoutStatus        `bson:"status,omitempty" json:"status"`                 // vacio en workouts viejos = finalizado
+	StartTime       time.Time            `bson:"start_time,omitempty" json:"start_time"`
+	EndTime         time.Time            `bson:"end_time,omitempty" json:"end_time"`
+	PausedAt        time.Time            `bson:"paused_at,omitempty" json:"paused_at"` // distinto de cero mientras esta pausado
+	PausedSeconds   int64                `bson:"paused_seconds" json:"paused_seconds"`
+	DurationSeconds int64                `bson:"duration_seconds" json:"duration_seconds"` // sin contar pausas
+	LastActivity    time.Time            `bson:"last_activity,omitempty" json:"last_activity"`
+	Notes           string               `bson:"notes,omitempty" json:"notes,omitempty"`
+	Routine         *RoutineSnapshot     `bson:"routine_snapshot,omitempty" json:"routine_snapshot,omitempty"` // nil en workouts anteriores al snapshot
+	Source          WorkoutSource        `bson:"source,omitempty" json:"source,omitempty"`                     // vacio = registrado en la aplicacion
+	EditionDate     time.Time            `bson:"edition_date,omitempty" json:"edition_date"`
+	EliminationDate time.Time            `bson:"elimination_date,omitempty" json:"elimination_date"`
+}
+
+// WorkoutSource indica de donde viene un workout que no se registro en la aplicacion
+type WorkoutSource string
+
+const (
+	WorkoutImported WorkoutSource = "import" // historial importado desde el CSV de otra aplicacion o planilla
+)
+
+// IsActive indica si el workout sigue en curso (pausado o no)
+func (w Workout) IsActive() bool {
+	return w.Status == WorkoutInProgress
+}
+
+// IsCompleted indica si el workout cuenta para estadisticas, progreso y records: terminado o viejo sin status. Los
+// abandonados no cuentan porque sus series suelen estar incompletas
+func (w Workout) IsCompleted() bool {
+	return w.Status != WorkoutInProgress && w.Status != WorkoutAbandoned
+}
+
+// RoutineSnapshot es la rutina tal como estaba al hacer el workout; se guarda una vez y nunca se actualiza,
+// asi el historial no cambia si la rutina se edita o se elimina despues
+type RoutineSnapshot struct {
+	Name      string              `bson:"name" json:"name"`
+	Exercises []ExcerciseSnapshot `bson:"exercises" json:"exercises"` // en el orden de la rutina
+	Groups    []ExcerciseGroup    `bson:"groups,omitempty" json:"groups,omitempty"`
+	TakenAt   time.Time           `bson:"taken_at" json:"taken_at"`
+}
+
+type ExcerciseSnapshot struct {
+	EntryID         primitive.ObjectID `bson:"entry_id,omitempty" json:"entry_id,omitempty"`
+	ExcerciseID     primitive.ObjectID `bson:"excercise_id" json:"exercise_id"`
+	GroupID         primitive.ObjectID `bson:"group_id,omitempty" json:"group_id,omitempty"`
+	Name            string             `bson:"name" json:"name"`
+	Category        CategoryLevel      `bson:"category,omitempty" json:"category,omitempty"`
+	MainMuscleGroup string             `bson:"main_muscle_group" json:"main_muscle_group"`
+	Repetitions     int                `bson:"repetitions" json:"repetitions"`
+	Series          int                `bson:"series" json:"series"`
+	Weight          float64            `bson:"weight" json:"weight"`
+	Sets            []SetPrescription  `bson:"sets,omitempty" json:"sets,omitempty"`
+	RestSeconds     int                `bson:"rest_seconds,omitempty" json:"rest_seconds,omitempty"`
+	Tempo           string             `bson:"tempo,omitempty" json:"tempo,omitempty"`
+	Cardio          *CardioTarget      `bson:"cardio,omitempty" json:"cardio,omitempty"`
+	HoldSeconds     int                `bson:"hold_seconds,omitempty" json:"hold_seconds,omitempty"`
+}
+
+// Entry reconstruye lo prescrito en la foto para reutilizar Prescription y Describe de la rutina
+func (e ExcerciseSnapshot) Entry() ExcerciseInRoutine {
+	return ExcerciseInRoutine{
+		Repetitions: e.Repetitions,
+		Series:      e.Series,
+		Weight:      e.Weight,
+		Sets:        e.Sets,
+		Cardio:      e.Cardio,
+		HoldSeconds: e.HoldSeconds,
+	}
+}
+
+// ExcerciseName busca el nombre de un ejercicio en el snapshot; vacio si no esta
+func (s *RoutineSnapshot) ExcerciseName(id primitive.ObjectID) string {
+	if s == nil {
+		return ""
+	}
+	for _, e := range s.Exercises {
+		if e.ExcerciseID == id {
+			return e.Name
+		}
+	}
+	return ""
+}
+
+type ExcerciseInWorkout struct {
+	EntryID     primitive.ObjectID `bson:"entry_id,omitempty" json:"entry_id,omitempty"` // entrada de la rutina; el mismo ejercicio puede estar dos veces
+	ExcerciseID primitive.ObjectID `bson:"excercise_id" json:"exercise_id"`
+	Sets        []WorkoutSet       `bson:"sets" json:"sets"`
+}
+
+type WorkoutSet struct {
+	Repetitions int     `bson:"repetitions" json:"repetitions"`
+	Weight      float64 `bson:"weight" json:"weight"`
+	RPE         float64 `bson:"rpe,omitempty" json:"rpe,omitempty"` // opcional, escala 1-10
+	Completed   bool    `bson:"completed" json:"completed"`         // false = serie fallida
+
+	// series por tiempo o distancia (cardio y flexibilidad)
+	DurationSeconds int     `bson:"duration_seconds,omitempty" json:"duration_seconds,omitempty"`
+	DistanceMeters  float64 `bson:"distance_meters,omitempty" json:"distance_meters,omitempty"`
+	HeartRate       int     `bson:"heart_rate,omitempty" json:"heart_rate,omitempty"` // pulso promedio
+}
+
+// Describe resume lo registrado en la serie con el formato de las prescripciones: repeticiones y peso, o tiempo y
+// distancia en las de cardio y flexibilidad, mas el pulso si se cargo
+func (s WorkoutSet) Describe() string {
+	var parts []string
+	if s.DurationSeconds > 0 || s.DistanceMeters > 0 {
+		if s.DurationSeconds > 0 {
+			parts = append(parts, FormatSeconds(s.DurationSeconds))
+		}
+		if s.DistanceMeters > 0 {
+			parts = append(parts, FormatDistance(s.DistanceMeters))
+		}
+		if s.Weight > 0 {
+			parts = append(parts, fmt.Sprintf("@%gkg", s.Weight))
+		}
+	} else {
+		parts = append(parts, fmt.Sprintf("%dx%gkg", s.Repetitions, s.Weight))
+	}
+	if s.HeartRate > 0 {
+		parts = append(parts, fmt.Sprintf("%d ppm", s.HeartRate))
+	}
+	return strings.Join(parts, " ")
+}
+
+// WorkoutStatsAggregate es el resultado del pipeline de estadisticas calculado en MongoDB
+type WorkoutStatsAggregate struct {
+	Total           int                 `bson:"total"`
+	DurationSeconds int                 `bson:"duration_seconds"` // suma de las series por tiempo completadas
+	DistanceMeters  float64             `bson:"distance_meters"`
+	First           time.Time           `bson:"first"`
+	Last            time.Time           `bson:"last"`
+	Routines        []WorkoutStatsCount `bson:"routines"`
+	Progress        []WorkoutStatsCount `bson:"progress"`
+}
+
+type WorkoutStatsCount struct {
+	Key             string  `bson:"_id"`
+	Count           int     `bson:"count"`
+	DurationSeconds int     `bson:"duration_seconds"`
+	DistanceMeters  float64 `bson:"distance_meters"`
 }
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestWorkoutIsCompleted(t *testing.T) {
+	cases := []struct {
+		status WorkoutStatus
+		want   bool
+	}{
+		{WorkoutFinished, true},
+		{"", true}, // workouts anteriores al ciclo de vida
+		{WorkoutInProgress, false},
+		{WorkoutAbandoned, false},
+	}
+	for _, tc := range cases {
+		if got := (Workout{Status: tc.status}).IsCompleted(); got != tc.want {
+			t.Errorf("status %q: IsCompleted = %v, se esperaba %v", tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestWorkoutSetDescribe(t *testing.T) {
+	cases := []struct {
+		set  WorkoutSet
+		want string
+	}{
+		{WorkoutSet{Repetitions: 8, Weight: 60}, "8x60kg"},
+		{WorkoutSet{DurationSeconds: 1800, DistanceMeters: 5000, HeartRate: 150}, "30:00 5km 150 ppm"},
+		{WorkoutSet{DurationSeconds: 45}, "0:45"},
+		{WorkoutSet{DurationSeconds: 60, Weight: 20}, "1:00 @20kg"},
+	}
+	for _, tc := range cases {
+		if got := tc.set.Describe(); got != tc.want {
+			t.Errorf("Describe(%+v) = %q, se esperaba %q", tc.set, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// la API usa exercise_id en todas las respuestas, tambien en las que serializan el modelo directamente; en MongoDB
+// el campo se sigue llamando excercise_id
+func TestExerciseIDSpelling(t *testing.T) {
+	id := primitive.NewObjectID()
+	values := []interface{}{
+		Workout{Exercises: []ExcerciseInWorkout{{ExcerciseID: id}}, Routine: &RoutineSnapshot{Exercises: []ExcerciseSnapshot{{ExcerciseID: id}}}},
+		Routine{ExcerciseList: []ExcerciseInRoutine{{ExcerciseID: id}}},
+		PersonalRecord{ExcerciseID: id},
+	}
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"exercise_id"`) || strings.Contains(string(data), `"excercise_id"`) {
+			t.Fatalf("%T: clave JSON inesperada: %s", value, data)
+		}
+	}
+}
@@ -5,20 +5,35 @@ import (
 	"AppFitness/models"
 	"AppFitness/utils"
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ExcerciseRepositoryInterface interface {
 	PostExcercise(excercise models.Excercise) (*mongo.InsertOneResult, error)
 	GetExcercises() ([]models.Excercise, error)
+	GetExcercisesPage(page models.PageRequest) ([]models.Excercise, models.PageInfo, error)
 	GetExcerciseByID(id string) (models.Excercise, error)
 	PutExcercise(excercise models.Excercise) (*mongo.UpdateResult, error)
-	DeleteExcercise(id string) (*mongo.DeleteResult, error)
+	DeleteExcercise(id string) (*mongo.UpdateResult, error)
+	RestoreExcercise(id string) (*mongo.UpdateResult, error)
+	GetDeletedExcercisesPage(page models.PageRequest) ([]models.Excercise, models.PageInfo, error)
+	PurgeDeletedExcercises(before time.Time) ([]primitive.ObjectID, error)
 	ExistByName(name string) (bool, error)
+	GetExcerciseByName(name string) (models.Excercise, error)
+	GetExcercisesByNames(names []string) ([]models.Excercise, error)
 	GetByFilters(filterDTO dto.ExerciseFilterDTO) ([]*models.Excercise, error)
+	SearchText(query string) (map[primitive.ObjectID]float64, error)
+	GetSearchCandidates(ids []primitive.ObjectID, prefixPattern string) ([]*models.Excercise, error)
+	CountByTaxonomy(kind models.TaxonomyKind, key string) (int64, error)
+	NormalizeMuscleGroups(values []string, key string) (int64, error)
+	CreateIndexes() error
 }
 
 type ExcerciseRepository struct { //campo para la conexion a la base de datos
@@ -42,7 +57,7 @@ func (repository ExcerciseRepository) PostExcercise(excercise models.Excercise)
 
 func (repository ExcerciseRepository) GetExcercises() ([]models.Excercise, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
-	filtro := bson.M{} //filtro vacio para traer todos los documentos
+	filtro := active(bson.M{}) //todos los documentos que no estan en la papelera
 
 	cursor, err := collection.Find(context.TODO(), filtro)
 
@@ -67,7 +82,7 @@ func (repository ExcerciseRepository) GetExcerciseByID(id string) (models.Excerc
 	if err != nil {
 		return models.Excercise{}, fmt.Errorf("ID de formato inválido") // Devuelve error 400
 	}
-	filtro := bson.M{"_id": objectID}
+	filtro := active(bson.M{"_id": objectID})
 
 	result := collection.FindOne(context.TODO(), filtro)
 	var excercise models.Excercise
@@ -92,6 +107,12 @@ func (repository ExcerciseRepository) PutExcercise(excercise models.Excercise) (
 		"instructions":      excercise.Instructions,
 		"edition_date":      excercise.EditionDate,
 		"difficult_level":   excercise.DifficultLevel,
+		"aliases":           excercise.Aliases,
+		"secondary_muscles": excercise.SecondaryMuscles,
+		"equipment":         excercise.Equipment,
+		"mechanics":         excercise.Mechanics,
+		"force":             excercise.Force,
+		"laterality":        excercise.Laterality,
 	}}
 
 	result, err := collection.UpdateOne(context.TODO(), filtro, entity)
@@ -101,21 +122,53 @@ func (repository ExcerciseRepository) PutExcercise(excercise models.Excercise) (
 	return result, err
 }
 
-func (repository ExcerciseRepository) DeleteExcercise(id string) (*mongo.DeleteResult, error) {
+// DeleteExcercise manda el ejercicio a la papelera
+func (repository ExcerciseRepository) DeleteExcercise(id string) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
-	objectID, err := utils.GetObjectIDFromStringID(id)
+	result, err := softDelete(collection, id)
 	if err != nil {
-		return nil, fmt.Errorf("ID de formato inválido") // Devuelve error 400
+		return result, fmt.Errorf("error al eliminar el ejercicio en ExcerciseRepository.DeleteExcercise(): %v", err)
 	}
-	filtro := bson.M{"_id": objectID}
+	return result, nil
+}
 
-	result, err := collection.DeleteOne(context.TODO(), filtro)
+func (repository ExcerciseRepository) RestoreExcercise(id string) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	result, err := restoreDeleted(collection, id)
 	if err != nil {
-		return result, fmt.Errorf("error al eliminar el ejercicio en ExcerciseRepository.DeleteExcercise(): %v", err)
+		return result, fmt.Errorf("error al restaurar el ejercicio en ExcerciseRepository.RestoreExcercise(): %v", err)
 	}
-	return result, err
+	return result, nil
+}
+
+// GetDeletedExcercisesPage devuelve una pagina de los ejercicios en la papelera
+func (repository ExcerciseRepository) GetDeletedExcercisesPage(page models.PageRequest) ([]models.Excercise, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	var excercises []models.Excercise
+	info, err := findDeletedPage(collection, page, func(document bson.Raw) error {
+		var excercise models.Excercise
+		if err := bson.Unmarshal(document, &excercise); err != nil {
+			return err
+		}
+		excercises = append(excercises, excercise)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en ExcerciseRepository.GetDeletedExcercisesPage(): %v", err)
+	}
+	return excercises, info, nil
 }
 
+func (repository ExcerciseRepository) PurgeDeletedExcercises(before time.Time) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	purged, err := purgeDeleted(collection, before)
+	if err != nil {
+		return nil, fmt.Errorf("error al purgar ejercicios en ExcerciseRepository.PurgeDeletedExcercises(): %v", err)
+	}
+	return purged, nil
+}
+
+// ExistByName cuenta tambien los ejercicios de la papelera, asi restaurar uno nunca duplica un nombre
 func (r ExcerciseRepository) ExistByName(name string) (bool, error) {
 	collection := r.db.GetClient().Database("AppFitness").Collection("excercises")
 	filter := bson.M{"name": name}
@@ -128,20 +181,52 @@ func (r ExcerciseRepository) ExistByName(name string) (bool, error) {
 	return count > 0, err
 }
 
-func (repository ExcerciseRepository) GetByFilters(filterDTO dto.ExerciseFilterDTO) ([]*models.Excercise, error) {
+// GetExcerciseByName busca un ejercicio por nombre sin distinguir mayusculas ni acentos; se usa al importar
+// rutinas de otra instalacion o de una planilla. Devuelve un ejercicio vacio (ID.IsZero()) si no existe
+func (repository ExcerciseRepository) GetExcerciseByName(name string) (models.Excercise, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
-	filter := bson.M{}
-	if filterDTO.Name != "" {
-		filter["name"] = filterDTO.Name
+	opts := options.FindOne().SetCollation(&options.Collation{Locale: "es", Strength: 1})
+
+	var excercise models.Excercise
+	err := collection.FindOne(context.TODO(), active(bson.M{"name": name}), opts).Decode(&excercise)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Excercise{}, nil
+		}
+		return models.Excercise{}, fmt.Errorf("error al obtener el ejercicio en ExcerciseRepository.GetExcerciseByName(): %v", err)
 	}
-	if filterDTO.Category != "" {
-		filter["category"] = filterDTO.Category
+	return excercise, nil
+}
+
+// GetExcercisesByNames busca de una vez los ejercicios con alguno de esos nombres, sin distinguir mayusculas ni
+// acentos; se usa al importar historial para no recorrer el catalogo entero
+func (repository ExcerciseRepository) GetExcercisesByNames(names []string) ([]models.Excercise, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	if len(names) == 0 {
+		return nil, nil
 	}
-	if filterDTO.MuscleGroup != "" {
-		filter["main_muscle_group"] = filterDTO.MuscleGroup
+	opts := options.Find().SetCollation(&options.Collation{Locale: "es", Strength: 1})
+
+	cursor, err := collection.Find(context.TODO(), active(bson.M{"name": bson.M{"$in": names}}), opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los ejercicios en ExcerciseRepository.GetExcercisesByNames(): %v", err)
 	}
+	defer cursor.Close(context.TODO())
+
+	var excercises []models.Excercise
+	if err := cursor.All(context.TODO(), &excercises); err != nil {
+		return nil, fmt.Errorf("error al decodificar los ejercicios en ExcerciseRepository.GetExcercisesByNames(): %v", err)
+	}
+	return excercises, nil
+}
+
+// GetByFilters devuelve los ejercicios de la categoria y el grupo muscular pedidos, sin distinguir mayusculas ni
+// acentos; con filtros la busqueda por texto (name o q) se resuelve en el servicio sobre este resultado
+func (repository ExcerciseRepository) GetByFilters(filterDTO dto.ExerciseFilterDTO) ([]*models.Excercise, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	opts := options.Find().SetCollation(&options.Collation{Locale: "es", Strength: 1})
 
-	result, err := collection.Find(context.TODO(), filter)
+	result, err := collection.Find(context.TODO(), active(structuredFilter(filterDTO)), opts)
 	if err != nil {
 		return nil, fmt.Errorf("erro al buscar ejercicios: %v", err)
 	}
@@ -159,3 +244,185 @@ func (repository ExcerciseRepository) GetByFilters(filterDTO dto.ExerciseFilterD
 	return excercises, nil
 
 }
+
+// SearchText busca query con el indice de texto y devuelve el puntaje de MongoDB de cada ejercicio encontrado.
+// No aplica los filtros de categoria y grupo muscular: $text no admite la collation que los hace insensibles a
+// mayusculas, asi que el servicio cruza este resultado con el de GetByFilters
+func (repository ExcerciseRepository) SearchText(query string) (map[primitive.ObjectID]float64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	filter := active(bson.M{"$text": bson.M{"$search": query}})
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "score": score}).SetSort(bson.M{"score": score})
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la busqueda de texto en ExcerciseRepository.SearchText(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	scores := make(map[primitive.ObjectID]float64)
+	for cursor.Next(context.TODO()) {
+		var found struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Score float64            `bson:"score"`
+		}
+		if err := cursor.Decode(&found); err != nil {
+			return nil, fmt.Errorf("error al decodificar el resultado en ExcerciseRepository.SearchText(): %v", err)
+		}
+		scores[found.ID] = found.Score
+	}
+	return scores, nil
+}
+
+// GetSearchCandidates devuelve los ejercicios que encontro el indice de texto (ids) mas los que tienen en el nombre o
+// en un alias una palabra que coincide con prefixPattern, para puntuar una busqueda sin filtros sin recorrer toda la
+// coleccion
+func (repository ExcerciseRepository) GetSearchCandidates(ids []primitive.ObjectID, prefixPattern string) ([]*models.Excercise, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	var conditions bson.A
+	if len(ids) > 0 {
+		conditions = append(conditions, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	if prefixPattern != "" {
+		regex := primitive.Regex{Pattern: prefixPattern, Options: "i"}
+		conditions = append(conditions, bson.M{"name": regex}, bson.M{"aliases": regex})
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := collection.Find(context.TODO(), active(bson.M{"$or": conditions}))
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar candidatos en ExcerciseRepository.GetSearchCandidates(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var excercises []*models.Excercise
+	for cursor.Next(context.TODO()) {
+		var e models.Excercise
+		if err := cursor.Decode(&e); err != nil {
+			return nil, fmt.Errorf("error al decodificar el resultado en ExcerciseRepository.GetSearchCandidates(): %v", err)
+		}
+		excercises = append(excercises, &e)
+	}
+	return excercises, nil
+}
+
+// structuredFilter arma el filtro de categoria y clasificacion
+func structuredFilter(filterDTO dto.ExerciseFilterDTO) bson.M {
+	filter := bson.M{}
+	if filterDTO.Category != "" {
+		filter["category"] = filterDTO.Category
+	}
+	if filterDTO.MuscleGroup != "" {
+		filter["main_muscle_group"] = filterDTO.MuscleGroup
+	}
+	if filterDTO.Muscle != "" {
+		filter["$or"] = bson.A{
+			bson.M{"main_muscle_group": filterDTO.Muscle},
+			bson.M{"secondary_muscles.muscle": filterDTO.Muscle},
+		}
+	}
+	if filterDTO.Equipment != "" {
+		filter["equipment"] = filterDTO.Equipment // coincide con cualquier elemento de la lista
+	}
+	if filterDTO.Mechanics != "" {
+		filter["mechanics"] = filterDTO.Mechanics
+	}
+	if filterDTO.Force != "" {
+		filter["force"] = filterDTO.Force
+	}
+	if filterDTO.Laterality != "" {
+		filter["laterality"] = filterDTO.Laterality
+	}
+	return filter
+}
+
+// taxonomyFields son los campos del ejercicio que guardan claves de cada vocabulario
+var taxonomyFields = map[models.TaxonomyKind][]string{
+	models.TaxonomyMuscle:     {"main_muscle_group", "secondary_muscles.muscle"},
+	models.TaxonomyEquipment:  {"equipment"},
+	models.TaxonomyMechanics:  {"mechanics"},
+	models.TaxonomyForce:      {"force"},
+	models.TaxonomyLaterality: {"laterality"},
+}
+
+// CountByTaxonomy cuenta los ejercicios que usan la clave de un vocabulario, incluidos los de la papelera para que
+// restaurarlos nunca deje un valor que ya no existe
+func (repository ExcerciseRepository) CountByTaxonomy(kind models.TaxonomyKind, key string) (int64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	var conditions bson.A
+	for _, field := range taxonomyFields[kind] {
+		conditions = append(conditions, bson.M{field: key})
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"$or": conditions})
+	if err != nil {
+		return 0, fmt.Errorf("error al contar documentos en ExcerciseRepository.CountByTaxonomy(): %v", err)
+	}
+	return count, nil
+}
+
+// NormalizeMuscleGroups reemplaza por key el grupo muscular de los ejercicios cargados como texto libre con alguno
+// de los values, sin distinguir mayusculas ni acentos
+func (repository ExcerciseRepository) NormalizeMuscleGroups(values []string, key string) (int64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	filter := bson.M{"main_muscle_group": bson.M{"$in": values}}
+	opts := options.Update().SetCollation(&options.Collation{Locale: "es", Strength: 1})
+
+	result, err := collection.UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{"main_muscle_group": key}}, opts)
+	if err != nil {
+		return 0, fmt.Errorf("error al normalizar grupos musculares en ExcerciseRepository.NormalizeMuscleGroups(): %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// CreateIndexes crea el indice de texto de la busqueda de ejercicios. Se usa el idioma "none" porque el catalogo
+// mezcla nombres en español e ingles y la raiz de una palabra en un idioma no sirve para el otro; los acentos y las
+// mayusculas los ignora el indice de todas formas
+func (repository ExcerciseRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	search := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "name", Value: "text"},
+			{Key: "aliases", Value: "text"},
+			{Key: "description", Value: "text"},
+			{Key: "instructions", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("excercise_search").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				{Key: "name", Value: 10},
+				{Key: "aliases", Value: 8},
+				{Key: "description", Value: 3},
+				{Key: "instructions", Value: 1},
+			}),
+	}
+	_, err := collection.Indexes().CreateOne(context.TODO(), search)
+	if err != nil {
+		return fmt.Errorf("error al crear indices en ExcerciseRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
+
+// GetExcercisesPage devuelve una pagina del catalogo
+func (repository ExcerciseRepository) GetExcercisesPage(page models.PageRequest) ([]models.Excercise, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("excercises")
+	var excercises []models.Excercise
+	info, err := findPage(collection, active(bson.M{}), page, func(document bson.Raw) error {
+		var excercise models.Excercise
+		if err := bson.Unmarshal(document, &excercise); err != nil {
+			return err
+		}
+		excercises = append(excercises, excercise)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en ExcerciseRepository.GetExcercisesPage(): %v", err)
+	}
+	return excercises, info, nil
+}
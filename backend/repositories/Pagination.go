@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"context"
+	"fmt"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findPage trae una pagina de la coleccion con paginacion por cursor (keyset): en lugar de saltear documentos
+// filtra por el valor del campo de orden y el _id del borde de la pagina anterior, asi el costo no crece con el
+// numero de pagina y los documentos insertados mientras se recorre no se repiten ni se pierden.
+// Cada documento se entrega a decode en el orden de la respuesta
+func findPage(collection *mongo.Collection, filter bson.M, page models.PageRequest, decode func(bson.Raw) error) (models.PageInfo, error) {
+	var info models.PageInfo
+	if page.Total {
+		total, err := collection.CountDocuments(context.TODO(), filter)
+		if err != nil {
+			return info, fmt.Errorf("error al contar los documentos de %s: %v", collection.Name(), err)
+		}
+		info.Total = &total
+	}
+
+	backward := page.Cursor != nil && page.Cursor.Backward
+	desc := page.SortDesc != backward // para la pagina anterior se recorre al reves y despues se invierte
+	query := filter
+	if page.Cursor != nil {
+		query = bson.M{"$and": bson.A{filter, keysetFilter(page.SortField, page.Cursor, desc)}}
+	}
+
+	direction := 1
+	if desc {
+		direction = -1
+	}
+	sort := bson.D{{Key: page.SortField, Value: direction}}
+	if page.SortField != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+	opts := options.Find().SetSort(sort).SetLimit(int64(page.Limit) + 1) // uno de mas para saber si hay otra pagina
+	if len(page.Projection) > 0 {
+		projection := bson.M{page.SortField: 1}
+		for _, field := range page.Projection {
+			projection[field] = 1
+		}
+		opts.SetProjection(projection)
+	}
+
+	cursor, err := collection.Find(context.TODO(), query, opts)
+	if err != nil {
+		return info, fmt.Errorf("error al ejecutar la consulta Find() paginada de %s: %v", collection.Name(), err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var documents []bson.Raw
+	for cursor.Next(context.TODO()) {
+		documents = append(documents, slices.Clone(cursor.Current))
+	}
+	if err := cursor.Err(); err != nil {
+		return info, fmt.Errorf("error al recorrer la consulta paginada de %s: %v", collection.Name(), err)
+	}
+	more := len(documents) > page.Limit
+	if more {
+		documents = documents[:page.Limit]
+	}
+	if backward {
+		slices.Reverse(documents)
+	}
+
+	if len(documents) > 0 {
+		first, last := documents[0], documents[len(documents)-1]
+		// hacia adelante hay pagina siguiente si sobro un documento y anterior si se llego con un cursor;
+		// hacia atras es al reves
+		if (!backward && more) || backward {
+			info.Next = page.NewCursor(sortValue(last, page.SortField), documentID(last), false)
+		}
+		if (!backward && page.Cursor != nil) || (backward && more) {
+			info.Prev = page.NewCursor(sortValue(first, page.SortField), documentID(first), true)
+		}
+	}
+
+	for _, document := range documents {
+		if err := decode(document); err != nil {
+			return info, fmt.Errorf("error al decodificar un documento de %s: %v", collection.Name(), err)
+		}
+	}
+	return info, nil
+}
+
+// keysetFilter selecciona los documentos que van despues del cursor en el orden pedido, desempatando por _id.
+// MongoDB ordena los documentos sin el campo (o con null) antes que cualquier valor, pero $gt y $lt solo comparan
+// valores del mismo tipo y nunca los devuelven, asi que el null se trata aparte para no perderlos
+func keysetFilter(field string, cursor *models.PageCursor, desc bool) bson.M {
+	operator := "$gt"
+	if desc {
+		operator = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{operator: cursor.ID}}
+	}
+
+	missing := bson.M{field: nil} // coincide con null y con el campo ausente
+	if cursor.Value.Type == bsontype.Null || cursor.Value.Type == 0 {
+		sameNull := bson.M{field: nil, "_id": bson.M{operator: cursor.ID}}
+		if desc { // los null son los ultimos
+			return sameNull
+		}
+		return bson.M{"$or": bson.A{sameNull, bson.M{field: bson.M{"$ne": nil}}}}
+	}
+
+	after := bson.A{
+		bson.M{field: bson.M{operator: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{operator: cursor.ID}},
+	}
+	if desc { // despues del ultimo valor vienen los null
+		after = append(after, missing)
+	}
+	return bson.M{"$or": after}
+}
+
+func sortValue(document bson.Raw, field string) bson.RawValue {
+	value, err := document.LookupErr(field)
+	if err != nil {
+		return bson.RawValue{Type: bsontype.Null}
+	}
+	return value
+}
+
+func documentID(document bson.Raw) primitive.ObjectID {
+	id, _ := document.Lookup("_id").ObjectIDOK()
+	return id
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matches evalua los filtros que arma keysetFilter sobre un documento en memoria, con las reglas de MongoDB:
+// {campo: nil} coincide con null y con el campo ausente, $gt/$lt solo comparan valores del mismo tipo
+func matches(t *testing.T, document bson.M, filter bson.M) bool {
+	for key, condition := range filter {
+		switch key {
+		case "$or":
+			found := false
+			for _, sub := range condition.(bson.A) {
+				found = found || matches(t, document, sub.(bson.M))
+			}
+			if !found {
+				return false
+			}
+			continue
+		}
+		value := document[key]
+		operators, isOperator := condition.(bson.M)
+		if !isOperator {
+			if compare(t, value, plain(condition)) != 0 {
+				return false
+			}
+			continue
+		}
+		for operator, operand := range operators {
+			operand = plain(operand)
+			switch operator {
+			case "$ne":
+				if compare(t, value, operand) == 0 {
+					return false
+				}
+			case "$gt", "$lt":
+				if value == nil || operand == nil {
+					return false
+				}
+				c := compare(t, value, operand)
+				if (operator == "$gt" && c <= 0) || (operator == "$lt" && c >= 0) {
+					return false
+				}
+			default:
+				t.Fatalf("operador no soportado por el test: %s", operator)
+			}
+		}
+	}
+	return true
+}
+
+// plain pasa el valor del cursor (bson.RawValue) a un valor de Go comparable
+func plain(value interface{}) interface{} {
+	raw, ok := value.(bson.RawValue)
+	if !ok {
+		return value
+	}
+	if number, ok := raw.AsInt64OK(); ok {
+		return int(number)
+	}
+	return nil
+}
+
+// compare ordena como MongoDB: null (o ausente) antes que cualquier numero
+func compare(t *testing.T, a interface{}, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch x := a.(type) {
+	case int:
+		return x - b.(int)
+	case primitive.ObjectID:
+		y := b.(primitive.ObjectID)
+		return slices.Compare(x[:], y[:])
+	}
+	t.Fatalf("tipo no soportado por el test: %T", a)
+	return 0
+}
+
+func TestKeysetFilterPagesAcrossMissingValues(t *testing.T) {
+	// duration_seconds tiene omitempty: los workouts sin duracion no tienen el campo
+	var documents []bson.M
+	for i, duration := range []interface{}{30, nil, 45, 30, nil, 60, nil, 45, 10} {
+		id := primitive.ObjectID{}
+		id[11] = byte(i + 1)
+		document := bson.M{"_id": id}
+		if duration != nil {
+			document["duration_seconds"] = duration
+		}
+		documents = append(documents, document)
+	}
+
+	for _, desc := range []bool{false, true} {
+		for _, limit := range []int{1, 2, 4} {
+			t.Run(fmt.Sprintf("desc=%v limit=%d", desc, limit), func(t *testing.T) {
+				ordered := slices.Clone(documents)
+				sort.SliceStable(ordered, func(a, b int) bool {
+					c := compare(t, ordered[a]["duration_seconds"], ordered[b]["duration_seconds"])
+					if c == 0 {
+						c = compare(t, ordered[a]["_id"], ordered[b]["_id"])
+					}
+					if desc {
+						return c > 0
+					}
+					return c < 0
+				})
+
+				var seen []bson.M
+				var cursor *models.PageCursor
+				for pages := 0; pages <= len(documents); pages++ {
+					var page []bson.M
+					for _, document := range ordered {
+						if cursor == nil || matches(t, document, keysetFilter("duration_seconds", cursor, desc)) {
+							page = append(page, document)
+						}
+						if len(page) == limit {
+							break
+						}
+					}
+					if len(page) == 0 {
+						break
+					}
+					seen = append(seen, page...)
+
+					last, err := bson.Marshal(page[len(page)-1])
+					if err != nil {
+						t.Fatalf("error inesperado: %v", err)
+					}
+					cursor = &models.PageCursor{Value: sortValue(last, "duration_seconds"), ID: documentID(last)}
+				}
+
+				if len(seen) != len(ordered) {
+					t.Fatalf("se recorrieron %d documentos de %d", len(seen), len(ordered))
+				}
+				for i := range ordered {
+					if seen[i]["_id"] != ordered[i]["_id"] {
+						t.Fatalf("posicion %d: se obtuvo %v, se esperaba %v", i, seen[i], ordered[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestKeysetFilterByID(t *testing.T) {
+	id := primitive.NewObjectID()
+	filter := keysetFilter("_id", &models.PageCursor{ID: id}, true)
+	if got := filter["_id"].(bson.M)["$lt"]; got != id {
+		t.Fatalf("se esperaba _id < cursor, se obtuvo %v", filter)
+	}
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PersonalRecordRepositoryInterface interface {
+	PostRecords(records []models.PersonalRecord) (*mongo.InsertManyResult, error)
+	GetRecordsByUserID(userID string) ([]models.PersonalRecord, error)
+	GetRecordsByUserAndExcercise(userID string, excerciseID string) ([]models.PersonalRecord, error)
+	DeleteRecordsByUserAndExcercise(userID string, excerciseID string) (*mongo.DeleteResult, error)
+	DeleteRecordsByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error)
+	DeleteRecordsByExcercises(excerciseIDs []primitive.ObjectID) (*mongo.DeleteResult, error)
+}
+
+type PersonalRecordRepository struct {
+	db DB
+}
+
+func NewPersonalRecordRepository(db DB) *PersonalRecordRepository {
+	return &PersonalRecordRepository{
+		db: db,
+	}
+}
+
+func (repository PersonalRecordRepository) PostRecords(records []models.PersonalRecord) (*mongo.InsertManyResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("personal_records")
+	documents := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		documents = append(documents, r)
+	}
+	result, err := collection.InsertMany(context.TODO(), documents)
+	if err != nil {
+		return result, fmt.Errorf("error al insertar los records en PersonalRecordRepository.PostRecords(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository PersonalRecordRepository) GetRecordsByUserID(userID string) ([]models.PersonalRecord, error) {
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return repository.find(bson.M{"user_id": userObjectID})
+}
+
+func (repository PersonalRecordRepository) GetRecordsByUserAndExcercise(userID string, excerciseID string) ([]models.PersonalRecord, error) {
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	excerciseObjectID, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+	return repository.find(bson.M{"user_id": userObjectID, "excercise_id": excerciseObjectID})
+}
+
+// DeleteRecordsByUserAndExcercise borra el historial de records de un ejercicio para volver a calcularlo
+func (repository PersonalRecordRepository) DeleteRecordsByUserAndExcercise(userID string, excerciseID string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("personal_records")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	excerciseObjectID, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"user_id": userObjectID, "excercise_id": excerciseObjectID})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar los records en PersonalRecordRepository.DeleteRecordsByUserAndExcercise(): %v", err)
+	}
+	return result, nil
+}
+
+// DeleteRecordsByUsers borra los records de los usuarios purgados
+func (repository PersonalRecordRepository) DeleteRecordsByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("personal_records")
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar los records en PersonalRecordRepository.DeleteRecordsByUsers(): %v", err)
+	}
+	return result, nil
+}
+
+// DeleteRecordsByExcercises borra los records de los ejercicios purgados
+func (repository PersonalRecordRepository) DeleteRecordsByExcercises(excerciseIDs []primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("personal_records")
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"excercise_id": bson.M{"$in": excerciseIDs}})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar los records en PersonalRecordRepository.DeleteRecordsByExcercises(): %v", err)
+	}
+	return result, nil
+}
+
+// find devuelve los records que cumplen el filtro ordenados cronologicamente
+func (repository PersonalRecordRepository) find(filter bson.M) ([]models.PersonalRecord, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("personal_records")
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "creation_date", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en PersonalRecordRepository: %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var records []models.PersonalRecord
+	for cursor.Next(context.Background()) {
+		var record models.PersonalRecord
+		if err := cursor.Decode(&record); err != nil {
+			return nil, fmt.Errorf("error al decodificar el record en PersonalRecordRepository: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
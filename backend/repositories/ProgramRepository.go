@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProgramRepositoryInterface interface {
+	PostProgram(program models.Program) (*mongo.InsertOneResult, error)
+	GetProgramByID(id string) (models.Program, error)
+	GetProgramsByCreator(userID string) ([]models.Program, error)
+	PutProgram(program models.Program) (*mongo.UpdateResult, error)
+	DeleteProgram(id string) (*mongo.DeleteResult, error)
+	PostEnrollment(enrollment models.ProgramEnrollment) (*mongo.InsertOneResult, error)
+	GetActiveEnrollment(userID string) (models.ProgramEnrollment, error)
+	CloseEnrollment(id primitive.ObjectID, status models.EnrollmentStatus, end time.Time) (*mongo.UpdateResult, error)
+	CancelEnrollmentsByProgram(programID primitive.ObjectID) (*mongo.UpdateResult, error)
+	DeleteEnrollmentsByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error)
+	CreateIndexes() error
+}
+
+type ProgramRepository struct {
+	db DB
+}
+
+func NewProgramRepository(db DB) *ProgramRepository {
+	return &ProgramRepository{
+		db: db,
+	}
+}
+
+func (repository ProgramRepository) PostProgram(program models.Program) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("programs")
+	result, err := collection.InsertOne(context.TODO(), program)
+	if err != nil {
+		return result, fmt.Errorf("error al insertar el programa en ProgramRepository.PostProgram(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ProgramRepository) GetProgramByID(id string) (models.Program, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("programs")
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return models.Program{}, err
+	}
+
+	var program models.Program
+	err = collection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&program)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Program{}, nil // ID.IsZero() = no existe
+		}
+		return models.Program{}, fmt.Errorf("error al obtener el programa en ProgramRepository.GetProgramByID(): %v", err)
+	}
+	return program, nil
+}
+
+func (repository ProgramRepository) GetProgramsByCreator(userID string) ([]models.Program, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("programs")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "edition_date", Value: -1}})
+	cursor, err := collection.Find(context.TODO(), bson.M{"creator_user_id": userObjectID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en ProgramRepository.GetProgramsByCreator(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var programs []models.Program
+	if err := cursor.All(context.TODO(), &programs); err != nil {
+		return nil, fmt.Errorf("error al decodificar los programas en ProgramRepository.GetProgramsByCreator(): %v", err)
+	}
+	return programs, nil
+}
+
+// PutProgram reemplaza el contenido del programa; el creador y la fecha de creacion no cambian
+func (repository ProgramRepository) PutProgram(program models.Program) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("programs")
+	update := bson.M{"$set": bson.M{
+		"name":         program.Name,
+		"description":  program.Description,
+		"weeks":        program.Weeks,
+		"progression":  program.Progression,
+		"visibility":   program.Visibility,
+		"edition_date": program.EditionDate,
+	}}
+
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": program.ID}, update)
+	if err != nil {
+		return result, fmt.Errorf("error al modificar el programa en ProgramRepository.PutProgram(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ProgramRepository) DeleteProgram(id string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("programs")
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": objectID})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar el programa en ProgramRepository.DeleteProgram(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ProgramRepository) PostEnrollment(enrollment models.ProgramEnrollment) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	result, err := collection.InsertOne(context.TODO(), enrollment)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) { // otra inscripcion activa creada al mismo tiempo
+			return nil, fmt.Errorf("ya tiene un programa activo")
+		}
+		return result, fmt.Errorf("error al insertar la inscripcion en ProgramRepository.PostEnrollment(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ProgramRepository) GetActiveEnrollment(userID string) (models.ProgramEnrollment, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return models.ProgramEnrollment{}, err
+	}
+
+	var enrollment models.ProgramEnrollment
+	err = collection.FindOne(context.TODO(), bson.M{"user_id": userObjectID, "status": models.EnrollmentActive}).Decode(&enrollment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.ProgramEnrollment{}, nil // ID.IsZero() = sin programa activo
+		}
+		return models.ProgramEnrollment{}, fmt.Errorf("error al obtener la inscripcion en ProgramRepository.GetActiveEnrollment(): %v", err)
+	}
+	return enrollment, nil
+}
+
+// CloseEnrollment marca la inscripcion como completada o cancelada
+func (repository ProgramRepository) CloseEnrollment(id primitive.ObjectID, status models.EnrollmentStatus, end time.Time) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	filter := bson.M{"_id": id, "status": models.EnrollmentActive}
+	update := bson.M{"$set": bson.M{"status": status, "end_date": end}}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return result, fmt.Errorf("error al cerrar la inscripcion en ProgramRepository.CloseEnrollment(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ProgramRepository) CancelEnrollmentsByProgram(programID primitive.ObjectID) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	filter := bson.M{"program_id": programID, "status": models.EnrollmentActive}
+	update := bson.M{"$set": bson.M{"status": models.EnrollmentCancelled, "end_date": time.Now()}}
+
+	result, err := collection.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return result, fmt.Errorf("error al cancelar las inscripciones en ProgramRepository.CancelEnrollmentsByProgram(): %v", err)
+	}
+	return result, nil
+}
+
+// DeleteEnrollmentsByUsers borra las inscripciones (activas o no) de los usuarios purgados
+func (repository ProgramRepository) DeleteEnrollmentsByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar las inscripciones en ProgramRepository.DeleteEnrollmentsByUsers(): %v", err)
+	}
+	return result, nil
+}
+
+// CreateIndexes crea el indice unico parcial que impide tener mas de un programa activo por usuario
+func (repository ProgramRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("program_enrollments")
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().
+			SetName("unique_active_enrollment_per_user").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": models.EnrollmentActive}),
+	}
+	if _, err := collection.Indexes().CreateOne(context.TODO(), index); err != nil {
+		return fmt.Errorf("error al crear indices en ProgramRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
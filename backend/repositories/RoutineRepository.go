@@ -5,6 +5,8 @@ import (
 	"AppFitness/utils"
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,13 +18,29 @@ import (
 type RoutineRepositoryInterface interface {
 	PostRoutine(models.Routine) (*mongo.InsertOneResult, error)
 	GetRoutines() ([]*models.Routine, error)
+	GetRoutinesByCreatorPage(userID string, page models.PageRequest) ([]*models.Routine, models.PageInfo, error)
 	GetRoutineByID(id string) (*models.Routine, error)
 	PutRoutine(routine models.Routine) (*mongo.UpdateResult, error)
-	DeleteRoutine(id string) (*mongo.DeleteResult, error)
-	AddExerciseRutine(exercise models.ExcerciseInRoutine, idRutine primitive.ObjectID) (*mongo.UpdateResult, error)
-	UpdateExerciseInRoutine(idRutine primitive.ObjectID, idExercise primitive.ObjectID, exerciseMod models.ExcerciseInRoutine) (*mongo.UpdateResult, error)
-	DeleteExerciseToRutine(rutineID primitive.ObjectID, exerciseID primitive.ObjectID) (*mongo.UpdateResult, error)
-	ExistByRutineName(rutineName string) (bool, error)
+	DeleteRoutine(id string) (*mongo.UpdateResult, error)
+	RestoreRoutine(id string) (*mongo.UpdateResult, error)
+	GetDeletedRoutineByID(id string) (*models.Routine, error)
+	GetDeletedRoutinesPage(page models.PageRequest) ([]*models.Routine, models.PageInfo, error)
+	PurgeDeletedRoutines(before time.Time) ([]primitive.ObjectID, error)
+	DeleteRoutinesByCreators(userIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
+	AddExerciseRutine(exercise models.ExcerciseInRoutine, idRutine primitive.ObjectID, position int) (*mongo.UpdateResult, error)
+	UpdateExerciseInRoutine(idRutine primitive.ObjectID, idEntry primitive.ObjectID, exerciseMod models.ExcerciseInRoutine) (*mongo.UpdateResult, error)
+	DeleteExerciseToRutine(rutineID primitive.ObjectID, entryID primitive.ObjectID) (*mongo.UpdateResult, error)
+	ExistByRutineName(creatorID primitive.ObjectID, rutineName string) (bool, error)
+	ReplaceRoutineContent(routine models.Routine) (*mongo.UpdateResult, error)
+	DetachExcercise(routine models.Routine, excerciseID primitive.ObjectID, editedAt time.Time) (*mongo.UpdateResult, error)
+	SetRoutineVersion(id primitive.ObjectID, version int) (*mongo.UpdateResult, error)
+	SetSuggestions(id primitive.ObjectID, suggestions []models.ProgressionSuggestion) (*mongo.UpdateResult, error)
+	SetEntryIDs(id primitive.ObjectID, list []models.ExcerciseInRoutine, positions []int) (*mongo.UpdateResult, error)
+	GetRoutinesByCreator(userID string) ([]*models.Routine, error)
+	GetRoutinesByExcercise(excerciseID string) ([]*models.Routine, error)
+	SetVisibility(id primitive.ObjectID, visibility models.RoutineVisibility) (*mongo.UpdateResult, error)
+	SetFeatured(id primitive.ObjectID, featured bool) (*mongo.UpdateResult, error)
+	SearchPublicRoutines(filter models.PublicRoutineFilter) ([]models.PublicRoutine, error)
 }
 
 type RoutineRepository struct {
@@ -46,7 +64,7 @@ func (repository RoutineRepository) PostRoutine(routine models.Routine) (*mongo.
 
 func (repository RoutineRepository) GetRoutines() ([]*models.Routine, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
-	filter := bson.M{}
+	filter := active(bson.M{})
 
 	cursor, err := collection.Find(context.TODO(), filter)
 	if err != nil {
@@ -72,7 +90,7 @@ func (repository RoutineRepository) GetRoutineByID(id string) (*models.Routine,
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.M{"_id": objID}
+	filter := active(bson.M{"_id": objID})
 
 	result := collection.FindOne(context.TODO(), filter)
 
@@ -95,26 +113,87 @@ func (repository RoutineRepository) PutRoutine(routine models.Routine) (*mongo.U
 	return result, nil
 }
 
-func (repository RoutineRepository) DeleteRoutine(id string) (*mongo.DeleteResult, error) {
+// DeleteRoutine manda la rutina a la papelera
+func (repository RoutineRepository) DeleteRoutine(id string) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
-	objectID, err := utils.GetObjectIDFromStringID(id)
+	result, err := softDelete(collection, id)
 	if err != nil {
-		return nil, err
+		return result, fmt.Errorf("error al eliminar la rutina en RoutineRepository.DeleteRoutine(): %v", err)
 	}
-	filter := bson.M{"_id": objectID}
-	result, err := collection.DeleteOne(context.TODO(), filter)
+	return result, nil
+}
+
+func (repository RoutineRepository) RestoreRoutine(id string) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	result, err := restoreDeleted(collection, id)
 	if err != nil {
-		return result, fmt.Errorf("error al eliminar la rutina en RoutineRepository.DeleteRoutine(): %v", err)
+		return result, fmt.Errorf("error al restaurar la rutina en RoutineRepository.RestoreRoutine(): %v", err)
 	}
 	return result, nil
 }
 
-func (repository RoutineRepository) AddExerciseRutine(exercise models.ExcerciseInRoutine, idRutine primitive.ObjectID) (*mongo.UpdateResult, error) {
+// GetDeletedRoutineByID busca la rutina en la papelera; devuelve nil si no esta
+func (repository RoutineRepository) GetDeletedRoutineByID(id string) (*models.Routine, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	var routine models.Routine
+	found, err := findDeletedByID(collection, id, &routine)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina en RoutineRepository.GetDeletedRoutineByID(): %v", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &routine, nil
+}
+
+// GetDeletedRoutinesPage devuelve una pagina de las rutinas en la papelera
+func (repository RoutineRepository) GetDeletedRoutinesPage(page models.PageRequest) ([]*models.Routine, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	var routines []*models.Routine
+	info, err := findDeletedPage(collection, page, func(document bson.Raw) error {
+		var routine models.Routine
+		if err := bson.Unmarshal(document, &routine); err != nil {
+			return err
+		}
+		routines = append(routines, &routine)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en RoutineRepository.GetDeletedRoutinesPage(): %v", err)
+	}
+	return routines, info, nil
+}
+
+func (repository RoutineRepository) PurgeDeletedRoutines(before time.Time) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	purged, err := purgeDeleted(collection, before)
+	if err != nil {
+		return nil, fmt.Errorf("error al purgar rutinas en RoutineRepository.PurgeDeletedRoutines(): %v", err)
+	}
+	return purged, nil
+}
+
+// DeleteRoutinesByCreators borra definitivamente las rutinas de los usuarios purgados y devuelve sus IDs
+func (repository RoutineRepository) DeleteRoutinesByCreators(userIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	deletedIDs, err := deleteOwnedBy(collection, "creator_user_id", userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error al eliminar rutinas en RoutineRepository.DeleteRoutinesByCreators(): %v", err)
+	}
+	return deletedIDs, nil
+}
+
+// AddExerciseRutine inserta la entrada en la posicion indicada (negativa = al final)
+func (repository RoutineRepository) AddExerciseRutine(exercise models.ExcerciseInRoutine, idRutine primitive.ObjectID, position int) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
 
+	push := bson.M{"$each": []models.ExcerciseInRoutine{exercise}}
+	if position >= 0 {
+		push["$position"] = position
+	}
 	update := bson.M{
 		"$push": bson.M{
-			"exercise_list": exercise,
+			"exercise_list": push,
 		},
 		"$set": bson.M{
 			"edition_date": time.Now(),
@@ -138,7 +217,8 @@ func (repository RoutineRepository) AddExerciseRutine(exercise models.ExcerciseI
 	return result, nil
 }
 
-func (repository RoutineRepository) UpdateExerciseInRoutine(idRutine primitive.ObjectID, idExercise primitive.ObjectID, exerciseMod models.ExcerciseInRoutine) (*mongo.UpdateResult, error) {
+// UpdateExerciseInRoutine modifica una sola entrada de la rutina, identificada por su entry_id
+func (repository RoutineRepository) UpdateExerciseInRoutine(idRutine primitive.ObjectID, idEntry primitive.ObjectID, exerciseMod models.ExcerciseInRoutine) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
 
 	set := bson.M{}
@@ -154,14 +234,46 @@ func (repository RoutineRepository) UpdateExerciseInRoutine(idRutine primitive.O
 	if len(set) == 0 {
 		return nil, fmt.Errorf("no se enviaron campos para actualizar")
 	}
+	// la prescripcion detallada se reemplaza completa: lo que no viene se borra
+	update := bson.M{"$set": set}
+	unset := bson.M{}
+	if len(exerciseMod.Sets) > 0 {
+		set["exercise_list.$[e].sets"] = exerciseMod.Sets
+	} else {
+		unset["exercise_list.$[e].sets"] = ""
+	}
+	if exerciseMod.RestSeconds > 0 {
+		set["exercise_list.$[e].rest_seconds"] = exerciseMod.RestSeconds
+	} else {
+		unset["exercise_list.$[e].rest_seconds"] = ""
+	}
+	if exerciseMod.Tempo != "" {
+		set["exercise_list.$[e].tempo"] = exerciseMod.Tempo
+	} else {
+		unset["exercise_list.$[e].tempo"] = ""
+	}
+	if exerciseMod.Cardio != nil {
+		set["exercise_list.$[e].cardio"] = exerciseMod.Cardio
+	} else {
+		unset["exercise_list.$[e].cardio"] = ""
+	}
+	if exerciseMod.HoldSeconds > 0 {
+		set["exercise_list.$[e].hold_seconds"] = exerciseMod.HoldSeconds
+	} else {
+		unset["exercise_list.$[e].hold_seconds"] = ""
+	}
+	if len(unset) > 0 { // MongoDB rechaza un $unset vacio
+		update["$unset"] = unset
+	}
+	set["edition_date"] = time.Now()
 	opts := options.Update().SetArrayFilters(options.ArrayFilters{ //Esa línea crea las opciones que le dicen a MongoDB qué elemento del array debe modificar, en lugar de tocar todos.
-		Filters: []interface{}{bson.M{"e.excercise_id": idExercise}},
+		Filters: []interface{}{bson.M{"e.entry_id": idEntry}},
 	})
 
 	res, err := collection.UpdateOne( //y aca buscamos la rutina con su id y seteamos la linea de opciones anteriormente obtenidas
 		context.TODO(),
 		bson.M{"_id": idRutine},
-		bson.M{"$set": set},
+		update,
 		opts,
 	)
 	if err != nil {
@@ -176,15 +288,18 @@ func (repository RoutineRepository) UpdateExerciseInRoutine(idRutine primitive.O
 	return res, nil
 }
 
-func (repository RoutineRepository) DeleteExerciseToRutine(rutineID primitive.ObjectID, exerciseID primitive.ObjectID) (*mongo.UpdateResult, error) {
+func (repository RoutineRepository) DeleteExerciseToRutine(rutineID primitive.ObjectID, entryID primitive.ObjectID) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
 
 	update := bson.M{
 		"$pull": bson.M{
 			"exercise_list": bson.M{
-				"excercise_id": exerciseID,
+				"entry_id": entryID,
 			},
 		},
+		"$set": bson.M{
+			"edition_date": time.Now(),
+		},
 	}
 	result, err := collection.UpdateOne(
 		context.TODO(),
@@ -206,9 +321,11 @@ func (repository RoutineRepository) DeleteExerciseToRutine(rutineID primitive.Ob
 	return result, nil
 }
 
-func (r RoutineRepository) ExistByRutineName(rutineName string) (bool, error) { //verificamos si existe algun usuario con el mismo nombre de ussuario y lo llamamos de UserService
+// ExistByRutineName indica si el usuario ya tiene una rutina con ese nombre; los nombres son unicos por creador, no
+// entre usuarios. Cuenta tambien las rutinas de la papelera, asi restaurar una nunca duplica un nombre
+func (r RoutineRepository) ExistByRutineName(creatorID primitive.ObjectID, rutineName string) (bool, error) {
 	collection := r.db.GetClient().Database("AppFitness").Collection("routines")
-	filter := bson.M{"name": rutineName}
+	filter := bson.M{"creator_user_id": creatorID, "name": rutineName}
 
 	count, err := collection.CountDocuments(context.TODO(), filter)
 
@@ -218,3 +335,265 @@ func (r RoutineRepository) ExistByRutineName(rutineName string) (bool, error) {
 
 	return count > 0, err
 }
+
+// ReplaceRoutineContent reescribe nombre, lista de ejercicios y grupos (aunque queden vacios); se usa para
+// rollbacks, reordenamientos y agrupaciones. El numero de version lo actualiza quien registra el cambio
+func (repository RoutineRepository) ReplaceRoutineContent(routine models.Routine) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": routine.ID}, routineContentUpdate(routine))
+	if err != nil {
+		return result, fmt.Errorf("error al reemplazar la rutina en RoutineRepository.ReplaceRoutineContent(): %v", err)
+	}
+	return result, nil
+}
+
+// DetachExcercise reescribe la lista y los grupos de una rutina (activa o en la papelera) que todavia usa el
+// ejercicio y no se edito desde editedAt; MatchedCount es 0 si ya se actualizo o si alguien la edito mientras tanto
+func (repository RoutineRepository) DetachExcercise(routine models.Routine, excerciseID primitive.ObjectID, editedAt time.Time) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	filter := bson.M{"_id": routine.ID, "exercise_list.excercise_id": excerciseID, "edition_date": editedAt}
+	result, err := collection.UpdateOne(context.TODO(), filter, routineContentUpdate(routine))
+	if err != nil {
+		return result, fmt.Errorf("error al quitar el ejercicio de la rutina en RoutineRepository.DetachExcercise(): %v", err)
+	}
+	return result, nil
+}
+
+// routineContentUpdate arma el $set del nombre, la lista y los grupos de la rutina
+func routineContentUpdate(routine models.Routine) bson.M {
+	exercises := routine.ExcerciseList
+	if exercises == nil {
+		exercises = []models.ExcerciseInRoutine{}
+	}
+	groups := routine.Groups
+	if groups == nil {
+		groups = []models.ExcerciseGroup{}
+	}
+	return bson.M{"$set": bson.M{
+		"name":          routine.Name,
+		"exercise_list": exercises,
+		"groups":        groups,
+		"edition_date":  routine.EditionDate,
+	}}
+}
+
+// SetSuggestions reemplaza las sugerencias de progresion guardadas; no cuenta como edicion de la rutina
+func (repository RoutineRepository) SetSuggestions(id primitive.ObjectID, suggestions []models.ProgressionSuggestion) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	update := bson.M{"$set": bson.M{"suggestions": suggestions}}
+	if len(suggestions) == 0 {
+		update = bson.M{"$unset": bson.M{"suggestions": ""}}
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return result, fmt.Errorf("error al guardar las sugerencias en RoutineRepository.SetSuggestions(): %v", err)
+	}
+	return result, nil
+}
+
+// SetEntryIDs guarda los entry_id asignados en las posiciones indicadas. Solo escribe si la lista todavia tiene el
+// mismo largo y esas posiciones siguen sin entry_id y con el mismo ejercicio; si otra edicion la cambio en el
+// medio no toca nada (MatchedCount == 0) en lugar de pisarla
+func (repository RoutineRepository) SetEntryIDs(id primitive.ObjectID, list []models.ExcerciseInRoutine, positions []int) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	filter := bson.M{"_id": id, "exercise_list": bson.M{"$size": len(list)}}
+	set := bson.M{}
+	for _, i := range positions {
+		prefix := fmt.Sprintf("exercise_list.%d.", i)
+		filter[prefix+"excercise_id"] = list[i].ExcerciseID
+		filter[prefix+"entry_id"] = bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+		set[prefix+"entry_id"] = list[i].EntryID
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": set})
+	if err != nil {
+		return result, fmt.Errorf("error al asignar los entry_id en RoutineRepository.SetEntryIDs(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository RoutineRepository) SetRoutineVersion(id primitive.ObjectID, version int) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"version": version}})
+	if err != nil {
+		return result, fmt.Errorf("error al actualizar la version en RoutineRepository.SetRoutineVersion(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository RoutineRepository) GetRoutinesByCreator(userID string) ([]*models.Routine, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	creatorObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(context.TODO(), active(bson.M{"creator_user_id": creatorObjectID}))
+	if err != nil {
+		return nil, fmt.Errorf("error en Find() RoutineRepository.GetRoutinesByCreator(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var routines []*models.Routine
+	for cursor.Next(context.Background()) {
+		var routine *models.Routine
+		if err := cursor.Decode(&routine); err != nil {
+			return nil, fmt.Errorf("error al decodificar la rutina en RoutineRepository.GetRoutinesByCreator(): %v", err)
+		}
+		routines = append(routines, routine)
+	}
+	return routines, nil
+}
+
+// GetRoutinesByExcercise devuelve las rutinas (de cualquier usuario, incluidas las de la papelera) que tienen el
+// ejercicio en su lista; las de la papelera cuentan porque restaurarlas dejaria una entrada sin ejercicio
+func (repository RoutineRepository) GetRoutinesByExcercise(excerciseID string) ([]*models.Routine, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	excerciseObjectID, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"exercise_list.excercise_id": excerciseObjectID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error en Find() RoutineRepository.GetRoutinesByExcercise(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var routines []*models.Routine
+	if err := cursor.All(context.TODO(), &routines); err != nil {
+		return nil, fmt.Errorf("error al decodificar las rutinas en RoutineRepository.GetRoutinesByExcercise(): %v", err)
+	}
+	return routines, nil
+}
+
+// SetVisibility cambia la visibilidad; una rutina que deja de ser publica pierde el destacado
+func (repository RoutineRepository) SetVisibility(id primitive.ObjectID, visibility models.RoutineVisibility) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	set := bson.M{"visibility": visibility, "edition_date": time.Now()}
+	if visibility != models.RoutinePublic {
+		set["featured"] = false
+	}
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return result, fmt.Errorf("error al actualizar la visibilidad en RoutineRepository.SetVisibility(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository RoutineRepository) SetFeatured(id primitive.ObjectID, featured bool) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"featured": featured}})
+	if err != nil {
+		return result, fmt.Errorf("error al actualizar el destacado en RoutineRepository.SetFeatured(): %v", err)
+	}
+	return result, nil
+}
+
+// SearchPublicRoutines busca en la biblioteca publica; grupo muscular, categoria y dificultad se filtran
+// sobre los ejercicios referenciados (alcanza con que uno coincida). Las destacadas van primero.
+// El texto busca por el comienzo del nombre: los nombres se guardan en minusculas, asi la expresion anclada y sin
+// opciones recorre solo un rango del indice public_routine_name en lugar de toda la coleccion
+func (repository RoutineRepository) SearchPublicRoutines(filter models.PublicRoutineFilter) ([]models.PublicRoutine, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+
+	match := active(bson.M{"visibility": models.RoutinePublic})
+	if filter.Query != "" {
+		match["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ToLower(strings.TrimSpace(filter.Query)))}
+	}
+	if filter.Featured {
+		match["featured"] = true
+	}
+
+	excerciseMatch := bson.M{}
+	if filter.MuscleGroup != "" {
+		excerciseMatch["main_muscle_group"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.MuscleGroup) + "$", "$options": "i"}
+	}
+	if filter.Category != "" {
+		excerciseMatch["category"] = filter.Category
+	}
+	if filter.Difficulty != "" {
+		excerciseMatch["difficult_level"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.Difficulty) + "$", "$options": "i"}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// las rutinas de un usuario en la papelera no se muestran hasta que se lo restaure
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   "creator_user_id",
+			"foreignField": "_id",
+			"as":           "creator",
+		}}},
+		{{Key: "$match", Value: bson.M{"creator": bson.M{"$elemMatch": bson.M{"elimination_date": notDeleted()}}}}},
+		{{Key: "$project", Value: bson.M{"creator": 0}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "excercises",
+			"localField":   "exercise_list.excercise_id",
+			"foreignField": "_id",
+			"as":           "excercise_docs",
+		}}},
+		// los ejercicios de la papelera no cuentan para los filtros ni se muestran
+		{{Key: "$addFields", Value: bson.M{"excercise_docs": bson.M{"$filter": bson.M{
+			"input": "$excercise_docs",
+			"as":    "e",
+			"cond":  bson.M{"$not": bson.A{bson.M{"$gt": bson.A{"$$e.elimination_date", time.Time{}}}}},
+		}}}}},
+	}
+	if len(excerciseMatch) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"excercise_docs": bson.M{"$elemMatch": excerciseMatch}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "featured", Value: -1}, {Key: "edition_date", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: filter.Limit}},
+	)
+
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error en Aggregate() RoutineRepository.SearchPublicRoutines(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var routines []models.PublicRoutine
+	if err := cursor.All(context.TODO(), &routines); err != nil {
+		return nil, fmt.Errorf("error al decodificar las rutinas en RoutineRepository.SearchPublicRoutines(): %v", err)
+	}
+	return routines, nil
+}
+
+// GetRoutinesByCreatorPage devuelve una pagina de las rutinas creadas por el usuario
+func (repository RoutineRepository) GetRoutinesByCreatorPage(userID string, page models.PageRequest) ([]*models.Routine, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	creatorObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	var routines []*models.Routine
+	info, err := findPage(collection, active(bson.M{"creator_user_id": creatorObjectID}), page, func(document bson.Raw) error {
+		var routine models.Routine
+		if err := bson.Unmarshal(document, &routine); err != nil {
+			return err
+		}
+		routines = append(routines, &routine)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en RoutineRepository.GetRoutinesByCreatorPage(): %v", err)
+	}
+	return routines, info, nil
+}
+
+// CreateIndexes crea el indice de la busqueda por nombre de la biblioteca publica
+func (repository RoutineRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routines")
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "visibility", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("public_routine_name"),
+	}
+	if _, err := collection.Indexes().CreateOne(context.TODO(), index); err != nil {
+		return fmt.Errorf("error al crear indices en RoutineRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
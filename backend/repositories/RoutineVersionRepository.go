@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoutineVersionRepositoryInterface interface {
+	PostVersion(version models.RoutineVersion) (*mongo.InsertOneResult, error)
+	GetVersionsByRoutineID(routineID string) ([]models.RoutineVersion, error)
+	GetVersion(routineID string, version int) (models.RoutineVersion, error)
+	GetLatestVersion(routineID string) (models.RoutineVersion, error)
+	DeleteVersionsByRoutineID(routineID string) (*mongo.DeleteResult, error)
+}
+
+type RoutineVersionRepository struct {
+	db DB
+}
+
+func NewRoutineVersionRepository(db DB) *RoutineVersionRepository {
+	return &RoutineVersionRepository{
+		db: db,
+	}
+}
+
+// CreateIndexes evita que dos cambios simultaneos generen el mismo numero de version
+func (repository RoutineVersionRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "routine_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetName("unique_routine_version").SetUnique(true),
+	}
+	if _, err := collection.Indexes().CreateOne(context.TODO(), index); err != nil {
+		return fmt.Errorf("error al crear indices en RoutineVersionRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
+
+func (repository RoutineVersionRepository) PostVersion(version models.RoutineVersion) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	result, err := collection.InsertOne(context.TODO(), version)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("la version %d de la rutina ya existe", version.Version)
+		}
+		return result, fmt.Errorf("error al insertar la version en RoutineVersionRepository.PostVersion(): %v", err)
+	}
+	return result, nil
+}
+
+// GetVersionsByRoutineID devuelve el historial de la rutina de la version mas vieja a la mas nueva
+func (repository RoutineVersionRepository) GetVersionsByRoutineID(routineID string) ([]models.RoutineVersion, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	routineObjectID, err := utils.GetObjectIDFromStringID(routineID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de rutina con formato inválido")
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"routine_id": routineObjectID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en RoutineVersionRepository.GetVersionsByRoutineID(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var versions []models.RoutineVersion
+	for cursor.Next(context.Background()) {
+		var version models.RoutineVersion
+		if err := cursor.Decode(&version); err != nil {
+			return nil, fmt.Errorf("error al decodificar la version en RoutineVersionRepository.GetVersionsByRoutineID(): %v", err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func (repository RoutineVersionRepository) GetVersion(routineID string, version int) (models.RoutineVersion, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	routineObjectID, err := utils.GetObjectIDFromStringID(routineID)
+	if err != nil {
+		return models.RoutineVersion{}, fmt.Errorf("ID de rutina con formato inválido")
+	}
+
+	var routineVersion models.RoutineVersion
+	err = collection.FindOne(context.TODO(), bson.M{"routine_id": routineObjectID, "version": version}).Decode(&routineVersion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.RoutineVersion{}, nil // version inexistente: ID.IsZero()
+		}
+		return models.RoutineVersion{}, fmt.Errorf("error al obtener la version en RoutineVersionRepository.GetVersion(): %v", err)
+	}
+	return routineVersion, nil
+}
+
+// GetLatestVersion devuelve la ultima version guardada de la rutina; si no tiene historial devuelve una vacia
+func (repository RoutineVersionRepository) GetLatestVersion(routineID string) (models.RoutineVersion, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	routineObjectID, err := utils.GetObjectIDFromStringID(routineID)
+	if err != nil {
+		return models.RoutineVersion{}, fmt.Errorf("ID de rutina con formato inválido")
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	var routineVersion models.RoutineVersion
+	err = collection.FindOne(context.TODO(), bson.M{"routine_id": routineObjectID}, opts).Decode(&routineVersion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.RoutineVersion{}, nil
+		}
+		return models.RoutineVersion{}, fmt.Errorf("error al obtener la ultima version en RoutineVersionRepository.GetLatestVersion(): %v", err)
+	}
+	return routineVersion, nil
+}
+
+func (repository RoutineVersionRepository) DeleteVersionsByRoutineID(routineID string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("routine_versions")
+	routineObjectID, err := utils.GetObjectIDFromStringID(routineID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de rutina con formato inválido")
+	}
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"routine_id": routineObjectID})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar las versiones en RoutineVersionRepository.DeleteVersionsByRoutineID(): %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ScheduleRepositoryInterface interface {
+	PostSchedule(schedule models.ScheduledWorkout) (*mongo.InsertOneResult, error)
+	GetSchedulesByUserID(userID string) ([]models.ScheduledWorkout, error)
+	GetScheduleByID(id string) (models.ScheduledWorkout, error)
+	DeleteSchedule(id string) (*mongo.DeleteResult, error)
+	DeleteSchedulesByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error)
+}
+
+type ScheduleRepository struct {
+	db DB
+}
+
+func NewScheduleRepository(db DB) *ScheduleRepository {
+	return &ScheduleRepository{
+		db: db,
+	}
+}
+
+func (repository ScheduleRepository) PostSchedule(schedule models.ScheduledWorkout) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("schedules")
+	result, err := collection.InsertOne(context.TODO(), schedule)
+	if err != nil {
+		return result, fmt.Errorf("error al insertar la planificacion en ScheduleRepository.PostSchedule(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository ScheduleRepository) GetSchedulesByUserID(userID string) ([]models.ScheduledWorkout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("schedules")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"user_id": userObjectID})
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en ScheduleRepository.GetSchedulesByUserID(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var schedules []models.ScheduledWorkout
+	for cursor.Next(context.Background()) {
+		var schedule models.ScheduledWorkout
+		if err := cursor.Decode(&schedule); err != nil {
+			return nil, fmt.Errorf("error al decodificar la planificacion en ScheduleRepository.GetSchedulesByUserID(): %v", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (repository ScheduleRepository) GetScheduleByID(id string) (models.ScheduledWorkout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("schedules")
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return models.ScheduledWorkout{}, err
+	}
+
+	var schedule models.ScheduledWorkout
+	err = collection.FindOne(context.TODO(), bson.M{"_id": objectID}).Decode(&schedule)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.ScheduledWorkout{}, nil // ID.IsZero() = no existe
+		}
+		return models.ScheduledWorkout{}, fmt.Errorf("error al obtener la planificacion en ScheduleRepository.GetScheduleByID(): %v", err)
+	}
+	return schedule, nil
+}
+
+func (repository ScheduleRepository) DeleteSchedule(id string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("schedules")
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": objectID})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar la planificacion en ScheduleRepository.DeleteSchedule(): %v", err)
+	}
+	return result, nil
+}
+
+// DeleteSchedulesByUsers borra las planificaciones de los usuarios purgados
+func (repository ScheduleRepository) DeleteSchedulesByUsers(userIDs []primitive.ObjectID) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("schedules")
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar las planificaciones en ScheduleRepository.DeleteSchedulesByUsers(): %v", err)
+	}
+	return result, nil
+}
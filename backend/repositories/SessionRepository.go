@@ -14,9 +14,11 @@ import (
 type SessionRepositoryInterface interface {
 	PostSession(models.Session) (*mongo.InsertOneResult, error)
 	GetSessions() ([]models.Session, error)
+	GetSessionsPage(page models.PageRequest) ([]models.Session, models.PageInfo, error)
 	GetSessionByID(id string) (models.Session, error)
 	PutSession(session models.Session) (*mongo.UpdateResult, error)
 	DeleteSession(id string) (*mongo.DeleteResult, error)
+	DeleteSessionsByUserID(userID string) (*mongo.DeleteResult, error)
 	IsUserActive(userID string) (bool, error)
 }
 
@@ -106,6 +108,20 @@ func (repository SessionRepository) DeleteSession(id string) (*mongo.DeleteResul
 	return result, nil
 }
 
+// DeleteSessionsByUserID cierra todas las sesiones del usuario
+func (repository SessionRepository) DeleteSessionsByUserID(userID string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("sessions")
+	objectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := collection.DeleteMany(context.TODO(), bson.M{"user_id": objectID})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar las sesiones en SessionRepository.DeleteSessionsByUserID(): %v", err)
+	}
+	return result, nil
+}
+
 func (repository SessionRepository) IsUserActive(userID string) (bool, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("sessions")
 	objectID, err := utils.GetObjectIDFromStringID(userID)
@@ -126,3 +142,21 @@ func (repository SessionRepository) IsUserActive(userID string) (bool, error) {
 
 	return count > 0, nil
 }
+
+// GetSessionsPage devuelve una pagina de sesiones
+func (repository SessionRepository) GetSessionsPage(page models.PageRequest) ([]models.Session, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("sessions")
+	var sessions []models.Session
+	info, err := findPage(collection, bson.M{}, page, func(document bson.Raw) error {
+		var session models.Session
+		if err := bson.Unmarshal(document, &session); err != nil {
+			return err
+		}
+		sessions = append(sessions, session)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en SessionRepository.GetSessionsPage(): %v", err)
+	}
+	return sessions, info, nil
+}
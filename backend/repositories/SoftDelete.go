@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Los ejercicios, rutinas, usuarios y workouts no se borran al eliminarlos: se marca elimination_date y quedan en la
+// papelera hasta que el purgado periodico los borra de verdad. Los documentos activos guardan elimination_date en
+// cero (o no lo tienen, como los workouts), por eso se comparan contra la fecha cero y no con $exists
+
+// notDeleted es la condicion sobre elimination_date de los documentos que no estan en la papelera
+func notDeleted() bson.M {
+	return bson.M{"$not": bson.M{"$gt": time.Time{}}}
+}
+
+// deleted es la condicion sobre elimination_date de los documentos que estan en la papelera
+func deleted() bson.M {
+	return bson.M{"$gt": time.Time{}}
+}
+
+// active agrega al filtro la condicion de no estar eliminado
+func active(filter bson.M) bson.M {
+	filter["elimination_date"] = notDeleted()
+	return filter
+}
+
+// softDelete manda el documento a la papelera; MatchedCount es 0 si no existe o ya estaba eliminado
+func softDelete(collection *mongo.Collection, id string) (*mongo.UpdateResult, error) {
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID de formato inválido")
+	}
+	filter := bson.M{"_id": objectID, "elimination_date": notDeleted()}
+	return collection.UpdateOne(context.TODO(), filter, bson.M{"$set": bson.M{"elimination_date": time.Now()}})
+}
+
+// restoreDeleted saca el documento de la papelera; MatchedCount es 0 si no existe o no estaba eliminado
+func restoreDeleted(collection *mongo.Collection, id string) (*mongo.UpdateResult, error) {
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID de formato inválido")
+	}
+	filter := bson.M{"_id": objectID, "elimination_date": deleted()}
+	return collection.UpdateOne(context.TODO(), filter, bson.M{"$unset": bson.M{"elimination_date": ""}})
+}
+
+// findDeletedByID busca un documento de la papelera; devuelve false (sin error) si no esta
+func findDeletedByID(collection *mongo.Collection, id string, out interface{}) (bool, error) {
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return false, fmt.Errorf("ID de formato inválido")
+	}
+	err = collection.FindOne(context.TODO(), bson.M{"_id": objectID, "elimination_date": deleted()}).Decode(out)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// findDeletedPage trae una pagina de la papelera de la coleccion
+func findDeletedPage(collection *mongo.Collection, page models.PageRequest, decode func(bson.Raw) error) (models.PageInfo, error) {
+	return findPage(collection, bson.M{"elimination_date": deleted()}, page, decode)
+}
+
+// purgeDeleted borra definitivamente los documentos eliminados antes de before y devuelve sus IDs, para que el
+// servicio borre lo que dependa de ellos
+func purgeDeleted(collection *mongo.Collection, before time.Time) ([]primitive.ObjectID, error) {
+	return deleteMatching(collection, bson.M{"elimination_date": bson.M{"$gt": time.Time{}, "$lt": before}})
+}
+
+// deleteOwnedBy borra definitivamente los documentos (activos o en la papelera) cuyo field es alguno de owners y
+// devuelve sus IDs; se usa al purgar usuarios
+func deleteOwnedBy(collection *mongo.Collection, field string, owners []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(owners) == 0 {
+		return nil, nil
+	}
+	return deleteMatching(collection, bson.M{field: bson.M{"$in": owners}})
+}
+
+func deleteMatching(collection *mongo.Collection, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := collection.Find(context.TODO(), filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var ids []primitive.ObjectID
+	for cursor.Next(context.TODO()) {
+		ids = append(ids, documentID(cursor.Current))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if _, err := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"AppFitness/models"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TaxonomyRepositoryInterface interface {
+	PostTerm(term models.TaxonomyTerm) (*mongo.InsertOneResult, error)
+	GetTerms(kind models.TaxonomyKind) ([]models.TaxonomyTerm, error)
+	GetTerm(kind models.TaxonomyKind, key string) (models.TaxonomyTerm, error)
+	PutTerm(term models.TaxonomyTerm) (*mongo.UpdateResult, error)
+	DeleteTerm(kind models.TaxonomyKind, key string) (*mongo.DeleteResult, error)
+	CountTerms(kind models.TaxonomyKind) (int64, error)
+	CreateIndexes() error
+}
+
+type TaxonomyRepository struct {
+	db DB
+}
+
+func NewTaxonomyRepository(db DB) *TaxonomyRepository {
+	return &TaxonomyRepository{
+		db: db,
+	}
+}
+
+func (repository TaxonomyRepository) PostTerm(term models.TaxonomyTerm) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	result, err := collection.InsertOne(context.TODO(), term)
+	if err != nil {
+		return result, fmt.Errorf("error al insertar el termino en TaxonomyRepository.PostTerm(): %v", err)
+	}
+	return result, nil
+}
+
+// GetTerms devuelve los terminos de un vocabulario ordenados por nombre; con kind vacio devuelve todos
+func (repository TaxonomyRepository) GetTerms(kind models.TaxonomyKind) ([]models.TaxonomyTerm, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	filter := bson.M{}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "kind", Value: 1}, {Key: "name", Value: 1}}).
+		SetCollation(&options.Collation{Locale: "es", Strength: 1})
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en TaxonomyRepository.GetTerms(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var terms []models.TaxonomyTerm
+	if err := cursor.All(context.TODO(), &terms); err != nil {
+		return nil, fmt.Errorf("error al decodificar los terminos en TaxonomyRepository.GetTerms(): %v", err)
+	}
+	return terms, nil
+}
+
+// GetTerm busca un termino por su clave; devuelve un termino vacio (ID.IsZero()) si no existe
+func (repository TaxonomyRepository) GetTerm(kind models.TaxonomyKind, key string) (models.TaxonomyTerm, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	var term models.TaxonomyTerm
+	err := collection.FindOne(context.TODO(), bson.M{"kind": kind, "key": key}).Decode(&term)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.TaxonomyTerm{}, nil
+		}
+		return models.TaxonomyTerm{}, fmt.Errorf("error al obtener el termino en TaxonomyRepository.GetTerm(): %v", err)
+	}
+	return term, nil
+}
+
+// PutTerm actualiza el nombre y los alias; la clave y el tipo no cambian
+func (repository TaxonomyRepository) PutTerm(term models.TaxonomyTerm) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	update := bson.M{"$set": bson.M{
+		"name":         term.Name,
+		"aliases":      term.Aliases,
+		"edition_date": term.EditionDate,
+	}}
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"kind": term.Kind, "key": term.Key}, update)
+	if err != nil {
+		return result, fmt.Errorf("error al actualizar el termino en TaxonomyRepository.PutTerm(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository TaxonomyRepository) DeleteTerm(kind models.TaxonomyKind, key string) (*mongo.DeleteResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	result, err := collection.DeleteOne(context.TODO(), bson.M{"kind": kind, "key": key})
+	if err != nil {
+		return result, fmt.Errorf("error al eliminar el termino en TaxonomyRepository.DeleteTerm(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository TaxonomyRepository) CountTerms(kind models.TaxonomyKind) (int64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"kind": kind})
+	if err != nil {
+		return 0, fmt.Errorf("error al contar terminos en TaxonomyRepository.CountTerms(): %v", err)
+	}
+	return count, nil
+}
+
+// CreateIndexes crea el indice unico de clave por vocabulario
+func (repository TaxonomyRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("taxonomy")
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetName("unique_key_per_kind").SetUnique(true),
+	}
+	if _, err := collection.Indexes().CreateOne(context.TODO(), index); err != nil {
+		return fmt.Errorf("error al crear indices en TaxonomyRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,15 +17,21 @@ import (
 
 type UserRepositoryInterface interface { //contrato que define metodos para manejar usuarios
 	GetUsers() ([]models.User, error)
+	GetUsersPage(page models.PageRequest) ([]models.User, models.PageInfo, error)
 	GetUsersByID(id string) (models.User, error)
 	GetUserByEmail(email string) (models.User, error)
 	PostUser(user models.User) (*mongo.InsertOneResult, error)
-	PutUser(user models.User) (*mongo.UpdateResult, error)
+	PutUser(user models.User, setWeeklyGoal bool) (*mongo.UpdateResult, error)
 	UpdateNewPassword(dto dto.PasswordChange, id string) (modified int64, err error)
-	DeleteUser(id string) (*mongo.DeleteResult, error)
+	DeleteUser(id string) (*mongo.UpdateResult, error)
+	RestoreUser(id string) (*mongo.UpdateResult, error)
+	GetDeletedUsersPage(page models.PageRequest) ([]models.User, models.PageInfo, error)
+	PurgeDeletedUsers(before time.Time) ([]primitive.ObjectID, error)
 	ExistByEmail(email string) (bool, error)
 	ExistByUserName(userName string) (bool, error)
 	ExistByUserNameExceptID(id string, userName string) (bool, error)
+	SetCalendarToken(id string, token string) (*mongo.UpdateResult, error)
+	GetUserByCalendarToken(token string) (models.User, error)
 }
 
 type UserRepository struct { //campo para la conexion a la base de datos
@@ -48,7 +55,7 @@ func (repository UserRepository) PostUser(user models.User) (*mongo.InsertOneRes
 
 func (repository UserRepository) GetUsers() ([]models.User, error) { //REVISAR Y AJUSTAR LA DEVOLUCION DE ERRORES
 	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
-	filter := bson.M{} //filtro vacio para traer todos los documentos
+	filter := active(bson.M{}) //todos los documentos que no estan en la papelera
 
 	cursor, err := collection.Find(context.TODO(), filter)
 	if err != nil {
@@ -79,7 +86,7 @@ func (repository UserRepository) GetUsersByID(id string) (models.User, error) {
 		return models.User{}, fmt.Errorf("ID de formato inválido")
 	}
 
-	filter := bson.M{"_id": objectID}
+	filter := active(bson.M{"_id": objectID})
 
 	var user models.User
 	err = collection.FindOne(context.TODO(), filter).Decode(&user)
@@ -96,7 +103,7 @@ func (repository UserRepository) GetUsersByID(id string) (models.User, error) {
 
 func (repository UserRepository) GetUserByEmail(email string) (models.User, error) { //para recuperar usuario por email en el login y recuperar ids en service
 	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
-	filter := bson.M{"email": email}
+	filter := active(bson.M{"email": email})
 
 	result := collection.FindOne(context.TODO(), filter)
 
@@ -108,11 +115,13 @@ func (repository UserRepository) GetUserByEmail(email string) (models.User, erro
 	return user, nil
 }
 
-func (repository UserRepository) PutUser(user models.User) (*mongo.UpdateResult, error) {
+// PutUser actualiza los datos del perfil; weekly_goal solo se escribe si setWeeklyGoal es true, para que un PUT
+// que no lo manda no pise el objetivo guardado
+func (repository UserRepository) PutUser(user models.User, setWeeklyGoal bool) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
 	filter := bson.M{"_id": user.ID}
 
-	entity := bson.M{"$set": bson.M{
+	set := bson.M{
 		"user_name":  user.UserName,
 		"email":      user.Email,
 		"role":       user.Role,
@@ -120,30 +129,65 @@ func (repository UserRepository) PutUser(user models.User) (*mongo.UpdateResult,
 		"height":     user.Height,
 		"experience": user.Experience,
 		"objetive":   user.Objetive,
-	}}
-	result, err := collection.UpdateOne(context.TODO(), filter, entity)
+	}
+	if setWeeklyGoal {
+		set["weekly_goal"] = user.WeeklyGoal
+	}
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": set})
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (repository UserRepository) DeleteUser(id string) (*mongo.DeleteResult, error) {
+// DeleteUser manda el usuario a la papelera; mientras este ahi no puede iniciar sesion
+func (repository UserRepository) DeleteUser(id string) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
-	objectID, err := utils.GetObjectIDFromStringID(id)
+	result, err := softDelete(collection, id)
 	if err != nil {
-		return nil, err
+		return result, fmt.Errorf("error al eliminar el usuario en UserRepository.DeleteUser(): %v", err)
 	}
-	filter := bson.M{"_id": objectID}
+	return result, nil
+}
 
-	result, err := collection.DeleteOne(context.TODO(), filter)
+func (repository UserRepository) RestoreUser(id string) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+	result, err := restoreDeleted(collection, id)
 	if err != nil {
-		return result, fmt.Errorf("error al eliminar el usuario en UserRepository.DeleteUser(): %v", err)
+		return result, fmt.Errorf("error al restaurar el usuario en UserRepository.RestoreUser(): %v", err)
 	}
+	return result, nil
+}
 
-	return result, err
+// GetDeletedUsersPage devuelve una pagina de los usuarios en la papelera
+func (repository UserRepository) GetDeletedUsersPage(page models.PageRequest) ([]models.User, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+	var users []models.User
+	info, err := findDeletedPage(collection, page, func(document bson.Raw) error {
+		var user models.User
+		if err := bson.Unmarshal(document, &user); err != nil {
+			return err
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en UserRepository.GetDeletedUsersPage(): %v", err)
+	}
+	return users, info, nil
+}
+
+func (repository UserRepository) PurgeDeletedUsers(before time.Time) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+	purged, err := purgeDeleted(collection, before)
+	if err != nil {
+		return nil, fmt.Errorf("error al purgar usuarios en UserRepository.PurgeDeletedUsers(): %v", err)
+	}
+	return purged, nil
 }
 
+// ExistByEmail y ExistByUserName cuentan tambien los usuarios de la papelera, asi restaurar uno nunca duplica
+// un email o un nombre de usuario
 func (r UserRepository) ExistByEmail(email string) (bool, error) { //verificamos si existe algun usuario con el mismo email y lo llamamos de UserService
 	collection := r.db.GetClient().Database("AppFitness").Collection("users")
 	filter := bson.M{"email": email}
@@ -213,3 +257,54 @@ func (r *UserRepository) UpdateNewPassword(dto dto.PasswordChange, id string) (m
 	//devolvemos la cantidad  de documentos q fueron modificados
 	return res.ModifiedCount, nil
 }
+
+// SetCalendarToken guarda el token del feed .ics del usuario; un token vacio revoca el feed
+func (repository UserRepository) SetCalendarToken(id string, token string) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+	objectID, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return nil, fmt.Errorf("ID de formato inválido")
+	}
+
+	update := bson.M{"$set": bson.M{"calendar_token": token}}
+	if token == "" {
+		update = bson.M{"$unset": bson.M{"calendar_token": ""}}
+	}
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": objectID}, update)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el token de calendario en UserRepository.SetCalendarToken(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository UserRepository) GetUserByCalendarToken(token string) (models.User, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+
+	var user models.User
+	err := collection.FindOne(context.TODO(), active(bson.M{"calendar_token": token})).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.User{}, nil // token inexistente o revocado: ID.IsZero()
+		}
+		return models.User{}, fmt.Errorf("error al obtener usuario por token de calendario: %w", err)
+	}
+	return user, nil
+}
+
+// GetUsersPage devuelve una pagina de usuarios
+func (repository UserRepository) GetUsersPage(page models.PageRequest) ([]models.User, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("users")
+	var users []models.User
+	info, err := findPage(collection, active(bson.M{}), page, func(document bson.Raw) error {
+		var user models.User
+		if err := bson.Unmarshal(document, &user); err != nil {
+			return err
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en UserRepository.GetUsersPage(): %v", err)
+	}
+	return users, info, nil
+}
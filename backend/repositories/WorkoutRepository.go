@@ -6,18 +6,40 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type WorkoutRepositoryInterface interface {
 	PostWorkout(workout models.Workout) (*mongo.InsertOneResult, error)
+	PostWorkouts(workouts []models.Workout) (*mongo.InsertManyResult, error)
 	GetWorkouts() ([]models.Workout, error)
 	GetWorkoutByID(id string) (models.Workout, error)
 	GetWorkoutsByUserID(userID string) ([]models.Workout, error)
+	GetWorkoutsByUserIDPage(userID string, page models.PageRequest) ([]models.Workout, models.PageInfo, error)
+	GetWorkoutsByUserAndExcercise(userID string, excerciseID string) ([]models.Workout, error)
+	CountWorkoutsByExcercise(excerciseID string) (int64, error)
+	GetWorkoutsByUserInRange(userID string, from time.Time, to time.Time) ([]models.Workout, error)
+	GetRecentWorkoutsByRoutine(userID string, routineID primitive.ObjectID, limit int64) ([]models.Workout, error)
+	GetWorkoutStats(userID string, from time.Time, to time.Time, dateFormat string, timeZone string) (models.WorkoutStatsAggregate, error)
 	PutWorkout(workout models.Workout) (*mongo.UpdateResult, error)
-	DeleteWorkout(id string) (*mongo.DeleteResult, error)
+	DeleteWorkout(id string) (*mongo.UpdateResult, error)
+	RestoreWorkout(id string) (*mongo.UpdateResult, error)
+	GetDeletedWorkoutByID(id string) (models.Workout, error)
+	GetDeletedWorkoutsPage(page models.PageRequest) ([]models.Workout, models.PageInfo, error)
+	PurgeDeletedWorkouts(before time.Time) ([]primitive.ObjectID, error)
+	DeleteWorkoutsByUsers(userIDs []primitive.ObjectID) ([]primitive.ObjectID, error)
+	PullExcercisesFromDeletedWorkouts(excerciseIDs []primitive.ObjectID) (*mongo.UpdateResult, error)
+	NormalizeSnapshotMuscleGroups(values []string, key string) (int64, error)
+	StartWorkout(workout models.Workout) (*mongo.InsertOneResult, error)
+	GetActiveWorkoutByUserID(userID string) (models.Workout, error)
+	AddSetToWorkout(workoutID primitive.ObjectID, exercise models.ExcerciseInWorkout, set models.WorkoutSet) (*mongo.UpdateResult, error)
+	UpdateWorkoutStatus(workout models.Workout) (*mongo.UpdateResult, error)
+	GetStaleActiveWorkouts(before time.Time) ([]models.Workout, error)
 }
 
 type WorkoutRepository struct {
@@ -39,9 +61,23 @@ func (repository WorkoutRepository) PostWorkout(workout models.Workout) (*mongo.
 	return result, nil
 }
 
+// PostWorkouts inserta varios workouts de una vez (importacion de historial)
+func (repository WorkoutRepository) PostWorkouts(workouts []models.Workout) (*mongo.InsertManyResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	documents := make([]interface{}, 0, len(workouts))
+	for _, workout := range workouts {
+		documents = append(documents, workout)
+	}
+	result, err := collection.InsertMany(context.TODO(), documents)
+	if err != nil {
+		return result, fmt.Errorf("error al insertar los workouts en WorkoutRepository.PostWorkouts(): %v", err)
+	}
+	return result, nil
+}
+
 func (repository WorkoutRepository) GetWorkouts() ([]models.Workout, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
-	filter := bson.M{}
+	filter := active(bson.M{})
 
 	cursor, err := collection.Find(context.TODO(), filter)
 	defer cursor.Close(context.TODO())
@@ -64,7 +100,7 @@ func (repository WorkoutRepository) GetWorkoutByID(id string) (models.Workout, e
 	if err != nil {
 		return models.Workout{}, err
 	}
-	filter := bson.M{"_id": objectID}
+	filter := active(bson.M{"_id": objectID})
 
 	result := collection.FindOne(context.TODO(), filter)
 
@@ -82,7 +118,16 @@ func (repository WorkoutRepository) GetWorkoutByID(id string) (models.Workout, e
 func (repository WorkoutRepository) PutWorkout(workout models.Workout) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
 	filter := bson.M{"_id": workout.ID}
-	entity := bson.M{"$set": workout}
+	// campos editables de un workout terminado; se listan para poder vaciar exercises y notes
+	entity := bson.M{"$set": bson.M{
+		"date_and_hours":   workout.Date,
+		"exercises":        workout.Exercises,
+		"notes":            workout.Notes,
+		"start_time":       workout.StartTime,
+		"end_time":         workout.EndTime,
+		"duration_seconds": workout.DurationSeconds,
+		"edition_date":     workout.EditionDate,
+	}}
 
 	result, err := collection.UpdateOne(context.TODO(), filter, entity)
 	if err != nil {
@@ -91,27 +136,114 @@ func (repository WorkoutRepository) PutWorkout(workout models.Workout) (*mongo.U
 	return result, nil
 }
 
-func (repository WorkoutRepository) DeleteWorkout(id string) (*mongo.DeleteResult, error) {
+// DeleteWorkout manda el workout a la papelera
+func (repository WorkoutRepository) DeleteWorkout(id string) (*mongo.UpdateResult, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
-	objectID, err := utils.GetObjectIDFromStringID(id)
+	result, err := softDelete(collection, id)
 	if err != nil {
-		return nil, err
+		return result, fmt.Errorf("error al eliminar el workout en WorkoutRepository.DeleteWorkout(): %v", err)
 	}
-	filter := bson.M{"_id": objectID}
-	result, err := collection.DeleteOne(context.TODO(), filter)
+	return result, nil
+}
+
+func (repository WorkoutRepository) RestoreWorkout(id string) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	result, err := restoreDeleted(collection, id)
 	if err != nil {
-		return result, fmt.Errorf("error al eliminar el workout en WorkoutRepository.DeleteWorkout(): %v", err)
+		return result, fmt.Errorf("error al restaurar el workout en WorkoutRepository.RestoreWorkout(): %v", err)
 	}
 	return result, nil
 }
 
+// GetDeletedWorkoutByID busca el workout en la papelera; devuelve un workout vacio (ID.IsZero()) si no esta
+func (repository WorkoutRepository) GetDeletedWorkoutByID(id string) (models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	var workout models.Workout
+	found, err := findDeletedByID(collection, id, &workout)
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("error al obtener el workout en WorkoutRepository.GetDeletedWorkoutByID(): %v", err)
+	}
+	if !found {
+		return models.Workout{}, nil
+	}
+	return workout, nil
+}
+
+// GetDeletedWorkoutsPage devuelve una pagina de los workouts en la papelera
+func (repository WorkoutRepository) GetDeletedWorkoutsPage(page models.PageRequest) ([]models.Workout, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	var workouts []models.Workout
+	info, err := findDeletedPage(collection, page, func(document bson.Raw) error {
+		var workout models.Workout
+		if err := bson.Unmarshal(document, &workout); err != nil {
+			return err
+		}
+		workouts = append(workouts, workout)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en WorkoutRepository.GetDeletedWorkoutsPage(): %v", err)
+	}
+	return workouts, info, nil
+}
+
+func (repository WorkoutRepository) PurgeDeletedWorkouts(before time.Time) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	purged, err := purgeDeleted(collection, before)
+	if err != nil {
+		return nil, fmt.Errorf("error al purgar workouts en WorkoutRepository.PurgeDeletedWorkouts(): %v", err)
+	}
+	return purged, nil
+}
+
+// DeleteWorkoutsByUsers borra definitivamente los workouts de los usuarios purgados y devuelve sus IDs
+func (repository WorkoutRepository) DeleteWorkoutsByUsers(userIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	deletedIDs, err := deleteOwnedBy(collection, "user_id", userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error al eliminar workouts en WorkoutRepository.DeleteWorkoutsByUsers(): %v", err)
+	}
+	return deletedIDs, nil
+}
+
+// PullExcercisesFromDeletedWorkouts quita de los workouts de la papelera las series de ejercicios purgados. Los
+// workouts activos no se tocan: conservan el nombre del ejercicio en su snapshot
+func (repository WorkoutRepository) PullExcercisesFromDeletedWorkouts(excerciseIDs []primitive.ObjectID) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	filter := bson.M{"elimination_date": deleted(), "exercises.excercise_id": bson.M{"$in": excerciseIDs}}
+	update := bson.M{"$pull": bson.M{"exercises": bson.M{"excercise_id": bson.M{"$in": excerciseIDs}}}}
+	result, err := collection.UpdateMany(context.TODO(), filter, update)
+	if err != nil {
+		return result, fmt.Errorf("error al quitar ejercicios en WorkoutRepository.PullExcercisesFromDeletedWorkouts(): %v", err)
+	}
+	return result, nil
+}
+
+// NormalizeSnapshotMuscleGroups reemplaza por key el grupo muscular de los ejercicios del snapshot de rutina que
+// se guardaron como texto libre con alguno de los values, sin distinguir mayusculas ni acentos. Es la unica
+// escritura sobre el snapshot: cambia como se guardo el grupo, no que grupo era. Incluye la papelera
+func (repository WorkoutRepository) NormalizeSnapshotMuscleGroups(values []string, key string) (int64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	filter := bson.M{"routine_snapshot.exercises.main_muscle_group": bson.M{"$in": values}}
+	update := bson.M{"$set": bson.M{"routine_snapshot.exercises.$[e].main_muscle_group": key}}
+	opts := options.Update().
+		SetCollation(&options.Collation{Locale: "es", Strength: 1}).
+		SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"e.main_muscle_group": bson.M{"$in": values}}}})
+
+	result, err := collection.UpdateMany(context.TODO(), filter, update, opts)
+	if err != nil {
+		return 0, fmt.Errorf("error al normalizar grupos musculares en WorkoutRepository.NormalizeSnapshotMuscleGroups(): %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
 func (repository WorkoutRepository) GetWorkoutsByUserID(userID string) ([]models.Workout, error) {
 	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
 	userObjectID, err := utils.GetObjectIDFromStringID(userID)
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.M{"user_id": userObjectID}
+	filter := active(bson.M{"user_id": userObjectID})
 
 	result, err := collection.Find(context.TODO(), filter)
 	if err != nil {
@@ -130,3 +262,394 @@ func (repository WorkoutRepository) GetWorkoutsByUserID(userID string) ([]models
 	}
 	return workouts, nil
 }
+
+// GetWorkoutsByUserAndExcercise devuelve los workouts del usuario que registraron series del ejercicio, ordenados por fecha
+func (repository WorkoutRepository) GetWorkoutsByUserAndExcercise(userID string, excerciseID string) ([]models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	excerciseObjectID, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+	filter := active(bson.M{"user_id": userObjectID, "exercises.excercise_id": excerciseObjectID})
+	opts := options.Find().SetSort(bson.D{{Key: "date_and_hours", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en WorkoutRepository.GetWorkoutsByUserAndExcercise(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var workouts []models.Workout
+	for cursor.Next(context.Background()) {
+		var workout models.Workout
+		if err := cursor.Decode(&workout); err != nil {
+			return nil, fmt.Errorf("error al decodificar el workout en WorkoutRepository.GetWorkoutsByUserAndExcercise(): %v", err)
+		}
+		workouts = append(workouts, workout)
+	}
+	return workouts, nil
+}
+
+// CountWorkoutsByExcercise cuenta los workouts de todos los usuarios que registraron series del ejercicio
+func (repository WorkoutRepository) CountWorkoutsByExcercise(excerciseID string) (int64, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	excerciseObjectID, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return 0, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+	count, err := collection.CountDocuments(context.TODO(), active(bson.M{"exercises.excercise_id": excerciseObjectID}))
+	if err != nil {
+		return 0, fmt.Errorf("error al contar workouts en WorkoutRepository.CountWorkoutsByExcercise(): %v", err)
+	}
+	return count, nil
+}
+
+// completedStatus es el filtro de models.Workout.IsCompleted: ni en curso ni abandonados (los workouts viejos no
+// tienen status)
+var completedStatus = bson.M{"$nin": bson.A{models.WorkoutInProgress, models.WorkoutAbandoned}}
+
+// GetRecentWorkoutsByRoutine devuelve los ultimos workouts completos de la rutina, del mas nuevo al mas viejo
+func (repository WorkoutRepository) GetRecentWorkoutsByRoutine(userID string, routineID primitive.ObjectID, limit int64) ([]models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	filter := active(bson.M{
+		"user_id":    userObjectID,
+		"routine_id": routineID,
+		"status":     completedStatus,
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "date_and_hours", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en WorkoutRepository.GetRecentWorkoutsByRoutine(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var workouts []models.Workout
+	if err := cursor.All(context.TODO(), &workouts); err != nil {
+		return nil, fmt.Errorf("error al decodificar los workouts en WorkoutRepository.GetRecentWorkoutsByRoutine(): %v", err)
+	}
+	return workouts, nil
+}
+
+// GetWorkoutsByUserInRange devuelve los workouts del usuario con fecha en [from, to), ordenados por fecha
+func (repository WorkoutRepository) GetWorkoutsByUserInRange(userID string, from time.Time, to time.Time) ([]models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, err
+	}
+	filter := active(bson.M{
+		"user_id":        userObjectID,
+		"date_and_hours": bson.M{"$gte": from, "$lt": to},
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "date_and_hours", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en WorkoutRepository.GetWorkoutsByUserInRange(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var workouts []models.Workout
+	for cursor.Next(context.Background()) {
+		var workout models.Workout
+		if err := cursor.Decode(&workout); err != nil {
+			return nil, fmt.Errorf("error al decodificar el workout en WorkoutRepository.GetWorkoutsByUserInRange(): %v", err)
+		}
+		workouts = append(workouts, workout)
+	}
+	return workouts, nil
+}
+
+// GetWorkoutStats agrega en MongoDB los workouts completos del usuario entre from y to (to exclusivo, fechas cero = sin limite).
+// dateFormat es el formato de $dateToString usado para agrupar el progreso en el tiempo
+func (repository WorkoutRepository) GetWorkoutStats(userID string, from time.Time, to time.Time, dateFormat string, timeZone string) (models.WorkoutStatsAggregate, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return models.WorkoutStatsAggregate{}, err
+	}
+
+	match := active(bson.M{
+		"user_id": userObjectID,
+		"status":  completedStatus,
+	})
+	dateRange := bson.M{}
+	if !from.IsZero() {
+		dateRange["$gte"] = from
+	}
+	if !to.IsZero() {
+		dateRange["$lt"] = to
+	}
+	if len(dateRange) > 0 {
+		match["date_and_hours"] = dateRange
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// tiempo y distancia de las series completadas de cada workout (cardio y flexibilidad)
+		{{Key: "$addFields", Value: bson.M{
+			"duration_seconds_done": completedSetsSum("duration_seconds"),
+			"distance_meters_done":  completedSetsSum("distance_meters"),
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":              nil,
+					"total":            bson.M{"$sum": 1},
+					"duration_seconds": bson.M{"$sum": "$duration_seconds_done"},
+					"distance_meters":  bson.M{"$sum": "$distance_meters_done"},
+					"first":            bson.M{"$min": "$date_and_hours"},
+					"last":             bson.M{"$max": "$date_and_hours"},
+				}},
+			},
+			"routines": bson.A{
+				bson.M{"$group": bson.M{"_id": "$routine_name", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"progress": bson.A{
+				bson.M{"$group": bson.M{
+					"_id": bson.M{"$dateToString": bson.M{
+						"format":   dateFormat,
+						"date":     "$date_and_hours",
+						"timezone": timeZone,
+					}},
+					"count":            bson.M{"$sum": 1},
+					"duration_seconds": bson.M{"$sum": "$duration_seconds_done"},
+					"distance_meters":  bson.M{"$sum": "$distance_meters_done"},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return models.WorkoutStatsAggregate{}, fmt.Errorf("error al ejecutar Aggregate() en WorkoutRepository.GetWorkoutStats(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var facets []struct {
+		Totals   []models.WorkoutStatsAggregate `bson:"totals"`
+		Routines []models.WorkoutStatsCount     `bson:"routines"`
+		Progress []models.WorkoutStatsCount     `bson:"progress"`
+	}
+	if err := cursor.All(context.TODO(), &facets); err != nil {
+		return models.WorkoutStatsAggregate{}, fmt.Errorf("error al decodificar las estadisticas en WorkoutRepository.GetWorkoutStats(): %v", err)
+	}
+
+	var stats models.WorkoutStatsAggregate
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	if len(facets[0].Totals) > 0 {
+		stats = facets[0].Totals[0]
+	}
+	stats.Routines = facets[0].Routines
+	stats.Progress = facets[0].Progress
+	return stats, nil
+}
+
+// completedSetsSum suma un campo de todas las series completadas del workout
+func completedSetsSum(field string) bson.M {
+	return bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$exercises", bson.A{}}},
+		"as":    "e",
+		"in": bson.M{"$sum": bson.M{"$map": bson.M{
+			"input": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$$e.sets", bson.A{}}},
+				"as":    "s",
+				"cond":  "$$s.completed",
+			}},
+			"as": "s",
+			"in": "$$s." + field,
+		}}},
+	}}}
+}
+
+// CreateIndexes crea el indice unico parcial que impide tener mas de un workout en curso por usuario
+// y el indice por usuario y fecha usado por las estadisticas
+func (repository WorkoutRepository) CreateIndexes() error {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().
+			SetName("unique_active_workout_per_user").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": models.WorkoutInProgress}),
+	}
+	// consultas de historial y estadisticas por usuario y rango de fechas
+	byDate := mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "date_and_hours", Value: 1}},
+		Options: options.Index().SetName("user_date"),
+	}
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{index, byDate})
+	if err != nil {
+		return fmt.Errorf("error al crear indices en WorkoutRepository.CreateIndexes(): %v", err)
+	}
+	return nil
+}
+
+func (repository WorkoutRepository) StartWorkout(workout models.Workout) (*mongo.InsertOneResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"user_id": workout.UserID, "status": models.WorkoutInProgress})
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar workouts en curso en WorkoutRepository.StartWorkout(): %v", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("ya existe un workout en curso para el usuario")
+	}
+
+	result, err := collection.InsertOne(context.TODO(), workout)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) { // otro request inicio un workout al mismo tiempo
+			return nil, fmt.Errorf("ya existe un workout en curso para el usuario")
+		}
+		return result, fmt.Errorf("error al insertar el workout en WorkoutRepository.StartWorkout(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository WorkoutRepository) GetActiveWorkoutByUserID(userID string) (models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	filter := active(bson.M{"user_id": userObjectID, "status": models.WorkoutInProgress})
+
+	var workout models.Workout
+	err = collection.FindOne(context.TODO(), filter).Decode(&workout)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Workout{}, nil // sin workout en curso: ID.IsZero()
+		}
+		return models.Workout{}, fmt.Errorf("error al obtener el workout en curso en WorkoutRepository.GetActiveWorkoutByUserID(): %v", err)
+	}
+	return workout, nil
+}
+
+func (repository WorkoutRepository) AddSetToWorkout(workoutID primitive.ObjectID, exercise models.ExcerciseInWorkout, set models.WorkoutSet) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	now := time.Now()
+
+	// si la entrada ya tiene series registradas agregamos la serie a su lista; se busca por entrada y no por
+	// ejercicio porque el mismo ejercicio puede estar dos veces. Sin entrada (rutinas anteriores a las entradas)
+	// se busca el ejercicio registrado sin entrada
+	entry := bson.M{"excercise_id": exercise.ExcerciseID, "entry_id": bson.M{"$exists": false}}
+	if !exercise.EntryID.IsZero() {
+		entry = bson.M{"excercise_id": exercise.ExcerciseID, "entry_id": exercise.EntryID}
+	}
+	elem := bson.M{}
+	for field, value := range entry {
+		elem["e."+field] = value
+	}
+	result, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": workoutID, "status": models.WorkoutInProgress, "exercises": bson.M{"$elemMatch": entry}},
+		bson.M{
+			"$push": bson.M{"exercises.$[e].sets": set},
+			"$set":  bson.M{"last_activity": now},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{elem}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la serie al workout: %v", err)
+	}
+	if result.MatchedCount > 0 {
+		return result, nil
+	}
+
+	// primera serie de este ejercicio
+	result, err = collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": workoutID, "status": models.WorkoutInProgress},
+		bson.M{
+			"$push": bson.M{"exercises": models.ExcerciseInWorkout{EntryID: exercise.EntryID, ExcerciseID: exercise.ExcerciseID, Sets: []models.WorkoutSet{set}}},
+			"$set":  bson.M{"last_activity": now},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al agregar la serie al workout: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("no hay ningún workout en curso con ese ID")
+	}
+	return result, nil
+}
+
+func (repository WorkoutRepository) UpdateWorkoutStatus(workout models.Workout) (*mongo.UpdateResult, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	filter := bson.M{"_id": workout.ID}
+
+	entity := bson.M{"$set": bson.M{
+		"status":           workout.Status,
+		"end_time":         workout.EndTime,
+		"paused_at":        workout.PausedAt,
+		"paused_seconds":   workout.PausedSeconds,
+		"duration_seconds": workout.DurationSeconds,
+		"last_activity":    workout.LastActivity,
+	}}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, entity)
+	if err != nil {
+		return result, fmt.Errorf("error al actualizar el estado del workout en WorkoutRepository.UpdateWorkoutStatus(): %v", err)
+	}
+	return result, nil
+}
+
+func (repository WorkoutRepository) GetStaleActiveWorkouts(before time.Time) ([]models.Workout, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	filter := active(bson.M{
+		"status":        models.WorkoutInProgress,
+		"last_activity": bson.M{"$lt": before},
+		"paused_at":     bson.M{"$not": bson.M{"$gt": time.Time{}}}, // los pausados no se cierran por inactividad
+	})
+
+	cursor, err := collection.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("error al ejecutar la consulta Find() en WorkoutRepository.GetStaleActiveWorkouts(): %v", err)
+	}
+	defer cursor.Close(context.TODO())
+
+	var workouts []models.Workout
+	for cursor.Next(context.Background()) {
+		var workout models.Workout
+		if err := cursor.Decode(&workout); err != nil {
+			return nil, fmt.Errorf("error al decodificar el workout en WorkoutRepository.GetStaleActiveWorkouts(): %v", err)
+		}
+		workouts = append(workouts, workout)
+	}
+	return workouts, nil
+}
+
+// GetWorkoutsByUserIDPage devuelve una pagina del historial del usuario
+func (repository WorkoutRepository) GetWorkoutsByUserIDPage(userID string, page models.PageRequest) ([]models.Workout, models.PageInfo, error) {
+	collection := repository.db.GetClient().Database("AppFitness").Collection("workouts")
+	userObjectID, err := utils.GetObjectIDFromStringID(userID)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	var workouts []models.Workout
+	info, err := findPage(collection, active(bson.M{"user_id": userObjectID}), page, func(document bson.Raw) error {
+		var workout models.Workout
+		if err := bson.Unmarshal(document, &workout); err != nil {
+			return err
+		}
+		workouts = append(workouts, workout)
+		return nil
+	})
+	if err != nil {
+		return nil, info, fmt.Errorf("error en WorkoutRepository.GetWorkoutsByUserIDPage(): %v", err)
+	}
+	return workouts, info, nil
+}
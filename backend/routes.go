@@ -0,0 +1,257 @@
+package main
+
+import (
+	"AppFitness/handlers"
+	"AppFitness/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// appHandlers son los handlers que atienden las rutas de la API
+type appHandlers struct {
+	auth     *handlers.AuthHandler
+	user     *handlers.UserHandler
+	exercise *handlers.ExerciseHandler
+	routine  *handlers.RoutineHandler
+	workout  *handlers.WorkoutHandler
+	record   *handlers.PersonalRecordHandler
+	schedule *handlers.ScheduleHandler
+	program  *handlers.ProgramHandler
+	admin    *handlers.AdminHandler
+	taxonomy *handlers.TaxonomyHandler
+}
+
+// newRouter registra las paginas y las rutas de la API. Gin entra en panico al arrancar si dos rutas chocan (por
+// ejemplo dos comodines con distinto nombre en la misma posicion), por eso se arma aparte y un test lo construye
+func newRouter(h appHandlers) *gin.Engine {
+	router := gin.Default()
+
+	// Configurar archivos státic y templates
+	router.Static("/statics", "./statics")
+	router.LoadHTMLGlob("templates/*")
+
+	router.GET("/", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "index.html", nil)
+	})
+	router.GET("/login", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "login.html", nil)
+	})
+	router.GET("/register", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "register1.html", nil)
+	})
+	router.GET("/register2", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "register2.html", nil)
+	})
+	router.GET("/dashboard-user", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-dashboard.html", nil)
+	})
+	router.GET("/dashboard-admin", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-dashboard.html", nil)
+	})
+
+	// Rutas de páginas de Usuario
+	router.GET("/user-exercise", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-exercise.html", nil)
+	})
+	router.GET("/user-routines", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-routines.html", nil)
+	})
+	router.GET("/user-progress", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-progress.html", nil)
+	})
+	router.GET("/user-record", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-record.html", nil)
+	})
+	router.GET("/user-routines-new", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-routines-new.html", nil)
+	})
+	router.GET("/user-routine-view.html", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-routine-view.html", nil)
+	})
+	router.GET("/user-routine-edit.html", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user-routine-edit.html", nil)
+	})
+
+	// Rutas de Perfil
+	router.GET("/profile", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "profile.html", nil)
+	})
+	router.GET("/profile-edit.html", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "profile-edit.html", nil)
+	})
+	router.GET("/profile-edit-password.html", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "profile-edit-password.html", nil)
+	})
+
+	// Rutas de páginas de Admin
+	router.GET("/admin-exercises", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-exercises.html", nil)
+	})
+	router.GET("/admin-users", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-users.html", nil)
+	})
+	router.GET("/admin-stats", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-stats.html", nil)
+	})
+	router.GET("/admin-system-logs", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-system-logs.html", nil)
+	})
+	// Rutas para crear/editar ejercicios de admin
+	router.GET("/admin-excercise-new", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-excercise-new.html", nil)
+	})
+	router.GET("/admin-excercise-edit", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-excercise-edit.html", nil)
+	})
+	router.GET("/admin-exercise-ranking", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "admin-exercise-ranking.html", nil)
+	})
+
+	// Rutas Públicas (Autenticación y Registro)
+	router.POST("/register", h.user.PostUser)
+	router.POST("/login", h.auth.PostLogin)
+	router.POST("/logout", h.auth.PostLogout)
+	router.POST("/refresh", h.auth.PostRefresh)
+	router.GET("/calendar/:token", h.schedule.GetICalendar) // feed .ics publico, protegido por el token secreto
+
+	api := router.Group("/api")
+	api.Use(middleware.AuthMiddleware())
+
+	//  Rutas de Perfil de Usuario
+	userRoutes := api.Group("/users")
+	{
+		userRoutes.GET("/:id", h.user.GetUserByID)
+		userRoutes.PUT("/:id", h.user.PutUser)
+		userRoutes.POST("/:id/password", h.user.PasswordModify)
+	}
+	exerciseRoutes := api.Group("/exercises")
+	{
+		exerciseRoutes.GET("/", h.exercise.GetExcercises)
+		exerciseRoutes.GET("/filter", h.exercise.GetByFilters) // Búsqueda y filtros
+		exerciseRoutes.GET("/:id", h.exercise.GetExcerciseByID)
+		exerciseRoutes.GET("/:id/records", h.record.GetRecordsByExcercise) // records personales del usuario logueado
+
+		adminExercise := exerciseRoutes.Group("/")
+		adminExercise.Use(middleware.CheckAdmin())
+		{
+			adminExercise.POST("/", h.exercise.PostExcercise)                  // Alta
+			adminExercise.PUT("/:id", h.exercise.PutExcercise)                 // Edición
+			adminExercise.GET("/:id/dependencies", h.exercise.GetDependencies) // rutinas y workouts que lo usan
+			adminExercise.DELETE("/:id", h.exercise.DeleteExcercise)           // ?mode=block|cascade|replace&replace_with=
+		}
+	}
+
+	// Vocabularios de clasificacion de ejercicios, para formularios y filtros
+	api.GET("/taxonomy", h.taxonomy.GetTaxonomy)
+
+	// Rutas de Rutinas
+	routineRoutes := api.Group("/routines")
+	routineRoutes.Use(middleware.CheckUser())
+	{
+		routineRoutes.POST("/", h.routine.PostRoutine)
+		routineRoutes.GET("/", h.routine.GetRoutines)                // solo las del usuario
+		routineRoutes.GET("/public", h.routine.SearchPublicRoutines) // biblioteca: ?q=&muscle_group=&category=&difficulty=&featured=
+		routineRoutes.GET("/export", h.routine.ExportRoutines)       // ?format=json|csv&id= (sin id: todas las del usuario)
+		routineRoutes.POST("/import", h.routine.ImportRoutines)      // ?format=json|csv&dry_run=true, archivo en el cuerpo
+		routineRoutes.GET("/:id", h.routine.GetRoutineByID)
+		routineRoutes.PUT("/:id", h.routine.PutRoutine)
+		routineRoutes.DELETE("/:id", h.routine.DeleteRoutine)
+		routineRoutes.POST("/:id/restore", h.routine.RestoreRoutine) // deshacer la eliminacion mientras siga en la papelera
+
+		// Manejo de ejercicios dentro de una rutina
+		routineRoutes.POST("/:id/exercises", h.routine.AddExcerciseToRoutine)
+		routineRoutes.PUT("/:id/exercises/:exercise_id", h.routine.UpdateExerciseInRoutine) // handler espera un DTO en el body, así que no usamos params
+		routineRoutes.DELETE("/exercises", h.routine.RemoveExerciseFromRoutine)             // body: entry_id o exercise_id
+		routineRoutes.PUT("/:id/order", h.routine.ReorderExercises)                         // {"entry_ids": [...]} con el orden completo
+		routineRoutes.POST("/:id/groups", h.routine.PostExcerciseGroup)                     // superseries, series gigantes y circuitos
+		routineRoutes.DELETE("/:id/groups/:group_id", h.routine.DeleteExcerciseGroup)
+
+		routineRoutes.POST("/:id/clone", h.routine.CloneRoutine) // body opcional: {"name": "..."}
+		routineRoutes.PUT("/:id/visibility", h.routine.SetRoutineVisibility)
+		routineRoutes.GET("/:id/versions", h.routine.GetRoutineVersions) // historial con diff entre versiones
+		routineRoutes.POST("/:id/versions/:version/rollback", h.routine.RollbackRoutine)
+	}
+
+	// Rutas de Seguimiento (Workouts)
+	workoutRoutes := api.Group("/workouts")
+	workoutRoutes.Use(middleware.CheckUser())
+	{
+		workoutRoutes.GET("/", h.workout.GetWorkouts)
+
+		workoutRoutes.POST("/import", h.workout.ImportWorkouts) // CSV en el cuerpo; ?dry_run=true&unit=&tz=&date_format=dmy|mdy&mapping[nombre]=id
+		workoutRoutes.POST("/:id_routine", h.workout.PostWorkout)
+
+		// Workout en vivo: solo puede haber uno en curso por usuario
+		workoutRoutes.POST("/start/:id_routine", h.workout.StartWorkout)
+		workoutRoutes.GET("/active", h.workout.GetActiveWorkout)
+		workoutRoutes.POST("/active/sets", h.workout.AddSetToActiveWorkout) // entry_id obligatorio si el ejercicio esta dos veces en la rutina
+		workoutRoutes.POST("/active/pause", h.workout.PauseWorkout)
+		workoutRoutes.POST("/active/resume", h.workout.ResumeWorkout)
+		workoutRoutes.POST("/active/finish", h.workout.FinishWorkout)
+
+		workoutRoutes.GET("/stats", h.workout.GetWorkoutStats)
+		workoutRoutes.GET("/stats/exercises/:id", h.workout.GetExcerciseProgress) // ?formula=epley|brzycki|lombardi&granularity=day|week|month
+		workoutRoutes.GET("/records", h.record.GetRecords)
+
+		workoutRoutes.GET("/:id", h.workout.GetWorkoutByID) // Ver un workout específico
+
+		workoutRoutes.PUT("/:id", h.workout.PutWorkout) // editar fecha, notas y series
+		workoutRoutes.DELETE("/:id", h.workout.DeleteWorkout)
+		workoutRoutes.POST("/restore/:id", h.workout.RestoreWorkout) // no puede ser /:id/restore: choca con /:id_routine
+	}
+
+	// Rutas de Planificación (calendario de entrenamientos)
+	scheduleRoutes := api.Group("/schedules")
+	scheduleRoutes.Use(middleware.CheckUser())
+	{
+		scheduleRoutes.POST("/", h.schedule.PostSchedule)
+		scheduleRoutes.GET("/", h.schedule.GetSchedules)
+		scheduleRoutes.GET("/calendar", h.schedule.GetCalendar) // ?from=YYYY-MM-DD&to=YYYY-MM-DD&tz=
+		scheduleRoutes.GET("/feed", h.schedule.GetCalendarFeed)
+		scheduleRoutes.POST("/feed", h.schedule.RotateCalendarFeed) // genera o regenera la URL secreta del .ics
+		scheduleRoutes.DELETE("/feed", h.schedule.RevokeCalendarFeed)
+		scheduleRoutes.DELETE("/:id", h.schedule.DeleteSchedule)
+	}
+
+	// Rutas de Programas (bloques de varias semanas con progresion)
+	programRoutes := api.Group("/programs")
+	programRoutes.Use(middleware.CheckUser())
+	{
+		programRoutes.POST("/", h.program.PostProgram)
+		programRoutes.GET("/", h.program.GetPrograms)
+		programRoutes.GET("/enrollment", h.program.GetEnrollment) // programa activo y semana actual
+		programRoutes.DELETE("/enrollment", h.program.CancelEnrollment)
+		programRoutes.GET("/today", h.program.GetToday) // ?tz= rutina del dia con la progresion aplicada
+		programRoutes.GET("/:id", h.program.GetProgramByID)
+		programRoutes.PUT("/:id", h.program.PutProgram)
+		programRoutes.DELETE("/:id", h.program.DeleteProgram)
+		programRoutes.POST("/:id/enroll", h.program.Enroll) // { start_date } opcional, por defecto hoy
+	}
+
+	// --- Rutas del Panel de Administración ---
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(middleware.CheckAdmin()) // Protegido solo para Admins
+	{
+		adminRoutes.GET("/users", h.user.GetUsers) // Gestión de usuarios
+		adminRoutes.DELETE("/users/:id", h.admin.DeleteUser)
+		adminRoutes.GET("/stats/users", h.admin.GetLogs)
+		adminRoutes.GET("/sessions", h.admin.GetSessions)
+		adminRoutes.GET("/stats/exercises", h.admin.GetGlobalStats)
+		adminRoutes.PUT("/routines/:id/featured", h.routine.SetRoutineFeatured)
+
+		// Papelera: :type es exercises, routines, users o workouts
+		adminRoutes.GET("/trash/:type", h.admin.GetTrash)
+		adminRoutes.POST("/trash/:type/:id/restore", h.admin.RestoreFromTrash)
+
+		// Vocabularios: :kind es muscle, equipment, mechanics, force o laterality
+		adminRoutes.POST("/taxonomy/:kind", h.taxonomy.PostTerm)
+		adminRoutes.PUT("/taxonomy/:kind/:key", h.taxonomy.PutTerm) // nombre y alias; la clave no cambia
+		adminRoutes.DELETE("/taxonomy/:kind/:key", h.taxonomy.DeleteTerm)
+
+		// Migraciones de datos anteriores; se lanzan a mano y se pueden repetir
+		adminRoutes.POST("/migrations/muscle-groups", h.taxonomy.NormalizeMuscleGroups)
+	}
+
+	return router
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"AppFitness/handlers"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var router *gin.Engine
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("las rutas chocan: %v", r)
+			}
+		}()
+		router = newRouter(appHandlers{
+			auth:     handlers.NewAuthHandler(nil),
+			user:     handlers.NewUserHandler(nil),
+			exercise: handlers.NewExerciseHandler(nil),
+			routine:  handlers.NewRoutineHandler(nil),
+			workout:  handlers.NewWorkoutHadler(nil),
+			record:   handlers.NewPersonalRecordHandler(nil),
+			schedule: handlers.NewScheduleHandler(nil),
+			program:  handlers.NewProgramHandler(nil),
+			admin:    handlers.NewAdminHandler(nil),
+			taxonomy: handlers.NewTaxonomyHandler(nil),
+		})
+	}()
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range []string{
+		http.MethodPost + " /api/workouts/:id_routine",
+		http.MethodPost + " /api/workouts/restore/:id",
+		http.MethodPost + " /api/workouts/import",
+		http.MethodPost + " /api/routines/:id/restore",
+		http.MethodDelete + " /api/exercises/:id",
+		http.MethodPost + " /api/admin/trash/:type/:id/restore",
+		http.MethodPost + " /api/admin/migrations/muscle-groups",
+	} {
+		if !registered[route] {
+			t.Errorf("falta la ruta %s", route)
+		}
+	}
+}
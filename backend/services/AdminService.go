@@ -2,17 +2,26 @@ package services
 
 import (
 	"AppFitness/dto"
+	"AppFitness/models"
 	"AppFitness/repositories"
 	"AppFitness/utils"
 	"fmt"
+	"log"
 	"sort"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type AdminInterface interface {
 	GetGlobalStats() ([]*dto.TopUsedExcerciseDTO, error)
-	GetLogs() ([]*dto.UserResponseDTO, int, error) //lista de users, cantidad
+	GetLogs(page dto.PageQueryDTO) (*dto.PageDTO, error) //pagina de users con su estado de sesion
+	GetSessions(page dto.PageQueryDTO) (*dto.PageDTO, error)
+	DeleteUser(id string, adminID string) error
+	GetTrash(kind string, page dto.PageQueryDTO) (*dto.PageDTO, error)
+	RestoreFromTrash(kind string, id string) error
+	PurgeTrash() (dto.TrashPurgeDTO, error)
 }
 
 type AdminService struct {
@@ -20,15 +29,34 @@ type AdminService struct {
 	ExcerciseRepository repositories.ExcerciseRepositoryInterface
 	RoutineRepository   repositories.RoutineRepositoryInterface
 	SessionRepository   repositories.SessionRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+	VersionRepository   repositories.RoutineVersionRepositoryInterface
+	RecordRepository    repositories.PersonalRecordRepositoryInterface
+	ScheduleRepository  repositories.ScheduleRepositoryInterface
+	ProgramRepository   repositories.ProgramRepositoryInterface
+	RecordService       PersonalRecordInterface
+	RoutineService      RoutineInterface // quita de las rutinas los ejercicios purgados
+	TrashRetention      time.Duration    // tiempo que un documento eliminado queda en la papelera antes de purgarse
 }
 
 func NewAdminService(
-	userRepo repositories.UserRepositoryInterface, exerciseRepo repositories.ExcerciseRepositoryInterface, routineRepo repositories.RoutineRepositoryInterface, sessionRepo repositories.SessionRepositoryInterface) AdminInterface {
+	userRepo repositories.UserRepositoryInterface, exerciseRepo repositories.ExcerciseRepositoryInterface, routineRepo repositories.RoutineRepositoryInterface, sessionRepo repositories.SessionRepositoryInterface,
+	workoutRepo repositories.WorkoutRepositoryInterface, versionRepo repositories.RoutineVersionRepositoryInterface, recordRepo repositories.PersonalRecordRepositoryInterface,
+	scheduleRepo repositories.ScheduleRepositoryInterface, programRepo repositories.ProgramRepositoryInterface, recordService PersonalRecordInterface, routineService RoutineInterface,
+	trashRetention time.Duration) AdminInterface {
 	return &AdminService{
 		UserRepository:      userRepo,
 		ExcerciseRepository: exerciseRepo,
 		RoutineRepository:   routineRepo,
 		SessionRepository:   sessionRepo,
+		WorkoutRepository:   workoutRepo,
+		VersionRepository:   versionRepo,
+		RecordRepository:    recordRepo,
+		ScheduleRepository:  scheduleRepo,
+		ProgramRepository:   programRepo,
+		RecordService:       recordService,
+		RoutineService:      routineService,
+		TrashRetention:      trashRetention,
 	}
 }
 
@@ -58,6 +86,9 @@ func (a *AdminService) GetGlobalStats() ([]*dto.TopUsedExcerciseDTO, error) {
 	topList := make([]*dto.TopUsedExcerciseDTO, 0, len(counts))
 	for te, i := range counts {
 		excerciseName, _ := a.ExcerciseRepository.GetExcerciseByID(utils.GetStringIDFromObjectID(te))
+		if excerciseName.ID.IsZero() { //ejercicio en la papelera
+			continue
+		}
 		topList = append(topList, &dto.TopUsedExcerciseDTO{
 			ExcerciseID:   utils.GetStringIDFromObjectID(te),
 			ExcerciseName: excerciseName.Name,
@@ -75,16 +106,18 @@ func (a *AdminService) GetGlobalStats() ([]*dto.TopUsedExcerciseDTO, error) {
 	return topList, nil
 }
 
-func (a *AdminService) GetLogs() ([]*dto.UserResponseDTO, int, error) {
+// GetLogs devuelve una pagina de usuarios indicando si tienen una sesion activa; el estado se consulta solo para
+// los usuarios de la pagina
+func (a *AdminService) GetLogs(page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.UserListFields, "UserName")
+	if err != nil {
+		return nil, err
+	}
 
 	//buscar users
-	usersDB, err := a.UserRepository.GetUsers()
+	usersDB, info, err := a.UserRepository.GetUsersPage(request)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error al recuperar users")
-	}
-	if len(usersDB) == 0 {
-		// Devuelve vacío en lugar de error
-		return []*dto.UserResponseDTO{}, 0, nil
+		return nil, fmt.Errorf("error al recuperar users")
 	}
 
 	var usersResponse []*dto.UserResponseDTO
@@ -101,6 +134,251 @@ func (a *AdminService) GetLogs() ([]*dto.UserResponseDTO, int, error) {
 		usersResponse = append(usersResponse, userDTO)
 	}
 
-	return usersResponse, len(usersResponse), nil
+	return dto.NewPageDTO(usersResponse, info, request.Fields)
+}
+
+// GetSessions devuelve las sesiones paginadas, por defecto de la mas nueva a la mas vieja
+func (a *AdminService) GetSessions(page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.SessionListFields, "-created")
+	if err != nil {
+		return nil, err
+	}
+	sessionsDB, info, err := a.SessionRepository.GetSessionsPage(request)
+	if err != nil {
+		return nil, fmt.Errorf("error al recuperar sesiones: %w", err)
+	}
 
+	var sessions []*dto.SessionResponseDTO
+	for _, s := range sessionsDB {
+		sessions = append(sessions, dto.NewSessionResponseDTO(s))
+	}
+	return dto.NewPageDTO(sessions, info, request.Fields)
+}
+
+// DeleteUser manda al usuario a la papelera y cierra sus sesiones. Sus rutinas y workouts se conservan para poder
+// restaurarlo, pero sus rutinas publicas dejan de aparecer en la biblioteca; al purgarlo se borra todo lo suyo
+func (a *AdminService) DeleteUser(id string, adminID string) error {
+	if id == adminID {
+		return fmt.Errorf("no puede eliminar su propio usuario")
+	}
+	result, err := a.UserRepository.DeleteUser(id)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no se encontró ningún usuario con el ID proporcionado")
+	}
+	if _, err := a.SessionRepository.DeleteSessionsByUserID(id); err != nil {
+		log.Printf("error al cerrar las sesiones del usuario eliminado %s: %v", id, err)
+	}
+	return nil
+}
+
+// GetTrash devuelve una pagina de la papelera del tipo pedido, por defecto lo eliminado mas recientemente primero
+func (a *AdminService) GetTrash(kind string, page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	fields, ok := dto.TrashListFields(kind)
+	if !ok {
+		return nil, fmt.Errorf("tipo de papelera inválido: %s", kind)
+	}
+	request, err := page.Resolve(fields, "-deleted_at")
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*dto.TrashItemDTO
+	var info models.PageInfo
+	switch kind {
+	case dto.TrashExcercises:
+		var excercises []models.Excercise
+		excercises, info, err = a.ExcerciseRepository.GetDeletedExcercisesPage(request)
+		for _, e := range excercises {
+			items = append(items, dto.NewTrashItemDTO(kind, e.ID, e.Name, e.CreatorUserID, e.EliminationDate, a.TrashRetention))
+		}
+	case dto.TrashRoutines:
+		var routines []*models.Routine
+		routines, info, err = a.RoutineRepository.GetDeletedRoutinesPage(request)
+		for _, r := range routines {
+			items = append(items, dto.NewTrashItemDTO(kind, r.ID, r.Name, r.CreatorUserID, r.EliminationDate, a.TrashRetention))
+		}
+	case dto.TrashUsers:
+		var users []models.User
+		users, info, err = a.UserRepository.GetDeletedUsersPage(request)
+		for _, u := range users {
+			items = append(items, dto.NewTrashItemDTO(kind, u.ID, u.UserName, primitive.NilObjectID, u.EliminationDate, a.TrashRetention))
+		}
+	case dto.TrashWorkouts:
+		var workouts []models.Workout
+		workouts, info, err = a.WorkoutRepository.GetDeletedWorkoutsPage(request)
+		for _, w := range workouts {
+			items = append(items, dto.NewTrashItemDTO(kind, w.ID, w.RoutineName, w.UserID, w.EliminationDate, a.TrashRetention))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al recuperar la papelera: %w", err)
+	}
+	return dto.NewPageDTO(items, info, request.Fields)
+}
+
+// RestoreFromTrash saca un documento de la papelera; los workouts restaurados vuelven a contar para los records
+func (a *AdminService) RestoreFromTrash(kind string, id string) error {
+	var result *mongo.UpdateResult
+	var err error
+	switch kind {
+	case dto.TrashExcercises:
+		result, err = a.ExcerciseRepository.RestoreExcercise(id)
+	case dto.TrashRoutines:
+		result, err = a.RoutineRepository.RestoreRoutine(id)
+	case dto.TrashUsers:
+		result, err = a.UserRepository.RestoreUser(id)
+	case dto.TrashWorkouts:
+		return a.restoreWorkout(id)
+	default:
+		return fmt.Errorf("tipo de papelera inválido: %s", kind)
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no se encontró el documento en la papelera")
+	}
+	return nil
+}
+
+func (a *AdminService) restoreWorkout(id string) error {
+	workout, err := a.WorkoutRepository.GetDeletedWorkoutByID(id)
+	if err != nil {
+		return err
+	}
+	if workout.ID.IsZero() {
+		return fmt.Errorf("no se encontró el documento en la papelera")
+	}
+	result, err := a.WorkoutRepository.RestoreWorkout(id)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no se encontró el documento en la papelera")
+	}
+
+	if a.RecordService != nil && len(workout.Exercises) > 0 {
+		var ids []primitive.ObjectID
+		for _, e := range workout.Exercises {
+			ids = append(ids, e.ExcerciseID)
+		}
+		if err := a.RecordService.RebuildRecords(workout.UserID.Hex(), ids); err != nil {
+			log.Printf("error al recalcular records del usuario %s: %v", workout.UserID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// PurgeTrash borra definitivamente lo que lleva en la papelera mas de TrashRetention; se ejecuta periodicamente.
+// Al purgar una rutina se borra tambien su historial de versiones; al purgar un ejercicio se quita de las rutinas
+// (activas o en la papelera) y de los workouts de la papelera, y se borran sus records; al purgar un usuario se
+// borra todo lo suyo (ver purgeUsers)
+func (a *AdminService) PurgeTrash() (dto.TrashPurgeDTO, error) {
+	var purged dto.TrashPurgeDTO
+	before := time.Now().Add(-a.TrashRetention)
+
+	excercises, err := a.ExcerciseRepository.PurgeDeletedExcercises(before)
+	if err != nil {
+		return purged, err
+	}
+	purged.Excercises = len(excercises)
+	a.detachExcercises(excercises)
+
+	users, err := a.UserRepository.PurgeDeletedUsers(before)
+	if err != nil {
+		return purged, err
+	}
+	purged.Users = len(users)
+	userRoutines, userWorkouts := a.purgeUsers(users)
+
+	routines, err := a.RoutineRepository.PurgeDeletedRoutines(before)
+	if err != nil {
+		return purged, err
+	}
+	purged.Routines = len(routines) + userRoutines
+
+	workouts, err := a.WorkoutRepository.PurgeDeletedWorkouts(before)
+	if err != nil {
+		return purged, err
+	}
+	purged.Workouts = len(workouts) + userWorkouts
+	a.deleteVersions(routines)
+	return purged, nil
+}
+
+// detachExcercises quita los ejercicios purgados de todo lo que todavia los nombra. Los workouts activos no se
+// tocan: guardan el nombre del ejercicio en su snapshot. Un error solo se registra, el ejercicio ya no existe
+func (a *AdminService) detachExcercises(excercises []primitive.ObjectID) {
+	if len(excercises) == 0 {
+		return
+	}
+	for _, id := range excercises {
+		if _, err := a.RoutineService.DetachExcercise(id.Hex(), nil, ""); err != nil {
+			log.Printf("error al quitar el ejercicio purgado %s de las rutinas: %v", id.Hex(), err)
+		}
+	}
+	if _, err := a.WorkoutRepository.PullExcercisesFromDeletedWorkouts(excercises); err != nil {
+		log.Printf("error al quitar los ejercicios purgados de los workouts de la papelera: %v", err)
+	}
+	if _, err := a.RecordRepository.DeleteRecordsByExcercises(excercises); err != nil {
+		log.Printf("error al eliminar los records de los ejercicios purgados: %v", err)
+	}
+}
+
+// purgeUsers borra las rutinas (con su historial), workouts, records, planificaciones, programas e inscripciones
+// de los usuarios purgados, esten o no en la papelera. Devuelve cuantas rutinas y workouts borro; un error solo se
+// registra para seguir con lo demas
+func (a *AdminService) purgeUsers(users []primitive.ObjectID) (int, int) {
+	if len(users) == 0 {
+		return 0, 0
+	}
+	routines, err := a.RoutineRepository.DeleteRoutinesByCreators(users)
+	if err != nil {
+		log.Printf("error al eliminar las rutinas de los usuarios purgados: %v", err)
+	}
+	a.deleteVersions(routines)
+
+	workouts, err := a.WorkoutRepository.DeleteWorkoutsByUsers(users)
+	if err != nil {
+		log.Printf("error al eliminar los workouts de los usuarios purgados: %v", err)
+	}
+	if _, err := a.RecordRepository.DeleteRecordsByUsers(users); err != nil {
+		log.Printf("error al eliminar los records de los usuarios purgados: %v", err)
+	}
+	if _, err := a.ScheduleRepository.DeleteSchedulesByUsers(users); err != nil {
+		log.Printf("error al eliminar las planificaciones de los usuarios purgados: %v", err)
+	}
+	if _, err := a.ProgramRepository.DeleteEnrollmentsByUsers(users); err != nil {
+		log.Printf("error al eliminar las inscripciones de los usuarios purgados: %v", err)
+	}
+	for _, user := range users {
+		programs, err := a.ProgramRepository.GetProgramsByCreator(user.Hex())
+		if err != nil {
+			log.Printf("error al obtener los programas del usuario purgado %s: %v", user.Hex(), err)
+			continue
+		}
+		for _, program := range programs {
+			// como en ProgramService.DeleteProgram, quien lo seguia queda libre para inscribirse en otro
+			if _, err := a.ProgramRepository.DeleteProgram(program.ID.Hex()); err != nil {
+				log.Printf("error al eliminar el programa %s del usuario purgado: %v", program.ID.Hex(), err)
+				continue
+			}
+			if _, err := a.ProgramRepository.CancelEnrollmentsByProgram(program.ID); err != nil {
+				log.Printf("error al cancelar las inscripciones del programa %s: %v", program.ID.Hex(), err)
+			}
+		}
+	}
+	return len(routines), len(workouts)
+}
+
+// deleteVersions borra el historial de versiones de las rutinas purgadas
+func (a *AdminService) deleteVersions(routines []primitive.ObjectID) {
+	for _, id := range routines {
+		if _, err := a.VersionRepository.DeleteVersionsByRoutineID(id.Hex()); err != nil {
+			log.Printf("error al eliminar el historial de la rutina %s: %v", id.Hex(), err)
+		}
+	}
 }
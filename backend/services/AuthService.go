@@ -36,7 +36,7 @@ func (s *AuthService) Login(loginDTO *dto.LoginRequestDTO) (*dto.LoginResponseDT
 		if err == mongo.ErrNoDocuments {
 			return nil, fmt.Errorf("credenciales inválidas: usuario no encontrado")
 		}
-		return nil, fmt.Errorf("error al buscar usuario: %w", &err)
+		return nil, fmt.Errorf("error al buscar usuario: %w", err)
 	}
 
 	isValidPassword := utils.CheckPasswordHash(loginDTO.Password, user.Password)
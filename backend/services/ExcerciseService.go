@@ -2,9 +2,15 @@ package services
 
 import (
 	"AppFitness/dto"
+	"AppFitness/models"
 	"AppFitness/repositories"
 	"AppFitness/utils"
 	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,19 +20,28 @@ import (
 type ExcerciseInterface interface { //POST, PUT y DELETE son accesibles solo por admins (reciben actor)
 	PostExcercise(excercise *dto.ExcerciseRegisterDTO) (*dto.ExcerciseResponseDTO, error)
 	PutExcercise(newData *dto.ExcerciseModifyDTO) (*dto.ExcerciseModifyResponseDTO, error)
-	DeleteExcercise(id string) (bool, error)
-	GetExcercises() ([]*dto.ExcerciseResponseDTO, error)
+	DeleteExcercise(delete dto.ExcerciseDeleteDTO) (*dto.ExcerciseDeleteResultDTO, error)
+	GetDependencies(id string) (*dto.ExcerciseDependenciesDTO, error)
+	GetExcercises(page dto.PageQueryDTO) (*dto.PageDTO, error)
 	GetExcerciseByID(id string) (*dto.ExcerciseResponseDTO, error)
 	GetByFilters(filterDTO dto.ExerciseFilterDTO) ([]*dto.ExcerciseResponseDTO, error)
 }
 
 type ExcerciseService struct {
 	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+	RoutineRepository   repositories.RoutineRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+	RoutineService      RoutineInterface  // quita o reemplaza el ejercicio en las rutinas al eliminarlo
+	TaxonomyService     TaxonomyInterface // vocabularios de grupos musculares, equipamiento, mecanica, fuerza y lateralidad
 }
 
-func NewExcerciseService(ExcerciseRepository repositories.ExcerciseRepositoryInterface) *ExcerciseService {
+func NewExcerciseService(ExcerciseRepository repositories.ExcerciseRepositoryInterface, routineRepository repositories.RoutineRepositoryInterface, workoutRepository repositories.WorkoutRepositoryInterface, routineService RoutineInterface, taxonomyService TaxonomyInterface) *ExcerciseService {
 	return &ExcerciseService{
 		ExcerciseRepository: ExcerciseRepository,
+		RoutineRepository:   routineRepository,
+		WorkoutRepository:   workoutRepository,
+		RoutineService:      routineService,
+		TaxonomyService:     taxonomyService,
 	}
 }
 
@@ -64,8 +79,11 @@ func (service *ExcerciseService) PostExcercise(excerciseDto *dto.ExcerciseRegist
 	}
 
 	//LOGICA
-	excerciseModel := dto.GetModelExcerciseRegister(excerciseDto)             //convertimos el dto a modelo para el repository
-	excerciseModel.CreatorUserID = idObjCreator                               //asignamos el ObjectID del usuario que crea el ejercicio
+	excerciseModel := dto.GetModelExcerciseRegister(excerciseDto) //convertimos el dto a modelo para el repository
+	excerciseModel.CreatorUserID = idObjCreator                   //asignamos el ObjectID del usuario que crea el ejercicio
+	if err := service.classify(excerciseModel, nil); err != nil {
+		return nil, err
+	}
 	result, err := service.ExcerciseRepository.PostExcercise(*excerciseModel) //ejecutamos post en repository
 	if err != nil {
 		return nil, err
@@ -73,6 +91,7 @@ func (service *ExcerciseService) PostExcercise(excerciseDto *dto.ExcerciseRegist
 
 	excerciseModel.ID = result.InsertedID.(primitive.ObjectID)        //asignamos el ID generado por MongoDB al model
 	excerciseResponse := dto.NewExcerciseResponseDTO(*excerciseModel) //convertimos el modelo a dto para la respuesta
+	excerciseResponse.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(*excerciseModel, service.labeler())
 	return excerciseResponse, nil
 }
 
@@ -91,7 +110,7 @@ func (service *ExcerciseService) PutExcercise(newData *dto.ExcerciseModifyDTO) (
 	}
 
 	//LOGICA
-	_, err = service.ExcerciseRepository.GetExcerciseByID(newData.ID) //comprobamos que el ejercicio a modificar existe
+	stored, err := service.ExcerciseRepository.GetExcerciseByID(newData.ID) //comprobamos que el ejercicio a modificar existe
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +118,9 @@ func (service *ExcerciseService) PutExcercise(newData *dto.ExcerciseModifyDTO) (
 	excerciseModel := dto.GetModelExcerciseModify(newData) //convertimos el dto a modelo para el repository
 	excerciseModel.ID = ObjetiveID                         //asignamos el ObjectID del ejercicio a modificar
 	excerciseModel.EditionDate = time.Now()                //actualizamos la fecha de edición
+	if err := service.classify(excerciseModel, &stored); err != nil {
+		return nil, err
+	}
 
 	result, err := service.ExcerciseRepository.PutExcercise(*excerciseModel) //ejecutamos put en repository
 	if err != nil {
@@ -113,21 +135,30 @@ func (service *ExcerciseService) PutExcercise(newData *dto.ExcerciseModifyDTO) (
 		return nil, fmt.Errorf("error al obtener el ejercicio modificado: %w", err)
 	}
 
-	return dto.NewExcerciseModifyResponseDTO(excerciseModify), nil
+	response := dto.NewExcerciseModifyResponseDTO(excerciseModify)
+	response.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(excerciseModify, service.labeler())
+	return response, nil
 }
 
-func (service *ExcerciseService) GetExcercises() ([]*dto.ExcerciseResponseDTO, error) {
-	excercisesDB, err := service.ExcerciseRepository.GetExcercises()
+// GetExcercises devuelve el catalogo paginado, por defecto ordenado por nombre
+func (service *ExcerciseService) GetExcercises(page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.ExcerciseListFields, "Name")
+	if err != nil {
+		return nil, err
+	}
+	excercisesDB, info, err := service.ExcerciseRepository.GetExcercisesPage(request)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener ejercicios: %w", err)
 	}
 
+	label := service.labeler()
 	var excercises []*dto.ExcerciseResponseDTO
 	for _, excerciseDB := range excercisesDB {
 		excercise := dto.NewExcerciseResponseDTO(excerciseDB)
+		excercise.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(excerciseDB, label)
 		excercises = append(excercises, excercise)
 	}
-	return excercises, nil
+	return dto.NewPageDTO(excercises, info, request.Fields)
 }
 
 func (service *ExcerciseService) GetExcerciseByID(id string) (*dto.ExcerciseResponseDTO, error) {
@@ -135,44 +166,444 @@ func (service *ExcerciseService) GetExcerciseByID(id string) (*dto.ExcerciseResp
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener ejercicio: %w", err)
 	}
-	return dto.NewExcerciseResponseDTO(userDB), nil
+	response := dto.NewExcerciseResponseDTO(userDB)
+	response.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(userDB, service.labeler())
+	return response, nil
+}
+
+// labeler devuelve con que nombrar las claves de clasificacion en las respuestas. Si no se pueden leer los
+// vocabularios la respuesta sale igual, solo con las claves
+func (service *ExcerciseService) labeler() func(kind models.TaxonomyKind, key string) string {
+	vocabularies, err := service.TaxonomyService.Vocabularies()
+	if err != nil {
+		log.Printf("error al obtener los vocabularios para nombrar las claves: %v", err)
+	}
+	return vocabularies.Label
 }
 
+// GetByFilters combina los filtros de categoria y clasificacion con la busqueda por texto; con texto los
+// resultados se ordenan por relevancia y se descartan los que no coinciden en nada
 func (service *ExcerciseService) GetByFilters(filterDTO dto.ExerciseFilterDTO) ([]*dto.ExcerciseResponseDTO, error) {
-	if filterDTO.Name == "" && filterDTO.Category == "" && filterDTO.MuscleGroup == "" {
-		return nil, fmt.Errorf("debe ingresar al menos un filtro de búsqueda (nombre, categoría o grupo muscular)")
+	query := strings.TrimSpace(filterDTO.Query)
+	if query == "" {
+		query = strings.TrimSpace(filterDTO.Name)
+	}
+	if query == "" && filterDTO.Empty() {
+		return nil, fmt.Errorf("debe ingresar al menos un filtro de búsqueda (texto, categoría o clasificación)")
+	}
+	if err := service.resolveFilters(&filterDTO); err != nil {
+		return nil, err
 	}
 
-	excercisesDB, err := service.ExcerciseRepository.GetByFilters(filterDTO)
+	if query == "" {
+		excercisesDB, err := service.ExcerciseRepository.GetByFilters(filterDTO)
+		if err != nil {
+			return nil, fmt.Errorf("error al botener ejercicios aplicando filtros")
+		}
+		label := service.labeler()
+		var excercises []*dto.ExcerciseResponseDTO
+		for _, excerciseDB := range excercisesDB {
+			excercise := dto.NewExcerciseResponseDTO(*excerciseDB)
+			excercise.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(*excerciseDB, label)
+			excercises = append(excercises, excercise)
+		}
+		return excercises, nil
+	}
+
+	// sin el indice de texto igual se busca por nombre y alias, solo se pierde descripcion e instrucciones
+	textScores, err := service.ExcerciseRepository.SearchText(query)
+	if err != nil {
+		log.Printf("error en la busqueda de texto de ejercicios: %v", err)
+	}
+	normalized := utils.NormalizeText(query)
+
+	// sin filtros solo se puntuan los que encontro el indice de texto y los que tienen una palabra que empieza como
+	// alguna de las buscadas; con filtros el resultado ya viene acotado por ellos
+	var excercisesDB []*models.Excercise
+	if filterDTO.Empty() {
+		ids := make([]primitive.ObjectID, 0, len(textScores))
+		for id := range textScores {
+			ids = append(ids, id)
+		}
+		excercisesDB, err = service.ExcerciseRepository.GetSearchCandidates(ids, searchPrefixPattern(normalized))
+	} else {
+		excercisesDB, err = service.ExcerciseRepository.GetByFilters(filterDTO)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error al botener ejercicios aplicando filtros")
 	}
 
+	label := service.labeler()
 	var excercises []*dto.ExcerciseResponseDTO
 	for _, excerciseDB := range excercisesDB {
+		score := searchScore(normalized, *excerciseDB, textScores[excerciseDB.ID])
+		if score == 0 {
+			continue
+		}
 		excercise := dto.NewExcerciseResponseDTO(*excerciseDB)
+		excercise.ExcerciseLabelsDTO = dto.NewExcerciseLabelsDTO(*excerciseDB, label)
+		excercise.Score = math.Round(score*1000) / 1000
 		excercises = append(excercises, excercise)
 	}
+	sort.SliceStable(excercises, func(a, b int) bool {
+		if excercises[a].Score != excercises[b].Score {
+			return excercises[a].Score > excercises[b].Score
+		}
+		return excercises[a].Name < excercises[b].Name
+	})
 	return excercises, nil
 }
 
-func (service *ExcerciseService) DeleteExcercise(id string) (bool, error) {
+// resolveFilters reemplaza los filtros de clasificacion por la clave del vocabulario
+func (service *ExcerciseService) resolveFilters(filterDTO *dto.ExerciseFilterDTO) error {
+	vocabularies, err := service.TaxonomyService.Vocabularies()
+	if err != nil {
+		return err
+	}
+	filters := []struct {
+		kind  models.TaxonomyKind
+		value *string
+	}{
+		{models.TaxonomyMuscle, &filterDTO.MuscleGroup},
+		{models.TaxonomyMuscle, &filterDTO.Muscle},
+		{models.TaxonomyEquipment, &filterDTO.Equipment},
+		{models.TaxonomyMechanics, &filterDTO.Mechanics},
+		{models.TaxonomyForce, &filterDTO.Force},
+		{models.TaxonomyLaterality, &filterDTO.Laterality},
+	}
+	for _, filter := range filters {
+		if *filter.value == "" {
+			continue
+		}
+		key, found := vocabularies.Resolve(filter.kind, *filter.value)
+		if !found {
+			return fmt.Errorf("filtro inválido: %q no es un valor de %s", *filter.value, filter.kind)
+		}
+		*filter.value = key
+	}
+	return nil
+}
+
+// defaultInvolvement es la participacion de un musculo secundario cargado sin indicarla
+const defaultInvolvement = 0.5
+
+// classify valida la clasificacion del ejercicio contra los vocabularios y reemplaza cada valor por su clave. El
+// grupo muscular principal es obligatorio; el resto es opcional. Al editar (previous es el ejercicio guardado) solo
+// se validan los campos que cambian, para poder corregir un ejercicio con un valor que ya no esta en el vocabulario
+// sin tener que reclasificarlo entero
+func (service *ExcerciseService) classify(excercise *models.Excercise, previous *models.Excercise) error {
+	vocabularies, err := service.TaxonomyService.Vocabularies()
+	if err != nil {
+		return err
+	}
+	editing := previous != nil
+	if !editing {
+		previous = &models.Excercise{}
+	}
+	changed := func(differs bool) bool { return !editing || differs }
+
+	mainChanged := changed(excercise.MainMuscleGroup != previous.MainMuscleGroup)
+	if mainChanged {
+		primary, found := vocabularies.Resolve(models.TaxonomyMuscle, excercise.MainMuscleGroup)
+		if !found {
+			return fmt.Errorf("grupo muscular inválido: %q no está en el vocabulario", excercise.MainMuscleGroup)
+		}
+		excercise.MainMuscleGroup = primary
+	}
+	// si cambia el principal se revisan igual los secundarios, que no pueden repetirlo
+	if mainChanged || changed(!slices.Equal(excercise.SecondaryMuscles, previous.SecondaryMuscles)) {
+		if err := classifySecondaryMuscles(excercise, vocabularies); err != nil {
+			return err
+		}
+	}
+	if changed(!slices.Equal(excercise.Equipment, previous.Equipment)) {
+		if err := classifyEquipment(excercise, vocabularies); err != nil {
+			return err
+		}
+	}
+
+	single := []struct {
+		kind     models.TaxonomyKind
+		label    string
+		value    *string
+		previous string
+	}{
+		{models.TaxonomyMechanics, "mecánica inválida", &excercise.Mechanics, previous.Mechanics},
+		{models.TaxonomyForce, "tipo de fuerza inválido", &excercise.Force, previous.Force},
+		{models.TaxonomyLaterality, "lateralidad inválida", &excercise.Laterality, previous.Laterality},
+	}
+	for _, field := range single {
+		if strings.TrimSpace(*field.value) == "" {
+			*field.value = ""
+			continue
+		}
+		if !changed(*field.value != field.previous) {
+			continue
+		}
+		key, found := vocabularies.Resolve(field.kind, *field.value)
+		if !found {
+			return fmt.Errorf("%s: %q no está en el vocabulario", field.label, *field.value)
+		}
+		*field.value = key
+	}
+	return nil
+}
+
+// classifySecondaryMuscles valida los musculos secundarios, que no pueden repetirse ni repetir el principal, y los
+// ordena de mayor a menor participacion, que es como se muestran
+func classifySecondaryMuscles(excercise *models.Excercise, vocabularies Vocabularies) error {
+	seen := map[string]bool{excercise.MainMuscleGroup: true}
+	for i, secondary := range excercise.SecondaryMuscles {
+		muscle, found := vocabularies.Resolve(models.TaxonomyMuscle, secondary.Muscle)
+		if !found {
+			return fmt.Errorf("músculo secundario inválido: %q no está en el vocabulario", secondary.Muscle)
+		}
+		if seen[muscle] {
+			return fmt.Errorf("músculo secundario inválido: %s está repetido o es el principal", muscle)
+		}
+		seen[muscle] = true
+		if secondary.Involvement == 0 {
+			secondary.Involvement = defaultInvolvement
+		}
+		if secondary.Involvement < 0 || secondary.Involvement >= 1 {
+			return fmt.Errorf("participación inválida para %s: debe ser mayor a 0 y menor a 1 (el principal es 1)", muscle)
+		}
+		excercise.SecondaryMuscles[i] = models.MuscleInvolvement{Muscle: muscle, Involvement: secondary.Involvement}
+	}
+	sort.SliceStable(excercise.SecondaryMuscles, func(a, b int) bool {
+		return excercise.SecondaryMuscles[a].Involvement > excercise.SecondaryMuscles[b].Involvement
+	})
+	return nil
+}
+
+// classifyEquipment valida el equipamiento y quita los repetidos
+func classifyEquipment(excercise *models.Excercise, vocabularies Vocabularies) error {
+	var equipment []string
+	seen := map[string]bool{}
+	for _, value := range excercise.Equipment {
+		key, found := vocabularies.Resolve(models.TaxonomyEquipment, value)
+		if !found {
+			return fmt.Errorf("equipamiento inválido: %q no está en el vocabulario", value)
+		}
+		if !seen[key] {
+			seen[key] = true
+			equipment = append(equipment, key)
+		}
+	}
+	excercise.Equipment = equipment
+	return nil
+}
+
+// puntajes de busqueda: coincidencias en el nombre, luego en los alias, luego por parecido (errores de tipeo)
+// y por ultimo las que solo encontro el indice de texto en descripcion o instrucciones
+const (
+	searchExact       = 1.0
+	searchPrefix      = 0.9  // el nombre empieza con lo buscado
+	searchWordPrefix  = 0.8  // cada palabra buscada es el comienzo de una palabra del nombre ("pres banc")
+	searchContains    = 0.7  // lo buscado aparece en el medio del nombre
+	searchFuzzy       = 0.6  // multiplicado por el parecido promedio de las palabras
+	searchFuzzyWord   = 0.75 // parecido minimo de una palabra para contar como typo
+	searchAliasFactor = 0.9
+	searchTextMax     = 0.5
+)
+
+// searchPrefixRunes es cuantas letras de cada palabra buscada tienen que coincidir con el comienzo de una palabra del
+// nombre o de un alias para que el ejercicio se puntue; el resto de la palabra puede tener errores de tipeo
+const searchPrefixRunes = 2
+
+// searchAccents son las variantes con acento que el patron acepta para cada letra, porque la expresion regular no
+// usa la collation que ignora acentos
+var searchAccents = map[rune]string{
+	'a': "aáàâä", 'e': "eéèêë", 'i': "iíìîï", 'o': "oóòôö", 'u': "uúùûü", 'n': "nñ", 'c': "cç",
+}
+
+// searchPrefixPattern arma la expresion regular que busca, sin distinguir acentos, alguna palabra que empiece con
+// las primeras letras de alguna de las palabras de query (ya normalizado); "" si no hay palabras
+func searchPrefixPattern(query string) string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, token := range strings.Fields(query) {
+		runes := []rune(token)
+		if len(runes) > searchPrefixRunes {
+			runes = runes[:searchPrefixRunes]
+		}
+		var prefix strings.Builder
+		for _, r := range runes {
+			if variants, ok := searchAccents[r]; ok {
+				prefix.WriteString("[" + variants + "]")
+			} else {
+				prefix.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !seen[prefix.String()] {
+			seen[prefix.String()] = true
+			prefixes = append(prefixes, prefix.String())
+		}
+	}
+	if len(prefixes) == 0 {
+		return ""
+	}
+	return `(^|[^\p{L}\p{N}])(` + strings.Join(prefixes, "|") + ")"
+}
+
+// searchScore puntua un ejercicio contra el texto buscado ya normalizado; textScore es el puntaje del indice de
+// texto de MongoDB (0 = no lo encontro)
+func searchScore(query string, excercise models.Excercise, textScore float64) float64 {
+	if query == "" {
+		return 0
+	}
+	best := matchScore(query, utils.NormalizeText(excercise.Name))
+	for _, alias := range excercise.Aliases {
+		best = math.Max(best, searchAliasFactor*matchScore(query, utils.NormalizeText(alias)))
+	}
+	if textScore > 0 {
+		best = math.Max(best, searchTextMax*textScore/(textScore+1))
+	}
+	return best
+}
+
+func matchScore(query string, text string) float64 {
+	switch {
+	case text == "":
+		return 0
+	case text == query:
+		return searchExact
+	case strings.HasPrefix(text, query):
+		return searchPrefix
+	}
+
+	words := strings.Fields(text)
+	tokens := strings.Fields(query)
+	allPrefixes := true
+	similarity := 0.0
+	for _, token := range tokens {
+		prefix, closest := false, 0.0
+		for _, word := range words {
+			if strings.HasPrefix(word, token) {
+				prefix = true
+			}
+			closest = math.Max(closest, utils.Similarity(token, word))
+			if len([]rune(word)) > len([]rune(token)) { // typo en una palabra a medio escribir ("benc" -> "bench")
+				closest = math.Max(closest, utils.Similarity(token, string([]rune(word)[:len([]rune(token))])))
+			}
+		}
+		allPrefixes = allPrefixes && prefix
+		similarity += closest
+	}
+	similarity /= float64(len(tokens))
+
+	switch {
+	case allPrefixes:
+		return searchWordPrefix
+	case strings.Contains(text, query):
+		return searchContains
+	case similarity >= searchFuzzyWord:
+		return searchFuzzy * similarity
+	}
+	return 0
+}
+
+// DeleteExcercise manda el ejercicio a la papelera. Si alguna rutina lo usa, segun el modo no se elimina (block),
+// se quita de esas rutinas (cascade) o se reemplaza en ellas por otro ejercicio de la misma categoria (replace)
+func (service *ExcerciseService) DeleteExcercise(delete dto.ExcerciseDeleteDTO) (*dto.ExcerciseDeleteResultDTO, error) {
 	//VALIDACIONES
-	result, err := service.ExcerciseRepository.GetExcerciseByID(id) //comprobamos que el ejercicio a eliminar existe
+	result, err := service.ExcerciseRepository.GetExcerciseByID(delete.ExcerciseID) //comprobamos que el ejercicio a eliminar existe
 	if err != nil {
-		return false, fmt.Errorf("error al obtener el ejercicio a eliminar: %w", err)
+		if strings.Contains(err.Error(), "inválid") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no existe un ejercicio con el id proporcionado")
 	}
 	if result.ID.IsZero() {
-		return false, fmt.Errorf("no existe un ejercicio con el id proporcionado")
+		return nil, fmt.Errorf("no existe un ejercicio con el id proporcionado")
+	}
+
+	mode := delete.Mode
+	if mode == "" {
+		mode = dto.ExcerciseDeleteBlock
+	}
+	var replacement *models.Excercise
+	if mode == dto.ExcerciseDeleteReplace {
+		if delete.ReplaceWith == "" {
+			return nil, fmt.Errorf("reemplazo inválido: falta replace_with")
+		}
+		if delete.ReplaceWith == delete.ExcerciseID {
+			return nil, fmt.Errorf("reemplazo inválido: no puede ser el mismo ejercicio")
+		}
+		found, err := service.ExcerciseRepository.GetExcerciseByID(delete.ReplaceWith)
+		if err != nil || found.ID.IsZero() {
+			return nil, fmt.Errorf("reemplazo inválido: no existe el ejercicio %s", delete.ReplaceWith)
+		}
+		// la prescripcion de cada entrada (series, cardio, tiempo) depende de la categoria
+		if found.Category != result.Category {
+			return nil, fmt.Errorf("reemplazo inválido: el ejercicio de reemplazo debe ser de la categoría %s", result.Category)
+		}
+		replacement = &found
+	}
+
+	routines, err := service.RoutineRepository.GetRoutinesByExcercise(delete.ExcerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar las rutinas que usan el ejercicio: %w", err)
+	}
+	// los workouts no bloquean en ningun modo: conservan el ejercicio en su snapshot
+	workouts, err := service.WorkoutRepository.CountWorkoutsByExcercise(delete.ExcerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los workouts que usan el ejercicio: %w", err)
 	}
 
 	//LOGICA
-	deleteResult, err := service.ExcerciseRepository.DeleteExcercise(id) //ejecutamos delete en repository
+	response := &dto.ExcerciseDeleteResultDTO{Mode: mode, WorkoutCount: workouts}
+	if len(routines) > 0 {
+		switch mode {
+		case dto.ExcerciseDeleteBlock:
+			return nil, fmt.Errorf("el ejercicio está en uso en %d rutinas (incluidas las de la papelera)", len(routines))
+		case dto.ExcerciseDeleteReplace:
+			response.ReplacedWith = replacement.ID.Hex()
+		}
+		response.RoutinesUpdated, err = service.RoutineService.DetachExcercise(delete.ExcerciseID, replacement, delete.AdminID)
+		if err != nil {
+			return nil, fmt.Errorf("error al actualizar las rutinas que usan el ejercicio (%d actualizadas): %w", response.RoutinesUpdated, err)
+		}
+	}
+
+	deleteResult, err := service.ExcerciseRepository.DeleteExcercise(delete.ExcerciseID) //ejecutamos delete en repository
+	if err != nil {
+		return nil, err
+	}
+	if deleteResult.MatchedCount == 0 {
+		return nil, fmt.Errorf("no se eliminó ningún ejercicio")
+	}
+	response.Deleted = true
+	return response, nil
+}
+
+// GetDependencies informa que rutinas y cuantos workouts usan el ejercicio, para decidir como eliminarlo
+func (service *ExcerciseService) GetDependencies(id string) (*dto.ExcerciseDependenciesDTO, error) {
+	excercise, err := service.ExcerciseRepository.GetExcerciseByID(id)
 	if err != nil {
-		return false, err
+		if strings.Contains(err.Error(), "inválid") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no existe un ejercicio con el id proporcionado")
+	}
+
+	routines, err := service.RoutineRepository.GetRoutinesByExcercise(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las rutinas que usan el ejercicio: %w", err)
+	}
+	workouts, err := service.WorkoutRepository.CountWorkoutsByExcercise(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los workouts que usan el ejercicio: %w", err)
+	}
+
+	dependencies := &dto.ExcerciseDependenciesDTO{
+		ExcerciseID:  utils.GetStringIDFromObjectID(excercise.ID),
+		Routines:     []*dto.ExcerciseRoutineUsageDTO{},
+		WorkoutCount: workouts,
+		BlocksDelete: len(routines) > 0,
 	}
-	if deleteResult.DeletedCount == 0 {
-		return false, fmt.Errorf("no se eliminó ningún ejercicio")
+	for _, r := range routines {
+		dependencies.Routines = append(dependencies.Routines, dto.NewExcerciseRoutineUsageDTO(*r, excercise.ID))
 	}
-	return true, nil
+	return dependencies, nil
 }
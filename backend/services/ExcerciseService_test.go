@@ -0,0 +1,159 @@
+package services
+
+import (
+	"AppFitness/models"
+	"AppFitness/utils"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchPrefixPattern(t *testing.T) {
+	catalog := []models.Excercise{
+		{Name: "Press de banca", Aliases: []string{"Bench press"}},
+		{Name: "Sentadilla búlgara"},
+		{Name: "Remo con barra"},
+		{Name: "Elevación de talones"},
+		{Name: "Dominadas (agarre prono)"},
+	}
+	cases := []struct {
+		name  string
+		query string
+		want  []string // candidatos que ademas puntuan
+	}{
+		{"prefijo del nombre", "pres", []string{"Press de banca"}},
+		{"typo despues de las primeras letras", "sentadila", []string{"Sentadilla búlgara"}},
+		{"acento en el nombre y no en lo buscado", "bulgara", []string{"Sentadilla búlgara"}},
+		{"acento en lo buscado y no en el nombre", "rémo", []string{"Remo con barra"}},
+		{"alias", "bench", []string{"Press de banca"}},
+		{"palabra despues de un parentesis", "agarre", []string{"Dominadas (agarre prono)"}},
+		{"palabras en otro orden", "talones elevacion", []string{"Elevación de talones"}},
+		{"sin coincidencias", "curl", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := utils.NormalizeText(tc.query)
+			pattern := regexp.MustCompile("(?i)" + searchPrefixPattern(query))
+			var got []string
+			for _, e := range catalog {
+				candidate := pattern.MatchString(e.Name)
+				for _, alias := range e.Aliases {
+					candidate = candidate || pattern.MatchString(alias)
+				}
+				if candidate && searchScore(query, e, 0) > 0 {
+					got = append(got, e.Name)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("se obtuvo %v, se esperaba %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("se obtuvo %v, se esperaba %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	if pattern := searchPrefixPattern(""); pattern != "" {
+		t.Fatalf("sin palabras no deberia haber patron, se obtuvo %q", pattern)
+	}
+}
+
+// vocabulariesStub solo responde los vocabularios, que es lo unico que usa classify
+type vocabulariesStub struct {
+	TaxonomyInterface
+	vocabularies Vocabularies
+}
+
+func (s vocabulariesStub) Vocabularies() (Vocabularies, error) {
+	return s.vocabularies, nil
+}
+
+func TestClassify(t *testing.T) {
+	service := &ExcerciseService{TaxonomyService: vocabulariesStub{vocabularies: Vocabularies{
+		models.TaxonomyMuscle: {
+			{Key: "chest", Name: "Pecho", Aliases: []string{"pectorales"}},
+			{Key: "triceps", Name: "Tríceps"},
+			{Key: "shoulders", Name: "Hombros"},
+		},
+		models.TaxonomyEquipment: {{Key: "barbell", Name: "Barra"}},
+		models.TaxonomyMechanics: {{Key: "compound", Name: "Compuesto"}},
+	}}}
+	// guardado antes de que "legacy" y "kettlebell" salieran del vocabulario
+	stored := models.Excercise{
+		MainMuscleGroup:  "chest",
+		SecondaryMuscles: []models.MuscleInvolvement{{Muscle: "legacy", Involvement: 0.3}},
+		Equipment:        []string{"kettlebell"},
+		Mechanics:        "compound",
+	}
+	cases := []struct {
+		name     string
+		input    models.Excercise
+		previous *models.Excercise
+		want     models.Excercise
+		err      string
+	}{
+		{
+			name:  "alta con nombres y alias",
+			input: models.Excercise{MainMuscleGroup: "Pectorales", SecondaryMuscles: []models.MuscleInvolvement{{Muscle: "Hombros", Involvement: 0.2}, {Muscle: "tríceps"}}, Equipment: []string{"Barra", "barbell"}},
+			want:  models.Excercise{MainMuscleGroup: "chest", SecondaryMuscles: []models.MuscleInvolvement{{Muscle: "triceps", Involvement: defaultInvolvement}, {Muscle: "shoulders", Involvement: 0.2}}, Equipment: []string{"barbell"}},
+		},
+		{
+			name:  "alta con un valor fuera del vocabulario",
+			input: models.Excercise{MainMuscleGroup: "chest", Equipment: []string{"kettlebell"}},
+			err:   "equipamiento inválido",
+		},
+		{
+			name:  "alta sin grupo muscular",
+			input: models.Excercise{},
+			err:   "grupo muscular inválido",
+		},
+		{
+			name:     "edicion que no toca la clasificacion",
+			input:    stored,
+			previous: &stored,
+			want:     stored,
+		},
+		{
+			name:     "edicion del equipamiento con un secundario viejo sin tocar",
+			input:    models.Excercise{MainMuscleGroup: "chest", SecondaryMuscles: stored.SecondaryMuscles, Equipment: []string{"Barra"}, Mechanics: "compound"},
+			previous: &stored,
+			want:     models.Excercise{MainMuscleGroup: "chest", SecondaryMuscles: stored.SecondaryMuscles, Equipment: []string{"barbell"}, Mechanics: "compound"},
+		},
+		{
+			name:     "edicion que cambia un campo a un valor fuera del vocabulario",
+			input:    models.Excercise{MainMuscleGroup: "chest", SecondaryMuscles: stored.SecondaryMuscles, Equipment: stored.Equipment, Mechanics: "isolation"},
+			previous: &stored,
+			err:      "mecánica inválida",
+		},
+		{
+			name:     "edicion que cambia el principal revisa los secundarios",
+			input:    models.Excercise{MainMuscleGroup: "triceps", SecondaryMuscles: stored.SecondaryMuscles, Equipment: stored.Equipment},
+			previous: &stored,
+			err:      "músculo secundario inválido",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			excercise := tc.input
+			excercise.SecondaryMuscles = slices.Clone(tc.input.SecondaryMuscles)
+			excercise.Equipment = slices.Clone(tc.input.Equipment)
+			err := service.classify(&excercise, tc.previous)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("se esperaba un error con %q, se obtuvo %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(excercise, tc.want) {
+				t.Errorf("classify = %+v, se esperaba %+v", excercise, tc.want)
+			}
+		})
+	}
+}
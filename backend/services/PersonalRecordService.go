@@ -0,0 +1,272 @@
+package services
+
+import (
+	"AppFitness/dto"
+	"AppFitness/models"
+	"AppFitness/repositories"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonalRecordInterface interface {
+	DetectRecords(workout models.Workout) ([]models.PersonalRecord, error)
+	GetRecords(userID string) ([]*dto.PersonalRecordResponseDTO, error)
+	GetRecordsByExcercise(userID string, excerciseID string) ([]*dto.PersonalRecordResponseDTO, error)
+	RebuildRecords(userID string, excerciseIDs []primitive.ObjectID) error
+}
+
+type PersonalRecordService struct {
+	RecordRepository    repositories.PersonalRecordRepositoryInterface
+	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+}
+
+func NewPersonalRecordService(recordRepository repositories.PersonalRecordRepositoryInterface, excerciseRepository repositories.ExcerciseRepositoryInterface, workoutRepository repositories.WorkoutRepositoryInterface) *PersonalRecordService {
+	return &PersonalRecordService{
+		RecordRepository:    recordRepository,
+		ExcerciseRepository: excerciseRepository,
+		WorkoutRepository:   workoutRepository,
+	}
+}
+
+// DetectRecords compara las series completadas del workout contra los records previos del usuario y guarda los nuevos
+func (s *PersonalRecordService) DetectRecords(workout models.Workout) ([]models.PersonalRecord, error) {
+	var newRecords []models.PersonalRecord
+	now := time.Now()
+
+	for _, exercise := range mergeByExcercise(workout.Exercises) {
+		previous, err := s.RecordRepository.GetRecordsByUserAndExcercise(workout.UserID.Hex(), exercise.ExcerciseID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener records previos: %w", err)
+		}
+		best := newBestRecords(previous)
+		newRecords = append(newRecords, best.beatenBy(workout, exercise, now)...)
+	}
+
+	if len(newRecords) == 0 {
+		return newRecords, nil
+	}
+	if _, err := s.RecordRepository.PostRecords(newRecords); err != nil {
+		return nil, fmt.Errorf("error al guardar records: %w", err)
+	}
+	return newRecords, nil
+}
+
+// RebuildRecords recalcula desde cero los records de los ejercicios indicados recorriendo el historial en orden;
+// se usa cuando un workout se edita, se borra o se registra con fecha pasada y los records guardados dejan de ser validos
+func (s *PersonalRecordService) RebuildRecords(userID string, excerciseIDs []primitive.ObjectID) error {
+	now := time.Now()
+	for _, excerciseID := range excerciseIDs {
+		workouts, err := s.WorkoutRepository.GetWorkoutsByUserAndExcercise(userID, excerciseID.Hex())
+		if err != nil {
+			return fmt.Errorf("error al obtener workouts del ejercicio: %w", err)
+		}
+
+		var records []models.PersonalRecord
+		best := newBestRecords(nil)
+		for _, workout := range workouts {
+			if !workout.IsCompleted() {
+				continue
+			}
+			for _, exercise := range mergeByExcercise(workout.Exercises) {
+				if exercise.ExcerciseID == excerciseID {
+					records = append(records, best.beatenBy(workout, exercise, now)...)
+				}
+			}
+		}
+
+		if _, err := s.RecordRepository.DeleteRecordsByUserAndExcercise(userID, excerciseID.Hex()); err != nil {
+			return fmt.Errorf("error al eliminar records previos: %w", err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if _, err := s.RecordRepository.PostRecords(records); err != nil {
+			return fmt.Errorf("error al guardar records: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PersonalRecordService) GetRecords(userID string) ([]*dto.PersonalRecordResponseDTO, error) {
+	records, err := s.RecordRepository.GetRecordsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener records: %w", err)
+	}
+	return s.toResponse(records), nil
+}
+
+func (s *PersonalRecordService) GetRecordsByExcercise(userID string, excerciseID string) ([]*dto.PersonalRecordResponseDTO, error) {
+	excercise, err := s.ExcerciseRepository.GetExcerciseByID(excerciseID)
+	if err != nil || excercise.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ningún ejercicio con ese ID")
+	}
+
+	records, err := s.RecordRepository.GetRecordsByUserAndExcercise(userID, excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener records: %w", err)
+	}
+	return s.toResponse(records), nil
+}
+
+// toResponse convierte los records a DTO resolviendo el nombre de cada ejercicio una sola vez
+func (s *PersonalRecordService) toResponse(records []models.PersonalRecord) []*dto.PersonalRecordResponseDTO {
+	names := make(map[primitive.ObjectID]string)
+	response := []*dto.PersonalRecordResponseDTO{}
+	for _, r := range records {
+		name, ok := names[r.ExcerciseID]
+		if !ok {
+			excercise, _ := s.ExcerciseRepository.GetExcerciseByID(r.ExcerciseID.Hex())
+			name = excercise.Name
+			names[r.ExcerciseID] = name
+		}
+		response = append(response, dto.NewPersonalRecordResponseDTO(r, name))
+	}
+	return response
+}
+
+// bestRecords guarda el mejor valor vigente por tipo (y por peso en el caso de repeticiones)
+type bestRecords struct {
+	byType   map[models.RecordType]models.PersonalRecord
+	repsByKg map[float64]models.PersonalRecord
+}
+
+func newBestRecords(records []models.PersonalRecord) bestRecords {
+	best := bestRecords{
+		byType:   make(map[models.RecordType]models.PersonalRecord),
+		repsByKg: make(map[float64]models.PersonalRecord),
+	}
+	for _, r := range records {
+		current, exists := best.get(r)
+		if !exists || r.Value > current.Value {
+			best.set(r)
+		}
+	}
+	return best
+}
+
+func (b bestRecords) get(r models.PersonalRecord) (models.PersonalRecord, bool) {
+	if r.Type == models.MostRepsAtWeight {
+		current, ok := b.repsByKg[r.Weight]
+		return current, ok
+	}
+	current, ok := b.byType[r.Type]
+	return current, ok
+}
+
+func (b bestRecords) set(r models.PersonalRecord) {
+	if r.Type == models.MostRepsAtWeight {
+		b.repsByKg[r.Weight] = r
+		return
+	}
+	b.byType[r.Type] = r
+}
+
+// beatenBy devuelve los records que supera el ejercicio del workout y los deja como nuevos mejores valores
+func (b bestRecords) beatenBy(workout models.Workout, exercise models.ExcerciseInWorkout, now time.Time) []models.PersonalRecord {
+	var records []models.PersonalRecord
+	for _, candidate := range recordCandidates(exercise) {
+		if candidate.Value <= 0 {
+			continue // ejercicios sin carga no generan records de peso
+		}
+		current, exists := b.get(candidate)
+		if exists && candidate.Value <= current.Value {
+			continue
+		}
+		candidate.UserID = workout.UserID
+		candidate.ExcerciseID = exercise.ExcerciseID
+		candidate.WorkoutID = workout.ID
+		candidate.Date = workout.Date
+		candidate.CreationDate = now
+		if exists {
+			candidate.PreviousValue = current.Value
+		}
+		b.set(candidate)
+		records = append(records, candidate)
+	}
+	return records
+}
+
+// mergeByExcercise junta las series de un mismo ejercicio que aparece varias veces en el workout (por ejemplo en
+// dos entradas de la rutina), para que la sesion genere un solo record de cada tipo y el volumen sea el total
+func mergeByExcercise(exercises []models.ExcerciseInWorkout) []models.ExcerciseInWorkout {
+	position := make(map[primitive.ObjectID]int, len(exercises))
+	var merged []models.ExcerciseInWorkout
+	for _, exercise := range exercises {
+		i, seen := position[exercise.ExcerciseID]
+		if !seen {
+			position[exercise.ExcerciseID] = len(merged)
+			merged = append(merged, models.ExcerciseInWorkout{ExcerciseID: exercise.ExcerciseID})
+			i = len(merged) - 1
+		}
+		merged[i].Sets = append(merged[i].Sets, exercise.Sets...)
+	}
+	return merged
+}
+
+// recordCandidates calcula los mejores valores de cada tipo de record dentro de un ejercicio del workout
+func recordCandidates(exercise models.ExcerciseInWorkout) []models.PersonalRecord {
+	var heaviest, bestE1RM *models.PersonalRecord
+	repsByKg := make(map[float64]models.PersonalRecord)
+	volume := 0.0
+
+	for _, set := range exercise.Sets {
+		if !set.Completed || set.Repetitions <= 0 {
+			continue // las series fallidas no cuentan como record
+		}
+		volume += set.Weight * float64(set.Repetitions)
+
+		if heaviest == nil || set.Weight > heaviest.Value {
+			heaviest = &models.PersonalRecord{Type: models.HeaviestWeight, Value: set.Weight, Weight: set.Weight, Repetitions: set.Repetitions}
+		}
+		e1rm := estimateOneRepMax(set.Weight, set.Repetitions, Epley)
+		if bestE1RM == nil || e1rm > bestE1RM.Value {
+			bestE1RM = &models.PersonalRecord{Type: models.BestEstimated1RM, Value: e1rm, Weight: set.Weight, Repetitions: set.Repetitions}
+		}
+		if current, ok := repsByKg[set.Weight]; !ok || set.Repetitions > current.Repetitions {
+			repsByKg[set.Weight] = models.PersonalRecord{Type: models.MostRepsAtWeight, Value: float64(set.Repetitions), Weight: set.Weight, Repetitions: set.Repetitions}
+		}
+	}
+
+	var candidates []models.PersonalRecord
+	if heaviest == nil {
+		return candidates
+	}
+	candidates = append(candidates, *heaviest, *bestE1RM)
+	for _, r := range repsByKg {
+		candidates = append(candidates, r)
+	}
+	if volume > 0 {
+		candidates = append(candidates, models.PersonalRecord{Type: models.HighestVolume, Value: volume})
+	}
+	return candidates
+}
+
+type OneRepMaxFormula string
+
+const (
+	Epley    OneRepMaxFormula = "epley"
+	Brzycki  OneRepMaxFormula = "brzycki"
+	Lombardi OneRepMaxFormula = "lombardi"
+)
+
+// estimateOneRepMax estima el 1RM de una serie con la formula elegida (Epley por defecto)
+func estimateOneRepMax(weight float64, reps int, formula OneRepMaxFormula) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	switch formula {
+	case Brzycki:
+		if reps >= 37 { // la formula no esta definida a partir de 37 repeticiones, usamos Epley
+			return weight * (1 + float64(reps)/30.0)
+		}
+		return weight * 36 / (37 - float64(reps))
+	case Lombardi:
+		return weight * math.Pow(float64(reps), 0.10)
+	default:
+		return weight * (1 + float64(reps)/30.0)
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"AppFitness/models"
+	"math"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMergeByExcerciseJoinsRepeatedEntries(t *testing.T) {
+	squat, bench := primitive.NewObjectID(), primitive.NewObjectID()
+	merged := mergeByExcercise([]models.ExcerciseInWorkout{
+		{ExcerciseID: squat, Sets: []models.WorkoutSet{{Repetitions: 5, Weight: 100, Completed: true}}},
+		{ExcerciseID: bench, Sets: []models.WorkoutSet{{Repetitions: 8, Weight: 60, Completed: true}}},
+		{ExcerciseID: squat, Sets: []models.WorkoutSet{{Repetitions: 3, Weight: 110, Completed: true}}},
+	})
+	if len(merged) != 2 || merged[0].ExcerciseID != squat || merged[1].ExcerciseID != bench {
+		t.Fatalf("se esperaban dos ejercicios en el orden original: %+v", merged)
+	}
+	if len(merged[0].Sets) != 2 {
+		t.Fatalf("las series de la sentadilla deberian juntarse: %+v", merged[0].Sets)
+	}
+}
+
+// un ejercicio repetido en el workout genera un solo record por tipo, y el volumen suma las dos entradas
+func TestRepeatedExcerciseProducesOneRecordPerType(t *testing.T) {
+	squat := primitive.NewObjectID()
+	workout := models.Workout{
+		ID:     primitive.NewObjectID(),
+		UserID: primitive.NewObjectID(),
+		Exercises: []models.ExcerciseInWorkout{
+			{ExcerciseID: squat, Sets: []models.WorkoutSet{{Repetitions: 5, Weight: 100, Completed: true}}},
+			{ExcerciseID: squat, Sets: []models.WorkoutSet{{Repetitions: 3, Weight: 110, Completed: true}}},
+		},
+	}
+	best := newBestRecords(nil)
+	var records []models.PersonalRecord
+	for _, exercise := range mergeByExcercise(workout.Exercises) {
+		records = append(records, best.beatenBy(workout, exercise, time.Now())...)
+	}
+
+	byType := map[models.RecordType]int{}
+	for _, r := range records {
+		byType[r.Type]++
+		if r.Type == models.HighestVolume && r.Value != 830 {
+			t.Errorf("volumen = %g, se esperaba 830", r.Value)
+		}
+		if r.Type == models.HeaviestWeight && r.Value != 110 {
+			t.Errorf("peso maximo = %g, se esperaba 110", r.Value)
+		}
+	}
+	for _, kind := range []models.RecordType{models.HeaviestWeight, models.BestEstimated1RM, models.HighestVolume} {
+		if byType[kind] != 1 {
+			t.Errorf("%s: %d records, se esperaba 1", kind, byType[kind])
+		}
+	}
+	if byType[models.MostRepsAtWeight] != 2 { // uno por cada peso distinto
+		t.Errorf("most_reps: %d records, se esperaban 2", byType[models.MostRepsAtWeight])
+	}
+}
+
+func TestBeatenByIgnoresEqualOrWorse(t *testing.T) {
+	squat := primitive.NewObjectID()
+	best := newBestRecords([]models.PersonalRecord{{Type: models.HeaviestWeight, Value: 120}})
+	records := best.beatenBy(models.Workout{}, models.ExcerciseInWorkout{
+		ExcerciseID: squat,
+		Sets:        []models.WorkoutSet{{Repetitions: 1, Weight: 120, Completed: true}, {Repetitions: 10, Weight: 200}},
+	}, time.Now())
+	for _, r := range records {
+		if r.Type == models.HeaviestWeight {
+			t.Fatalf("igualar el record o una serie no completada no deberia generar uno nuevo: %+v", r)
+		}
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	cases := []struct {
+		formula OneRepMaxFormula
+		want    float64
+	}{
+		{Epley, 100 * (1 + 10.0/30)},
+		{Brzycki, 100 * 36 / 27.0},
+		{Lombardi, 100 * math.Pow(10, 0.1)},
+	}
+	for _, tc := range cases {
+		if got := estimateOneRepMax(100, 10, tc.formula); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: %g, se esperaba %g", tc.formula, got, tc.want)
+		}
+	}
+	if estimateOneRepMax(100, 1, Brzycki) != 100 {
+		t.Error("con una repeticion el 1RM es el peso levantado")
+	}
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"AppFitness/dto"
+	"AppFitness/models"
+	"AppFitness/repositories"
+	"AppFitness/utils"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProgramInterface interface {
+	PostProgram(programDTO *dto.ProgramRegisterDTO) (*dto.ProgramResponseDTO, error)
+	GetPrograms(userID string) ([]*dto.ProgramResponseDTO, error)
+	GetProgramByID(programID string, userID string) (*dto.ProgramResponseDTO, error)
+	PutProgram(programDTO *dto.ProgramRegisterDTO) (*dto.ProgramResponseDTO, error)
+	DeleteProgram(programID string, userID string) error
+	Enroll(enroll dto.ProgramEnrollDTO) (*dto.EnrollmentResponseDTO, error)
+	GetEnrollment(userID string) (*dto.EnrollmentResponseDTO, error)
+	CancelEnrollment(userID string) error
+	GetToday(filter dto.ProgramTodayFilterDTO) (*dto.ProgramTodayDTO, error)
+}
+
+type ProgramService struct {
+	ProgramRepository repositories.ProgramRepositoryInterface
+	RoutineRepository repositories.RoutineRepositoryInterface
+	RecordRepository  repositories.PersonalRecordRepositoryInterface
+}
+
+func NewProgramService(programRepository repositories.ProgramRepositoryInterface, routineRepository repositories.RoutineRepositoryInterface, recordRepository repositories.PersonalRecordRepositoryInterface) *ProgramService {
+	return &ProgramService{
+		ProgramRepository: programRepository,
+		RoutineRepository: routineRepository,
+		RecordRepository:  recordRepository,
+	}
+}
+
+// defaultDeloadFactor es la fraccion del peso que se usa en las semanas de descarga si el programa no indica otra
+const defaultDeloadFactor = 0.6
+
+func (s *ProgramService) PostProgram(programDTO *dto.ProgramRegisterDTO) (*dto.ProgramResponseDTO, error) {
+	userOID, err := utils.GetObjectIDFromStringID(programDTO.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuario con formato inválido: %w", err)
+	}
+	weeks, names, err := s.buildWeeks(programDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	program := models.Program{
+		Name:          programDTO.Name,
+		Description:   programDTO.Description,
+		CreatorUserID: userOID,
+		Weeks:         weeks,
+		Progression:   dto.GetModelProgressionRuleDTO(programDTO.Progression),
+		Visibility:    programVisibility(programDTO.Visibility),
+		CreationDate:  now,
+		EditionDate:   now,
+	}
+	result, err := s.ProgramRepository.PostProgram(program)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el programa: %w", err)
+	}
+	program.ID = result.InsertedID.(primitive.ObjectID)
+
+	return dto.NewProgramResponseDTO(program, names), nil
+}
+
+func (s *ProgramService) GetPrograms(userID string) ([]*dto.ProgramResponseDTO, error) {
+	programs, err := s.ProgramRepository.GetProgramsByCreator(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*dto.ProgramResponseDTO, 0, len(programs))
+	for _, program := range programs {
+		result = append(result, dto.NewProgramResponseDTO(program, s.routineNames(program)))
+	}
+	return result, nil
+}
+
+func (s *ProgramService) GetProgramByID(programID string, userID string) (*dto.ProgramResponseDTO, error) {
+	program, err := s.visibleProgram(programID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewProgramResponseDTO(program, s.routineNames(program)), nil
+}
+
+// PutProgram reemplaza semanas y progresion; las inscripciones activas siguen en la semana que les toca por fecha
+func (s *ProgramService) PutProgram(programDTO *dto.ProgramRegisterDTO) (*dto.ProgramResponseDTO, error) {
+	program, err := s.ownedProgram(programDTO.ProgramID, programDTO.UserID)
+	if err != nil {
+		return nil, err
+	}
+	weeks, names, err := s.buildWeeks(programDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	program.Name = programDTO.Name
+	program.Description = programDTO.Description
+	program.Weeks = weeks
+	program.Progression = dto.GetModelProgressionRuleDTO(programDTO.Progression)
+	program.Visibility = programVisibility(programDTO.Visibility)
+	program.EditionDate = time.Now()
+	if _, err := s.ProgramRepository.PutProgram(program); err != nil {
+		return nil, fmt.Errorf("error al modificar el programa: %w", err)
+	}
+
+	return dto.NewProgramResponseDTO(program, names), nil
+}
+
+func (s *ProgramService) DeleteProgram(programID string, userID string) error {
+	program, err := s.ownedProgram(programID, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.ProgramRepository.DeleteProgram(programID); err != nil {
+		return fmt.Errorf("error al eliminar el programa: %w", err)
+	}
+	// sin el programa las inscripciones no tienen nada que mostrar; se cancelan para liberar al usuario
+	if _, err := s.ProgramRepository.CancelEnrollmentsByProgram(program.ID); err != nil {
+		log.Printf("No se pudieron cancelar las inscripciones del programa %s: %v", programID, err)
+	}
+	return nil
+}
+
+// Enroll inscribe al usuario en un programa propio o compartido por otro usuario
+func (s *ProgramService) Enroll(enroll dto.ProgramEnrollDTO) (*dto.EnrollmentResponseDTO, error) {
+	program, err := s.visibleProgram(enroll.ProgramID, enroll.UserID)
+	if err != nil {
+		return nil, err
+	}
+	userOID, err := utils.GetObjectIDFromStringID(enroll.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuario con formato inválido: %w", err)
+	}
+	if enroll.StartDate == "" {
+		enroll.StartDate = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", enroll.StartDate); err != nil {
+		return nil, fmt.Errorf("fecha inválida %q: use YYYY-MM-DD", enroll.StartDate)
+	}
+
+	active, err := s.ProgramRepository.GetActiveEnrollment(enroll.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !active.ID.IsZero() {
+		return nil, fmt.Errorf("ya tiene un programa activo")
+	}
+
+	enrollment := models.ProgramEnrollment{
+		ProgramID:    program.ID,
+		UserID:       userOID,
+		StartDate:    enroll.StartDate,
+		Status:       models.EnrollmentActive,
+		CreationDate: time.Now(),
+	}
+	result, err := s.ProgramRepository.PostEnrollment(enrollment)
+	if err != nil {
+		return nil, err
+	}
+	enrollment.ID = result.InsertedID.(primitive.ObjectID)
+
+	week, _ := programWeek(enrollment.StartDate, time.Now().In(time.UTC))
+	return dto.NewEnrollmentResponseDTO(enrollment, program, min(week, len(program.Weeks))), nil
+}
+
+func (s *ProgramService) GetEnrollment(userID string) (*dto.EnrollmentResponseDTO, error) {
+	enrollment, program, err := s.activeEnrollment(userID)
+	if err != nil {
+		return nil, err
+	}
+	week, _ := programWeek(enrollment.StartDate, time.Now().In(time.UTC))
+	return dto.NewEnrollmentResponseDTO(enrollment, program, min(week, len(program.Weeks))), nil
+}
+
+func (s *ProgramService) CancelEnrollment(userID string) error {
+	active, err := s.ProgramRepository.GetActiveEnrollment(userID)
+	if err != nil {
+		return err
+	}
+	if active.ID.IsZero() {
+		return fmt.Errorf("no tiene ningun programa activo")
+	}
+	if _, err := s.ProgramRepository.CloseEnrollment(active.ID, models.EnrollmentCancelled, time.Now()); err != nil {
+		return fmt.Errorf("error al cancelar la inscripcion: %w", err)
+	}
+	return nil
+}
+
+// GetToday resuelve la semana del programa segun la fecha de inicio y devuelve la rutina del dia con la progresion
+// de esa semana aplicada. Al pasar la ultima semana la inscripcion se marca como completada. Si la rutina del dia
+// esta en la papelera o el creador dejo de compartirla se informa como unavailable en lugar de fallar
+func (s *ProgramService) GetToday(filter dto.ProgramTodayFilterDTO) (*dto.ProgramTodayDTO, error) {
+	if filter.TimeZone == "" {
+		filter.TimeZone = "UTC"
+	}
+	location, err := time.LoadLocation(filter.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria inválida: %s", filter.TimeZone)
+	}
+	enrollment, program, err := s.activeEnrollment(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(location)
+	week, err := programWeek(enrollment.StartDate, now)
+	if err != nil {
+		return nil, err
+	}
+	today := &dto.ProgramTodayDTO{
+		Date:        now.Format("2006-01-02"),
+		ProgramID:   utils.GetStringIDFromObjectID(program.ID),
+		ProgramName: program.Name,
+		Week:        week,
+		TotalWeeks:  len(program.Weeks),
+	}
+
+	switch {
+	case week < 1:
+		today.Status = "not_started"
+		return today, nil
+	case week > len(program.Weeks):
+		today.Status = "completed"
+		if _, err := s.ProgramRepository.CloseEnrollment(enrollment.ID, models.EnrollmentCompleted, time.Now()); err != nil {
+			log.Printf("No se pudo completar la inscripcion %s: %v", enrollment.ID.Hex(), err)
+		}
+		return today, nil
+	}
+
+	current := program.Weeks[week-1]
+	today.Deload = current.Deload
+	today.Status = "rest"
+	for _, day := range current.Days {
+		if day.Weekday != int(now.Weekday()) {
+			continue
+		}
+		routine, err := s.RoutineRepository.GetRoutineByID(day.RoutineID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener la rutina del dia: %w", err)
+		}
+		if routine == nil || routine.ID.IsZero() || !routine.VisibleTo(filter.UserID) {
+			today.Status = "unavailable"
+			today.RoutineID = utils.GetStringIDFromObjectID(day.RoutineID)
+			break
+		}
+		s.applyProgression(routine, program.Progression, current, filter.UserID)
+		today.Status = "training"
+		today.SetRoutine(*routine)
+		break
+	}
+	return today, nil
+}
+
+// applyProgression ajusta en memoria las entradas de la rutina a la semana del programa. Las de cardio y
+// flexibilidad no se tocan; en las de repeticiones se ajustan las series de trabajo y se dejan los calentamientos
+func (s *ProgramService) applyProgression(routine *models.Routine, rule models.ProgressionRule, week models.ProgramWeek, userID string) {
+	for i, entry := range routine.ExcerciseList {
+		if entry.IsTimed() {
+			continue
+		}
+		oneRM := 0.0
+		if rule.Type == models.PercentageProgression {
+			oneRM = s.bestEstimated1RM(userID, entry.ExcerciseID.Hex())
+		}
+
+		sets := entry.Prescription()
+		for j, set := range sets {
+			if set.Type == models.WarmupSet {
+				continue
+			}
+			set.Weight, set.MinReps, set.MaxReps = progressSet(rule, week.Number, set, oneRM)
+			if week.Deload {
+				factor := rule.DeloadFactor
+				if factor == 0 {
+					factor = defaultDeloadFactor
+				}
+				set.Weight = roundToPlate(set.Weight * factor)
+				set.Count = (set.Count + 1) / 2
+			}
+			sets[j] = set
+		}
+		entry.Sets = sets
+		entry.Series, entry.Repetitions, entry.Weight = models.SummarizeSets(sets)
+		routine.ExcerciseList[i] = entry
+	}
+}
+
+// progressSet calcula peso y repeticiones de una serie de trabajo para la semana indicada (1 a N). El porcentaje
+// del 1RM solo tiene sentido en las series de trabajo: los drop sets conservan el peso de la rutina
+func progressSet(rule models.ProgressionRule, week int, set models.SetPrescription, oneRM float64) (float64, int, int) {
+	switch rule.Type {
+	case models.LinearProgression:
+		steps := week - 1
+		return set.Weight + rule.WeightIncrement*float64(steps), set.MinReps + rule.RepsIncrement*steps, set.MaxReps + rule.RepsIncrement*steps
+	case models.PercentageProgression:
+		if oneRM <= 0 || len(rule.Percentages) == 0 || set.Type != models.WorkingSet { // sin 1RM estimado se mantiene el peso de la rutina
+			return set.Weight, set.MinReps, set.MaxReps
+		}
+		percentage := rule.Percentages[min(week, len(rule.Percentages))-1]
+		return roundToPlate(oneRM * percentage), set.MinReps, set.MaxReps
+	case models.WaveProgression:
+		if len(rule.Pattern) == 0 {
+			return set.Weight, set.MinReps, set.MaxReps
+		}
+		return roundToPlate(set.Weight * rule.Pattern[(week-1)%len(rule.Pattern)]), set.MinReps, set.MaxReps
+	default:
+		return set.Weight, set.MinReps, set.MaxReps
+	}
+}
+
+// roundToPlate redondea al multiplo de 2.5 kg mas cercano, el salto mas chico con discos estandar
+func roundToPlate(weight float64) float64 {
+	return math.Round(weight/2.5) * 2.5
+}
+
+func (s *ProgramService) bestEstimated1RM(userID string, excerciseID string) float64 {
+	records, err := s.RecordRepository.GetRecordsByUserAndExcercise(userID, excerciseID)
+	if err != nil {
+		log.Printf("No se pudieron obtener los records para la progresion: %v", err)
+		return 0
+	}
+	best := 0.0
+	for _, record := range records {
+		if record.Type == models.BestEstimated1RM && record.Value > best {
+			best = record.Value
+		}
+	}
+	return best
+}
+
+// programWeek devuelve la semana (1 a N) que corresponde a la fecha; 0 o menos si el programa todavia no empezo
+func programWeek(startDate string, now time.Time) (int, error) {
+	start, err := time.ParseInLocation("2006-01-02", startDate, now.Location())
+	if err != nil {
+		return 0, fmt.Errorf("fecha de inicio inválida en la inscripcion: %w", err)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := int(math.Floor(today.Sub(start).Hours()/24 + 0.5)) // redondeo por los cambios de horario
+	if days < 0 {
+		return 0, nil
+	}
+	return days/7 + 1, nil
+}
+
+// buildWeeks valida las semanas y la progresion y devuelve las semanas del modelo junto con los nombres de las rutinas
+func (s *ProgramService) buildWeeks(programDTO *dto.ProgramRegisterDTO) ([]models.ProgramWeek, map[string]string, error) {
+	rule := programDTO.Progression
+	switch models.ProgressionType(rule.Type) {
+	case models.PercentageProgression:
+		if len(rule.Percentages) != len(programDTO.Weeks) {
+			return nil, nil, fmt.Errorf("progresion inválida: debe indicar un porcentaje por semana")
+		}
+	case models.WaveProgression:
+		if len(rule.Pattern) == 0 {
+			return nil, nil, fmt.Errorf("progresion inválida: debe indicar el patron de la onda")
+		}
+	}
+
+	shared := programVisibility(programDTO.Visibility) != models.RoutinePrivate
+	names := make(map[string]string)
+	weeks := make([]models.ProgramWeek, 0, len(programDTO.Weeks))
+	for i, weekDTO := range programDTO.Weeks {
+		week := models.ProgramWeek{Number: i + 1, Deload: weekDTO.Deload, Days: []models.ProgramDay{}}
+		seen := make(map[int]bool)
+		for _, dayDTO := range weekDTO.Days {
+			if seen[dayDTO.Weekday] {
+				return nil, nil, fmt.Errorf("semana %d inválida: el dia %d esta repetido", i+1, dayDTO.Weekday)
+			}
+			seen[dayDTO.Weekday] = true
+
+			routine, err := s.RoutineRepository.GetRoutineByID(dayDTO.RoutineID)
+			if err != nil || routine == nil || routine.ID.IsZero() || !routine.VisibleTo(programDTO.UserID) {
+				return nil, nil, fmt.Errorf("rutina no encontrada: %s", dayDTO.RoutineID)
+			}
+			// quien sigue un programa compartido tiene que poder ver cada una de sus rutinas
+			if shared && routine.Visibility != models.RoutinePublic && routine.Visibility != models.RoutineUnlisted {
+				return nil, nil, fmt.Errorf("rutina inválida en un programa compartido: %s es privada", dayDTO.RoutineID)
+			}
+			names[routine.ID.Hex()] = routine.Name
+			week.Days = append(week.Days, models.ProgramDay{Weekday: dayDTO.Weekday, RoutineID: routine.ID})
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks, names, nil
+}
+
+// routineNames resuelve los nombres de las rutinas del programa; las borradas quedan sin nombre
+func (s *ProgramService) routineNames(program models.Program) map[string]string {
+	names := make(map[string]string)
+	for _, week := range program.Weeks {
+		for _, day := range week.Days {
+			id := day.RoutineID.Hex()
+			if _, ok := names[id]; ok {
+				continue
+			}
+			routine, err := s.RoutineRepository.GetRoutineByID(id)
+			if err != nil || routine == nil {
+				names[id] = ""
+				continue
+			}
+			names[id] = routine.Name
+		}
+	}
+	return names
+}
+
+// programVisibility aplica el default de los programas nuevos: privados de quien los crea
+func programVisibility(visibility string) models.RoutineVisibility {
+	if visibility == "" {
+		return models.RoutinePrivate
+	}
+	return models.RoutineVisibility(visibility)
+}
+
+// visibleProgram obtiene un programa que el usuario puede ver y seguir: propio, publico o no listado. Uno privado
+// ajeno se informa como inexistente, igual que las rutinas
+func (s *ProgramService) visibleProgram(programID string, userID string) (models.Program, error) {
+	if _, err := utils.GetObjectIDFromStringID(programID); err != nil {
+		return models.Program{}, fmt.Errorf("ID de programa con formato inválido")
+	}
+	program, err := s.ProgramRepository.GetProgramByID(programID)
+	if err != nil {
+		return models.Program{}, err
+	}
+	if program.ID.IsZero() || !program.VisibleTo(userID) {
+		return models.Program{}, fmt.Errorf("programa no encontrado")
+	}
+	return program, nil
+}
+
+// ownedProgram obtiene un programa para modificarlo o borrarlo, cosa que solo puede hacer su creador
+func (s *ProgramService) ownedProgram(programID string, userID string) (models.Program, error) {
+	program, err := s.visibleProgram(programID, userID)
+	if err != nil {
+		return models.Program{}, err
+	}
+	if program.CreatorUserID.Hex() != userID {
+		return models.Program{}, fmt.Errorf("Al no ser el creador de este programa no se brinda permisos para dicha accion")
+	}
+	return program, nil
+}
+
+// activeEnrollment devuelve la inscripcion activa del usuario junto con su programa
+func (s *ProgramService) activeEnrollment(userID string) (models.ProgramEnrollment, models.Program, error) {
+	enrollment, err := s.ProgramRepository.GetActiveEnrollment(userID)
+	if err != nil {
+		return models.ProgramEnrollment{}, models.Program{}, err
+	}
+	if enrollment.ID.IsZero() {
+		return models.ProgramEnrollment{}, models.Program{}, fmt.Errorf("no tiene ningun programa activo")
+	}
+	program, err := s.ProgramRepository.GetProgramByID(enrollment.ProgramID.Hex())
+	if err != nil {
+		return models.ProgramEnrollment{}, models.Program{}, err
+	}
+	if program.ID.IsZero() {
+		return models.ProgramEnrollment{}, models.Program{}, fmt.Errorf("programa no encontrado")
+	}
+	return enrollment, program, nil
+}
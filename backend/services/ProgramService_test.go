@@ -0,0 +1,113 @@
+package services
+
+import (
+	"AppFitness/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProgressSet(t *testing.T) {
+	working := models.SetPrescription{Type: models.WorkingSet, Count: 3, MinReps: 8, MaxReps: 10, Weight: 60}
+	drop := models.SetPrescription{Type: models.DropSet, Count: 1, MinReps: 12, MaxReps: 12, Weight: 40, Drops: 2}
+	cases := []struct {
+		name   string
+		rule   models.ProgressionRule
+		week   int
+		set    models.SetPrescription
+		oneRM  float64
+		weight float64
+		min    int
+		max    int
+	}{
+		{"lineal primera semana", models.ProgressionRule{Type: models.LinearProgression, WeightIncrement: 2.5, RepsIncrement: 1}, 1, working, 0, 60, 8, 10},
+		{"lineal tercera semana", models.ProgressionRule{Type: models.LinearProgression, WeightIncrement: 2.5, RepsIncrement: 1}, 3, working, 0, 65, 10, 12},
+		{"porcentaje del 1RM", models.ProgressionRule{Type: models.PercentageProgression, Percentages: []float64{0.7, 0.75}}, 2, working, 100, 75, 8, 10},
+		{"porcentaje redondea al disco", models.ProgressionRule{Type: models.PercentageProgression, Percentages: []float64{0.7}}, 1, working, 103, 72.5, 8, 10},
+		{"porcentaje sin 1RM mantiene el peso", models.ProgressionRule{Type: models.PercentageProgression, Percentages: []float64{0.7}}, 1, working, 0, 60, 8, 10},
+		{"porcentaje no toca los drop sets", models.ProgressionRule{Type: models.PercentageProgression, Percentages: []float64{0.9}}, 1, drop, 100, 40, 12, 12},
+		{"onda en ciclo", models.ProgressionRule{Type: models.WaveProgression, Pattern: []float64{1, 1.1}}, 4, working, 0, 65, 8, 10},
+		{"sin progresion", models.ProgressionRule{Type: models.NoProgression}, 5, working, 0, 60, 8, 10},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			weight, minReps, maxReps := progressSet(tc.rule, tc.week, tc.set, tc.oneRM)
+			if weight != tc.weight || minReps != tc.min || maxReps != tc.max {
+				t.Fatalf("%g kg %d-%d, se esperaba %g kg %d-%d", weight, minReps, maxReps, tc.weight, tc.min, tc.max)
+			}
+		})
+	}
+}
+
+func TestApplyProgressionDeload(t *testing.T) {
+	routine := &models.Routine{ExcerciseList: []models.ExcerciseInRoutine{
+		{ExcerciseID: primitive.NewObjectID(), Sets: []models.SetPrescription{
+			{Type: models.WarmupSet, Count: 2, MinReps: 10, MaxReps: 10, Weight: 20},
+			{Type: models.WorkingSet, Count: 4, MinReps: 5, MaxReps: 5, Weight: 100},
+		}},
+		{ExcerciseID: primitive.NewObjectID(), Series: 1, Cardio: &models.CardioTarget{DurationSeconds: 600}},
+	}}
+	rule := models.ProgressionRule{Type: models.LinearProgression, WeightIncrement: 5}
+
+	var s ProgramService
+	s.applyProgression(routine, rule, models.ProgramWeek{Number: 2, Deload: true}, "")
+
+	sets := routine.ExcerciseList[0].Sets
+	if sets[0].Weight != 20 || sets[0].Count != 2 {
+		t.Errorf("el calentamiento no deberia cambiar: %+v", sets[0])
+	}
+	if sets[1].Weight != 62.5 || sets[1].Count != 2 { // (100 + 5) * 0.6 = 63 -> 62.5, la mitad de las series
+		t.Errorf("serie de descarga inesperada: %+v", sets[1])
+	}
+	if routine.ExcerciseList[0].Weight != 62.5 || routine.ExcerciseList[0].Series != 2 {
+		t.Errorf("los campos simples no se resumieron: %+v", routine.ExcerciseList[0])
+	}
+	if routine.ExcerciseList[1].Sets != nil {
+		t.Errorf("el cardio no deberia progresar: %+v", routine.ExcerciseList[1])
+	}
+}
+
+func TestProgramWeek(t *testing.T) {
+	now := time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		start string
+		want  int
+	}{
+		{"2024-03-20", 1},
+		{"2024-03-14", 1},
+		{"2024-03-13", 2},
+		{"2024-03-21", 0},
+	}
+	for _, tc := range cases {
+		got, err := programWeek(tc.start, now)
+		if err != nil {
+			t.Fatalf("error inesperado: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("inicio %s: semana %d, se esperaba %d", tc.start, got, tc.want)
+		}
+	}
+	if _, err := programWeek("20/03/2024", now); err == nil {
+		t.Error("una fecha mal formada deberia fallar")
+	}
+}
+
+func TestProgramVisibleTo(t *testing.T) {
+	creator := primitive.NewObjectID()
+	other := primitive.NewObjectID().Hex()
+	for visibility, want := range map[models.RoutineVisibility]bool{
+		"":                     false,
+		models.RoutinePrivate:  false,
+		models.RoutineUnlisted: true,
+		models.RoutinePublic:   true,
+	} {
+		program := models.Program{CreatorUserID: creator, Visibility: visibility}
+		if !program.VisibleTo(creator.Hex()) {
+			t.Errorf("%q: el creador siempre ve su programa", visibility)
+		}
+		if program.VisibleTo(other) != want {
+			t.Errorf("%q: visible para otro usuario = %v, se esperaba %v", visibility, !want, want)
+		}
+	}
+}
@@ -2,35 +2,56 @@ package services
 
 import (
 	"AppFitness/dto"
+	"AppFitness/models"
 	"AppFitness/repositories"
 	"AppFitness/utils"
 	"fmt"
+	"log"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/gin-gonic/gin/binding"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type RoutineInterface interface {
 	PostRoutine(routineDTO *dto.RoutineRegisterDTO) (*dto.RoutineResponseDTO, error)
-	GetRoutines() ([]*dto.RoutineResponseDTO, error)
-	GetRoutineByID(id string) (*dto.RoutineResponseDTO, error)
+	GetRoutines(userID string, page dto.PageQueryDTO) (*dto.PageDTO, error)
+	GetRoutineByID(id string, userID string) (*dto.RoutineResponseDTO, error)
 	PutRoutine(modify dto.RoutineModifyDTO) (*dto.RoutineResponseDTO, error)
 	AddExcerciseToRoutine(routineID string, exercise *dto.ExcerciseInRoutineDTO, idEditor string) (*dto.RoutineResponseDTO, error)
 	RemoveExcerciseFromRoutine(idEditor string, remove dto.RoutineRemoveDTO) (*dto.RoutineResponseDTO, error)
 	UpdateExerciseInRoutine(idEditor string, exerciseMod *dto.ExcerciseInRoutineModifyDTO) (*dto.RoutineResponseDTO, error)
 	DeleteRoutine(id string, idEditor string) (bool, error)
+	RestoreRoutine(id string, idEditor string) (*dto.RoutineResponseDTO, error)
+	DetachExcercise(excerciseID string, replacement *models.Excercise, idEditor string) (int, error)
+	GetRoutineVersions(routineID string, idEditor string) ([]*dto.RoutineVersionDTO, error)
+	RollbackRoutine(rollback dto.RoutineRollbackDTO) (*dto.RoutineResponseDTO, error)
+	CloneRoutine(clone dto.RoutineCloneDTO) (*dto.RoutineResponseDTO, error)
+	SetRoutineVisibility(visibility dto.RoutineVisibilityDTO) (*dto.RoutineResponseDTO, error)
+	SetRoutineFeatured(featured dto.RoutineFeaturedDTO) (*dto.RoutineResponseDTO, error)
+	SearchPublicRoutines(filter dto.PublicRoutineFilterDTO) ([]*dto.PublicRoutineDTO, error)
+	ReorderExercises(reorder dto.RoutineReorderDTO) (*dto.RoutineResponseDTO, error)
+	PostExcerciseGroup(group dto.RoutineGroupRegisterDTO) (*dto.RoutineResponseDTO, error)
+	DeleteExcerciseGroup(routineID string, groupID string, idEditor string) (*dto.RoutineResponseDTO, error)
+	ExportRoutines(userID string, routineID string) ([]dto.RoutineExportDTO, error)
+	ImportRoutines(importDTO dto.RoutineImportDTO) (*dto.RoutineImportResultDTO, error)
 }
 
 type RoutineService struct {
 	RoutineRepository   repositories.RoutineRepositoryInterface
 	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+	VersionRepository   repositories.RoutineVersionRepositoryInterface
+	TaxonomyService     TaxonomyInterface
 }
 
-func NewRoutineService(routineRepository repositories.RoutineRepositoryInterface, excerciseRspository repositories.ExcerciseRepositoryInterface) *RoutineService {
+func NewRoutineService(routineRepository repositories.RoutineRepositoryInterface, excerciseRspository repositories.ExcerciseRepositoryInterface, versionRepository repositories.RoutineVersionRepositoryInterface, taxonomyService TaxonomyInterface) *RoutineService {
 	return &RoutineService{
 		RoutineRepository:   routineRepository,
 		ExcerciseRepository: excerciseRspository,
+		VersionRepository:   versionRepository,
+		TaxonomyService:     taxonomyService,
 	}
 }
 
@@ -45,7 +66,13 @@ func (service *RoutineService) PostRoutine(routineDTO *dto.RoutineRegisterDTO) (
 		return nil, fmt.Errorf("el ID del usuario creador no puede estar vacío")
 	}
 
-	verificacion, err := service.RoutineRepository.ExistByRutineName(routineDTO.Name)
+	//LOGICA
+	model, err := dto.GetModelRoutineRegisterDTO(routineDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	verificacion, err := service.RoutineRepository.ExistByRutineName(model.CreatorUserID, routineDTO.Name)
 	if err != nil {
 		return nil, fmt.Errorf("no se pudo verificar si existe una rutina con el mismo nombre: %w", err)
 	}
@@ -53,12 +80,6 @@ func (service *RoutineService) PostRoutine(routineDTO *dto.RoutineRegisterDTO) (
 	if verificacion {
 		return nil, fmt.Errorf("dicho nombre de rutina ya existe")
 	}
-
-	//LOGICA
-	model, err := dto.GetModelRoutineRegisterDTO(routineDTO)
-	if err != nil {
-		return nil, err
-	}
 	result, err := service.RoutineRepository.PostRoutine(*model) //insertamos la rutina en la base de datos
 	if err != nil {
 		return nil, fmt.Errorf("error al crear la rutina en RoutineService.PostRoutine(): %v", err)
@@ -69,6 +90,7 @@ func (service *RoutineService) PostRoutine(routineDTO *dto.RoutineRegisterDTO) (
 		return nil, fmt.Errorf("no se pudo obtener el ObjectID insertado")
 	}
 	idStr := utils.GetStringIDFromObjectID(oid)
+	service.recordVersion(nil, idStr, models.RoutineCreated, routineDTO.CreatorUserID, 0)
 
 	routineModel, err := service.RoutineRepository.GetRoutineByID(idStr) //obtenemos la rutina de tipo response creada para devolver
 
@@ -79,13 +101,20 @@ func (service *RoutineService) PostRoutine(routineDTO *dto.RoutineRegisterDTO) (
 	return dto.NewRoutineResponseDTO(*routineModel), nil
 }
 
-func (service *RoutineService) GetRoutines() ([]*dto.RoutineResponseDTO, error) {
-	routinesDB, err := service.RoutineRepository.GetRoutines()
+// GetRoutines devuelve solo las rutinas del usuario, paginadas y por defecto ordenadas por nombre; las de otros se
+// buscan en la biblioteca publica
+func (service *RoutineService) GetRoutines(userID string, page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.RoutineListFields, "Name")
+	if err != nil {
+		return nil, err
+	}
+	routinesDB, info, err := service.RoutineRepository.GetRoutinesByCreatorPage(userID, request)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener rutinas %v:", err)
 	}
 
-	if len(routinesDB) == 0 {
+	// la primera pagina vacia significa que el usuario no tiene rutinas
+	if len(routinesDB) == 0 && request.Cursor == nil {
 		return nil, fmt.Errorf("no existen rutinas registradas")
 	}
 
@@ -95,18 +124,25 @@ func (service *RoutineService) GetRoutines() ([]*dto.RoutineResponseDTO, error)
 		routines = append(routines, routine)
 	}
 
-	return routines, nil
+	return dto.NewPageDTO(routines, info, request.Fields)
 }
 
-func (service *RoutineService) GetRoutineByID(id string) (*dto.RoutineResponseDTO, error) {
+func (service *RoutineService) GetRoutineByID(id string, userID string) (*dto.RoutineResponseDTO, error) {
 	routineDB, err := service.RoutineRepository.GetRoutineByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener la rutina por ID en RoutineService.GetRoutineByID(): %v", err)
 	}
-	if routineDB == nil || routineDB.ID.IsZero() {
+	// una rutina privada ajena se informa como inexistente para no revelar que existe
+	if routineDB == nil || routineDB.ID.IsZero() || !routineDB.VisibleTo(userID) {
 		return nil, fmt.Errorf("no existe ninguna rutina con el ID proporcionado")
 	}
-	return dto.NewRoutineResponseDTO(*routineDB), nil
+	// el creador necesita los entry_id para editar, reordenar o agrupar, y ve las sugerencias de progresion
+	if routineDB.CreatorUserID.Hex() != userID {
+		return dto.NewRoutineResponseDTO(*routineDB), nil
+	}
+	response := dto.NewRoutineResponseDTO(*routineDB)
+	response.Suggestions = currentSuggestions(routineDB)
+	return response, nil
 }
 
 // PUT SOLO DE NAME
@@ -119,6 +155,10 @@ func (service *RoutineService) PutRoutine(modify dto.RoutineModifyDTO) (*dto.Rou
 	if routineDB == nil || routineDB.ID.IsZero() {
 		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
 	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != modify.IDEditor {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+	before := *routineDB
 	newName := strings.ToLower(strings.TrimSpace(modify.Name))
 	if newName == "" {
 		return nil, fmt.Errorf("el nombre de la rutina no puede estar vacío")
@@ -137,6 +177,8 @@ func (service *RoutineService) PutRoutine(modify dto.RoutineModifyDTO) (*dto.Rou
 	if result.ModifiedCount == 0 {
 		return nil, fmt.Errorf("no se modificó ninguna rutina")
 	}
+	service.recordVersion(&before, modify.IDRoutine, models.RoutineRenamed, modify.IDEditor, 0)
+
 	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(modify.IDRoutine)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener la rutina modificada en RoutineService.PutRoutine(): %v", err)
@@ -167,19 +209,34 @@ func (service *RoutineService) AddExcerciseToRoutine(routineID string, exercise
 		return nil, fmt.Errorf("no existe ningún ejercicio con ese ID")
 	}
 
-	exerciseModel, err := dto.GetModelExerciseInRoutineDTO(exercise)
+	exerciseModel, err := dto.GetModelExerciseInRoutineDTO(exercise, exerciseDB.Category)
 	if err != nil {
 		return nil, err
 	}
+	exerciseModel.EntryID = primitive.NewObjectID()
 	exerciseModel.CreationDate = time.Now()
 
-	result, err := service.RoutineRepository.AddExerciseRutine(exerciseModel, routineDB.ID)
+	// sin posicion se agrega al final; en el medio no puede quedar entre dos ejercicios del mismo grupo
+	position := -1
+	if exercise.Position != nil && *exercise.Position < len(routineDB.ExcerciseList) {
+		position = *exercise.Position
+		list := routineDB.ExcerciseList
+		if position > 0 && !list[position].GroupID.IsZero() && list[position-1].GroupID == list[position].GroupID {
+			return nil, fmt.Errorf("la posición indicada separa un grupo de ejercicios")
+		}
+	}
+	if err := service.ensureEntryIDs(routineDB); err != nil {
+		return nil, err
+	}
+
+	result, err := service.RoutineRepository.AddExerciseRutine(exerciseModel, routineDB.ID, position)
 	if err != nil {
 		return nil, fmt.Errorf("error al agregar el ejercicio a la rutina: %w", err)
 	}
 	if result.ModifiedCount == 0 {
 		return nil, fmt.Errorf("no se agregó ningún ejercicio a la rutina")
 	}
+	service.recordVersion(routineDB, routineID, models.RoutineExerciseAdded, idEditor, 0)
 
 	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(routineID)
 	if err != nil {
@@ -211,34 +268,40 @@ func (service *RoutineService) RemoveExcerciseFromRoutine(idEditor string, remov
 		return nil, fmt.Errorf("ID de rutina con formato inválido: %w", err)
 	}
 
-	exerciseDB, err := service.ExcerciseRepository.GetExcerciseByID(remove.IDExercise)
-	if err != nil {
+	if err := service.ensureEntryIDs(routineDB); err != nil {
 		return nil, err
 	}
-	if exerciseDB.ID.IsZero() {
-		return nil, fmt.Errorf("no existe ningún ejercicio con ese ID")
+	entryRef := remove.IDEntry
+	if entryRef == "" {
+		entryRef = remove.IDExercise
 	}
-	exerciseObjectID, err := utils.GetObjectIDFromStringID(remove.IDExercise)
+	index, err := findEntry(routineDB, entryRef)
 	if err != nil {
-		// Este es un error de formato de ID (400)
-		return nil, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
+		return nil, err
 	}
+	entry := routineDB.ExcerciseList[index]
 
 	//lógica de eliminación
-	result, err := service.RoutineRepository.DeleteExerciseToRutine(routineObjectID, exerciseObjectID)
-	if err != nil {
-		return nil, fmt.Errorf("error al eliminar el ejercicio de la rutina: %w", err)
-	}
-	if result.ModifiedCount == 0 {
-		return nil, fmt.Errorf("no se eliminó ningún ejercicio de la rutina")
-	}
-
-	//modificar la fecha de edición de la rutina
-	routineDB.EditionDate = time.Now()
-	_, err = service.RoutineRepository.PutRoutine(*routineDB)
-	if err != nil {
-		return nil, fmt.Errorf("error al actualizar la fecha de edición de la rutina en RoutineService.RemoveExcerciseFromRoutine(): %v", err)
+	if entry.GroupID.IsZero() {
+		result, err := service.RoutineRepository.DeleteExerciseToRutine(routineObjectID, entry.EntryID)
+		if err != nil {
+			return nil, fmt.Errorf("error al eliminar el ejercicio de la rutina: %w", err)
+		}
+		if result.ModifiedCount == 0 {
+			return nil, fmt.Errorf("no se eliminó ningún ejercicio de la rutina")
+		}
+	} else {
+		// si el grupo queda con un solo ejercicio se disuelve, por eso se reescribe la lista completa
+		updated := *routineDB
+		updated.ExcerciseList = append(append([]models.ExcerciseInRoutine{}, routineDB.ExcerciseList[:index]...), routineDB.ExcerciseList[index+1:]...)
+		updated.Groups = normalizeGroups(updated.ExcerciseList, routineDB.Groups)
+		updated.EditionDate = time.Now()
+		if _, err := service.RoutineRepository.ReplaceRoutineContent(updated); err != nil {
+			return nil, fmt.Errorf("error al eliminar el ejercicio de la rutina: %w", err)
+		}
 	}
+	// la fecha de edición viaja en la misma escritura que quita la entrada
+	service.recordVersion(routineDB, remove.IDRoutine, models.RoutineExerciseRemoved, idEditor, 0)
 
 	//buscamos rutina para devolver
 	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(remove.IDRoutine)
@@ -272,34 +335,44 @@ func (service *RoutineService) UpdateExerciseInRoutine(idEditor string, exercise
 
 	}
 
-	exerciseDB, err := service.ExcerciseRepository.GetExcerciseByID(exerciseMod.ExcerciseID)
+	if err := service.ensureEntryIDs(routineDB); err != nil {
+		return nil, err
+	}
+	index, err := findEntry(routineDB, exerciseMod.ExcerciseID)
+	if err != nil {
+		return nil, err
+	}
+
+	//lógica de modificación
+	// la categoria define si se prescriben repeticiones, tiempo o distancia
+	entry := routineDB.ExcerciseList[index]
+	exerciseDB, err := service.ExcerciseRepository.GetExcerciseByID(entry.ExcerciseID.Hex())
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener el ejercicio a modificar: %w", err)
 	}
-	if exerciseDB.ID.IsZero() {
-		return nil, fmt.Errorf("no existe ningún ejercicio con ese ID")
+	if exerciseMod.AcceptSuggestion {
+		suggestion, ok := findSuggestion(routineDB, entry)
+		if !ok {
+			return nil, fmt.Errorf("no hay ninguna sugerencia de progresion para este ejercicio")
+		}
+		exerciseMod = dto.NewExcerciseInRoutineModifyDTO(exerciseMod.RoutineID, exerciseMod.ExcerciseID, suggestion.Suggested)
+		if err := binding.Validator.ValidateStruct(exerciseMod); err != nil {
+			return nil, fmt.Errorf("sugerencia de progresion inválida: %v", err)
+		}
 	}
-	exerciseObjectID, err := utils.GetObjectIDFromStringID(exerciseMod.ExcerciseID)
+	exerciseModel, err := dto.GetModelFromExerciseInRoutineModifyDTO(exerciseMod, exerciseDB.Category)
 	if err != nil {
-		return nil, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
+		return nil, err
 	}
-
-	//lógica de modificación
-	exerciseModel := dto.GetModelFromExerciseInRoutineModifyDTO(exerciseMod)
-	result, err := service.RoutineRepository.UpdateExerciseInRoutine(routineObjectID, exerciseObjectID, exerciseModel)
+	result, err := service.RoutineRepository.UpdateExerciseInRoutine(routineObjectID, entry.EntryID, exerciseModel)
 	if err != nil {
 		return nil, fmt.Errorf("error al modificar el ejercicio de la rutina: %w", err)
 	}
 	if result.ModifiedCount == 0 {
 		return nil, fmt.Errorf("no se modificó ningún ejercicio de la rutina")
 	}
-
-	//modificar la fecha de edición de la rutina
-	routineDB.EditionDate = time.Now()
-	_, err = service.RoutineRepository.PutRoutine(*routineDB)
-	if err != nil {
-		return nil, fmt.Errorf("error al actualizar la fecha de edición de la rutina en RoutineService.UpdateExerciseInRoutine(): %v", err)
-	}
+	// igual que al eliminar, la fecha de edición viaja en el mismo update del ejercicio
+	service.recordVersion(routineDB, exerciseMod.RoutineID, models.RoutineExerciseUpdated, idEditor, 0)
 
 	//buscamos rutina para devolver
 	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(exerciseMod.RoutineID)
@@ -312,6 +385,163 @@ func (service *RoutineService) UpdateExerciseInRoutine(idEditor string, exercise
 	return dto.NewRoutineResponseDTO(*updatedRoutineDB), nil
 }
 
+// ownedRoutine obtiene una rutina del editor con todas sus entradas identificadas
+func (service *RoutineService) ownedRoutine(routineID string, idEditor string) (*models.Routine, error) {
+	routineDB, err := service.RoutineRepository.GetRoutineByID(routineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina a modificar: %w", err)
+	}
+	if routineDB == nil || routineDB.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != idEditor {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+	if err := service.ensureEntryIDs(routineDB); err != nil {
+		return nil, err
+	}
+	return routineDB, nil
+}
+
+// saveRoutineContent reescribe la lista y los grupos de la rutina, registra la version y devuelve la rutina actualizada
+func (service *RoutineService) saveRoutineContent(before *models.Routine, updated models.Routine, change models.RoutineChange, idEditor string) (*dto.RoutineResponseDTO, error) {
+	updated.EditionDate = time.Now()
+	if _, err := service.RoutineRepository.ReplaceRoutineContent(updated); err != nil {
+		return nil, fmt.Errorf("error al modificar la rutina: %w", err)
+	}
+	routineID := before.ID.Hex()
+	service.recordVersion(before, routineID, change, idEditor, 0)
+
+	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(routineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina modificada: %v", err)
+	}
+	return dto.NewRoutineResponseDTO(*updatedRoutineDB), nil
+}
+
+// ReorderExercises recibe el orden completo de las entradas; las de un mismo grupo tienen que quedar seguidas
+func (service *RoutineService) ReorderExercises(reorder dto.RoutineReorderDTO) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.ownedRoutine(reorder.RoutineID, reorder.EditorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reorder.EntryIDs) != len(routineDB.ExcerciseList) {
+		return nil, fmt.Errorf("el orden debe incluir cada entrada de la rutina exactamente una vez")
+	}
+	byID := make(map[string]models.ExcerciseInRoutine, len(routineDB.ExcerciseList))
+	for _, e := range routineDB.ExcerciseList {
+		byID[e.EntryID.Hex()] = e
+	}
+	ordered := make([]models.ExcerciseInRoutine, 0, len(reorder.EntryIDs))
+	for _, id := range reorder.EntryIDs {
+		e, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("el orden debe incluir cada entrada de la rutina exactamente una vez")
+		}
+		delete(byID, id)
+		ordered = append(ordered, e)
+	}
+	if !groupsContiguous(ordered) {
+		return nil, fmt.Errorf("los ejercicios de un grupo deben quedar seguidos")
+	}
+	if slices.EqualFunc(ordered, routineDB.ExcerciseList, func(a, b models.ExcerciseInRoutine) bool { return a.EntryID == b.EntryID }) {
+		return nil, fmt.Errorf("la rutina ya tiene ese orden")
+	}
+
+	updated := *routineDB
+	updated.ExcerciseList = ordered
+	return service.saveRoutineContent(routineDB, updated, models.RoutineReordered, reorder.EditorID)
+}
+
+// PostExcerciseGroup arma una superserie, serie gigante o circuito. Las entradas se mueven juntas, en el orden
+// pedido, a la posicion de la primera de ellas en la rutina
+func (service *RoutineService) PostExcerciseGroup(group dto.RoutineGroupRegisterDTO) (*dto.RoutineResponseDTO, error) {
+	groupType := models.GroupType(group.Type)
+	switch {
+	case groupType == models.Superset && len(group.EntryIDs) != 2:
+		return nil, fmt.Errorf("una superserie debe tener exactamente 2 ejercicios")
+	case groupType == models.GiantSet && len(group.EntryIDs) < 3:
+		return nil, fmt.Errorf("una serie gigante debe tener al menos 3 ejercicios")
+	}
+
+	routineDB, err := service.ownedRoutine(group.RoutineID, group.EditorID)
+	if err != nil {
+		return nil, err
+	}
+
+	newGroup := models.ExcerciseGroup{
+		ID:          primitive.NewObjectID(),
+		Type:        groupType,
+		RestSeconds: group.RestSeconds,
+	}
+	if groupType == models.Circuit {
+		newGroup.Rounds = max(group.Rounds, 1)
+	}
+
+	first := len(routineDB.ExcerciseList)
+	inGroup := make(map[primitive.ObjectID]bool, len(group.EntryIDs))
+	var members []models.ExcerciseInRoutine
+	for _, ref := range group.EntryIDs {
+		index, err := findEntry(routineDB, ref)
+		if err != nil {
+			return nil, err
+		}
+		entry := routineDB.ExcerciseList[index]
+		if inGroup[entry.EntryID] {
+			return nil, fmt.Errorf("una entrada no puede repetirse dentro del grupo")
+		}
+		if !entry.GroupID.IsZero() {
+			return nil, fmt.Errorf("el ejercicio ya pertenece a un grupo: elimine ese grupo primero")
+		}
+		inGroup[entry.EntryID] = true
+		entry.GroupID = newGroup.ID
+		members = append(members, entry)
+		first = min(first, index)
+	}
+
+	list := make([]models.ExcerciseInRoutine, 0, len(routineDB.ExcerciseList))
+	for i, e := range routineDB.ExcerciseList {
+		if i == first {
+			list = append(list, members...)
+		}
+		if !inGroup[e.EntryID] {
+			list = append(list, e)
+		}
+	}
+
+	updated := *routineDB
+	updated.ExcerciseList = list
+	updated.Groups = append(append([]models.ExcerciseGroup{}, routineDB.Groups...), newGroup)
+	return service.saveRoutineContent(routineDB, updated, models.RoutineGrouped, group.EditorID)
+}
+
+// DeleteExcerciseGroup disuelve el grupo; sus ejercicios quedan en la rutina, en el mismo lugar
+func (service *RoutineService) DeleteExcerciseGroup(routineID string, groupID string, idEditor string) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.ownedRoutine(routineID, idEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []models.ExcerciseGroup{}
+	found := false
+	for _, g := range routineDB.Groups {
+		if g.ID.Hex() == groupID {
+			found = true
+			continue
+		}
+		groups = append(groups, g)
+	}
+	if !found {
+		return nil, fmt.Errorf("no existe el grupo indicado en la rutina")
+	}
+
+	updated := *routineDB
+	updated.ExcerciseList = append([]models.ExcerciseInRoutine{}, routineDB.ExcerciseList...)
+	updated.Groups = normalizeGroups(updated.ExcerciseList, groups)
+	return service.saveRoutineContent(routineDB, updated, models.RoutineUngrouped, idEditor)
+}
+
 func (service *RoutineService) DeleteRoutine(id string, idEditor string) (bool, error) {
 	//validacion de existencia
 	exist, err := service.RoutineRepository.GetRoutineByID(id)
@@ -331,8 +561,1040 @@ func (service *RoutineService) DeleteRoutine(id string, idEditor string) (bool,
 	if err != nil {
 		return false, fmt.Errorf("error al eliminar la rutina en RoutineService.DeleteRoutine(): %v", err)
 	}
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return false, fmt.Errorf("no se eliminó ninguna rutina")
 	}
+	// el historial de versiones se conserva mientras la rutina este en la papelera y se borra al purgarla
 	return true, nil
 }
+
+// DetachExcercise quita el ejercicio de todas las rutinas que lo usan o, si hay replacement, lo reemplaza
+// conservando la prescripcion de cada entrada; se usa al eliminar un ejercicio del catalogo, incluidas las rutinas de
+// la papelera. Cada rutina activa modificada registra una version a nombre del admin. Primero se arman todas las rutinas
+// nuevas y despues se escriben, cada una solo si todavia usa el ejercicio y nadie la edito mientras tanto: si una
+// escritura falla, las ya actualizadas dejan de usarlo y repetir la eliminacion sigue con las que faltan.
+// Devuelve cuantas rutinas cambiaron
+func (service *RoutineService) DetachExcercise(excerciseID string, replacement *models.Excercise, idEditor string) (int, error) {
+	target, err := utils.GetObjectIDFromStringID(excerciseID)
+	if err != nil {
+		return 0, fmt.Errorf("ID de ejercicio con formato inválido")
+	}
+	routines, err := service.RoutineRepository.GetRoutinesByExcercise(excerciseID)
+	if err != nil {
+		return 0, err
+	}
+
+	change := models.RoutineExerciseRemoved
+	if replacement != nil {
+		change = models.RoutineExerciseReplaced
+	}
+	now := time.Now()
+	updates := make([]models.Routine, 0, len(routines))
+	for _, routine := range routines {
+		updates = append(updates, detachedRoutine(*routine, target, replacement, now))
+	}
+
+	updatedCount := 0
+	for i, routine := range routines {
+		result, err := service.RoutineRepository.DetachExcercise(updates[i], target, routine.EditionDate)
+		if err != nil {
+			return updatedCount, fmt.Errorf("error al modificar la rutina %s: %w", routine.ID.Hex(), err)
+		}
+		if result.MatchedCount == 0 {
+			return updatedCount, fmt.Errorf("la rutina %s cambió mientras se editaba, vuelva a intentarlo", routine.ID.Hex())
+		}
+		// el versionado lee la rutina activa; una de la papelera queda con el cambio sin version propia
+		if routine.EliminationDate.IsZero() {
+			service.recordVersion(routine, routine.ID.Hex(), change, idEditor, 0)
+		}
+		updatedCount++
+	}
+	return updatedCount, nil
+}
+
+// detachedRoutine devuelve la rutina sin las entradas del ejercicio target o, si hay replacement, con ese ejercicio
+// en su lugar
+func detachedRoutine(routine models.Routine, target primitive.ObjectID, replacement *models.Excercise, now time.Time) models.Routine {
+	updated := routine
+	updated.ExcerciseList = make([]models.ExcerciseInRoutine, 0, len(routine.ExcerciseList))
+	for _, e := range routine.ExcerciseList {
+		if e.ExcerciseID == target {
+			if replacement == nil {
+				continue
+			}
+			e.ExcerciseID = replacement.ID
+		}
+		updated.ExcerciseList = append(updated.ExcerciseList, e)
+	}
+	// un grupo que pierde ejercicios puede quedar con uno solo y disolverse
+	updated.Groups = normalizeGroups(updated.ExcerciseList, routine.Groups)
+	updated.EditionDate = now
+	return updated
+}
+
+// RestoreRoutine saca de la papelera una rutina del usuario
+func (service *RoutineService) RestoreRoutine(id string, idEditor string) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.RoutineRepository.GetDeletedRoutineByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina eliminada en RoutineService.RestoreRoutine(): %v", err)
+	}
+	if routineDB == nil {
+		return nil, fmt.Errorf("no existe ninguna rutina eliminada con ese ID")
+	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != idEditor {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+
+	result, err := service.RoutineRepository.RestoreRoutine(id)
+	if err != nil {
+		return nil, fmt.Errorf("error al restaurar la rutina en RoutineService.RestoreRoutine(): %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("no existe ninguna rutina eliminada con ese ID")
+	}
+	return service.GetRoutineByID(id, idEditor)
+}
+
+// GetRoutineVersions devuelve el historial de la rutina (solo para su creador) con el diff de cada version
+func (service *RoutineService) GetRoutineVersions(routineID string, idEditor string) ([]*dto.RoutineVersionDTO, error) {
+	routineDB, err := service.RoutineRepository.GetRoutineByID(routineID)
+	if err != nil || routineDB == nil || routineDB.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != idEditor {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+
+	versions, err := service.VersionRepository.GetVersionsByRoutineID(routineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el historial de la rutina: %w", err)
+	}
+
+	response := []*dto.RoutineVersionDTO{}
+	previous := models.RoutineVersion{}
+	for i, version := range versions {
+		diff := diffRoutineVersions(previous, version)
+		if i == 0 {
+			diff = diffRoutineVersions(models.RoutineVersion{Name: version.Name}, version) // la primera version se compara contra una rutina vacia
+		}
+		response = append(response, dto.NewRoutineVersionDTO(version, diff))
+		previous = version
+	}
+	return response, nil
+}
+
+// RollbackRoutine restaura el contenido de una version anterior; el rollback queda registrado como una version nueva
+func (service *RoutineService) RollbackRoutine(rollback dto.RoutineRollbackDTO) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.RoutineRepository.GetRoutineByID(rollback.RoutineID)
+	if err != nil || routineDB == nil || routineDB.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != rollback.EditorID {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+
+	target, err := service.VersionRepository.GetVersion(rollback.RoutineID, rollback.Version)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la version: %w", err)
+	}
+	if target.ID.IsZero() {
+		return nil, fmt.Errorf("no existe la version %d de la rutina", rollback.Version)
+	}
+	if target.Version == routineDB.Version {
+		return nil, fmt.Errorf("la rutina ya está en la version %d", target.Version)
+	}
+	if target.Name != routineDB.Name {
+		exists, err := service.RoutineRepository.ExistByRutineName(routineDB.CreatorUserID, target.Name)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo verificar si existe una rutina con el mismo nombre: %w", err)
+		}
+		if exists {
+			return nil, fmt.Errorf("dicho nombre de rutina ya existe: no se puede restaurar la version %d", target.Version)
+		}
+	}
+
+	before := *routineDB
+	restored := *routineDB
+	restored.Name = target.Name
+	restored.ExcerciseList = target.ExcerciseList
+	restored.Groups = target.Groups
+	assignEntryIDs(restored.ExcerciseList) // versiones guardadas antes de que existiera entry_id
+	restored.EditionDate = time.Now()
+	if _, err := service.RoutineRepository.ReplaceRoutineContent(restored); err != nil {
+		return nil, fmt.Errorf("error al restaurar la rutina: %w", err)
+	}
+	service.recordVersion(&before, rollback.RoutineID, models.RoutineRolledBack, rollback.EditorID, target.Version)
+
+	updatedRoutineDB, err := service.RoutineRepository.GetRoutineByID(rollback.RoutineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina restaurada en RoutineService.RollbackRoutine(): %v", err)
+	}
+	return dto.NewRoutineResponseDTO(*updatedRoutineDB), nil
+}
+
+func (service *RoutineService) SetRoutineVisibility(visibility dto.RoutineVisibilityDTO) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.RoutineRepository.GetRoutineByID(visibility.RoutineID)
+	if err != nil || routineDB == nil || routineDB.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	if utils.GetStringIDFromObjectID(routineDB.CreatorUserID) != visibility.EditorID {
+		return nil, fmt.Errorf("Al no ser el creador de esta rutina no se brinda permisos para dicha accion")
+	}
+
+	if _, err := service.RoutineRepository.SetVisibility(routineDB.ID, models.RoutineVisibility(visibility.Visibility)); err != nil {
+		return nil, fmt.Errorf("error al cambiar la visibilidad de la rutina: %w", err)
+	}
+	return service.GetRoutineByID(visibility.RoutineID, visibility.EditorID)
+}
+
+func (service *RoutineService) SetRoutineFeatured(featured dto.RoutineFeaturedDTO) (*dto.RoutineResponseDTO, error) {
+	routineDB, err := service.RoutineRepository.GetRoutineByID(featured.RoutineID)
+	if err != nil || routineDB == nil || routineDB.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	if *featured.Featured && routineDB.Visibility != models.RoutinePublic {
+		return nil, fmt.Errorf("solo las rutinas publicas pueden destacarse")
+	}
+
+	if _, err := service.RoutineRepository.SetFeatured(routineDB.ID, *featured.Featured); err != nil {
+		return nil, fmt.Errorf("error al destacar la rutina: %w", err)
+	}
+	routineDB.Featured = *featured.Featured
+	return dto.NewRoutineResponseDTO(*routineDB), nil
+}
+
+// publicRoutinesDefaultLimit es la cantidad de resultados de la biblioteca si no se pide otra
+const publicRoutinesDefaultLimit = 50
+
+func (service *RoutineService) SearchPublicRoutines(filter dto.PublicRoutineFilterDTO) ([]*dto.PublicRoutineDTO, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Limit == 0 {
+		filter.Limit = publicRoutinesDefaultLimit
+	}
+	// el grupo muscular se escribe a mano en la biblioteca: si es un nombre o alias del vocabulario se busca por su
+	// clave, si no se busca tal cual
+	if filter.MuscleGroup != "" {
+		if vocabularies, err := service.TaxonomyService.Vocabularies(); err == nil {
+			if key, found := vocabularies.Resolve(models.TaxonomyMuscle, filter.MuscleGroup); found {
+				filter.MuscleGroup = key
+			}
+		}
+	}
+
+	routines, err := service.RoutineRepository.SearchPublicRoutines(dto.GetModelPublicRoutineFilterDTO(filter))
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar rutinas publicas: %w", err)
+	}
+
+	response := []*dto.PublicRoutineDTO{}
+	for _, routine := range routines {
+		response = append(response, dto.NewPublicRoutineDTO(routine))
+	}
+	return response, nil
+}
+
+// cloneNameAttempts limita cuantos sufijos se prueban al buscar un nombre libre para la copia
+const cloneNameAttempts = 20
+
+// CloneRoutine copia los ejercicios de una rutina a una nueva del usuario, guardando de cual se copio
+func (service *RoutineService) CloneRoutine(clone dto.RoutineCloneDTO) (*dto.RoutineResponseDTO, error) {
+	source, err := service.RoutineRepository.GetRoutineByID(clone.RoutineID)
+	if err != nil || source == nil || source.ID.IsZero() || !source.VisibleTo(clone.UserID) {
+		return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+	}
+	creatorOID, err := utils.GetObjectIDFromStringID(clone.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de creador con formato inválido: %w", err)
+	}
+
+	// nombre: el pedido debe estar libre; el automatico prueba "(copia)", "(copia 2)", ...
+	name := strings.ToLower(strings.TrimSpace(clone.Name))
+	if name != "" {
+		exists, err := service.RoutineRepository.ExistByRutineName(creatorOID, name)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo verificar si existe una rutina con el mismo nombre: %w", err)
+		}
+		if exists {
+			return nil, fmt.Errorf("dicho nombre de rutina ya existe")
+		}
+	} else {
+		for i := 1; i <= cloneNameAttempts && name == ""; i++ {
+			candidate := source.Name + " (copia)"
+			if i > 1 {
+				candidate = fmt.Sprintf("%s (copia %d)", source.Name, i)
+			}
+			exists, err := service.RoutineRepository.ExistByRutineName(creatorOID, candidate)
+			if err != nil {
+				return nil, fmt.Errorf("no se pudo verificar si existe una rutina con el mismo nombre: %w", err)
+			}
+			if !exists {
+				name = candidate
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("dicho nombre de rutina ya existe: indique un nombre para la copia")
+		}
+	}
+
+	// la copia tiene sus propios IDs de entradas y grupos para que editarla no se confunda con la original
+	now := time.Now()
+	groupIDs := make(map[primitive.ObjectID]primitive.ObjectID, len(source.Groups))
+	groups := []models.ExcerciseGroup{}
+	for _, g := range source.Groups {
+		groupIDs[g.ID] = primitive.NewObjectID()
+		g.ID = groupIDs[g.ID]
+		groups = append(groups, g)
+	}
+	exercises := []models.ExcerciseInRoutine{}
+	for _, e := range source.ExcerciseList {
+		if !e.EliminationDate.IsZero() {
+			continue
+		}
+		e.EntryID = primitive.NewObjectID()
+		e.GroupID = groupIDs[e.GroupID]
+		e.CreationDate = now
+		exercises = append(exercises, e)
+	}
+	routine := models.Routine{
+		Name:          name,
+		CreatorUserID: creatorOID,
+		Visibility:    models.RoutinePrivate,
+		ExcerciseList: exercises,
+		Groups:        normalizeGroups(exercises, groups),
+		CreationDate:  now,
+		EditionDate:   now,
+		ForkedFrom:    source.ID,
+		ForkedVersion: source.Version,
+	}
+
+	result, err := service.RoutineRepository.PostRoutine(routine)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la rutina en RoutineService.CloneRoutine(): %v", err)
+	}
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("no se pudo obtener el ObjectID insertado")
+	}
+	idStr := utils.GetStringIDFromObjectID(oid)
+	service.recordVersion(nil, idStr, models.RoutineCloned, clone.UserID, 0)
+
+	routineModel, err := service.RoutineRepository.GetRoutineByID(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la rutina creada en RoutineService.CloneRoutine(): %v", err)
+	}
+	return dto.NewRoutineResponseDTO(*routineModel), nil
+}
+
+// recordVersion guarda el estado actual de la rutina como una version nueva. El numero sale del historial guardado
+// y no del campo version de la rutina, que puede haber quedado atrasado si un intento anterior fallo a mitad de
+// camino. Si la rutina todavia no tiene historial (es anterior al versionado o fallo su version base) primero
+// guarda su estado previo como base, para poder compararlo y restaurarlo; si eso falla no se guarda nada y se
+// vuelve a intentar en la proxima edicion. La rutina ya quedo modificada, asi que los fallos solo van al log.
+func (service *RoutineService) recordVersion(before *models.Routine, routineID string, change models.RoutineChange, editorID string, restoredFrom int) {
+	if err := service.saveVersion(before, routineID, change, editorID, restoredFrom); err != nil {
+		log.Printf("error al versionar la rutina %s: %v", routineID, err)
+	}
+}
+
+func (service *RoutineService) saveVersion(before *models.Routine, routineID string, change models.RoutineChange, editorID string, restoredFrom int) error {
+	editorOID, _ := utils.GetObjectIDFromStringID(editorID)
+	now := time.Now()
+
+	latest, err := service.VersionRepository.GetLatestVersion(routineID)
+	if err != nil {
+		return err
+	}
+	next := latest.Version + 1
+	if latest.ID.IsZero() && before != nil {
+		baseline := models.RoutineVersion{
+			RoutineID:     before.ID,
+			Version:       1,
+			Name:          before.Name,
+			ExcerciseList: before.ExcerciseList,
+			Groups:        before.Groups,
+			Change:        models.RoutineBaseline,
+			EditorUserID:  before.CreatorUserID,
+			CreationDate:  now,
+		}
+		if _, err := service.VersionRepository.PostVersion(baseline); err != nil {
+			return fmt.Errorf("no se pudo guardar la version base: %w", err)
+		}
+		next = 2
+	}
+
+	after, err := service.RoutineRepository.GetRoutineByID(routineID)
+	if err != nil || after == nil || after.ID.IsZero() {
+		return fmt.Errorf("no se pudo obtener la rutina: %v", err)
+	}
+	version := models.RoutineVersion{
+		RoutineID:     after.ID,
+		Version:       next,
+		Name:          after.Name,
+		ExcerciseList: after.ExcerciseList,
+		Groups:        after.Groups,
+		Change:        change,
+		RestoredFrom:  restoredFrom,
+		EditorUserID:  editorOID,
+		CreationDate:  now,
+	}
+	if _, err := service.VersionRepository.PostVersion(version); err != nil {
+		return fmt.Errorf("no se pudo guardar la version %d: %w", next, err)
+	}
+	if _, err := service.RoutineRepository.SetRoutineVersion(after.ID, next); err != nil {
+		return fmt.Errorf("no se pudo actualizar el numero de version: %w", err)
+	}
+	return nil
+}
+
+// diffRoutineVersions compara dos versiones consecutivas: nombre, entradas agregadas, eliminadas o modificadas,
+// cambios de orden y grupos creados o disueltos
+func diffRoutineVersions(previous models.RoutineVersion, current models.RoutineVersion) []dto.RoutineDiffDTO {
+	var diff []dto.RoutineDiffDTO
+	if previous.Name != current.Name {
+		diff = append(diff, dto.RoutineDiffDTO{Type: "name_changed", Field: "name", From: previous.Name, To: current.Name})
+	}
+
+	// las versiones guardadas antes de que existiera entry_id solo se pueden comparar por ejercicio
+	key := models.ExcerciseInRoutine.EntryKey
+	if !hasEntryIDs(previous.ExcerciseList) || !hasEntryIDs(current.ExcerciseList) {
+		key = func(e models.ExcerciseInRoutine) string { return e.ExcerciseID.Hex() }
+	}
+
+	before := make(map[string]models.ExcerciseInRoutine, len(previous.ExcerciseList))
+	for _, e := range previous.ExcerciseList {
+		before[key(e)] = e
+	}
+	seen := make(map[string]bool, len(current.ExcerciseList))
+	var afterOrder []string
+	for _, e := range current.ExcerciseList {
+		k := key(e)
+		seen[k] = true
+		entryID, id := entryHex(e), e.ExcerciseID.Hex()
+		old, existed := before[k]
+		if !existed {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_added", EntryID: entryID, ExcerciseID: id})
+			continue
+		}
+		afterOrder = append(afterOrder, k)
+		if old.ExcerciseID != e.ExcerciseID {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "excercise_id", From: old.ExcerciseID.Hex(), To: id})
+		}
+		if old.Repetitions != e.Repetitions {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "repetitions", From: old.Repetitions, To: e.Repetitions})
+		}
+		if old.Series != e.Series {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "series", From: old.Series, To: e.Series})
+		}
+		if old.Weight != e.Weight {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "weight", From: old.Weight, To: e.Weight})
+		}
+		// sin detalle serie a serie la prescripcion sale de los campos simples, que ya se compararon arriba
+		if (len(old.Sets) > 0 || len(e.Sets) > 0) && !slices.Equal(old.Prescription(), e.Prescription()) {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "sets", From: models.DescribeSets(old.Prescription()), To: models.DescribeSets(e.Prescription())})
+		}
+		if old.RestSeconds != e.RestSeconds {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "rest_seconds", From: old.RestSeconds, To: e.RestSeconds})
+		}
+		if old.Tempo != e.Tempo {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "tempo", From: old.Tempo, To: e.Tempo})
+		}
+		if old.IsTimed() || e.IsTimed() {
+			if before, after := old.Describe(), e.Describe(); before != after {
+				diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_changed", EntryID: entryID, ExcerciseID: id, Field: "target", From: before, To: after})
+			}
+		}
+	}
+	var beforeOrder []string
+	for _, e := range previous.ExcerciseList {
+		if !seen[key(e)] {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "exercise_removed", EntryID: entryHex(e), ExcerciseID: e.ExcerciseID.Hex()})
+			continue
+		}
+		beforeOrder = append(beforeOrder, key(e))
+	}
+	// el orden solo se compara entre las entradas que estan en ambas versiones
+	if !slices.Equal(beforeOrder, afterOrder) {
+		diff = append(diff, dto.RoutineDiffDTO{Type: "order_changed"})
+	}
+
+	previousGroups := make(map[primitive.ObjectID]bool, len(previous.Groups))
+	for _, g := range previous.Groups {
+		previousGroups[g.ID] = true
+	}
+	currentGroups := make(map[primitive.ObjectID]bool, len(current.Groups))
+	for _, g := range current.Groups {
+		currentGroups[g.ID] = true
+		if !previousGroups[g.ID] {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "group_added", GroupID: g.ID.Hex(), Field: "type", To: string(g.Type)})
+		}
+	}
+	for _, g := range previous.Groups {
+		if !currentGroups[g.ID] {
+			diff = append(diff, dto.RoutineDiffDTO{Type: "group_removed", GroupID: g.ID.Hex(), Field: "type", From: string(g.Type)})
+		}
+	}
+	return diff
+}
+
+func hasEntryIDs(list []models.ExcerciseInRoutine) bool {
+	for _, e := range list {
+		if e.EntryID.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+func entryHex(e models.ExcerciseInRoutine) string {
+	if e.EntryID.IsZero() {
+		return ""
+	}
+	return e.EntryID.Hex()
+}
+
+// assignEntryIDs completa los entry_id faltantes; devuelve las posiciones a las que les asigno uno
+func assignEntryIDs(list []models.ExcerciseInRoutine) []int {
+	var assigned []int
+	for i := range list {
+		if list[i].EntryID.IsZero() {
+			list[i].EntryID = primitive.NewObjectID()
+			assigned = append(assigned, i)
+		}
+	}
+	return assigned
+}
+
+// ensureEntryIDs guarda entry_id en las entradas de rutinas anteriores a los grupos; no cuenta como edicion
+// ni genera una version nueva porque el contenido no cambia
+func (service *RoutineService) ensureEntryIDs(routine *models.Routine) error {
+	positions := assignEntryIDs(routine.ExcerciseList)
+	if len(positions) == 0 {
+		return nil
+	}
+	result, err := service.RoutineRepository.SetEntryIDs(routine.ID, routine.ExcerciseList, positions)
+	if err != nil {
+		return fmt.Errorf("error al asignar los entry_id de la rutina %s: %w", routine.ID.Hex(), err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("la rutina cambió mientras se editaba, vuelva a intentarlo")
+	}
+	return nil
+}
+
+// findEntry busca la entrada por entry_id; con el ID del ejercicio solo la encuentra si aparece una unica vez
+func findEntry(routine *models.Routine, id string) (int, error) {
+	oid, err := utils.GetObjectIDFromStringID(id)
+	if err != nil {
+		return -1, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
+	}
+	for i, e := range routine.ExcerciseList {
+		if e.EntryID == oid {
+			return i, nil
+		}
+	}
+	match := -1
+	for i, e := range routine.ExcerciseList {
+		if e.ExcerciseID != oid {
+			continue
+		}
+		if match >= 0 {
+			return -1, fmt.Errorf("el ejercicio aparece varias veces en la rutina: indique el entry_id")
+		}
+		match = i
+	}
+	if match < 0 {
+		return -1, fmt.Errorf("no se encontró el ejercicio dentro de la rutina")
+	}
+	return match, nil
+}
+
+// groupsContiguous verifica que las entradas de cada grupo queden una detras de otra
+func groupsContiguous(list []models.ExcerciseInRoutine) bool {
+	closed := make(map[primitive.ObjectID]bool)
+	var current primitive.ObjectID
+	for _, e := range list {
+		if e.GroupID == current {
+			continue
+		}
+		if !current.IsZero() {
+			closed[current] = true
+		}
+		if !e.GroupID.IsZero() && closed[e.GroupID] {
+			return false
+		}
+		current = e.GroupID
+	}
+	return true
+}
+
+// normalizeGroups descarta los grupos que quedaron con menos de dos entradas (liberando la que quede) y convierte
+// en superserie a una serie gigante que quedo con dos
+func normalizeGroups(list []models.ExcerciseInRoutine, groups []models.ExcerciseGroup) []models.ExcerciseGroup {
+	members := make(map[primitive.ObjectID]int, len(groups))
+	for _, e := range list {
+		if !e.GroupID.IsZero() {
+			members[e.GroupID]++
+		}
+	}
+	kept := []models.ExcerciseGroup{}
+	valid := make(map[primitive.ObjectID]bool, len(groups))
+	for _, g := range groups {
+		if members[g.ID] < 2 {
+			continue
+		}
+		if g.Type == models.GiantSet && members[g.ID] < 3 {
+			g.Type = models.Superset
+		}
+		valid[g.ID] = true
+		kept = append(kept, g)
+	}
+	for i := range list {
+		if !valid[list[i].GroupID] {
+			list[i].GroupID = primitive.NilObjectID
+		}
+	}
+	return kept
+}
+
+// overloadWorkouts es cuantos workouts recientes de la rutina se revisan para sugerir la progresion
+const overloadWorkouts = 10
+
+const (
+	overloadIncrement    = 2.5 // kg a sumar cuando todas las series llegaron al tope del rango
+	overloadDeloadFactor = 0.9 // fraccion del peso tras dos sesiones fallidas seguidas
+)
+
+const (
+	increaseWeight = "increase_weight"
+	increaseReps   = "increase_reps" // ejercicios sin peso: se progresa en repeticiones
+	deloadWeight   = "deload"
+)
+
+type sessionOutcome int
+
+const (
+	sessionOnTrack    sessionOutcome = iota // cumplio el minimo pero no llego al tope
+	sessionTopOfRange                       // todas las series de trabajo llegaron al tope del rango
+	sessionFailed                           // alguna serie fallida, por debajo del minimo o con menos peso
+)
+
+// progressionSuggestions calcula el objetivo de la proxima sesion de cada entrada segun los ultimos workouts. No se
+// ofrecen las que una modificacion manual no permitiria, por ejemplo por pasarse del maximo de repeticiones o peso
+func progressionSuggestions(routine *models.Routine, workouts []models.Workout, now time.Time) []models.ProgressionSuggestion {
+	var suggestions []models.ProgressionSuggestion
+	for _, entry := range routine.ExcerciseList {
+		suggested, action, reason, ok := suggestOverload(entry, routine.ExcerciseList, workouts)
+		if !ok {
+			continue
+		}
+		modify := dto.NewExcerciseInRoutineModifyDTO(routine.ID.Hex(), entryHex(entry), suggested)
+		if err := binding.Validator.ValidateStruct(modify); err != nil {
+			continue
+		}
+		suggestions = append(suggestions, models.ProgressionSuggestion{
+			EntryID:      entry.EntryID,
+			ExcerciseID:  entry.ExcerciseID,
+			Suggested:    suggested,
+			Action:       action,
+			Reason:       reason,
+			BasedOn:      entry.Describe(),
+			CreationDate: now,
+		})
+	}
+	return suggestions
+}
+
+// currentSuggestions devuelve las sugerencias guardadas que siguen vigentes para la prescripcion actual
+func currentSuggestions(routine *models.Routine) []dto.ProgressionSuggestionDTO {
+	var suggestions []dto.ProgressionSuggestionDTO
+	for _, entry := range routine.ExcerciseList {
+		if suggestion, ok := findSuggestion(routine, entry); ok {
+			suggestions = append(suggestions, dto.NewProgressionSuggestionDTO(entry, suggestion.Suggested, suggestion.Action, suggestion.Reason))
+		}
+	}
+	return suggestions
+}
+
+// findSuggestion busca la sugerencia vigente de la entrada
+func findSuggestion(routine *models.Routine, entry models.ExcerciseInRoutine) (models.ProgressionSuggestion, bool) {
+	for _, suggestion := range routine.Suggestions {
+		if suggestion.Matches(entry) {
+			return suggestion, true
+		}
+	}
+	return models.ProgressionSuggestion{}, false
+}
+
+// suggestOverload compara las dos ultimas sesiones registradas de la entrada con lo prescrito en cada una. Solo
+// cuentan las sesiones hechas con la prescripcion actual: si la entrada cambio despues, ya se ajusto a mano
+func suggestOverload(entry models.ExcerciseInRoutine, list []models.ExcerciseInRoutine, workouts []models.Workout) (models.ExcerciseInRoutine, string, string, bool) {
+	if entry.IsTimed() {
+		return models.ExcerciseInRoutine{}, "", "", false
+	}
+	// los workouts registran series por ejercicio: si esta dos veces en la rutina no se sabe a que entrada van
+	repeated := 0
+	for _, e := range list {
+		if e.ExcerciseID == entry.ExcerciseID {
+			repeated++
+		}
+	}
+	if repeated > 1 {
+		return models.ExcerciseInRoutine{}, "", "", false
+	}
+
+	var outcomes []sessionOutcome
+	for _, workout := range workouts {
+		logged, ok := loggedSets(workout, entry.ExcerciseID)
+		if !ok {
+			continue
+		}
+		if prescribed, ok := snapshotEntry(workout.Routine, entry); ok && prescribed.Describe() != entry.Describe() {
+			break
+		}
+		outcomes = append(outcomes, evaluateSession(entry.Prescription(), logged))
+		if len(outcomes) == 2 {
+			break
+		}
+	}
+
+	switch {
+	case len(outcomes) > 0 && outcomes[0] == sessionTopOfRange:
+		suggested, action := overloadEntry(entry, increaseWeight)
+		return suggested, action, "todas las series llegaron al tope del rango en la ultima sesion", true
+	case len(outcomes) == 2 && outcomes[0] == sessionFailed && outcomes[1] == sessionFailed:
+		suggested, action := overloadEntry(entry, deloadWeight)
+		return suggested, action, "no se completo lo prescrito en las dos ultimas sesiones", true
+	}
+	return models.ExcerciseInRoutine{}, "", "", false
+}
+
+// loggedSets devuelve las series registradas del ejercicio en el workout
+func loggedSets(workout models.Workout, excerciseID primitive.ObjectID) ([]models.WorkoutSet, bool) {
+	for _, e := range workout.Exercises {
+		if e.ExcerciseID == excerciseID && len(e.Sets) > 0 {
+			return e.Sets, true
+		}
+	}
+	return nil, false
+}
+
+// snapshotEntry busca la entrada en la foto de la rutina guardada con el workout
+func snapshotEntry(snapshot *models.RoutineSnapshot, entry models.ExcerciseInRoutine) (models.ExcerciseInRoutine, bool) {
+	if snapshot == nil {
+		return models.ExcerciseInRoutine{}, false
+	}
+	for _, e := range snapshot.Exercises {
+		if (!e.EntryID.IsZero() && e.EntryID == entry.EntryID) || (e.EntryID.IsZero() && e.ExcerciseID == entry.ExcerciseID) {
+			return e.Entry(), true
+		}
+	}
+	return models.ExcerciseInRoutine{}, false
+}
+
+// evaluateSession compara las series registradas con las de trabajo prescritas, en orden. Si se registraron
+// mas series que las de trabajo, las primeras se toman como calentamiento
+func evaluateSession(sets []models.SetPrescription, logged []models.WorkoutSet) sessionOutcome {
+	warmups := 0
+	var targets []models.SetPrescription // una por serie de trabajo
+	for _, s := range sets {
+		if s.Type == models.WarmupSet {
+			warmups += s.Count
+			continue
+		}
+		for i := 0; i < s.Count; i++ {
+			targets = append(targets, s)
+		}
+	}
+	if extra := len(logged) - len(targets); extra > 0 {
+		logged = logged[min(warmups, extra):]
+	}
+
+	outcome := sessionTopOfRange
+	for i, target := range targets {
+		if i >= len(logged) {
+			return sessionFailed
+		}
+		set := logged[i]
+		if !set.Completed || set.Repetitions < target.MinReps || set.Weight < target.Weight {
+			return sessionFailed
+		}
+		if set.Repetitions < target.MaxReps {
+			outcome = sessionOnTrack
+		}
+	}
+	return outcome
+}
+
+// overloadEntry aplica la sugerencia a las series de trabajo; los calentamientos no cambian. Sin peso se progresa
+// en repeticiones y la descarga baja las repeticiones en vez del peso
+func overloadEntry(entry models.ExcerciseInRoutine, action string) (models.ExcerciseInRoutine, string) {
+	sets := slices.Clone(entry.Prescription())
+	bodyweight := true
+	for _, s := range sets {
+		if s.Type != models.WarmupSet && s.Weight > 0 {
+			bodyweight = false
+		}
+	}
+	if bodyweight && action == increaseWeight {
+		action = increaseReps
+	}
+
+	for i, s := range sets {
+		if s.Type == models.WarmupSet {
+			continue
+		}
+		switch {
+		case action == increaseReps:
+			s.MinReps++
+			s.MaxReps++
+		case action == increaseWeight:
+			s.Weight += overloadIncrement
+		case bodyweight:
+			s.MinReps = max(int(float64(s.MinReps)*overloadDeloadFactor), 1)
+			s.MaxReps = max(int(float64(s.MaxReps)*overloadDeloadFactor), s.MinReps)
+		default:
+			s.Weight = roundToPlate(s.Weight * overloadDeloadFactor)
+		}
+		sets[i] = s
+	}
+
+	// las entradas sin series detalladas siguen siendo series x repeticiones
+	if len(entry.Sets) > 0 {
+		entry.Sets = sets
+	}
+	entry.Series, entry.Repetitions, entry.Weight = models.SummarizeSets(sets)
+	return entry, action
+}
+
+// ExportRoutines devuelve una rutina visible para el usuario o, sin routineID, todas las suyas, con los nombres
+// de los ejercicios resueltos
+func (service *RoutineService) ExportRoutines(userID string, routineID string) ([]dto.RoutineExportDTO, error) {
+	var routines []*models.Routine
+	if routineID != "" {
+		routine, err := service.RoutineRepository.GetRoutineByID(routineID)
+		if err != nil || routine == nil || routine.ID.IsZero() || !routine.VisibleTo(userID) {
+			return nil, fmt.Errorf("no existe ninguna rutina con ese ID")
+		}
+		routines = append(routines, routine)
+	} else {
+		var err error
+		routines, err = service.RoutineRepository.GetRoutinesByCreator(userID)
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener rutinas: %w", err)
+		}
+	}
+
+	names := make(map[primitive.ObjectID]string)
+	exported := make([]dto.RoutineExportDTO, 0, len(routines))
+	for _, routine := range routines {
+		for _, e := range routine.ExcerciseList {
+			if _, ok := names[e.ExcerciseID]; ok {
+				continue
+			}
+			excercise, err := service.ExcerciseRepository.GetExcerciseByID(e.ExcerciseID.Hex())
+			if err != nil {
+				log.Printf("No se pudo resolver el ejercicio %s al exportar: %v", e.ExcerciseID.Hex(), err)
+			}
+			names[e.ExcerciseID] = excercise.Name
+		}
+		exported = append(exported, dto.NewRoutineExportDTO(*routine, names))
+	}
+	return exported, nil
+}
+
+// ImportRoutines crea las rutinas del archivo como rutinas privadas del usuario. Cada ejercicio se busca por ID y,
+// si no existe, por nombre; una rutina con filas sin ejercicio o invalidas no se importa y se informa por que.
+// En dry_run se hace todo el chequeo sin guardar nada
+func (service *RoutineService) ImportRoutines(importDTO dto.RoutineImportDTO) (*dto.RoutineImportResultDTO, error) {
+	creatorOID, err := utils.GetObjectIDFromStringID(importDTO.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de creador con formato inválido: %w", err)
+	}
+
+	result := &dto.RoutineImportResultDTO{DryRun: importDTO.DryRun, Routines: []dto.RoutineImportReportDTO{}}
+	matcher := excerciseMatcher{repository: service.ExcerciseRepository, byID: map[string]models.Excercise{}, byName: map[string]models.Excercise{}}
+	seen := make(map[string]bool) // nombres ya usados en este mismo archivo
+	for _, routineDTO := range importDTO.Routines {
+		name := strings.ToLower(strings.TrimSpace(routineDTO.Name))
+		report := dto.RoutineImportReportDTO{Name: name}
+		routine := service.buildImportedRoutine(routineDTO, &matcher, &report)
+
+		switch {
+		case name == "":
+			report.Errors = append(report.Errors, "el nombre de la rutina no puede estar vacío")
+		case seen[name]:
+			report.Errors = append(report.Errors, "el nombre de la rutina está repetido en el archivo")
+		default:
+			exists, err := service.RoutineRepository.ExistByRutineName(creatorOID, name)
+			if err != nil {
+				return nil, fmt.Errorf("no se pudo verificar si existe una rutina con el mismo nombre: %w", err)
+			}
+			if exists {
+				report.Errors = append(report.Errors, "dicho nombre de rutina ya existe")
+			}
+		}
+		seen[name] = true
+
+		if len(report.Errors) > 0 || len(report.Unmatched) > 0 {
+			report.Status = "skipped"
+			result.Skipped++
+			result.Routines = append(result.Routines, report)
+			continue
+		}
+		if importDTO.DryRun {
+			report.Status = "ready"
+			result.Created++
+			result.Routines = append(result.Routines, report)
+			continue
+		}
+
+		now := time.Now()
+		routine.Name = name
+		routine.CreatorUserID = creatorOID
+		routine.Visibility = models.RoutinePrivate
+		routine.CreationDate = now
+		routine.EditionDate = now
+		inserted, err := service.RoutineRepository.PostRoutine(routine)
+		if err != nil {
+			log.Printf("No se pudo importar la rutina %q: %v", name, err)
+			report.Status = "skipped"
+			report.Errors = append(report.Errors, "error al guardar la rutina")
+			result.Skipped++
+			result.Routines = append(result.Routines, report)
+			continue
+		}
+		oid := inserted.InsertedID.(primitive.ObjectID)
+		service.recordVersion(nil, oid.Hex(), models.RoutineImported, importDTO.UserID, 0)
+
+		report.Status = "created"
+		report.RoutineID = oid.Hex()
+		result.Created++
+		result.Routines = append(result.Routines, report)
+	}
+	return result, nil
+}
+
+// buildImportedRoutine arma la lista de entradas y grupos de una rutina importada y anota en el reporte lo que
+// se encontro, lo que no y los errores de la rutina
+func (service *RoutineService) buildImportedRoutine(routineDTO dto.RoutineExportDTO, matcher *excerciseMatcher, report *dto.RoutineImportReportDTO) models.Routine {
+	rows := slices.Clone(routineDTO.Exercises)
+	slices.SortStableFunc(rows, func(a, b dto.RoutineExportEntryDTO) int { return a.Position - b.Position })
+	report.Exercises = len(rows)
+
+	groupIDs := make(map[string]primitive.ObjectID, len(routineDTO.Groups))
+	groups := []models.ExcerciseGroup{}
+	for _, g := range routineDTO.Groups {
+		if _, ok := groupIDs[g.Label]; ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("el grupo %s está repetido", g.Label))
+			continue
+		}
+		group := models.ExcerciseGroup{ID: primitive.NewObjectID(), Type: models.GroupType(g.Type), RestSeconds: g.RestSeconds}
+		if group.Type == models.Circuit {
+			group.Rounds = max(g.Rounds, 1)
+		}
+		groupIDs[g.Label] = group.ID
+		groups = append(groups, group)
+	}
+
+	now := time.Now()
+	list := []models.ExcerciseInRoutine{}
+	members := make(map[primitive.ObjectID]int, len(groups))
+	for _, row := range rows {
+		unmatched := dto.RoutineImportRowDTO{Position: row.Position, ExcerciseID: row.ExcerciseID, ExcerciseName: row.ExcerciseName}
+		excercise, matchedBy, err := matcher.match(row.ExcerciseID, row.ExcerciseName)
+		if err != nil {
+			unmatched.Reason = err.Error()
+			report.Unmatched = append(report.Unmatched, unmatched)
+			continue
+		}
+
+		entryDTO := dto.ExcerciseInRoutineDTO{
+			ExcerciseID:     excercise.ID.Hex(),
+			Repetitions:     row.Repetitions,
+			Series:          row.Series,
+			Weight:          row.Weight,
+			PrescriptionDTO: row.PrescriptionDTO,
+		}
+		entry, err := dto.GetModelExerciseInRoutineDTO(&entryDTO, excercise.Category)
+		if err != nil {
+			unmatched.Reason = err.Error()
+			report.Unmatched = append(report.Unmatched, unmatched)
+			continue
+		}
+		if row.Group != "" {
+			groupID, ok := groupIDs[row.Group]
+			if !ok {
+				unmatched.Reason = fmt.Sprintf("el grupo %s no está definido", row.Group)
+				report.Unmatched = append(report.Unmatched, unmatched)
+				continue
+			}
+			entry.GroupID = groupID
+			members[groupID]++
+		}
+		entry.EntryID = primitive.NewObjectID()
+		entry.CreationDate = now
+		list = append(list, entry)
+		report.Matched = append(report.Matched, dto.RoutineImportMatchDTO{
+			Position:      row.Position,
+			ExcerciseID:   excercise.ID.Hex(),
+			ExcerciseName: excercise.Name,
+			MatchedBy:     matchedBy,
+		})
+	}
+
+	if !groupsContiguous(list) {
+		report.Errors = append(report.Errors, "las entradas de un grupo deben ir seguidas")
+	}
+	for _, g := range groups {
+		switch {
+		case g.Type == models.Superset && members[g.ID] != 2:
+			report.Errors = append(report.Errors, "una superserie debe tener exactamente 2 ejercicios")
+		case g.Type == models.GiantSet && members[g.ID] < 3:
+			report.Errors = append(report.Errors, "una serie gigante debe tener al menos 3 ejercicios")
+		}
+	}
+	return models.Routine{ExcerciseList: list, Groups: normalizeGroups(list, groups)}
+}
+
+// excerciseMatcher resuelve los ejercicios de una importacion, guardando lo ya buscado para no repetir consultas
+type excerciseMatcher struct {
+	repository repositories.ExcerciseRepositoryInterface
+	byID       map[string]models.Excercise
+	byName     map[string]models.Excercise
+}
+
+// match busca el ejercicio por ID y, si el ID no existe o no vino, por nombre; devuelve por cual lo encontro
+func (m *excerciseMatcher) match(id string, name string) (models.Excercise, string, error) {
+	id, name = strings.TrimSpace(id), strings.TrimSpace(name)
+	if id != "" {
+		excercise, ok := m.byID[id]
+		if !ok {
+			if _, err := primitive.ObjectIDFromHex(id); err == nil {
+				// un ID que no existe en esta instalacion no es un error: se prueba con el nombre
+				excercise, _ = m.repository.GetExcerciseByID(id)
+			}
+			m.byID[id] = excercise
+		}
+		if !excercise.ID.IsZero() {
+			return excercise, "id", nil
+		}
+	}
+	if name == "" {
+		return models.Excercise{}, "", fmt.Errorf("no se encontró ningún ejercicio con ese ID y la fila no tiene nombre")
+	}
+
+	key := strings.ToLower(name)
+	excercise, ok := m.byName[key]
+	if !ok {
+		var err error
+		excercise, err = m.repository.GetExcerciseByName(name)
+		if err != nil {
+			log.Printf("%v", err)
+		}
+		m.byName[key] = excercise
+	}
+	if excercise.ID.IsZero() {
+		return models.Excercise{}, "", fmt.Errorf("no se encontró ningún ejercicio con ese nombre")
+	}
+	return excercise, "name", nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"AppFitness/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffRoutineVersions(t *testing.T) {
+	squat, bench, row := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	entrySquat, entryBench, entryRow := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	group := primitive.NewObjectID()
+
+	previous := models.RoutineVersion{
+		Name: "Pierna",
+		ExcerciseList: []models.ExcerciseInRoutine{
+			{EntryID: entrySquat, ExcerciseID: squat, Repetitions: 5, Series: 5, Weight: 100},
+			{EntryID: entryBench, ExcerciseID: bench, Repetitions: 8, Series: 3, Weight: 60},
+		},
+	}
+	current := models.RoutineVersion{
+		Name: "Pierna y empuje",
+		ExcerciseList: []models.ExcerciseInRoutine{
+			{EntryID: entryBench, ExcerciseID: bench, Repetitions: 8, Series: 3, Weight: 60},
+			{EntryID: entrySquat, ExcerciseID: squat, Repetitions: 5, Series: 5, Weight: 105},
+			{EntryID: entryRow, ExcerciseID: row, Repetitions: 10, Series: 3},
+		},
+		Groups: []models.ExcerciseGroup{{ID: group, Type: models.Superset}},
+	}
+
+	got := map[string]int{}
+	for _, d := range diffRoutineVersions(previous, current) {
+		got[d.Type+"/"+d.Field]++
+		if d.Type == "exercise_changed" && d.Field == "weight" && (d.From != 100.0 || d.To != 105.0) {
+			t.Errorf("cambio de peso %v -> %v, se esperaba 100 -> 105", d.From, d.To)
+		}
+	}
+	want := map[string]int{
+		"name_changed/name":       1,
+		"exercise_changed/weight": 1,
+		"exercise_added/":         1,
+		"order_changed/":          1,
+		"group_added/type":        1,
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Errorf("%s: %d cambios, se esperaba %d (diff: %v)", k, got[k], n, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("cambios inesperados: %v", got)
+	}
+}
+
+// sin entry_id (versiones anteriores a que existiera) las entradas se emparejan por ejercicio
+func TestDiffRoutineVersionsWithoutEntryIDs(t *testing.T) {
+	squat, bench := primitive.NewObjectID(), primitive.NewObjectID()
+	previous := models.RoutineVersion{ExcerciseList: []models.ExcerciseInRoutine{
+		{ExcerciseID: squat, Repetitions: 5, Series: 5},
+		{ExcerciseID: bench, Repetitions: 8, Series: 3},
+	}}
+	current := models.RoutineVersion{ExcerciseList: []models.ExcerciseInRoutine{
+		{EntryID: primitive.NewObjectID(), ExcerciseID: squat, Repetitions: 5, Series: 5},
+	}}
+
+	diff := diffRoutineVersions(previous, current)
+	if len(diff) != 1 || diff[0].Type != "exercise_removed" || diff[0].ExcerciseID != bench.Hex() {
+		t.Fatalf("se esperaba solo la eliminacion del press, se obtuvo %+v", diff)
+	}
+}
+
+func TestProgressionSuggestions(t *testing.T) {
+	squat, pushup, bench := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	routine := &models.Routine{ID: primitive.NewObjectID(), ExcerciseList: []models.ExcerciseInRoutine{
+		{EntryID: primitive.NewObjectID(), ExcerciseID: squat, Series: 2, Repetitions: 5, Weight: 100},
+		{EntryID: primitive.NewObjectID(), ExcerciseID: pushup, Series: 2, Repetitions: 100},           // ya en el maximo de repeticiones
+		{EntryID: primitive.NewObjectID(), ExcerciseID: bench, Series: 2, Repetitions: 5, Weight: 999}, // +2.5 kg se pasa de 1000
+	}}
+	done := func(reps int, weight float64) []models.WorkoutSet {
+		return []models.WorkoutSet{{Repetitions: reps, Weight: weight, Completed: true}, {Repetitions: reps, Weight: weight, Completed: true}}
+	}
+	workouts := []models.Workout{{Exercises: []models.ExcerciseInWorkout{
+		{ExcerciseID: squat, Sets: done(5, 100)},
+		{ExcerciseID: pushup, Sets: done(100, 0)},
+		{ExcerciseID: bench, Sets: done(5, 999)},
+	}}}
+
+	suggestions := progressionSuggestions(routine, workouts, time.Now())
+	if len(suggestions) != 1 || suggestions[0].ExcerciseID != squat {
+		t.Fatalf("solo la sentadilla deberia tener sugerencia, se obtuvo %+v", suggestions)
+	}
+	if s := suggestions[0]; s.Action != increaseWeight || s.Suggested.Weight != 102.5 || s.BasedOn != "2x5 @100kg" {
+		t.Fatalf("sugerencia inesperada: %+v", s)
+	}
+
+	// la sugerencia guardada deja de valer si la entrada se modifica
+	routine.Suggestions = suggestions
+	if len(currentSuggestions(routine)) != 1 {
+		t.Fatal("la sugerencia deberia seguir vigente")
+	}
+	routine.ExcerciseList[0].Weight = 105
+	if len(currentSuggestions(routine)) != 0 {
+		t.Fatal("la sugerencia no deberia valer para una prescripcion distinta")
+	}
+}
+
+func TestDetachedRoutine(t *testing.T) {
+	squat, bench, row, dip := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	group := primitive.NewObjectID()
+	edited := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := edited.Add(time.Hour)
+	routine := models.Routine{
+		Name: "Torso",
+		ExcerciseList: []models.ExcerciseInRoutine{
+			{ExcerciseID: squat, Series: 5, Repetitions: 5},
+			{ExcerciseID: bench, Series: 3, Repetitions: 8, GroupID: group},
+			{ExcerciseID: row, Series: 3, Repetitions: 10, GroupID: group},
+			{ExcerciseID: bench, Series: 2, Repetitions: 12},
+		},
+		Groups:      []models.ExcerciseGroup{{ID: group, Type: models.Superset}},
+		EditionDate: edited,
+	}
+
+	removed := detachedRoutine(routine, bench, nil, now)
+	if len(removed.ExcerciseList) != 2 || removed.ExcerciseList[0].ExcerciseID != squat || removed.ExcerciseList[1].ExcerciseID != row {
+		t.Fatalf("lista inesperada al quitar el ejercicio: %+v", removed.ExcerciseList)
+	}
+	if len(removed.Groups) != 0 || !removed.ExcerciseList[1].GroupID.IsZero() {
+		t.Fatalf("la superserie con un solo ejercicio deberia disolverse: %+v", removed.Groups)
+	}
+	if !removed.EditionDate.Equal(now) {
+		t.Fatalf("fecha de edicion %s, se esperaba %s", removed.EditionDate, now)
+	}
+	if len(routine.ExcerciseList) != 4 || routine.ExcerciseList[2].GroupID != group {
+		t.Fatalf("no deberia modificarse la rutina leida: %+v", routine.ExcerciseList)
+	}
+
+	replaced := detachedRoutine(routine, bench, &models.Excercise{ID: dip}, now)
+	if len(replaced.ExcerciseList) != 4 || replaced.ExcerciseList[1].ExcerciseID != dip || replaced.ExcerciseList[3].ExcerciseID != dip {
+		t.Fatalf("lista inesperada al reemplazar el ejercicio: %+v", replaced.ExcerciseList)
+	}
+	if replaced.ExcerciseList[3].Repetitions != 12 || len(replaced.Groups) != 1 || replaced.ExcerciseList[1].GroupID != group {
+		t.Fatalf("el reemplazo deberia conservar la prescripcion y el grupo: %+v", replaced)
+	}
+}
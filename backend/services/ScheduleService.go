@@ -0,0 +1,556 @@
+package services
+
+import (
+	"AppFitness/dto"
+	"AppFitness/models"
+	"AppFitness/repositories"
+	"AppFitness/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduleInterface interface {
+	PostSchedule(scheduleDTO *dto.ScheduleRegisterDTO) (*dto.ScheduleResponseDTO, error)
+	GetSchedules(userID string) ([]*dto.ScheduleResponseDTO, error)
+	DeleteSchedule(delete dto.ScheduleDeleteDTO) error
+	GetCalendar(filter dto.CalendarFilterDTO) (*dto.CalendarDTO, error)
+	GetCalendarFeed(userID string) (*dto.CalendarFeedDTO, error)
+	RotateCalendarFeed(userID string) (*dto.CalendarFeedDTO, error)
+	RevokeCalendarFeed(userID string) error
+	GetICalendar(token string) (string, error)
+}
+
+type ScheduleService struct {
+	ScheduleRepository  repositories.ScheduleRepositoryInterface
+	RoutineRepository   repositories.RoutineRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+	UserRepository      repositories.UserRepositoryInterface
+	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+}
+
+func NewScheduleService(scheduleRepository repositories.ScheduleRepositoryInterface, routineRepository repositories.RoutineRepositoryInterface, workoutRepository repositories.WorkoutRepositoryInterface, userRepository repositories.UserRepositoryInterface, excerciseRepository repositories.ExcerciseRepositoryInterface) *ScheduleService {
+	return &ScheduleService{
+		ScheduleRepository:  scheduleRepository,
+		RoutineRepository:   routineRepository,
+		WorkoutRepository:   workoutRepository,
+		UserRepository:      userRepository,
+		ExcerciseRepository: excerciseRepository,
+	}
+}
+
+// calendarMaxDays limita el rango del calendario para no generar respuestas enormes
+const calendarMaxDays = 366
+
+func (s *ScheduleService) PostSchedule(scheduleDTO *dto.ScheduleRegisterDTO) (*dto.ScheduleResponseDTO, error) {
+	//validaciones
+	if scheduleDTO.Date == "" && len(scheduleDTO.Weekdays) == 0 {
+		return nil, fmt.Errorf("debe indicar una fecha o al menos un dia de la semana")
+	}
+	if scheduleDTO.Date != "" && len(scheduleDTO.Weekdays) > 0 {
+		return nil, fmt.Errorf("fecha y dias de la semana son excluyentes: fecha inválida")
+	}
+	for _, value := range []string{scheduleDTO.Date, scheduleDTO.StartDate, scheduleDTO.EndDate} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, fmt.Errorf("fecha inválida %q: use YYYY-MM-DD", value)
+		}
+	}
+	if len(scheduleDTO.Weekdays) > 0 && scheduleDTO.StartDate == "" {
+		scheduleDTO.StartDate = time.Now().Format("2006-01-02")
+	}
+	if scheduleDTO.EndDate != "" && scheduleDTO.EndDate < scheduleDTO.StartDate {
+		return nil, fmt.Errorf("rango de fechas inválido: end_date debe ser posterior a start_date")
+	}
+
+	routine, err := s.RoutineRepository.GetRoutineByID(scheduleDTO.RoutineID)
+	if err != nil || routine == nil || routine.ID.IsZero() || !routine.VisibleTo(scheduleDTO.UserID) {
+		return nil, fmt.Errorf("rutina no encontrada")
+	}
+	userOID, err := utils.GetObjectIDFromStringID(scheduleDTO.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuario con formato inválido: %w", err)
+	}
+
+	//LOGICA
+	schedule := models.ScheduledWorkout{
+		UserID:       userOID,
+		RoutineID:    routine.ID,
+		RoutineName:  routine.Name,
+		Date:         scheduleDTO.Date,
+		Weekdays:     uniqueWeekdays(scheduleDTO.Weekdays),
+		StartDate:    scheduleDTO.StartDate,
+		EndDate:      scheduleDTO.EndDate,
+		CreationDate: time.Now(),
+	}
+	if schedule.Date != "" { // las sesiones puntuales no usan vigencia
+		schedule.StartDate, schedule.EndDate = "", ""
+	}
+
+	result, err := s.ScheduleRepository.PostSchedule(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la planificacion: %w", err)
+	}
+	schedule.ID = result.InsertedID.(primitive.ObjectID)
+	return dto.NewScheduleResponseDTO(schedule), nil
+}
+
+func (s *ScheduleService) GetSchedules(userID string) ([]*dto.ScheduleResponseDTO, error) {
+	schedules, err := s.ScheduleRepository.GetSchedulesByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener planificaciones: %w", err)
+	}
+
+	response := []*dto.ScheduleResponseDTO{}
+	for _, schedule := range schedules {
+		response = append(response, dto.NewScheduleResponseDTO(schedule))
+	}
+	return response, nil
+}
+
+func (s *ScheduleService) DeleteSchedule(delete dto.ScheduleDeleteDTO) error {
+	schedule, err := s.ScheduleRepository.GetScheduleByID(delete.ScheduleID)
+	if err != nil {
+		return fmt.Errorf("error al obtener la planificacion: %w", err)
+	}
+	if schedule.ID.IsZero() {
+		return fmt.Errorf("planificacion no encontrada")
+	}
+	if schedule.UserID.Hex() != delete.UserID {
+		return fmt.Errorf("al no ser el creador de dicha planificacion no tienes permisos para esta accion")
+	}
+
+	result, err := s.ScheduleRepository.DeleteSchedule(delete.ScheduleID)
+	if err != nil {
+		return fmt.Errorf("error al eliminar la planificacion: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("no se pudo eliminar la planificacion")
+	}
+	return nil
+}
+
+// GetCalendar cruza las sesiones planificadas con los workouts realizados y marca cada dia como done, missed o upcoming
+func (s *ScheduleService) GetCalendar(filter dto.CalendarFilterDTO) (*dto.CalendarDTO, error) {
+	location, first, last, err := calendarRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.ScheduleRepository.GetSchedulesByUserID(filter.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener planificaciones: %w", err)
+	}
+	workouts, err := s.WorkoutRepository.GetWorkoutsByUserInRange(filter.UserID, first, last.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener workouts: %w", err)
+	}
+
+	// workouts terminados agrupados por dia en la zona del usuario
+	completedByDay := make(map[string][]models.Workout)
+	for _, w := range workouts {
+		if w.IsActive() {
+			continue
+		}
+		day := w.Date.In(location).Format("2006-01-02")
+		completedByDay[day] = append(completedByDay[day], w)
+	}
+
+	today := time.Now().In(location).Format("2006-01-02")
+	calendar := &dto.CalendarDTO{
+		From: first.Format("2006-01-02"),
+		To:   last.Format("2006-01-02"),
+		Days: []dto.CalendarDayDTO{},
+	}
+
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		day := date.Format("2006-01-02")
+		calendarDay := dto.CalendarDayDTO{
+			Date:      day,
+			Planned:   []dto.CalendarPlannedDTO{},
+			Completed: []dto.CalendarCompletedDTO{},
+		}
+
+		// cada workout del dia puede cubrir una sola sesion planificada, priorizando la misma rutina
+		completed := completedByDay[day]
+		used := make([]bool, len(completed))
+		for _, schedule := range schedules {
+			if !schedule.OccursOn(day, date.Weekday()) {
+				continue
+			}
+			planned := dto.CalendarPlannedDTO{
+				ScheduleID:  schedule.ID.Hex(),
+				RoutineID:   schedule.RoutineID.Hex(),
+				RoutineName: schedule.RoutineName,
+			}
+			for i, w := range completed {
+				if !used[i] && w.RoutineID == schedule.RoutineID {
+					used[i], planned.Done = true, true
+					break
+				}
+			}
+			calendarDay.Planned = append(calendarDay.Planned, planned)
+		}
+		for i, w := range completed {
+			calendarDay.Completed = append(calendarDay.Completed, dto.CalendarCompletedDTO{
+				WorkoutID:   w.ID.Hex(),
+				RoutineID:   w.RoutineID.Hex(),
+				RoutineName: w.RoutineName,
+			})
+			// un workout de otra rutina tambien cuenta para una sesion planificada sin cubrir
+			if used[i] {
+				continue
+			}
+			for j := range calendarDay.Planned {
+				if !calendarDay.Planned[j].Done {
+					used[i], calendarDay.Planned[j].Done = true, true
+					break
+				}
+			}
+		}
+
+		if len(calendarDay.Planned) == 0 && len(calendarDay.Completed) == 0 {
+			continue // dia de descanso sin actividad
+		}
+		calendarDay.Status = calendarDayStatus(calendarDay, day, today)
+
+		if day <= today {
+			for _, p := range calendarDay.Planned {
+				calendar.PlannedSessions++
+				if p.Done {
+					calendar.CompletedPlanned++
+				}
+			}
+		}
+		calendar.Days = append(calendar.Days, calendarDay)
+	}
+
+	if calendar.PlannedSessions > 0 {
+		calendar.Adherence = float64(calendar.CompletedPlanned) / float64(calendar.PlannedSessions)
+	}
+	return calendar, nil
+}
+
+func (s *ScheduleService) GetCalendarFeed(userID string) (*dto.CalendarFeedDTO, error) {
+	user, err := s.UserRepository.GetUsersByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el usuario: %w", err)
+	}
+	if user.ID.IsZero() {
+		return nil, fmt.Errorf("usuario no encontrado")
+	}
+	if user.CalendarToken == "" {
+		return nil, fmt.Errorf("feed de calendario no encontrado")
+	}
+	return dto.NewCalendarFeedDTO(user.CalendarToken), nil
+}
+
+// RotateCalendarFeed genera un token nuevo para el feed .ics; la URL anterior deja de funcionar
+func (s *ScheduleService) RotateCalendarFeed(userID string) (*dto.CalendarFeedDTO, error) {
+	token, err := utils.GenerateSecureToken(calendarTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error al generar el token de calendario: %w", err)
+	}
+
+	result, err := s.UserRepository.SetCalendarToken(userID, token)
+	if err != nil {
+		return nil, fmt.Errorf("error al guardar el token de calendario: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("usuario no encontrado")
+	}
+	return dto.NewCalendarFeedDTO(token), nil
+}
+
+func (s *ScheduleService) RevokeCalendarFeed(userID string) error {
+	result, err := s.UserRepository.SetCalendarToken(userID, "")
+	if err != nil {
+		return fmt.Errorf("error al revocar el token de calendario: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("usuario no encontrado")
+	}
+	return nil
+}
+
+// GetICalendar arma el archivo .ics con los workouts terminados y las sesiones planificadas del dueño del token
+func (s *ScheduleService) GetICalendar(token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("feed de calendario no encontrado")
+	}
+	user, err := s.UserRepository.GetUserByCalendarToken(token)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener el usuario del feed: %w", err)
+	}
+	if user.ID.IsZero() {
+		return "", fmt.Errorf("feed de calendario no encontrado")
+	}
+	userID := user.ID.Hex()
+
+	workouts, err := s.WorkoutRepository.GetWorkoutsByUserID(userID)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener workouts: %w", err)
+	}
+	schedules, err := s.ScheduleRepository.GetSchedulesByUserID(userID)
+	if err != nil {
+		return "", fmt.Errorf("error al obtener planificaciones: %w", err)
+	}
+
+	feed := icalFeed{
+		stamp:      time.Now().UTC().Format(icalDateTimeLayout),
+		routines:   make(map[primitive.ObjectID]*models.Routine),
+		excercises: make(map[primitive.ObjectID]string),
+		service:    s,
+	}
+	feed.line("BEGIN:VCALENDAR")
+	feed.line("VERSION:2.0")
+	feed.line("PRODID:-//AppFitness//Calendario de entrenamientos//ES")
+	feed.line("CALSCALE:GREGORIAN")
+	feed.line("METHOD:PUBLISH")
+	feed.line("X-WR-CALNAME:" + utils.EscapeICalText("AppFitness - "+user.UserName))
+
+	for _, w := range workouts {
+		if w.IsActive() {
+			continue // solo se publican los entrenamientos terminados
+		}
+		feed.workoutEvent(w)
+	}
+	for _, schedule := range schedules {
+		feed.scheduleEvent(schedule)
+	}
+
+	feed.line("END:VCALENDAR")
+	return feed.body.String(), nil
+}
+
+const (
+	calendarTokenBytes = 24
+	icalDateTimeLayout = "20060102T150405Z"
+	icalDateLayout     = "20060102"
+)
+
+// rruleWeekdays traduce models.ScheduledWorkout.Weekdays (0 = domingo) al formato BYDAY del RFC 5545
+var rruleWeekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// icalFeed acumula las lineas del .ics y cachea rutinas y nombres de ejercicios para no repetir consultas
+type icalFeed struct {
+	body       strings.Builder
+	stamp      string
+	routines   map[primitive.ObjectID]*models.Routine
+	excercises map[primitive.ObjectID]string
+	service    *ScheduleService
+}
+
+func (f *icalFeed) line(content string) {
+	f.body.WriteString(utils.FoldICalLine(content))
+	f.body.WriteString("\r\n")
+}
+
+func (f *icalFeed) workoutEvent(w models.Workout) {
+	start := w.Date
+	if !w.StartTime.IsZero() {
+		start = w.StartTime
+	}
+	end := w.EndTime
+	if end.IsZero() || !end.After(start) {
+		end = start.Add(time.Hour) // workouts sin duracion registrada se muestran como una hora
+		if w.DurationSeconds > 0 {
+			end = start.Add(time.Duration(w.DurationSeconds) * time.Second)
+		}
+	}
+
+	f.line("BEGIN:VEVENT")
+	f.line("UID:workout-" + w.ID.Hex() + "@appfitness")
+	f.line("DTSTAMP:" + f.stamp)
+	f.line("DTSTART:" + start.UTC().Format(icalDateTimeLayout))
+	f.line("DTEND:" + end.UTC().Format(icalDateTimeLayout))
+	f.line("SUMMARY:" + utils.EscapeICalText("Entrenamiento: "+w.RoutineName))
+	f.line("DESCRIPTION:" + utils.EscapeICalText(f.workoutDescription(w)))
+	f.line("STATUS:CONFIRMED")
+	f.line("END:VEVENT")
+}
+
+func (f *icalFeed) scheduleEvent(schedule models.ScheduledWorkout) {
+	var start time.Time
+	var rrule string
+	if schedule.Date != "" {
+		start, _ = time.Parse("2006-01-02", schedule.Date)
+	} else {
+		first, err := time.Parse("2006-01-02", schedule.StartDate)
+		if err != nil || len(schedule.Weekdays) == 0 {
+			return
+		}
+		// DTSTART tiene que ser la primera ocurrencia real de la regla
+		for !schedule.OccursOn(first.Format("2006-01-02"), first.Weekday()) {
+			first = first.AddDate(0, 0, 1)
+			if schedule.EndDate != "" && first.Format("2006-01-02") > schedule.EndDate {
+				return
+			}
+		}
+		start = first
+
+		days := make([]string, 0, len(schedule.Weekdays))
+		for _, weekday := range schedule.Weekdays {
+			if weekday >= 0 && weekday < len(rruleWeekdays) {
+				days = append(days, rruleWeekdays[weekday])
+			}
+		}
+		rrule = "RRULE:FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",")
+		if end, err := time.Parse("2006-01-02", schedule.EndDate); err == nil {
+			rrule += ";UNTIL=" + end.Format(icalDateLayout)
+		}
+	}
+	if start.IsZero() {
+		return
+	}
+
+	f.line("BEGIN:VEVENT")
+	f.line("UID:schedule-" + schedule.ID.Hex() + "@appfitness")
+	f.line("DTSTAMP:" + f.stamp)
+	f.line("DTSTART;VALUE=DATE:" + start.Format(icalDateLayout))
+	f.line("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format(icalDateLayout))
+	if rrule != "" {
+		f.line(rrule)
+	}
+	f.line("SUMMARY:" + utils.EscapeICalText("Planificado: "+schedule.RoutineName))
+	f.line("DESCRIPTION:" + utils.EscapeICalText(f.routineDescription(schedule.RoutineName, schedule.RoutineID)))
+	f.line("TRANSP:TRANSPARENT")
+	f.line("END:VEVENT")
+}
+
+// workoutDescription lista las series registradas; los workouts sin series muestran lo prescrito en la rutina
+// (del snapshot si lo tienen, para que el evento no cambie al editar la rutina)
+func (f *icalFeed) workoutDescription(w models.Workout) string {
+	if len(w.Exercises) == 0 && w.Routine != nil {
+		lines := []string{"Rutina: " + w.Routine.Name}
+		for _, e := range w.Routine.Exercises {
+			lines = append(lines, fmt.Sprintf("- %s: %s", e.Name, e.Entry().Describe()))
+		}
+		return strings.Join(lines, "\n")
+	}
+	if len(w.Exercises) == 0 {
+		return f.routineDescription(w.RoutineName, w.RoutineID)
+	}
+
+	lines := []string{"Rutina: " + w.RoutineName}
+	for _, e := range w.Exercises {
+		var sets []string
+		for _, set := range e.Sets {
+			if set.Completed {
+				sets = append(sets, set.Describe())
+			}
+		}
+		if len(sets) == 0 {
+			continue
+		}
+		name := w.Routine.ExcerciseName(e.ExcerciseID)
+		if name == "" {
+			name = f.excerciseName(e.ExcerciseID)
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", name, strings.Join(sets, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f *icalFeed) routineDescription(routineName string, routineID primitive.ObjectID) string {
+	lines := []string{"Rutina: " + routineName}
+	routine := f.routine(routineID)
+	if routine == nil {
+		return strings.Join(lines, "\n")
+	}
+	for _, e := range routine.ExcerciseList {
+		if !e.EliminationDate.IsZero() {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", f.excerciseName(e.ExcerciseID), e.Describe()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f *icalFeed) routine(id primitive.ObjectID) *models.Routine {
+	if routine, ok := f.routines[id]; ok {
+		return routine
+	}
+	routine, err := f.service.RoutineRepository.GetRoutineByID(id.Hex())
+	if err != nil || routine == nil || routine.ID.IsZero() {
+		routine = nil // rutina eliminada: el evento queda solo con el nombre
+	}
+	f.routines[id] = routine
+	return routine
+}
+
+func (f *icalFeed) excerciseName(id primitive.ObjectID) string {
+	if name, ok := f.excercises[id]; ok {
+		return name
+	}
+	name := "Ejercicio eliminado"
+	if excercise, err := f.service.ExcerciseRepository.GetExcerciseByID(id.Hex()); err == nil && !excercise.ID.IsZero() {
+		name = excercise.Name
+	}
+	f.excercises[id] = name
+	return name
+}
+
+// calendarDayStatus: done si se cumplio todo lo planificado (o se entreno sin plan), missed si el dia ya paso, upcoming si no
+func calendarDayStatus(calendarDay dto.CalendarDayDTO, day string, today string) string {
+	for _, p := range calendarDay.Planned {
+		if !p.Done {
+			if day < today {
+				return "missed"
+			}
+			return "upcoming"
+		}
+	}
+	return "done"
+}
+
+// calendarRange valida los parametros y devuelve la zona horaria y el primer y ultimo dia (inclusive) del calendario
+func calendarRange(filter dto.CalendarFilterDTO) (*time.Location, time.Time, time.Time, error) {
+	if filter.TimeZone == "" {
+		filter.TimeZone = "UTC"
+	}
+	location, err := time.LoadLocation(filter.TimeZone)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("zona horaria inválida: %s", filter.TimeZone)
+	}
+
+	now := time.Now().In(location)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	last := first.AddDate(0, 1, -1)
+	if filter.From != "" {
+		if first, err = time.ParseInLocation("2006-01-02", filter.From, location); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("fecha inválida %q: use YYYY-MM-DD", filter.From)
+		}
+	}
+	if filter.To != "" {
+		if last, err = time.ParseInLocation("2006-01-02", filter.To, location); err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("fecha inválida %q: use YYYY-MM-DD", filter.To)
+		}
+	}
+	if last.Before(first) {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("rango de fechas inválido: from debe ser anterior a to")
+	}
+	if last.Sub(first).Hours()/24 > calendarMaxDays {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("rango de fechas inválido: máximo %d días", calendarMaxDays)
+	}
+	return location, first, last, nil
+}
+
+// uniqueWeekdays elimina repetidos y ordena los dias de la semana
+func uniqueWeekdays(weekdays []int) []int {
+	seen := make(map[int]bool)
+	var result []int
+	for _, w := range weekdays {
+		if !seen[w] {
+			seen[w] = true
+			result = append(result, w)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"AppFitness/dto"
+	"AppFitness/models"
+	"AppFitness/repositories"
+	"AppFitness/utils"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TaxonomyInterface interface {
+	GetTaxonomy() (dto.TaxonomyDTO, error)
+	PostTerm(term dto.TaxonomyTermRegisterDTO) (*dto.TaxonomyTermDTO, error)
+	PutTerm(term dto.TaxonomyTermModifyDTO) (*dto.TaxonomyTermDTO, error)
+	DeleteTerm(kind string, key string) error
+	Vocabularies() (Vocabularies, error)
+	SeedDefaults() error
+	NormalizeMuscleGroups() (dto.MuscleGroupMigrationDTO, error)
+}
+
+type TaxonomyService struct {
+	TaxonomyRepository  repositories.TaxonomyRepositoryInterface
+	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+}
+
+func NewTaxonomyService(taxonomyRepository repositories.TaxonomyRepositoryInterface, excerciseRepository repositories.ExcerciseRepositoryInterface, workoutRepository repositories.WorkoutRepositoryInterface) *TaxonomyService {
+	return &TaxonomyService{
+		TaxonomyRepository:  taxonomyRepository,
+		ExcerciseRepository: excerciseRepository,
+		WorkoutRepository:   workoutRepository,
+	}
+}
+
+// Vocabularies son los terminos de cada vocabulario, cargados una vez para validar o filtrar varios campos
+type Vocabularies map[models.TaxonomyKind][]models.TaxonomyTerm
+
+// Resolve busca value entre las claves, nombres y alias del vocabulario, sin distinguir mayusculas ni acentos, y
+// devuelve la clave del termino
+func (v Vocabularies) Resolve(kind models.TaxonomyKind, value string) (string, bool) {
+	normalized := utils.NormalizeText(value)
+	if normalized == "" {
+		return "", false
+	}
+	for _, term := range v[kind] {
+		if term.Key == value || utils.NormalizeText(term.Key) == normalized || utils.NormalizeText(term.Name) == normalized {
+			return term.Key, true
+		}
+		for _, alias := range term.Aliases {
+			if utils.NormalizeText(alias) == normalized {
+				return term.Key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Label devuelve el nombre del termino con esa clave, o vacio si la clave no esta en el vocabulario
+func (v Vocabularies) Label(kind models.TaxonomyKind, key string) string {
+	for _, term := range v[kind] {
+		if term.Key == key {
+			return term.Name
+		}
+	}
+	return ""
+}
+
+// taxonomyKeyPattern son las claves validas: minusculas, digitos y guion bajo ("pullup_bar")
+var taxonomyKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// taxonomyKind valida el tipo de vocabulario recibido en la URL
+func taxonomyKind(kind string) (models.TaxonomyKind, error) {
+	for _, k := range models.TaxonomyKinds {
+		if string(k) == kind {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("tipo de vocabulario inválido: use muscle, equipment, mechanics, force o laterality")
+}
+
+// GetTaxonomy devuelve todos los vocabularios; cada tipo aparece aunque no tenga terminos
+func (s *TaxonomyService) GetTaxonomy() (dto.TaxonomyDTO, error) {
+	terms, err := s.TaxonomyRepository.GetTerms("")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los vocabularios: %w", err)
+	}
+
+	taxonomy := dto.TaxonomyDTO{}
+	for _, kind := range models.TaxonomyKinds {
+		taxonomy[string(kind)] = []*dto.TaxonomyTermDTO{}
+	}
+	for _, term := range terms {
+		taxonomy[string(term.Kind)] = append(taxonomy[string(term.Kind)], dto.NewTaxonomyTermDTO(term))
+	}
+	return taxonomy, nil
+}
+
+func (s *TaxonomyService) Vocabularies() (Vocabularies, error) {
+	terms, err := s.TaxonomyRepository.GetTerms("")
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los vocabularios: %w", err)
+	}
+	vocabularies := Vocabularies{}
+	for _, term := range terms {
+		vocabularies[term.Kind] = append(vocabularies[term.Kind], term)
+	}
+	return vocabularies, nil
+}
+
+func (s *TaxonomyService) PostTerm(termDTO dto.TaxonomyTermRegisterDTO) (*dto.TaxonomyTermDTO, error) {
+	kind, err := taxonomyKind(termDTO.Kind)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.TrimSpace(termDTO.Key)
+	if !taxonomyKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("clave inválida: use minúsculas, dígitos y guion bajo (ej. pullup_bar)")
+	}
+	term := models.TaxonomyTerm{
+		Kind:    kind,
+		Key:     key,
+		Name:    strings.TrimSpace(termDTO.Name),
+		Aliases: termDTO.Aliases,
+	}
+	if err := s.checkCollisions(term); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	term.CreationDate = now
+	term.EditionDate = now
+	result, err := s.TaxonomyRepository.PostTerm(term)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el término: %w", err)
+	}
+	term.ID = result.InsertedID.(primitive.ObjectID)
+	return dto.NewTaxonomyTermDTO(term), nil
+}
+
+// PutTerm cambia el nombre y los alias; los ejercicios guardan la clave, asi que no hace falta tocarlos
+func (s *TaxonomyService) PutTerm(termDTO dto.TaxonomyTermModifyDTO) (*dto.TaxonomyTermDTO, error) {
+	kind, err := taxonomyKind(termDTO.Kind)
+	if err != nil {
+		return nil, err
+	}
+	term, err := s.TaxonomyRepository.GetTerm(kind, termDTO.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el término: %w", err)
+	}
+	if term.ID.IsZero() {
+		return nil, fmt.Errorf("no existe el término %s en el vocabulario %s", termDTO.Key, kind)
+	}
+
+	term.Name = strings.TrimSpace(termDTO.Name)
+	term.Aliases = termDTO.Aliases
+	if err := s.checkCollisions(term); err != nil {
+		return nil, err
+	}
+	term.EditionDate = time.Now()
+	if _, err := s.TaxonomyRepository.PutTerm(term); err != nil {
+		return nil, fmt.Errorf("error al modificar el término: %w", err)
+	}
+	return dto.NewTaxonomyTermDTO(term), nil
+}
+
+// DeleteTerm borra un termino que ningun ejercicio usa; si esta en uso hay que reclasificar esos ejercicios antes
+func (s *TaxonomyService) DeleteTerm(kindParam string, key string) error {
+	kind, err := taxonomyKind(kindParam)
+	if err != nil {
+		return err
+	}
+	term, err := s.TaxonomyRepository.GetTerm(kind, key)
+	if err != nil {
+		return fmt.Errorf("error al obtener el término: %w", err)
+	}
+	if term.ID.IsZero() {
+		return fmt.Errorf("no existe el término %s en el vocabulario %s", key, kind)
+	}
+
+	used, err := s.ExcerciseRepository.CountByTaxonomy(kind, key)
+	if err != nil {
+		return fmt.Errorf("error al verificar los ejercicios que usan el término: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("el término está en uso en %d ejercicios", used)
+	}
+
+	if _, err := s.TaxonomyRepository.DeleteTerm(kind, key); err != nil {
+		return fmt.Errorf("error al eliminar el término: %w", err)
+	}
+	return nil
+}
+
+// checkCollisions impide que la clave, el nombre o un alias del termino ya identifiquen a otro termino del mismo
+// vocabulario, porque al cargar un ejercicio no se sabria a cual se refiere
+func (s *TaxonomyService) checkCollisions(term models.TaxonomyTerm) error {
+	terms, err := s.TaxonomyRepository.GetTerms(term.Kind)
+	if err != nil {
+		return fmt.Errorf("error al verificar el vocabulario: %w", err)
+	}
+	vocabulary := Vocabularies{}
+	for _, other := range terms {
+		if other.ID != term.ID {
+			vocabulary[term.Kind] = append(vocabulary[term.Kind], other)
+		}
+	}
+
+	for _, value := range append([]string{term.Key, term.Name}, term.Aliases...) {
+		if existing, found := vocabulary.Resolve(term.Kind, value); found {
+			return fmt.Errorf("ya existe un término (%s) que se reconoce como %q", existing, value)
+		}
+	}
+	return nil
+}
+
+// defaultTaxonomy son los vocabularios con los que arranca una instalacion nueva; despues los administra el admin
+var defaultTaxonomy = map[models.TaxonomyKind][]models.TaxonomyTerm{
+	models.TaxonomyMuscle: {
+		{Key: "chest", Name: "Pecho", Aliases: []string{"pectorales", "pectoral"}},
+		{Key: "back", Name: "Espalda", Aliases: []string{"dorsales", "dorsal"}},
+		{Key: "lower_back", Name: "Zona lumbar", Aliases: []string{"lumbares", "lumbar"}},
+		{Key: "traps", Name: "Trapecio", Aliases: []string{"trapecios"}},
+		{Key: "shoulders", Name: "Hombros", Aliases: []string{"hombro", "deltoides"}},
+		{Key: "biceps", Name: "Bíceps"},
+		{Key: "triceps", Name: "Tríceps"},
+		{Key: "forearms", Name: "Antebrazos", Aliases: []string{"antebrazo"}},
+		{Key: "abs", Name: "Abdominales", Aliases: []string{"abdomen", "core"}},
+		{Key: "obliques", Name: "Oblicuos"},
+		{Key: "glutes", Name: "Glúteos", Aliases: []string{"gluteo"}},
+		{Key: "legs", Name: "Piernas", Aliases: []string{"pierna", "tren inferior"}},
+		{Key: "quadriceps", Name: "Cuádriceps"},
+		{Key: "hamstrings", Name: "Isquiotibiales", Aliases: []string{"femorales", "isquios"}},
+		{Key: "calves", Name: "Gemelos", Aliases: []string{"pantorrillas", "pantorrilla"}},
+		{Key: "adductors", Name: "Aductores"},
+		{Key: "full_body", Name: "Cuerpo completo", Aliases: []string{"full body"}},
+	},
+	models.TaxonomyEquipment: {
+		{Key: "barbell", Name: "Barra"},
+		{Key: "dumbbell", Name: "Mancuerna", Aliases: []string{"mancuernas"}},
+		{Key: "kettlebell", Name: "Pesa rusa"},
+		{Key: "machine", Name: "Máquina"},
+		{Key: "smith_machine", Name: "Máquina Smith", Aliases: []string{"multipower"}},
+		{Key: "cable", Name: "Polea", Aliases: []string{"poleas"}},
+		{Key: "bodyweight", Name: "Peso corporal", Aliases: []string{"sin equipo"}},
+		{Key: "band", Name: "Banda elástica", Aliases: []string{"banda", "bandas"}},
+		{Key: "bench", Name: "Banco"},
+		{Key: "pullup_bar", Name: "Barra de dominadas"},
+		{Key: "medicine_ball", Name: "Balón medicinal"},
+		{Key: "suspension", Name: "Suspensión", Aliases: []string{"trx"}},
+		{Key: "cardio_machine", Name: "Máquina de cardio", Aliases: []string{"cinta", "bicicleta fija", "eliptica"}},
+	},
+	models.TaxonomyMechanics: {
+		{Key: "compound", Name: "Compuesto", Aliases: []string{"multiarticular"}},
+		{Key: "isolation", Name: "Aislamiento", Aliases: []string{"monoarticular"}},
+	},
+	models.TaxonomyForce: {
+		{Key: "push", Name: "Empuje"},
+		{Key: "pull", Name: "Tracción", Aliases: []string{"tiron"}},
+		{Key: "static", Name: "Estático", Aliases: []string{"isometrico"}},
+	},
+	models.TaxonomyLaterality: {
+		{Key: "bilateral", Name: "Bilateral"},
+		{Key: "unilateral", Name: "Unilateral"},
+		{Key: "alternating", Name: "Alternado"},
+	},
+}
+
+// SeedDefaults carga los terminos por defecto de los vocabularios que estan vacios
+func (s *TaxonomyService) SeedDefaults() error {
+	now := time.Now()
+	for _, kind := range models.TaxonomyKinds {
+		count, err := s.TaxonomyRepository.CountTerms(kind)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		for _, term := range defaultTaxonomy[kind] {
+			term.Kind = kind
+			term.CreationDate = now
+			term.EditionDate = now
+			if _, err := s.TaxonomyRepository.PostTerm(term); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeMuscleGroups es la migracion que pasa a la clave del vocabulario los grupos musculares que los
+// ejercicios y los snapshots de los workouts anteriores guardaban como texto libre ("Pecho" -> chest). No corre
+// sola al arrancar: la lanza un admin una vez, y volver a lanzarla no cambia nada ya normalizado
+func (s *TaxonomyService) NormalizeMuscleGroups() (dto.MuscleGroupMigrationDTO, error) {
+	var result dto.MuscleGroupMigrationDTO
+	muscles, err := s.TaxonomyRepository.GetTerms(models.TaxonomyMuscle)
+	if err != nil {
+		return result, fmt.Errorf("error al obtener el vocabulario de músculos: %w", err)
+	}
+	for _, term := range muscles {
+		values := append([]string{term.Key, term.Name}, term.Aliases...)
+		excercises, err := s.ExcerciseRepository.NormalizeMuscleGroups(values, term.Key)
+		if err != nil {
+			return result, err
+		}
+		result.Excercises += excercises
+		workouts, err := s.WorkoutRepository.NormalizeSnapshotMuscleGroups(values, term.Key)
+		if err != nil {
+			return result, err
+		}
+		result.Workouts += workouts
+	}
+	return result, nil
+}
@@ -14,7 +14,7 @@ import (
 
 type UserInterface interface {
 	PostUser(user *dto.UserRegisterDTO) (*dto.UserResponseDTO, error)
-	GetUsers() ([]*dto.UserResponseDTO, error)
+	GetUsers(page dto.PageQueryDTO) (*dto.PageDTO, error)
 	GetUserByID(id string) (*dto.UserResponseDTO, error)
 	PutUser(user *dto.UserModifyDTO) (*dto.UserModifyResponseDTO, error)
 	PasswordModify(dto dto.PasswordChange, id string) (bool, error)
@@ -83,8 +83,13 @@ func (service *UserService) PostUser(userDto *dto.UserRegisterDTO) (*dto.UserRes
 	return userResponse, nil
 }
 
-func (services *UserService) GetUsers() ([]*dto.UserResponseDTO, error) {
-	usersDB, err := services.UserRepository.GetUsers()
+// GetUsers devuelve los usuarios paginados, por defecto ordenados por nombre de usuario
+func (services *UserService) GetUsers(page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.UserListFields, "UserName")
+	if err != nil {
+		return nil, err
+	}
+	usersDB, info, err := services.UserRepository.GetUsersPage(request)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener usuarios: %w", err)
 	}
@@ -95,7 +100,7 @@ func (services *UserService) GetUsers() ([]*dto.UserResponseDTO, error) {
 		users = append(users, user)
 	}
 
-	return users, nil
+	return dto.NewPageDTO(users, info, request.Fields)
 }
 
 func (services *UserService) GetUserByID(id string) (*dto.UserResponseDTO, error) {
@@ -138,9 +143,12 @@ func (s *UserService) PutUser(newData *dto.UserModifyDTO) (*dto.UserModifyRespon
 	if err != nil {
 		return nil, err
 	}
+	if newData.WeeklyGoal == nil { // la respuesta muestra el objetivo que sigue guardado
+		userDB.WeeklyGoal = user.WeeklyGoal
+	}
 	userResp := dto.NewUserModifyResponseDTO(userDB)
 
-	if _, err := s.UserRepository.PutUser(userDB); err != nil {
+	if _, err := s.UserRepository.PutUser(userDB, newData.WeeklyGoal != nil); err != nil {
 		return nil, fmt.Errorf("error al modificar usuario: %w", err)
 	}
 
@@ -2,10 +2,15 @@ package services
 
 import (
 	"AppFitness/dto"
+	"AppFitness/models"
 	"AppFitness/repositories"
 	"AppFitness/utils"
 	"fmt"
+	"log"
+	"math"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,23 +18,39 @@ import (
 
 type WorkoutInterface interface {
 	PostWorkout(*dto.WorkoutRegisterDTO) (*dto.WorkoutResponseDTO, error)
-	GetWorkouts(idUser string) ([]*dto.WorkoutResponseDTO, error)
+	GetWorkouts(idUser string, page dto.PageQueryDTO) (*dto.PageDTO, error)
 	GetWorkoutByID(workoutID string, userID string) (*dto.WorkoutResponseDTO, error)
+	PutWorkout(modify *dto.WorkoutModifyDTO) (*dto.WorkoutResponseDTO, error)
 	DeleteWorkout(dto.WorkoutDeleteDTO) error
-	GetWorkoutStats(userID string) (*dto.WorkoutStatsDTO, error)
+	RestoreWorkout(dto.WorkoutDeleteDTO) (*dto.WorkoutResponseDTO, error)
+	GetWorkoutStats(filter dto.WorkoutStatsFilterDTO) (*dto.WorkoutStatsDTO, error)
+	GetExcerciseProgress(userID string, excerciseID string, formula string, granularity string) (*dto.ExcerciseProgressDTO, error)
+	StartWorkout(*dto.WorkoutRegisterDTO) (*dto.WorkoutResponseDTO, error)
+	GetActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error)
+	AddSetToActiveWorkout(set *dto.WorkoutSetRegisterDTO) (*dto.WorkoutResponseDTO, error)
+	PauseActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error)
+	ResumeActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error)
+	FinishActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error)
+	ImportWorkouts(importDTO dto.WorkoutImportDTO) (*dto.WorkoutImportResultDTO, error)
 }
 
 type WorkoutService struct {
-	WorkoutRepository repositories.WorkoutRepositoryInterface
-	RoutineRepository repositories.RoutineRepositoryInterface
-	UserRepository    repositories.UserRepositoryInterface
+	WorkoutRepository   repositories.WorkoutRepositoryInterface
+	RoutineRepository   repositories.RoutineRepositoryInterface
+	UserRepository      repositories.UserRepositoryInterface
+	ExcerciseRepository repositories.ExcerciseRepositoryInterface
+	RecordService       PersonalRecordInterface
+	AbandonTimeout      time.Duration // inactividad tras la cual un workout en curso se cierra solo
 }
 
-func NewWorkoutService(workoutRepository repositories.WorkoutRepositoryInterface, routineRepository repositories.RoutineRepositoryInterface, userRepository repositories.UserRepositoryInterface) *WorkoutService {
+func NewWorkoutService(workoutRepository repositories.WorkoutRepositoryInterface, routineRepository repositories.RoutineRepositoryInterface, userRepository repositories.UserRepositoryInterface, excerciseRepository repositories.ExcerciseRepositoryInterface, recordService PersonalRecordInterface, abandonTimeout time.Duration) *WorkoutService {
 	return &WorkoutService{
-		WorkoutRepository: workoutRepository,
-		RoutineRepository: routineRepository,
-		UserRepository:    userRepository,
+		WorkoutRepository:   workoutRepository,
+		RoutineRepository:   routineRepository,
+		UserRepository:      userRepository,
+		ExcerciseRepository: excerciseRepository,
+		RecordService:       recordService,
+		AbandonTimeout:      abandonTimeout,
 	}
 }
 
@@ -38,7 +59,7 @@ func (ws WorkoutService) PostWorkout(workoutDTO *dto.WorkoutRegisterDTO /*UserID
 	if err != nil {
 		return nil, fmt.Errorf("rutina no encontrada: %w", err)
 	}
-	if result.ID.IsZero() {
+	if result.ID.IsZero() || !result.VisibleTo(workoutDTO.UserID) {
 		return nil, fmt.Errorf("rutina no encontrada")
 	}
 
@@ -46,8 +67,24 @@ func (ws WorkoutService) PostWorkout(workoutDTO *dto.WorkoutRegisterDTO /*UserID
 	if err != nil {
 		return nil, err
 	}
-	workoutModel.Date = time.Now()
+	if err := validateExcercisesInWorkout(workoutModel.Exercises, result); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	date := now
+	if workoutDTO.Date != nil { // workout olvidado que se registra despues
+		if err := validateWorkoutDate(*workoutDTO.Date, now); err != nil {
+			return nil, err
+		}
+		date = *workoutDTO.Date
+	}
+	workoutModel.Date = date
 	workoutModel.RoutineName = result.Name
+	workoutModel.Routine = ws.snapshotRoutine(result, now)
+	workoutModel.Status = models.WorkoutFinished
+	workoutModel.StartTime = date
+	workoutModel.EndTime = date
+	workoutModel.LastActivity = now
 
 	insertResult, err := ws.WorkoutRepository.PostWorkout(workoutModel)
 	if err != nil {
@@ -68,6 +105,13 @@ func (ws WorkoutService) PostWorkout(workoutDTO *dto.WorkoutRegisterDTO /*UserID
 	if createdWorkout.ID.IsZero() {
 		return nil, fmt.Errorf("workout creado no encontrado")
 	}
+	if workoutDTO.Date != nil {
+		// con fecha pasada los records posteriores pueden dejar de serlo: se recalculan
+		ws.rebuildRecords(createdWorkout.UserID.Hex(), createdWorkout.Exercises)
+	} else {
+		ws.detectRecords(createdWorkout)
+	}
+	ws.refreshSuggestions(workoutDTO.UserID, createdWorkout.RoutineID)
 
 	//convertir a dto y devolver
 	workoutResponse := dto.NewWorkoutResponseDTO(createdWorkout)
@@ -75,8 +119,49 @@ func (ws WorkoutService) PostWorkout(workoutDTO *dto.WorkoutRegisterDTO /*UserID
 	return workoutResponse, nil
 }
 
-// GetWorkouts obtiene todos los workouts de un usuario específico
-func (ws WorkoutService) GetWorkouts(idUser string) ([]*dto.WorkoutResponseDTO, error) {
+// validateExcercisesInWorkout comprueba que cada ejercicio registrado pertenezca a la rutina y que sus series sean coherentes
+// (routine nil = la rutina ya no existe y solo se validan las series)
+func validateExcercisesInWorkout(exercises []models.ExcerciseInWorkout, routine *models.Routine) error {
+	inRoutine := make(map[primitive.ObjectID]bool)
+	entries := make(map[primitive.ObjectID]primitive.ObjectID) // entrada -> ejercicio
+	if routine != nil {
+		for _, e := range routine.ExcerciseList {
+			inRoutine[e.ExcerciseID] = true
+			entries[e.EntryID] = e.ExcerciseID
+		}
+	}
+
+	for _, e := range exercises {
+		if routine != nil && !inRoutine[e.ExcerciseID] {
+			return fmt.Errorf("el ejercicio %s no pertenece a la rutina", e.ExcerciseID.Hex())
+		}
+		if routine != nil && !e.EntryID.IsZero() && entries[e.EntryID] != e.ExcerciseID {
+			return fmt.Errorf("entrada inválida: %s no es una entrada del ejercicio %s en la rutina", e.EntryID.Hex(), e.ExcerciseID.Hex())
+		}
+		if len(e.Sets) == 0 {
+			return fmt.Errorf("el ejercicio %s debe tener al menos una serie registrada", e.ExcerciseID.Hex())
+		}
+		for _, s := range e.Sets {
+			if s.Repetitions < 0 || s.Weight < 0 {
+				return fmt.Errorf("repeticiones o peso inválidos en el ejercicio %s", e.ExcerciseID.Hex())
+			}
+			if s.DurationSeconds < 0 || s.DistanceMeters < 0 || s.HeartRate < 0 {
+				return fmt.Errorf("duración, distancia o pulso inválidos en el ejercicio %s", e.ExcerciseID.Hex())
+			}
+			if s.RPE != 0 && (s.RPE < 1 || s.RPE > 10) {
+				return fmt.Errorf("RPE inválido en el ejercicio %s: debe estar entre 1 y 10", e.ExcerciseID.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// GetWorkouts obtiene el historial paginado de un usuario específico, por defecto del mas reciente al mas viejo
+func (ws WorkoutService) GetWorkouts(idUser string, page dto.PageQueryDTO) (*dto.PageDTO, error) {
+	request, err := page.Resolve(dto.WorkoutListFields, "-DoneAt")
+	if err != nil {
+		return nil, err
+	}
 
 	//validar existencia de user
 	user, err := ws.UserRepository.GetUsersByID(idUser)
@@ -88,11 +173,11 @@ func (ws WorkoutService) GetWorkouts(idUser string) ([]*dto.WorkoutResponseDTO,
 	}
 
 	//obtener workouts del user
-	workoutsModel, err := ws.WorkoutRepository.GetWorkoutsByUserID(idUser)
+	workoutsModel, info, err := ws.WorkoutRepository.GetWorkoutsByUserIDPage(idUser, request)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener workouts: %w", err)
 	}
-	if len(workoutsModel) == 0 {
+	if len(workoutsModel) == 0 && request.Cursor == nil {
 		return nil, fmt.Errorf("no se encontraron workouts para el usuario")
 	}
 
@@ -102,7 +187,7 @@ func (ws WorkoutService) GetWorkouts(idUser string) ([]*dto.WorkoutResponseDTO,
 		workoutsDTO = append(workoutsDTO, workoutDTO)
 	}
 
-	return workoutsDTO, nil
+	return dto.NewPageDTO(workoutsDTO, info, request.Fields)
 }
 
 // GetWorkoutByID obtiene un workout por su ID
@@ -125,6 +210,122 @@ func (ws WorkoutService) GetWorkoutByID(workoutID string, userID string) (*dto.W
 	return workoutDTO, nil
 }
 
+// snapshotRoutine copia los ejercicios vigentes de la rutina con su nombre y grupo muscular actuales
+func (ws WorkoutService) snapshotRoutine(routine *models.Routine, now time.Time) *models.RoutineSnapshot {
+	snapshot := &models.RoutineSnapshot{
+		Name:      routine.Name,
+		Exercises: []models.ExcerciseSnapshot{},
+		Groups:    routine.Groups,
+		TakenAt:   now,
+	}
+	for _, e := range routine.ExcerciseList {
+		if !e.EliminationDate.IsZero() {
+			continue
+		}
+		excerciseSnapshot := models.ExcerciseSnapshot{
+			EntryID:     e.EntryID,
+			ExcerciseID: e.ExcerciseID,
+			GroupID:     e.GroupID,
+			Repetitions: e.Repetitions,
+			Series:      e.Series,
+			Weight:      e.Weight,
+			Sets:        e.Sets,
+			RestSeconds: e.RestSeconds,
+			Tempo:       e.Tempo,
+		}
+		// un ejercicio que ya no existe queda en el snapshot sin nombre en vez de romper el workout
+		if excercise, err := ws.ExcerciseRepository.GetExcerciseByID(e.ExcerciseID.Hex()); err == nil {
+			excerciseSnapshot.Name = excercise.Name
+			excerciseSnapshot.Category = excercise.Category
+			excerciseSnapshot.MainMuscleGroup = excercise.MainMuscleGroup
+		}
+		snapshot.Exercises = append(snapshot.Exercises, excerciseSnapshot)
+	}
+	return snapshot
+}
+
+// validateWorkoutDate evita fechas futuras o vacias al registrar o editar un workout
+func validateWorkoutDate(date time.Time, now time.Time) error {
+	if date.IsZero() {
+		return fmt.Errorf("fecha inválida: no puede estar vacia")
+	}
+	if date.After(now.Add(workoutDateTolerance)) {
+		return fmt.Errorf("fecha inválida: el workout no puede estar en el futuro")
+	}
+	return nil
+}
+
+// workoutDateTolerance absorbe la diferencia de reloj entre el cliente y el servidor
+const workoutDateTolerance = 5 * time.Minute
+
+// PutWorkout edita fecha, notas y series de un workout terminado del usuario y recalcula sus records
+func (ws WorkoutService) PutWorkout(modify *dto.WorkoutModifyDTO) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.WorkoutRepository.GetWorkoutByID(modify.WorkoutID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener workout: %w", err)
+	}
+	if workout.ID.IsZero() {
+		return nil, fmt.Errorf("workout no encontrado")
+	}
+	if utils.GetStringIDFromObjectID(workout.UserID) != modify.UserID {
+		return nil, fmt.Errorf("el usuario no tiene permiso para acceder a workouts")
+	}
+	if workout.IsActive() {
+		return nil, fmt.Errorf("no se puede editar un workout en curso: use los endpoints de /active")
+	}
+
+	now := time.Now()
+	touched := workout.Exercises // ejercicios cuyos records hay que recalcular (antes y despues de editar)
+
+	if modify.Date != nil {
+		if err := validateWorkoutDate(*modify.Date, now); err != nil {
+			return nil, err
+		}
+		// se corre el workout completo conservando su duracion
+		shift := modify.Date.Sub(workout.Date)
+		workout.Date = *modify.Date
+		if !workout.StartTime.IsZero() {
+			workout.StartTime = workout.StartTime.Add(shift)
+		}
+		if !workout.EndTime.IsZero() {
+			workout.EndTime = workout.EndTime.Add(shift)
+		}
+	}
+	if modify.Notes != nil {
+		workout.Notes = *modify.Notes
+	}
+	if modify.Exercises != nil {
+		exercises, err := dto.GetModelExcercisesInWorkoutDTO(*modify.Exercises)
+		if err != nil {
+			return nil, err
+		}
+		routine, err := ws.RoutineRepository.GetRoutineByID(workout.RoutineID.Hex())
+		if err != nil || routine == nil || routine.ID.IsZero() {
+			routine = nil
+		}
+		if err := validateExcercisesInWorkout(exercises, routine); err != nil {
+			return nil, err
+		}
+		workout.Exercises = exercises
+		touched = append(touched, exercises...)
+	}
+	workout.EditionDate = now
+
+	result, err := ws.WorkoutRepository.PutWorkout(workout)
+	if err != nil {
+		return nil, fmt.Errorf("error al modificar el workout: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("workout no encontrado")
+	}
+
+	if modify.Date != nil || modify.Exercises != nil {
+		ws.rebuildRecords(modify.UserID, touched)
+		ws.refreshSuggestions(modify.UserID, workout.RoutineID)
+	}
+	return ws.getWorkoutResponse(workout.ID)
+}
+
 func (ws WorkoutService) DeleteWorkout(delete dto.WorkoutDeleteDTO) error {
 	//validacion de existencia de workout
 	workout, err := ws.WorkoutRepository.GetWorkoutByID(delete.RoutineID)
@@ -139,21 +340,57 @@ func (ws WorkoutService) DeleteWorkout(delete dto.WorkoutDeleteDTO) error {
 		return fmt.Errorf("al no ser el creador de dicho workout no tienes permisos para esta accion")
 	}
 
+	// un workout en curso se cierra antes de mandarlo a la papelera, si no seguiria ocupando el unico lugar de
+	// workout en curso del usuario
+	if workout.Status == models.WorkoutInProgress {
+		closeWorkout(&workout, time.Now(), models.WorkoutAbandoned)
+		if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+			return fmt.Errorf("error al eliminar workout: %w", err)
+		}
+	}
+
 	//eliminar workout
 	result, err := ws.WorkoutRepository.DeleteWorkout(delete.RoutineID)
 	if err != nil {
 		return fmt.Errorf("error al eliminar workout: %w", err)
 	}
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return fmt.Errorf("no se pudo eliminar el workout")
 	}
+	ws.rebuildRecords(delete.UserID, workout.Exercises) // los records que aportaba el workout ya no valen
+	ws.refreshSuggestions(delete.UserID, workout.RoutineID)
 	return nil
 }
 
-func (ws WorkoutService) GetWorkoutStats(userID string) (*dto.WorkoutStatsDTO, error) {
+// RestoreWorkout saca de la papelera un workout del usuario y vuelve a contar sus records
+func (ws WorkoutService) RestoreWorkout(restore dto.WorkoutDeleteDTO) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.WorkoutRepository.GetDeletedWorkoutByID(restore.RoutineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener workout: %w", err)
+	}
+	if workout.ID.IsZero() {
+		return nil, fmt.Errorf("workout eliminado no encontrado")
+	}
+	if restore.UserID != workout.UserID.Hex() {
+		return nil, fmt.Errorf("al no ser el creador de dicho workout no tienes permisos para esta accion")
+	}
+
+	result, err := ws.WorkoutRepository.RestoreWorkout(restore.RoutineID)
+	if err != nil {
+		return nil, fmt.Errorf("error al restaurar workout: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("workout eliminado no encontrado")
+	}
+	ws.rebuildRecords(restore.UserID, workout.Exercises)
+	ws.refreshSuggestions(restore.UserID, workout.RoutineID)
+	return ws.getWorkoutResponse(workout.ID)
+}
+
+func (ws WorkoutService) GetWorkoutStats(filter dto.WorkoutStatsFilterDTO) (*dto.WorkoutStatsDTO, error) {
 
 	// validacion de existencia de user
-	userModel, err := ws.UserRepository.GetUsersByID(userID)
+	userModel, err := ws.UserRepository.GetUsersByID(filter.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("Error al recuperar usuario")
 	}
@@ -161,34 +398,80 @@ func (ws WorkoutService) GetWorkoutStats(userID string) (*dto.WorkoutStatsDTO, e
 		return nil, fmt.Errorf("No se encontro user")
 	}
 
-	// logica
-	workoutsUser, err := ws.WorkoutRepository.GetWorkoutsByUserID(userID) // lista de workouts del user
+	// validacion de parametros
+	if filter.TimeZone == "" {
+		filter.TimeZone = "UTC"
+	}
+	location, err := time.LoadLocation(filter.TimeZone)
+	if err != nil || filter.TimeZone == "Local" { // "Local" depende del servidor y MongoDB no la reconoce
+		return nil, fmt.Errorf("zona horaria inválida: %s", filter.TimeZone)
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = "month"
+	}
+	dateFormat, ok := statsDateFormats[filter.Granularity]
+	if !ok {
+		return nil, errGranularity
+	}
+	from, err := parseStatsDate(filter.From, location, false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseStatsDate(filter.To, location, true)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, fmt.Errorf("rango de fechas inválido: from debe ser anterior a to")
+	}
+
+	// logica: la agregacion se resuelve en MongoDB
+	aggregate, err := ws.WorkoutRepository.GetWorkoutStats(filter.UserID, from, to, dateFormat, location.String())
 	if err != nil {
 		return nil, fmt.Errorf("Error al obtener workouts")
 	}
 
 	// Inicializamos el DTO con valores por defecto para evitar nulos en el JSON
 	status := &dto.WorkoutStatsDTO{
-		TotalWorkouts:    len(workoutsUser),
-		MostUsedRoutines: []dto.RoutineUsageDTO{},
-		ProgressOverTime: []dto.ProgressPointDTO{},
-		WeeklyFrequency:  0.0,
+		TotalWorkouts:        aggregate.Total,
+		TotalDurationSeconds: aggregate.DurationSeconds,
+		TotalDistanceMeters:  aggregate.DistanceMeters,
+		MostUsedRoutines:     []dto.RoutineUsageDTO{},
+		ProgressOverTime:     []dto.ProgressPointDTO{},
+		WeeklyFrequency:      0.0,
+		WeeklyAdherence:      []dto.WeeklyAdherenceDTO{},
 	}
 
-	// Si hay 0 o 1 workout, devolvemos lo básico porque no se puede calcular frecuencia entre fechas
-	if len(workoutsUser) <= 1 {
-		return status, nil
+	// --- constancia: rachas y cumplimiento del objetivo semanal ---
+	if err := ws.fillConsistency(status, userModel.WeeklyGoal, filter.UserID, location, from, to); err != nil {
+		return nil, err
 	}
 
-	// (logica: (cantidad de dias entre el primer y el ult workots - 1) / cantidad de entrenamientos)
-	sort.Slice(workoutsUser, func(i, j int) bool { // ordena ascendentemente por fecha
-		return workoutsUser[i].Date.Before(workoutsUser[j].Date)
-	})
+	// --- MostUsedRoutines (ranking de rutinas mas usadas, ya ordenado por el pipeline) ---
+	for _, r := range aggregate.Routines {
+		status.MostUsedRoutines = append(status.MostUsedRoutines, dto.RoutineUsageDTO{
+			RoutineName: r.Key,
+			Count:       r.Count,
+		})
+	}
 
-	primerWorkout := workoutsUser[0]
-	ultWorkout := workoutsUser[len(workoutsUser)-1]
+	// ---grafica ---
+	for _, p := range aggregate.Progress {
+		status.ProgressOverTime = append(status.ProgressOverTime, dto.ProgressPointDTO{
+			Date:            p.Key,
+			Count:           p.Count,
+			DurationSeconds: p.DurationSeconds,
+			DistanceMeters:  p.DistanceMeters,
+		})
+	}
+
+	// Si hay 0 o 1 workout no se puede calcular frecuencia entre fechas
+	if aggregate.Total <= 1 {
+		return status, nil
+	}
 
-	dayDifference := ultWorkout.Date.Sub(primerWorkout.Date).Hours() / 24
+	// (logica: cantidad de entrenamientos / semanas entre el primero y el ultimo)
+	dayDifference := aggregate.Last.Sub(aggregate.First).Hours() / 24
 
 	if dayDifference < 1 {
 		// Si todos los entrenamientos fueron el mismo día (diferencia < 1 día)
@@ -198,46 +481,899 @@ func (ws WorkoutService) GetWorkoutStats(userID string) (*dto.WorkoutStatsDTO, e
 		status.WeeklyFrequency = float64(status.TotalWorkouts) / (dayDifference / 7.0)
 	}
 
-	// --- MostUsedRoutines (ranking de rutinas mas usadas) ---
-	counts := make(map[string]int)
-	for _, w := range workoutsUser {
-		counts[w.RoutineName]++
+	return status, nil
+}
+
+// adherenceDefaultWeeks es la ventana usada para el cumplimiento semanal cuando no se pide un rango
+const adherenceDefaultWeeks = 12
+
+// fillConsistency calcula rachas (sobre todo el historial) y el cumplimiento semanal (sobre el rango pedido)
+func (ws WorkoutService) fillConsistency(status *dto.WorkoutStatsDTO, weeklyGoal int, userID string, location *time.Location, from time.Time, to time.Time) error {
+	goal := weeklyGoal
+	if goal <= 0 {
+		goal = 1
 	}
+	status.WeeklyGoal = goal
 
-	for name, c := range counts {
-		status.MostUsedRoutines = append(status.MostUsedRoutines, dto.RoutineUsageDTO{
-			RoutineName: name,
-			Count:       c,
+	weekly, err := ws.WorkoutRepository.GetWorkoutStats(userID, time.Time{}, time.Time{}, statsDateFormats["week"], location.String())
+	if err != nil {
+		return fmt.Errorf("Error al obtener workouts")
+	}
+	sessions := make(map[string]int, len(weekly.Progress))
+	for _, w := range weekly.Progress {
+		sessions[w.Key] = w.Count
+	}
+
+	now := time.Now().In(location)
+	currentWeek := startOfWeek(now)
+
+	// rachas: desde la semana del primer workout hasta la actual
+	if weekly.Total > 0 {
+		run := 0
+		for week := startOfWeek(weekly.First.In(location)); !week.After(currentWeek); week = week.AddDate(0, 0, 7) {
+			if sessions[isoWeekKey(week)] >= goal {
+				run++
+				if run > status.LongestStreak {
+					status.LongestStreak = run
+				}
+				continue
+			}
+			if !week.Equal(currentWeek) { // la semana actual todavia puede cumplirse, no corta la racha
+				run = 0
+			}
+		}
+		status.CurrentStreak = run
+	}
+
+	// cumplimiento semana a semana dentro del periodo
+	last := currentWeek
+	if !to.IsZero() {
+		last = startOfWeek(to.Add(-time.Nanosecond).In(location))
+	}
+	first := last.AddDate(0, 0, -7*(adherenceDefaultWeeks-1))
+	if !from.IsZero() {
+		first = startOfWeek(from.In(location))
+	}
+
+	met := 0
+	for week := first; !week.After(last); week = week.AddDate(0, 0, 7) {
+		count := sessions[isoWeekKey(week)]
+		status.WeeklyAdherence = append(status.WeeklyAdherence, dto.WeeklyAdherenceDTO{
+			Week:     isoWeekKey(week),
+			Sessions: count,
+			Goal:     goal,
+			Met:      count >= goal,
 		})
+		if count >= goal {
+			met++
+		}
+	}
+	if len(status.WeeklyAdherence) > 0 {
+		status.GoalMetRatio = float64(met) / float64(len(status.WeeklyAdherence))
+	}
+	return nil
+}
+
+// startOfWeek devuelve el lunes 00:00 de la semana de la fecha, en su misma zona horaria
+func startOfWeek(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7 // lunes = 0
+	return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, date.Location())
+}
+
+// isoWeekKey usa el mismo formato que %G-W%V de MongoDB
+func isoWeekKey(date time.Time) string {
+	year, week := date.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// errGranularity es el error de una granularidad desconocida, igual en las estadisticas y en el progreso por ejercicio
+var errGranularity = fmt.Errorf("granularidad inválida: use day, week, month o year")
+
+// statsDateFormats traduce la granularidad al formato de $dateToString (semana ISO para week); bucketKey agrupa
+// igual en Go, asi que las dos aceptan las mismas granularidades
+var statsDateFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+	"year":  "%Y",
+}
+
+// parseStatsDate interpreta YYYY-MM-DD en la zona del usuario o RFC3339. Si endOfDay es true el valor se corre
+// justo despues del limite (el dia siguiente para una fecha, un milisegundo, la precision de MongoDB, para un
+// instante) porque las consultas usan $lt: asi el rango documentado como inclusivo lo es en los dos formatos
+func parseStatsDate(value string, location *time.Location, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
-	// ordenamos primero por mas usados, segundo alfabeticamente
-	sort.Slice(status.MostUsedRoutines, func(i, j int) bool {
-		if status.MostUsedRoutines[i].Count == status.MostUsedRoutines[j].Count {
-			return status.MostUsedRoutines[i].RoutineName < status.MostUsedRoutines[j].RoutineName
+	if date, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
 		}
-		return status.MostUsedRoutines[i].Count > status.MostUsedRoutines[j].Count
-	})
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha inválida %q: use YYYY-MM-DD o RFC3339", value)
+	}
+	if endOfDay {
+		date = date.Truncate(time.Millisecond).Add(time.Millisecond)
+	}
+	return date, nil
+}
 
-	// ---grafica ---
-	buckets := make(map[string]int)
-	for _, w := range workoutsUser {
-		key := w.Date.Format("2006-01") // yyyy-mm
-		buckets[key]++
+// GetExcerciseProgress arma las series de 1RM estimado, mejor serie y volumen total de un ejercicio
+func (ws WorkoutService) GetExcerciseProgress(userID string, excerciseID string, formula string, granularity string) (*dto.ExcerciseProgressDTO, error) {
+	if formula == "" {
+		formula = string(Epley)
+	}
+	switch OneRepMaxFormula(formula) {
+	case Epley, Brzycki, Lombardi:
+	default:
+		return nil, fmt.Errorf("fórmula inválida: use epley, brzycki o lombardi")
+	}
+	if granularity == "" {
+		granularity = "week"
+	}
+	if _, err := bucketKey(time.Now(), granularity); err != nil {
+		return nil, err
+	}
+
+	excercise, err := ws.ExcerciseRepository.GetExcerciseByID(excerciseID)
+	if err != nil || excercise.ID.IsZero() {
+		return nil, fmt.Errorf("no existe ningún ejercicio con ese ID")
+	}
+
+	workouts, err := ws.WorkoutRepository.GetWorkoutsByUserAndExcercise(userID, excerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener workouts")
+	}
+
+	type bucket struct {
+		bestE1RM float64
+		bestSet  models.WorkoutSet
+		volume   float64
+		lifted   bool
+		duration int
+		distance float64
+		bestPace float64
+	}
+	buckets := make(map[string]*bucket)
+	for _, w := range workouts {
+		if !w.IsCompleted() {
+			continue
+		}
+		key, _ := bucketKey(w.Date, granularity)
+		for _, e := range w.Exercises {
+			if e.ExcerciseID != excercise.ID {
+				continue
+			}
+			for _, set := range e.Sets {
+				timed := set.DurationSeconds > 0 || set.DistanceMeters > 0
+				if !set.Completed || (set.Repetitions <= 0 && !timed) {
+					continue
+				}
+				b, ok := buckets[key]
+				if !ok {
+					b = &bucket{}
+					buckets[key] = b
+				}
+				if timed {
+					b.duration += set.DurationSeconds
+					b.distance += set.DistanceMeters
+					if set.DurationSeconds > 0 && set.DistanceMeters > 0 {
+						if pace := float64(set.DurationSeconds) / (set.DistanceMeters / 1000); b.bestPace == 0 || pace < b.bestPace {
+							b.bestPace = pace
+						}
+					}
+				}
+				if set.Repetitions <= 0 {
+					continue
+				}
+				b.lifted = true
+				b.volume += set.Weight * float64(set.Repetitions)
+				if e1rm := estimateOneRepMax(set.Weight, set.Repetitions, OneRepMaxFormula(formula)); e1rm > b.bestE1RM {
+					b.bestE1RM = e1rm
+					b.bestSet = set
+				}
+			}
+		}
 	}
 
-	// Pasar a slice ordenado cronológicamente
 	keys := make([]string, 0, len(buckets))
 	for k := range buckets {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
+	progress := &dto.ExcerciseProgressDTO{
+		ExcerciseID:        excerciseID,
+		ExcerciseName:      excercise.Name,
+		Formula:            formula,
+		Granularity:        granularity,
+		EstimatedOneRepMax: []dto.ProgressValueDTO{},
+		BestSet:            []dto.BestSetPointDTO{},
+		TotalVolume:        []dto.ProgressValueDTO{},
+		TotalDuration:      []dto.ProgressValueDTO{},
+		TotalDistance:      []dto.ProgressValueDTO{},
+		BestPace:           []dto.ProgressValueDTO{},
+	}
 	for _, k := range keys {
-		status.ProgressOverTime = append(status.ProgressOverTime, dto.ProgressPointDTO{
-			Date:  k,
-			Count: buckets[k],
-		})
+		b := buckets[k]
+		if b.duration > 0 || b.distance > 0 {
+			progress.TotalDuration = append(progress.TotalDuration, dto.ProgressValueDTO{Date: k, Value: float64(b.duration)})
+			progress.TotalDistance = append(progress.TotalDistance, dto.ProgressValueDTO{Date: k, Value: b.distance})
+			if b.bestPace > 0 {
+				progress.BestPace = append(progress.BestPace, dto.ProgressValueDTO{Date: k, Value: math.Round(b.bestPace)})
+			}
+		}
+		if !b.lifted {
+			continue
+		}
+		progress.EstimatedOneRepMax = append(progress.EstimatedOneRepMax, dto.ProgressValueDTO{Date: k, Value: math.Round(b.bestE1RM*10) / 10})
+		progress.BestSet = append(progress.BestSet, dto.BestSetPointDTO{Date: k, Weight: b.bestSet.Weight, Repetitions: b.bestSet.Repetitions})
+		progress.TotalVolume = append(progress.TotalVolume, dto.ProgressValueDTO{Date: k, Value: b.volume})
 	}
+	return progress, nil
+}
 
-	return status, nil
+// bucketKey devuelve la clave del periodo al que pertenece la fecha (dia, semana ISO, mes o año), con el mismo
+// formato que statsDateFormats
+func bucketKey(date time.Time, granularity string) (string, error) {
+	switch granularity {
+	case "day":
+		return date.Format("2006-01-02"), nil
+	case "week":
+		return isoWeekKey(date), nil
+	case "month":
+		return date.Format("2006-01"), nil
+	case "year":
+		return date.Format("2006"), nil
+	default:
+		return "", errGranularity
+	}
+}
+
+// --- Workouts en vivo: inicio, series, pausa y fin ---
+
+// StartWorkout crea un workout en curso a partir de una rutina, solo puede haber uno activo por usuario
+func (ws WorkoutService) StartWorkout(workoutDTO *dto.WorkoutRegisterDTO) (*dto.WorkoutResponseDTO, error) {
+	routine, err := ws.RoutineRepository.GetRoutineByID(workoutDTO.RoutineID)
+	if err != nil {
+		return nil, fmt.Errorf("rutina no encontrada: %w", err)
+	}
+	if routine.ID.IsZero() || !routine.VisibleTo(workoutDTO.UserID) {
+		return nil, fmt.Errorf("rutina no encontrada")
+	}
+	if workoutDTO.Date != nil {
+		return nil, fmt.Errorf("fecha inválida: un workout en vivo siempre empieza ahora")
+	}
+
+	// si quedo un workout abandonado lo cerramos antes de verificar
+	if _, err := ws.getActiveWorkout(workoutDTO.UserID); err != nil && !strings.Contains(err.Error(), "no hay ningún workout en curso") {
+		return nil, err
+	}
+
+	workoutModel, err := dto.GetModelWorkoutRegisterDTO(workoutDTO)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateExcercisesInWorkout(workoutModel.Exercises, routine); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	workoutModel.RoutineName = routine.Name
+	workoutModel.Routine = ws.snapshotRoutine(routine, now)
+	workoutModel.Status = models.WorkoutInProgress
+	workoutModel.Date = now
+	workoutModel.StartTime = now
+	workoutModel.LastActivity = now
+
+	insertResult, err := ws.WorkoutRepository.StartWorkout(workoutModel)
+	if err != nil {
+		if strings.Contains(err.Error(), "ya existe un workout en curso") {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error al crear el workout: %w", err)
+	}
+
+	created, err := ws.WorkoutRepository.GetWorkoutByID(insertResult.InsertedID.(primitive.ObjectID).Hex())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener el workout creado: %w", err)
+	}
+	if created.ID.IsZero() {
+		return nil, fmt.Errorf("workout creado no encontrado")
+	}
+	return dto.NewWorkoutResponseDTO(created), nil
+}
+
+func (ws WorkoutService) GetActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.getActiveWorkout(userID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewWorkoutResponseDTO(workout), nil
+}
+
+// AddSetToActiveWorkout registra una serie recien terminada en el workout en curso
+func (ws WorkoutService) AddSetToActiveWorkout(setDTO *dto.WorkoutSetRegisterDTO) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.getActiveWorkout(setDTO.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !workout.PausedAt.IsZero() {
+		return nil, fmt.Errorf("el workout está pausado, reanudalo para registrar series")
+	}
+
+	excerciseOID, err := utils.GetObjectIDFromStringID(setDTO.ExcerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de ejercicio con formato inválido: %w", err)
+	}
+	var entryOID primitive.ObjectID
+	if setDTO.EntryID != "" {
+		if entryOID, err = utils.GetObjectIDFromStringID(setDTO.EntryID); err != nil {
+			return nil, fmt.Errorf("ID de entrada con formato inválido: %w", err)
+		}
+	}
+	set := dto.GetModelWorkoutSetRegisterDTO(setDTO)
+
+	routine, err := ws.RoutineRepository.GetRoutineByID(workout.RoutineID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("rutina no encontrada: %w", err)
+	}
+	if entryOID.IsZero() {
+		if entryOID, err = soleEntry(routine, excerciseOID); err != nil {
+			return nil, err
+		}
+	}
+	exercise := []models.ExcerciseInWorkout{{EntryID: entryOID, ExcerciseID: excerciseOID, Sets: []models.WorkoutSet{set}}}
+	if err := validateExcercisesInWorkout(exercise, routine); err != nil {
+		return nil, err
+	}
+
+	if _, err := ws.WorkoutRepository.AddSetToWorkout(workout.ID, exercise[0], set); err != nil {
+		return nil, fmt.Errorf("error al registrar la serie: %w", err)
+	}
+	return ws.getWorkoutResponse(workout.ID)
+}
+
+// soleEntry devuelve la entrada de la rutina del ejercicio cuando la serie no la indica. Si el ejercicio esta mas de
+// una vez (calentamiento y series efectivas) no se adivina: hay que mandar entry_id. Puede ser cero en rutinas
+// anteriores a las entradas
+func soleEntry(routine *models.Routine, excerciseID primitive.ObjectID) (primitive.ObjectID, error) {
+	var entries []primitive.ObjectID
+	if routine != nil {
+		for _, e := range routine.ExcerciseList {
+			if e.ExcerciseID == excerciseID {
+				entries = append(entries, e.EntryID)
+			}
+		}
+	}
+	if len(entries) > 1 {
+		return primitive.NilObjectID, fmt.Errorf("ejercicio ambiguo: %s está %d veces en la rutina, indique entry_id", excerciseID.Hex(), len(entries))
+	}
+	if len(entries) == 1 {
+		return entries[0], nil
+	}
+	return primitive.NilObjectID, nil
+}
+
+func (ws WorkoutService) PauseActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.getActiveWorkout(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !workout.PausedAt.IsZero() {
+		return nil, fmt.Errorf("el workout ya está pausado")
+	}
+
+	now := time.Now()
+	workout.PausedAt = now
+	workout.LastActivity = now
+	if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+		return nil, fmt.Errorf("error al pausar el workout: %w", err)
+	}
+	return ws.getWorkoutResponse(workout.ID)
+}
+
+func (ws WorkoutService) ResumeActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.getActiveWorkout(userID)
+	if err != nil {
+		return nil, err
+	}
+	if workout.PausedAt.IsZero() {
+		return nil, fmt.Errorf("el workout no está pausado")
+	}
+
+	now := time.Now()
+	workout.PausedSeconds += int64(now.Sub(workout.PausedAt).Seconds())
+	workout.PausedAt = time.Time{}
+	workout.LastActivity = now
+	if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+		return nil, fmt.Errorf("error al reanudar el workout: %w", err)
+	}
+	return ws.getWorkoutResponse(workout.ID)
+}
+
+func (ws WorkoutService) FinishActiveWorkout(userID string) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.getActiveWorkout(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	closeWorkout(&workout, time.Now(), models.WorkoutFinished)
+	if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+		return nil, fmt.Errorf("error al finalizar el workout: %w", err)
+	}
+	ws.detectRecords(workout)
+	ws.refreshSuggestions(userID, workout.RoutineID)
+	return ws.getWorkoutResponse(workout.ID)
+}
+
+// CloseAbandonedWorkouts cierra los workouts en curso sin actividad durante mas de AbandonTimeout, devuelve cuantos cerro
+func (ws WorkoutService) CloseAbandonedWorkouts() (int, error) {
+	stale, err := ws.WorkoutRepository.GetStaleActiveWorkouts(time.Now().Add(-ws.AbandonTimeout))
+	if err != nil {
+		return 0, fmt.Errorf("error al obtener workouts abandonados: %w", err)
+	}
+
+	closed := 0
+	for _, workout := range stale {
+		if !isAbandoned(workout, time.Now(), ws.AbandonTimeout) {
+			continue
+		}
+		closeWorkout(&workout, workout.LastActivity, models.WorkoutAbandoned)
+		if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+			return closed, fmt.Errorf("error al cerrar el workout abandonado %s: %w", workout.ID.Hex(), err)
+		}
+		closed++
+	}
+	return closed, nil
+}
+
+// getActiveWorkout devuelve el workout en curso del usuario, cerrandolo si quedo abandonado
+func (ws WorkoutService) getActiveWorkout(userID string) (models.Workout, error) {
+	workout, err := ws.WorkoutRepository.GetActiveWorkoutByUserID(userID)
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("error al obtener workout: %w", err)
+	}
+	if workout.ID.IsZero() {
+		return models.Workout{}, fmt.Errorf("no hay ningún workout en curso")
+	}
+
+	if isAbandoned(workout, time.Now(), ws.AbandonTimeout) {
+		closeWorkout(&workout, workout.LastActivity, models.WorkoutAbandoned)
+		if _, err := ws.WorkoutRepository.UpdateWorkoutStatus(workout); err != nil {
+			return models.Workout{}, fmt.Errorf("error al cerrar el workout abandonado: %w", err)
+		}
+		return models.Workout{}, fmt.Errorf("no hay ningún workout en curso")
+	}
+	return workout, nil
+}
+
+func (ws WorkoutService) getWorkoutResponse(id primitive.ObjectID) (*dto.WorkoutResponseDTO, error) {
+	workout, err := ws.WorkoutRepository.GetWorkoutByID(id.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener workout: %w", err)
+	}
+	if workout.ID.IsZero() {
+		return nil, fmt.Errorf("workout no encontrado")
+	}
+	return dto.NewWorkoutResponseDTO(workout), nil
+}
+
+// detectRecords busca nuevos records personales; el workout ya quedo guardado, asi que un fallo solo se registra en el log
+func (ws WorkoutService) detectRecords(workout models.Workout) {
+	if ws.RecordService == nil || len(workout.Exercises) == 0 || !workout.IsCompleted() {
+		return
+	}
+	if _, err := ws.RecordService.DetectRecords(workout); err != nil {
+		log.Printf("error al detectar records del workout %s: %v", workout.ID.Hex(), err)
+	}
+}
+
+// rebuildRecords recalcula los records de los ejercicios afectados; como detectRecords, un fallo no revierte el cambio
+func (ws WorkoutService) rebuildRecords(userID string, exercises []models.ExcerciseInWorkout) {
+	if ws.RecordService == nil || len(exercises) == 0 {
+		return
+	}
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, e := range exercises {
+		if !seen[e.ExcerciseID] {
+			seen[e.ExcerciseID] = true
+			ids = append(ids, e.ExcerciseID)
+		}
+	}
+	if err := ws.RecordService.RebuildRecords(userID, ids); err != nil {
+		log.Printf("error al recalcular records del usuario %s: %v", userID, err)
+	}
+}
+
+// refreshSuggestions recalcula las sugerencias de progresion de la rutina cuando cambia su historial de workouts
+// terminados, asi el detalle de la rutina no las recalcula en cada lectura. Solo se guardan si el workout es del
+// creador, que es quien puede aplicarlas; como con los records, un fallo solo se registra en el log
+func (ws WorkoutService) refreshSuggestions(userID string, routineID primitive.ObjectID) {
+	if routineID.IsZero() {
+		return
+	}
+	routine, err := ws.RoutineRepository.GetRoutineByID(routineID.Hex())
+	if err != nil {
+		log.Printf("error al obtener la rutina %s para sus sugerencias: %v", routineID.Hex(), err)
+		return
+	}
+	if routine == nil || routine.ID.IsZero() || routine.CreatorUserID.Hex() != userID {
+		return
+	}
+	workouts, err := ws.WorkoutRepository.GetRecentWorkoutsByRoutine(userID, routine.ID, overloadWorkouts)
+	if err != nil {
+		log.Printf("error al obtener los workouts de la rutina %s para sus sugerencias: %v", routineID.Hex(), err)
+		return
+	}
+	if _, err := ws.RoutineRepository.SetSuggestions(routine.ID, progressionSuggestions(routine, workouts, time.Now())); err != nil {
+		log.Printf("error al guardar las sugerencias de la rutina %s: %v", routineID.Hex(), err)
+	}
+}
+
+// isAbandoned indica si el workout en curso lleva mas de timeout sin actividad. Mientras esta pausado el reloj de
+// inactividad no corre: al reanudar se toma como actividad y vuelve a contar desde ahi
+func isAbandoned(workout models.Workout, now time.Time, timeout time.Duration) bool {
+	if timeout <= 0 || workout.Status != models.WorkoutInProgress || !workout.PausedAt.IsZero() {
+		return false
+	}
+	return now.Sub(workout.LastActivity) > timeout
+}
+
+// closeWorkout marca el fin del workout y calcula su duracion descontando las pausas
+func closeWorkout(workout *models.Workout, end time.Time, status models.WorkoutStatus) {
+	if !workout.PausedAt.IsZero() {
+		if end.After(workout.PausedAt) {
+			workout.PausedSeconds += int64(end.Sub(workout.PausedAt).Seconds())
+		}
+		workout.PausedAt = time.Time{}
+	}
+
+	workout.Status = status
+	workout.EndTime = end
+	workout.LastActivity = end
+	workout.DurationSeconds = int64(end.Sub(workout.StartTime).Seconds()) - workout.PausedSeconds
+	if workout.DurationSeconds < 0 {
+		workout.DurationSeconds = 0
+	}
+}
+
+// umbrales de parecido entre un nombre del CSV y uno del catalogo
+const (
+	importFuzzyMatch     = 0.85 // desde aca se asigna solo
+	importCandidateScore = 0.4  // desde aca se sugiere como candidato
+	importMaxCandidates  = 3
+	importMaxRowErrors   = 50
+)
+
+// ImportWorkouts crea en bloque los workouts del historial de otra aplicacion. Cada nombre de ejercicio se asigna
+// por el mapping enviado, por nombre exacto o por parecido; si queda alguno sin asignar solo se puede previsualizar
+// con dry_run. Los workouts ya importados con la misma fecha y nombre se saltean, asi reimportar el mismo archivo no
+// duplica y dos entrenamientos distintos registrados a la misma hora se importan los dos
+func (ws WorkoutService) ImportWorkouts(importDTO dto.WorkoutImportDTO) (*dto.WorkoutImportResultDTO, error) {
+	userID, err := utils.GetObjectIDFromStringID(importDTO.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuario inválido: %w", err)
+	}
+	if importDTO.TimeZone == "" {
+		importDTO.TimeZone = "UTC"
+	}
+	location, err := time.LoadLocation(importDTO.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("zona horaria inválida: %s", importDTO.TimeZone)
+	}
+	if importDTO.Unit == "" {
+		importDTO.Unit = "kg"
+	}
+
+	rows, rowErrors, err := dto.ReadWorkoutLogCSV(strings.NewReader(importDTO.CSV), location, importDTO.Unit, importDTO.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	valid := rows[:0]
+	for _, row := range rows {
+		if err := validateWorkoutDate(row.Date, now); err != nil {
+			rowErrors = append(rowErrors, dto.WorkoutImportRowErrorDTO{Line: row.Line, Reason: err.Error()})
+			continue
+		}
+		valid = append(valid, row)
+	}
+	rows = valid
+
+	catalogue, err := ws.importCatalogue(rows, importDTO.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los ejercicios: %w", err)
+	}
+	result := &dto.WorkoutImportResultDTO{DryRun: importDTO.DryRun, Exercises: []dto.WorkoutImportExcerciseDTO{}}
+	assigned, unmatched, err := matchImportedExcercises(rows, catalogue, importDTO.Mapping, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(unmatched) > 0 && !importDTO.DryRun {
+		return nil, fmt.Errorf("hay ejercicios sin asignar (%s): indique el ejercicio de cada uno en mapping, o \"\" para ignorarlo", strings.Join(unmatched, ", "))
+	}
+
+	workouts := groupImportedWorkouts(rows, assigned, userID, now)
+	if len(workouts) > 0 {
+		from, to := workouts[0].Date, workouts[len(workouts)-1].Date
+		result.From, result.To = &from, &to
+
+		existing, err := ws.WorkoutRepository.GetWorkoutsByUserInRange(importDTO.UserID, from, to.Add(time.Millisecond))
+		if err != nil {
+			return nil, fmt.Errorf("error al obtener los workouts del usuario: %w", err)
+		}
+		imported := make(map[string]bool)
+		for _, w := range existing {
+			if w.Source == models.WorkoutImported {
+				imported[importedWorkoutKey(w)] = true
+			}
+		}
+		fresh := workouts[:0]
+		for _, w := range workouts {
+			if imported[importedWorkoutKey(w)] {
+				result.Duplicates++
+				continue
+			}
+			fresh = append(fresh, w)
+		}
+		workouts = fresh
+	}
+
+	var touched []models.ExcerciseInWorkout
+	for _, w := range workouts {
+		for _, e := range w.Exercises {
+			result.Sets += len(e.Sets)
+		}
+		touched = append(touched, w.Exercises...)
+	}
+	result.Workouts = len(workouts)
+	if len(rowErrors) > importMaxRowErrors {
+		result.ErrorsOmitted = len(rowErrors) - importMaxRowErrors
+		rowErrors = rowErrors[:importMaxRowErrors]
+	}
+	result.Errors = rowErrors
+
+	if importDTO.DryRun || len(workouts) == 0 {
+		return result, nil
+	}
+	if _, err := ws.WorkoutRepository.PostWorkouts(workouts); err != nil {
+		return nil, fmt.Errorf("error al importar los workouts: %w", err)
+	}
+	// el historial puede superar los records actuales: se recalculan los ejercicios importados
+	ws.rebuildRecords(importDTO.UserID, touched)
+	return result, nil
+}
+
+// importCatalogue trae solo los ejercicios que pueden corresponder a los nombres del CSV: los del mapping, los de
+// nombre igual y, para los nombres sin ejercicio igual, los que tienen una palabra que empieza como alguna de las
+// suyas (los mismos candidatos que la busqueda de ejercicios), que son contra los que se mide el parecido
+func (ws WorkoutService) importCatalogue(rows []dto.WorkoutLogRowDTO, mapping map[string]string) ([]models.Excercise, error) {
+	var catalogue []models.Excercise
+	seen := make(map[primitive.ObjectID]bool)
+	add := func(excercise models.Excercise) {
+		if !excercise.ID.IsZero() && !seen[excercise.ID] {
+			seen[excercise.ID] = true
+			catalogue = append(catalogue, excercise)
+		}
+	}
+
+	mapped := make(map[string]bool, len(mapping))
+	for name, id := range mapping {
+		mapped[utils.NormalizeText(name)] = true
+		if _, err := primitive.ObjectIDFromHex(strings.TrimSpace(id)); err != nil {
+			continue // vacio = ignorar; un ID mal formado lo rechaza matchImportedExcercises
+		}
+		excercise, err := ws.ExcerciseRepository.GetExcerciseByID(strings.TrimSpace(id))
+		if err == nil {
+			add(excercise)
+		}
+	}
+
+	var names []string
+	pending := make(map[string]string) // nombre normalizado -> nombre del CSV
+	for _, row := range rows {
+		key := utils.NormalizeText(row.Excercise)
+		if _, ok := pending[key]; ok || mapped[key] {
+			continue
+		}
+		pending[key] = row.Excercise
+		names = append(names, row.Excercise)
+	}
+	exact, err := ws.ExcerciseRepository.GetExcercisesByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, excercise := range exact {
+		add(excercise)
+		delete(pending, utils.NormalizeText(excercise.Name))
+	}
+
+	var missing []string
+	for key := range pending {
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+	if pattern := searchPrefixPattern(strings.Join(missing, " ")); pattern != "" {
+		candidates, err := ws.ExcerciseRepository.GetSearchCandidates(nil, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, excercise := range candidates {
+			add(*excercise)
+		}
+	}
+	return catalogue, nil
+}
+
+// matchImportedExcercises asigna cada nombre del CSV a un ejercicio del catalogo y completa result.Exercises.
+// Devuelve el ejercicio de cada nombre normalizado (cero = ignorado) y los nombres que quedaron sin asignar
+func matchImportedExcercises(rows []dto.WorkoutLogRowDTO, catalogue []models.Excercise, mapping map[string]string, result *dto.WorkoutImportResultDTO) (map[string]primitive.ObjectID, []string, error) {
+	byID := make(map[primitive.ObjectID]models.Excercise, len(catalogue))
+	normalized := make([]string, len(catalogue))
+	for i, e := range catalogue {
+		byID[e.ID] = e
+		normalized[i] = utils.NormalizeText(e.Name)
+	}
+	manual := make(map[string]string, len(mapping))
+	for name, id := range mapping {
+		manual[utils.NormalizeText(name)] = strings.TrimSpace(id)
+	}
+
+	assigned := make(map[string]primitive.ObjectID)
+	index := make(map[string]int) // nombre normalizado -> posicion en result.Exercises
+	var unmatched []string
+	for _, row := range rows {
+		key := utils.NormalizeText(row.Excercise)
+		if i, ok := index[key]; ok {
+			result.Exercises[i].Rows++
+			continue
+		}
+		index[key] = len(result.Exercises)
+		report := dto.WorkoutImportExcerciseDTO{Name: row.Excercise, Rows: 1}
+
+		if id, ok := manual[key]; ok {
+			if id == "" {
+				report.MatchedBy = "skipped"
+				assigned[key] = primitive.NilObjectID
+				result.Exercises = append(result.Exercises, report)
+				continue
+			}
+			oid, err := utils.GetObjectIDFromStringID(id)
+			if err != nil || byID[oid].ID.IsZero() {
+				return nil, nil, fmt.Errorf("ejercicio inválido en mapping para %q: %s", row.Excercise, id)
+			}
+			report.MatchedBy = "mapping"
+			report.ExcerciseID, report.ExcerciseName = id, byID[oid].Name
+			assigned[key] = oid
+			result.Exercises = append(result.Exercises, report)
+			continue
+		}
+
+		best, bestScore := -1, 0.0
+		var candidates []dto.WorkoutImportCandidateDTO
+		for i, name := range normalized {
+			score := utils.Similarity(key, name)
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+			if score >= importCandidateScore {
+				candidates = append(candidates, dto.WorkoutImportCandidateDTO{
+					ExcerciseID: utils.GetStringIDFromObjectID(catalogue[i].ID),
+					Name:        catalogue[i].Name,
+					Score:       math.Round(score*100) / 100,
+				})
+			}
+		}
+		if best >= 0 && bestScore >= importFuzzyMatch {
+			report.MatchedBy = "fuzzy"
+			if bestScore == 1 {
+				report.MatchedBy = "exact"
+			}
+			report.Score = math.Round(bestScore*100) / 100
+			report.ExcerciseID = utils.GetStringIDFromObjectID(catalogue[best].ID)
+			report.ExcerciseName = catalogue[best].Name
+			assigned[key] = catalogue[best].ID
+		} else {
+			sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].Score > candidates[b].Score })
+			if len(candidates) > importMaxCandidates {
+				candidates = candidates[:importMaxCandidates]
+			}
+			report.MatchedBy = "unmatched"
+			report.Candidates = candidates
+			unmatched = append(unmatched, row.Excercise)
+		}
+		result.Exercises = append(result.Exercises, report)
+	}
+	return assigned, unmatched, nil
+}
+
+// importedWorkoutKey identifica un workout importado por su fecha y nombre, igual que se agrupan las filas del CSV
+func importedWorkoutKey(workout models.Workout) string {
+	return fmt.Sprintf("%d|%s", workout.Date.UnixMilli(), strings.ToLower(workout.RoutineName))
+}
+
+// groupImportedWorkouts arma un workout por cada fecha y nombre de entrenamiento del CSV, con los ejercicios
+// en el orden en que aparecen y las series ordenadas por su numero; devuelve los workouts ordenados por fecha
+func groupImportedWorkouts(rows []dto.WorkoutLogRowDTO, assigned map[string]primitive.ObjectID, userID primitive.ObjectID, now time.Time) []models.Workout {
+	type importedSet struct {
+		order int
+		set   models.WorkoutSet
+	}
+	type importedWorkout struct {
+		workout   models.Workout
+		exercises []primitive.ObjectID
+		sets      map[primitive.ObjectID][]importedSet
+		notes     []string
+	}
+
+	var grouped []*importedWorkout
+	index := make(map[string]*importedWorkout)
+	for _, row := range rows {
+		excerciseID := assigned[utils.NormalizeText(row.Excercise)]
+		if excerciseID.IsZero() { // ignorado o sin asignar (dry_run)
+			continue
+		}
+		key := fmt.Sprintf("%d|%s", row.Date.UnixMilli(), strings.ToLower(row.Workout))
+		current, ok := index[key]
+		if !ok {
+			name := row.Workout
+			if name == "" {
+				name = "Importado"
+			}
+			current = &importedWorkout{
+				workout: models.Workout{
+					UserID:       userID,
+					RoutineName:  name,
+					Date:         row.Date,
+					Status:       models.WorkoutFinished,
+					StartTime:    row.Date,
+					EndTime:      row.Date,
+					LastActivity: now,
+					Source:       models.WorkoutImported,
+				},
+				sets: make(map[primitive.ObjectID][]importedSet),
+			}
+			index[key] = current
+			grouped = append(grouped, current)
+		}
+		if _, seen := current.sets[excerciseID]; !seen {
+			current.exercises = append(current.exercises, excerciseID)
+		}
+		order := row.SetOrder
+		if order == 0 {
+			order = len(current.sets[excerciseID]) + 1
+		}
+		current.sets[excerciseID] = append(current.sets[excerciseID], importedSet{order: order, set: models.WorkoutSet{
+			Repetitions:     row.Repetitions,
+			Weight:          row.Weight,
+			RPE:             row.RPE,
+			Completed:       true,
+			DurationSeconds: row.DurationSeconds,
+			DistanceMeters:  row.DistanceMeters,
+		}})
+		if row.Notes != "" && !slices.Contains(current.notes, row.Notes) {
+			current.notes = append(current.notes, row.Notes)
+		}
+	}
+
+	workouts := make([]models.Workout, 0, len(grouped))
+	for _, g := range grouped {
+		for _, id := range g.exercises {
+			sets := g.sets[id]
+			sort.SliceStable(sets, func(a, b int) bool { return sets[a].order < sets[b].order })
+			entry := models.ExcerciseInWorkout{ExcerciseID: id, Sets: make([]models.WorkoutSet, 0, len(sets))}
+			for _, s := range sets {
+				entry.Sets = append(entry.Sets, s.set)
+			}
+			g.workout.Exercises = append(g.workout.Exercises, entry)
+		}
+		g.workout.Notes = strings.Join(g.notes, "; ")
+		workouts = append(workouts, g.workout)
+	}
+	sort.SliceStable(workouts, func(a, b int) bool { return workouts[a].Date.Before(workouts[b].Date) })
+	return workouts
 }
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"AppFitness/dto"
+	"AppFitness/models"
+	"AppFitness/repositories"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateExcercisesInWorkout(t *testing.T) {
+	inRoutine, outside := primitive.NewObjectID(), primitive.NewObjectID()
+	entry, otherEntry := primitive.NewObjectID(), primitive.NewObjectID()
+	routine := &models.Routine{ExcerciseList: []models.ExcerciseInRoutine{{EntryID: entry, ExcerciseID: inRoutine}, {EntryID: otherEntry, ExcerciseID: outside}}}
+	single := &models.Routine{ExcerciseList: []models.ExcerciseInRoutine{{ExcerciseID: inRoutine}}}
+
+	cases := []struct {
+		name      string
+		exercises []models.ExcerciseInWorkout
+		routine   *models.Routine
+		err       string
+	}{
+		{"series validas", []models.ExcerciseInWorkout{{ExcerciseID: inRoutine, Sets: []models.WorkoutSet{{Repetitions: 10, Weight: 50, RPE: 8}}}}, routine, ""},
+		{"ejercicio fuera de la rutina", []models.ExcerciseInWorkout{{ExcerciseID: outside, Sets: []models.WorkoutSet{{Repetitions: 10}}}}, single, "no pertenece a la rutina"},
+		{"entrada del ejercicio", []models.ExcerciseInWorkout{{EntryID: entry, ExcerciseID: inRoutine, Sets: []models.WorkoutSet{{Repetitions: 10}}}}, routine, ""},
+		{"entrada de otro ejercicio", []models.ExcerciseInWorkout{{EntryID: otherEntry, ExcerciseID: inRoutine, Sets: []models.WorkoutSet{{Repetitions: 10}}}}, routine, "entrada inválida"},
+		{"sin rutina se acepta cualquier ejercicio", []models.ExcerciseInWorkout{{ExcerciseID: outside, Sets: []models.WorkoutSet{{Repetitions: 10}}}}, nil, ""},
+		{"sin series", []models.ExcerciseInWorkout{{ExcerciseID: inRoutine}}, routine, "al menos una serie"},
+		{"peso negativo", []models.ExcerciseInWorkout{{ExcerciseID: inRoutine, Sets: []models.WorkoutSet{{Repetitions: 10, Weight: -5}}}}, routine, "inválidos"},
+		{"RPE fuera de rango", []models.ExcerciseInWorkout{{ExcerciseID: inRoutine, Sets: []models.WorkoutSet{{Repetitions: 10, RPE: 11}}}}, routine, "RPE inválido"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateExcercisesInWorkout(tc.exercises, tc.routine)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("se esperaba un error con %q, se obtuvo %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestIsAbandoned(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeout := 4 * time.Hour
+	cases := []struct {
+		name    string
+		workout models.Workout
+		want    bool
+	}{
+		{"activo hace poco", models.Workout{Status: models.WorkoutInProgress, LastActivity: now.Add(-time.Hour)}, false},
+		{"sin actividad", models.Workout{Status: models.WorkoutInProgress, LastActivity: now.Add(-5 * time.Hour)}, true},
+		{"pausado hace mucho", models.Workout{Status: models.WorkoutInProgress, LastActivity: now.Add(-10 * time.Hour), PausedAt: now.Add(-10 * time.Hour)}, false},
+		{"finalizado", models.Workout{Status: models.WorkoutFinished, LastActivity: now.Add(-10 * time.Hour)}, false},
+	}
+	for _, tc := range cases {
+		if got := isAbandoned(tc.workout, now, timeout); got != tc.want {
+			t.Errorf("%s: isAbandoned = %v, se esperaba %v", tc.name, got, tc.want)
+		}
+	}
+	if isAbandoned(models.Workout{Status: models.WorkoutInProgress, LastActivity: now.Add(-48 * time.Hour)}, now, 0) {
+		t.Error("sin timeout configurado nunca se abandona")
+	}
+}
+
+func TestCloseWorkoutDiscountsPauses(t *testing.T) {
+	start := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	workout := models.Workout{
+		Status:        models.WorkoutInProgress,
+		StartTime:     start,
+		PausedSeconds: 600,                         // una pausa anterior de 10 minutos
+		PausedAt:      start.Add(50 * time.Minute), // pausado al cerrar
+	}
+	closeWorkout(&workout, start.Add(time.Hour), models.WorkoutFinished)
+
+	if workout.PausedSeconds != 1200 {
+		t.Errorf("PausedSeconds = %d, se esperaba 1200", workout.PausedSeconds)
+	}
+	if workout.DurationSeconds != 2400 {
+		t.Errorf("DurationSeconds = %d, se esperaba 2400", workout.DurationSeconds)
+	}
+	if !workout.PausedAt.IsZero() || workout.Status != models.WorkoutFinished {
+		t.Errorf("el workout deberia quedar finalizado y sin pausa: %+v", workout)
+	}
+}
+
+func TestParseStatsDate(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip("sin base de zonas horarias")
+	}
+	cases := []struct {
+		name     string
+		value    string
+		endOfDay bool
+		want     time.Time
+		wantErr  bool
+	}{
+		{"vacio", "", true, time.Time{}, false},
+		{"fecha desde", "2024-03-10", false, time.Date(2024, 3, 10, 0, 0, 0, 0, madrid), false},
+		{"fecha hasta incluye el dia", "2024-03-10", true, time.Date(2024, 3, 11, 0, 0, 0, 0, madrid), false},
+		{"instante desde", "2024-03-10T18:30:00Z", false, time.Date(2024, 3, 10, 18, 30, 0, 0, time.UTC), false},
+		{"instante hasta incluye el instante", "2024-03-10T18:30:00Z", true, time.Date(2024, 3, 10, 18, 30, 0, int(time.Millisecond), time.UTC), false},
+		{"formato ajeno", "10/03/2024", false, time.Time{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseStatsDate(tc.value, madrid, tc.endOfDay)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "inválida") {
+					t.Fatalf("se esperaba un error de fecha inválida, se obtuvo %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("%s, se esperaba %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// el progreso por ejercicio agrupa en Go lo que las estadisticas agrupan en MongoDB: mismas granularidades, mismas claves
+func TestBucketKeyMatchesStatsGranularities(t *testing.T) {
+	date := time.Date(2025, time.December, 30, 10, 0, 0, 0, time.UTC) // semana ISO 1 de 2026
+	want := map[string]string{"day": "2025-12-30", "week": "2026-W01", "month": "2025-12", "year": "2025"}
+	for granularity := range statsDateFormats {
+		got, err := bucketKey(date, granularity)
+		if err != nil {
+			t.Fatalf("%s: error inesperado: %v", granularity, err)
+		}
+		if got != want[granularity] {
+			t.Errorf("%s: bucketKey = %q, se esperaba %q", granularity, got, want[granularity])
+		}
+	}
+	if _, err := bucketKey(date, "hour"); err != errGranularity {
+		t.Errorf("se esperaba errGranularity, se obtuvo %v", err)
+	}
+}
+
+func TestSoleEntry(t *testing.T) {
+	bench, squat := primitive.NewObjectID(), primitive.NewObjectID()
+	warmUp, working, squatEntry := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	routine := &models.Routine{ExcerciseList: []models.ExcerciseInRoutine{
+		{EntryID: warmUp, ExcerciseID: bench},
+		{EntryID: squatEntry, ExcerciseID: squat},
+		{EntryID: working, ExcerciseID: bench},
+	}}
+
+	if got, err := soleEntry(routine, squat); err != nil || got != squatEntry {
+		t.Errorf("soleEntry(squat) = %v, %v; se esperaba %v", got, err, squatEntry)
+	}
+	if _, err := soleEntry(routine, bench); err == nil || !strings.Contains(err.Error(), "ambiguo") {
+		t.Errorf("se esperaba un error de ejercicio ambiguo, se obtuvo %v", err)
+	}
+	if got, err := soleEntry(routine, primitive.NewObjectID()); err != nil || !got.IsZero() {
+		t.Errorf("un ejercicio fuera de la rutina no tiene entrada: %v, %v", got, err)
+	}
+}
+
+// importCatalogueStub responde las busquedas por nombre del import y anota el patron de candidatos pedido
+type importCatalogueStub struct {
+	repositories.ExcerciseRepositoryInterface
+	catalogue []models.Excercise
+	pattern   string
+}
+
+func (s *importCatalogueStub) GetExcercisesByNames(names []string) ([]models.Excercise, error) {
+	var found []models.Excercise
+	for _, e := range s.catalogue {
+		for _, name := range names {
+			if strings.EqualFold(e.Name, name) {
+				found = append(found, e)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *importCatalogueStub) GetSearchCandidates(ids []primitive.ObjectID, pattern string) ([]*models.Excercise, error) {
+	s.pattern = pattern
+	return []*models.Excercise{&s.catalogue[1]}, nil
+}
+
+func (s *importCatalogueStub) GetExcerciseByID(id string) (models.Excercise, error) {
+	for _, e := range s.catalogue {
+		if e.ID.Hex() == id {
+			return e, nil
+		}
+	}
+	return models.Excercise{}, nil
+}
+
+func TestImportCatalogueOnlyLooksUpCSVNames(t *testing.T) {
+	stub := &importCatalogueStub{catalogue: []models.Excercise{
+		{ID: primitive.NewObjectID(), Name: "Press de banca"},
+		{ID: primitive.NewObjectID(), Name: "Sentadilla"},
+		{ID: primitive.NewObjectID(), Name: "Remo con barra"},
+	}}
+	ws := WorkoutService{ExcerciseRepository: stub}
+	rows := []dto.WorkoutLogRowDTO{{Excercise: "press de banca"}, {Excercise: "Sentadila"}, {Excercise: "Press de Banca"}, {Excercise: "Curl"}}
+	mapping := map[string]string{"Curl": stub.catalogue[2].ID.Hex()}
+
+	catalogue, err := ws.importCatalogue(rows, mapping)
+	if err != nil {
+		t.Fatalf("error inesperado: %v", err)
+	}
+	var names []string
+	for _, e := range catalogue {
+		names = append(names, e.Name)
+	}
+	if got, want := strings.Join(names, ","), "Remo con barra,Press de banca,Sentadilla"; got != want {
+		t.Errorf("catalogo = %s, se esperaba %s", got, want)
+	}
+	// solo el nombre sin ejercicio igual ni mapping pide candidatos
+	if stub.pattern != searchPrefixPattern("sentadila") {
+		t.Errorf("patron de candidatos = %q", stub.pattern)
+	}
+}
@@ -48,6 +48,8 @@ async function handleSaveExercise() {
     difficult_level: document.getElementById('ex_difficulty').value,
     example: document.getElementById('ex_sample').value.trim(),
     instructions: document.getElementById('ex_instructions').value.trim(),
+    // otros nombres separados por coma, para que la búsqueda lo encuentre ("bench press, pecho plano")
+    aliases: (document.getElementById('ex_aliases')?.value || '').split(',').map(a => a.trim()).filter(Boolean),
   };
 
   if (!payload.name || !payload.main_muscle_group || !payload.description || !payload.category || !payload.difficult_level) {
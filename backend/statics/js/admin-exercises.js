@@ -55,7 +55,7 @@ function renderExercises(exercises, tableBody) {
         <td>${exercise.Name || ''}</td>
         <td>${exercise.Description || ''}</td>
         <td>${exercise.Category || ''}</td>
-        <td>${exercise.MainMuscleGroup || ''}</td>
+        <td>${exercise.MainMuscleGroupName || exercise.MainMuscleGroup || ''}</td>
         <td>${exercise.DifficultLevel || ''}</td>
         <td><a href="${exercise.Example || '#'}" target="_blank" rel="noopener">URL</a></td>
       `;
@@ -82,7 +82,7 @@ async function loadExercises() {
   const params = new URLSearchParams();
 
   // Construir la URL del endpoint
-  if (name) params.append('name', name);
+  if (name) params.append('q', name); // búsqueda por relevancia, tolera acentos y errores de tipeo
   if (category) params.append('category', category);
   if (muscleGroup) params.append('muscle_group', muscleGroup);
 
@@ -94,7 +94,7 @@ async function loadExercises() {
     endpoint = `/api/exercises/filter?${queryString}`;
   } else {
     // Usamos el endpoint que trae todos
-    endpoint = '/api/exercises';
+    endpoint = '/api/exercises?limit=100';
   }
 
   try {
@@ -105,7 +105,9 @@ async function loadExercises() {
       throw new Error(errorData.error || `Error ${response.status}: No se pudieron cargar los ejercicios.`);
     }
 
-    const exercises = await response.json();
+    // el catalogo completo viene paginado ({data, next, prev}); la busqueda devuelve la lista directamente
+    const body = await response.json();
+    const exercises = Array.isArray(body) ? body : body.data;
     renderExercises(exercises, tableBody); // Usar la función de renderizado
 
   } catch (error) {
@@ -122,22 +124,45 @@ async function loadExercises() {
 /**
  * Maneja el clic en el botón de eliminar ejercicio.
  */
-async function handleDeleteExercise(exerciseId) {
-  if (!confirm('¿Estás seguro de que deseas eliminar este ejercicio? Esta acción no se puede deshacer.')) {
+async function handleDeleteExercise(exerciseId, query = '') {
+  if (!query && !confirm('¿Estás seguro de que deseas eliminar este ejercicio? Quedará en la papelera.')) {
     return;
   }
 
   try {
-    const response = await fetchApi(`/api/exercises/${exerciseId}`, {
+    const response = await fetchApi(`/api/exercises/${exerciseId}${query}`, {
       method: 'DELETE'
     });
 
+    if (response.status === 409) {
+      // El ejercicio está en uso: se muestran las rutinas y se pregunta qué hacer con ellas
+      const errorData = await response.json();
+      const routines = errorData.dependencies?.routines || [];
+      const names = routines.map(r => `- ${r.name}${r.in_trash ? ' (en la papelera)' : ''}`).join('\n');
+      const replaceWith = prompt(
+        `${errorData.error}:\n${names}\n\n` +
+        'Escribe el ID de un ejercicio de la misma categoría para reemplazarlo, ' +
+        'o deja vacío para quitarlo de esas rutinas.'
+      );
+      if (replaceWith === null) return; // cancelado
+      const next = replaceWith.trim()
+        ? `?mode=replace&replace_with=${encodeURIComponent(replaceWith.trim())}`
+        : '?mode=cascade';
+      return handleDeleteExercise(exerciseId, next);
+    }
+
     if (!response.ok) {
       const errorData = await response.json();
       throw new Error(errorData.error || 'Error al eliminar el ejercicio.');
     }
 
-    alert('Ejercicio eliminado correctamente.');
+    const result = await response.json();
+    const workouts = result.workout_count
+      ? ` Los ${result.workout_count} workouts que lo registraron conservan su historial.`
+      : '';
+    alert((result.routines_updated
+      ? `Ejercicio eliminado correctamente. Rutinas actualizadas: ${result.routines_updated}.`
+      : 'Ejercicio eliminado correctamente.') + workouts);
     loadExercises(); // Recargar la tabla para mostrar los cambios
 
   } catch (error) {
@@ -38,7 +38,7 @@ async function loadUserCountAndAges() {
   countElement.textContent = '...';
 
   try {
-    const response = await fetchApi('/api/admin/stats/users');
+    const response = await fetchApi('/api/admin/stats/users?total=true&limit=100&fields=BirthDate');
 
     if (response.status === 204) {
       countElement.textContent = '0';
@@ -54,8 +54,8 @@ async function loadUserCountAndAges() {
     countElement.textContent = data.total || 0;
 
     // Tarjeta 3 (Gráfico de Edades)
-    if (data.users && data.users.length > 0) {
-      processAgeChart(data.users); // Llamar a la función del gráfico
+    if (data.data && data.data.length > 0) {
+      processAgeChart(data.data); // Llamar a la función del gráfico
     }
 
   } catch (error) {
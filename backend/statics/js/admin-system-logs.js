@@ -41,7 +41,7 @@ async function loadActiveUsers() {
 
   try {
     // Llamamos al mismo endpoint que usa admin-users
-    const response = await fetchApi('/api/admin/stats/users');
+    const response = await fetchApi('/api/admin/stats/users?limit=100');
 
     if (response.status === 204) {
       tableBody.innerHTML = '<tr><td colspan="4">No hay usuarios en el sistema.</td></tr>';
@@ -55,7 +55,7 @@ async function loadActiveUsers() {
     const data = await response.json();
 
     // FILTRAMOS por IsActive = true
-    const activeUsers = data.users.filter(user => user.is_active === true);
+    const activeUsers = data.data.filter(user => user.is_active === true);
 
     tableBody.innerHTML = ''; // Limpiar "cargando"
 
@@ -24,12 +24,15 @@ async function fetchApi(url, options = {}) {
   return response;
 }
 
-async function loadUsers() {
+// enlaces a la pagina anterior y siguiente que devuelve la API
+let usersPage = { next: null, prev: null };
+
+async function loadUsers(url = '/api/admin/stats/users?total=true&limit=50') {
   const tableBody = document.querySelector('.table tbody');
   tableBody.innerHTML = '<tr><td colspan="12">Cargando usuarios...</td></tr>';
 
   try {
-    const response = await fetchApi('/api/admin/stats/users');
+    const response = await fetchApi(url);
 
     if (!response.ok) {
       if (response.status === 204) {
@@ -41,9 +44,15 @@ async function loadUsers() {
 
     const data = await response.json();
     tableBody.innerHTML = '';
+    usersPage = { next: data.next, prev: data.prev };
+    document.getElementById('btn_users_next')?.toggleAttribute('disabled', !data.next);
+    document.getElementById('btn_users_prev')?.toggleAttribute('disabled', !data.prev);
+    if (data.total !== undefined && document.getElementById('users_total')) {
+      document.getElementById('users_total').textContent = data.total;
+    }
 
-    if (data.users && data.users.length > 0) {
-      data.users.forEach((user, index) => {
+    if (data.data && data.data.length > 0) {
+      data.data.forEach((user, index) => {
         const row = document.createElement('tr');
 
         const birthDate = user.BirthDate ? user.BirthDate.split('T')[0] : 'N/D';
@@ -76,6 +85,12 @@ async function loadUsers() {
               ${user.Role === 'admin' ? 'disabled' : ''}>
               Hacer Admin
             </button>
+            <button
+              type="button"
+              class="btn btn-outline-danger btn-sm btn-delete-user"
+              data-user-id="${user.id}">
+              Eliminar
+            </button>
           </td>
         `;
         tableBody.appendChild(row);
@@ -134,8 +149,107 @@ async function handlePromoteUser(event) {
 }
 
 
+async function handleDeleteUser(event) {
+  const userId = event.target.dataset.userId;
+  const errorElement = document.getElementById('error_msg');
+  errorElement.textContent = '';
+
+  if (!confirm('¿Eliminar este usuario? Quedará en la papelera y se podrá restaurar hasta que se purgue.')) {
+    return;
+  }
+
+  try {
+    const response = await fetchApi(`/api/admin/users/${userId}`, { method: 'DELETE' });
+    if (!response.ok) {
+      const errorData = await response.json();
+      throw new Error(errorData.error || 'No se pudo eliminar el usuario.');
+    }
+    loadUsers();
+    loadTrash();
+  } catch (error) {
+    console.error('Error al eliminar usuario:', error);
+    errorElement.textContent = error.message;
+  }
+}
+
+// papelera: ejercicios, rutinas, usuarios y workouts eliminados que todavia no se purgaron
+let trashPage = { next: null, prev: null };
+
+async function loadTrash(url) {
+  const tableBody = document.getElementById('trash_body');
+  if (!tableBody) return;
+  const type = document.getElementById('trash_type')?.value || 'users';
+  url = url || `/api/admin/trash/${type}?total=true`;
+  tableBody.innerHTML = '<tr><td colspan="5">Cargando papelera...</td></tr>';
+
+  try {
+    const response = await fetchApi(url);
+    if (!response.ok) {
+      throw new Error(`Error ${response.status}: No se pudo cargar la papelera.`);
+    }
+    const data = await response.json();
+    trashPage = { next: data.next, prev: data.prev };
+    document.getElementById('btn_trash_next')?.toggleAttribute('disabled', !data.next);
+    document.getElementById('btn_trash_prev')?.toggleAttribute('disabled', !data.prev);
+
+    if (!data.data || data.data.length === 0) {
+      tableBody.innerHTML = '<tr><td colspan="5">La papelera está vacía.</td></tr>';
+      return;
+    }
+    tableBody.innerHTML = '';
+    data.data.forEach(item => {
+      const row = document.createElement('tr');
+      row.innerHTML = `
+        <td>${item.name || item.id}</td>
+        <td>${item.owner_id || ''}</td>
+        <td>${new Date(item.deleted_at).toLocaleString()}</td>
+        <td>${new Date(item.purge_at).toLocaleString()}</td>
+        <td>
+          <button type="button" class="btn btn-outline-success btn-sm btn-restore"
+            data-type="${item.type}" data-id="${item.id}">Restaurar</button>
+        </td>
+      `;
+      tableBody.appendChild(row);
+    });
+  } catch (error) {
+    console.error('Error al cargar la papelera:', error);
+    tableBody.innerHTML = `<tr><td colspan="5" class="text-danger">Error: ${error.message}</td></tr>`;
+  }
+}
+
+async function handleRestore(event) {
+  const { type, id } = event.target.dataset;
+  const errorElement = document.getElementById('error_msg');
+  errorElement.textContent = '';
+
+  try {
+    const response = await fetchApi(`/api/admin/trash/${type}/${id}/restore`, { method: 'POST' });
+    if (!response.ok) {
+      const errorData = await response.json();
+      throw new Error(errorData.error || 'No se pudo restaurar.');
+    }
+    loadTrash();
+    if (type === 'users') loadUsers();
+  } catch (error) {
+    console.error('Error al restaurar:', error);
+    errorElement.textContent = error.message;
+  }
+}
+
+
 document.addEventListener('DOMContentLoaded', () => {
   loadUsers();
+  loadTrash();
+  document.getElementById('trash_type')?.addEventListener('change', () => loadTrash());
+  document.getElementById('btn_trash_next')?.addEventListener('click', () => trashPage.next && loadTrash(trashPage.next));
+  document.getElementById('btn_trash_prev')?.addEventListener('click', () => trashPage.prev && loadTrash(trashPage.prev));
+  document.getElementById('trash_body')?.addEventListener('click', (event) => {
+    if (event.target.classList.contains('btn-restore')) {
+      handleRestore(event);
+    }
+  });
+  document.getElementById('btn_users_next')?.addEventListener('click', () => usersPage.next && loadUsers(usersPage.next));
+  document.getElementById('btn_users_prev')?.addEventListener('click', () => usersPage.prev && loadUsers(usersPage.prev));
 
 
   const tableBody = document.querySelector('.table tbody');
@@ -143,5 +257,8 @@ document.addEventListener('DOMContentLoaded', () => {
     if (event.target.classList.contains('btn-promote')) {
       handlePromoteUser(event);
     }
+    if (event.target.classList.contains('btn-delete-user')) {
+      handleDeleteUser(event);
+    }
   });
 });
\ No newline at end of file
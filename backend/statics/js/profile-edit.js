@@ -59,6 +59,7 @@ async function loadCurrentData(userId) {
         document.getElementById('edit_username').value = user.UserName;
         document.getElementById('edit_height').value = user.Height;
         document.getElementById('edit_weight').value = user.Weight;
+        document.getElementById('edit_weekly_goal').value = user.weekly_goal || 0;
         document.getElementById('edit_experience').value = user.Experience;
         document.getElementById('edit_objective').value = user.Objetive;
 
@@ -82,6 +83,7 @@ async function handleSaveChanges(userId, userRole) {
             email: document.getElementById('edit_email').value.trim(),
             height: parseFloat(document.getElementById('edit_height').value),
             weight: parseFloat(document.getElementById('edit_weight').value),
+            weekly_goal: parseInt(document.getElementById('edit_weekly_goal').value, 10) || 0,
             experience: document.getElementById('edit_experience').value,
             objetive: document.getElementById('edit_objective').value,
             role: userRole
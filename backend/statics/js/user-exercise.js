@@ -69,7 +69,7 @@ function renderUserExercises(exercises, tableBody) {
         <td>${exercise.Name || ''}</td>
         <td>${exercise.Description || ''}</td>
         <td>${exercise.Category || ''}</td>
-        <td>${exercise.MainMuscleGroup || ''}</td>
+        <td>${exercise.MainMuscleGroupName || exercise.MainMuscleGroup || ''}</td>
         <td>${exercise.DifficultLevel || ''}</td>
         <td><a href="${exercise.Example || '#'}" target="_blank" rel="noopener">Ver Video</a></td>
         <td>
@@ -77,6 +77,7 @@ function renderUserExercises(exercises, tableBody) {
             class="btn btn-outline-primary btn-sm btn-add-exercise" 
             data-exercise-id="${exercise.id}" 
             data-exercise-name="${exercise.Name}"
+            data-exercise-category="${exercise.Category || ''}"
             data-bs-toggle="modal" 
             data-bs-target="#addExerciseModal">
             + Añadir
@@ -101,7 +102,7 @@ async function loadUserRoutines() {
   if (!currentUser) return;
 
   try {
-    const response = await fetchApi('/api/routines');
+    const response = await fetchApi('/api/routines?limit=100&fields=ID,Name,CreatorUserID');
     if (!response.ok) {
       if (response.status === 404) {
         selectElement.innerHTML = '<option value="">No tienes rutinas creadas</option>';
@@ -110,7 +111,7 @@ async function loadUserRoutines() {
       throw new Error('No se pudieron cargar tus rutinas');
     }
 
-    const allRoutines = await response.json();
+    const allRoutines = (await response.json()).data; // respuesta paginada
 
     // Filtramos SÓLO las rutinas del usuario actual
     userRoutines = allRoutines.filter(r => r.CreatorUserID === currentUser.id);
@@ -147,7 +148,7 @@ async function loadExercises() {
   let endpoint = '';
   const params = new URLSearchParams();
 
-  if (name) params.append('name', name);
+  if (name) params.append('q', name); // búsqueda por relevancia, tolera acentos y errores de tipeo
   if (category) params.append('category', category);
   if (muscleGroup) params.append('muscle_group', muscleGroup);
 
@@ -156,7 +157,7 @@ async function loadExercises() {
   if (queryString) {
     endpoint = `/api/exercises/filter?${queryString}`;
   } else {
-    endpoint = '/api/exercises';
+    endpoint = '/api/exercises?limit=100';
   }
 
   try {
@@ -167,7 +168,9 @@ async function loadExercises() {
       throw new Error(errorData.error || `Error ${response.status}: No se pudieron cargar los ejercicios.`);
     }
 
-    const exercises = await response.json();
+    // el catalogo completo viene paginado ({data, next, prev}); la busqueda devuelve la lista directamente
+    const body = await response.json();
+    const exercises = Array.isArray(body) ? body : body.data;
     renderUserExercises(exercises, tableBody);
 
   } catch (error) {
@@ -201,22 +204,44 @@ async function handleSaveToRoutine() {
     errorElement.textContent = 'Error: No se seleccionó un ejercicio. Cierra el modal e inténtalo de nuevo.';
     return;
   }
-  if (isNaN(series) || series <= 0 || isNaN(reps) || reps <= 0) {
-    errorElement.textContent = 'Las series y repeticiones deben ser números mayores a 0.';
-    return;
-  }
-  if (isNaN(weight) || weight < 0) {
-    errorElement.textContent = 'El peso debe ser un número igual o mayor a 0.';
-    return;
+  const category = document.getElementById('modal_exercise_category').value;
+  let payload;
+  if (category === 'cardio') {
+    const minutes = parseFloat(document.getElementById('modal_duration').value) || 0;
+    const km = parseFloat(document.getElementById('modal_distance').value) || 0;
+    const zone = parseInt(document.getElementById('modal_hr_zone').value, 10);
+    if (minutes <= 0 && km <= 0) {
+      errorElement.textContent = 'Indica una duración o una distancia.';
+      return;
+    }
+    payload = {
+      exercise_id: exerciseId,
+      cardio: {
+        duration_seconds: Math.round(minutes * 60) || undefined,
+        distance_meters: Math.round(km * 1000) || undefined,
+        heart_rate_zone: zone || undefined
+      }
+    };
+  } else {
+    if (isNaN(series) || series <= 0 || (category !== 'flexibility' && (isNaN(reps) || reps <= 0))) {
+      errorElement.textContent = 'Las series y repeticiones deben ser números mayores a 0.';
+      return;
+    }
+    if (isNaN(weight) || weight < 0) {
+      errorElement.textContent = 'El peso debe ser un número igual o mayor a 0.';
+      return;
+    }
+    payload = {
+      exercise_id: exerciseId,
+      repetitions: category === 'flexibility' ? 0 : reps,
+      series: series,
+      weight: weight
+    };
+    if (category === 'flexibility') {
+      payload.hold_seconds = parseInt(document.getElementById('modal_hold').value, 10) || 0;
+    }
   }
 
-  const payload = {
-    exercise_id: exerciseId,
-    repetitions: reps,
-    series: series,
-    weight: weight
-  };
-
   try {
     const response = await fetchApi(`/api/routines/${routineId}/exercises`, {
       method: 'POST',
@@ -264,6 +289,13 @@ document.addEventListener('DOMContentLoaded', () => {
       document.getElementById('modal_exercise_id').value = exerciseId;
       document.getElementById('modal_exercise_name').textContent = exerciseName;
 
+      // cardio se prescribe por tiempo/distancia y flexibilidad por segundos sostenidos
+      const category = button.dataset.exerciseCategory;
+      document.getElementById('modal_exercise_category').value = category;
+      document.getElementById('modal_cardio_fields').hidden = category !== 'cardio';
+      document.getElementById('modal_flexibility_fields').hidden = category !== 'flexibility';
+      document.getElementById('modal_strength_fields').hidden = category === 'cardio';
+
       document.getElementById('modal_error_msg').textContent = '';
     });
   }
@@ -142,5 +142,70 @@ function renderProgressChart(progressData) {
 }
 
 
+const RECORD_LABELS = {
+  heaviest_weight: 'Mayor peso',
+  most_reps: 'Más repeticiones',
+  best_estimated_1rm: '1RM estimado',
+  highest_volume: 'Mayor volumen'
+};
+
+/**
+ * Carga la línea de tiempo de records personales desde /api/workouts/records
+ */
+async function loadRecords() {
+  const tableBody = document.getElementById('records_body');
+  if (!tableBody) return;
+
+  try {
+    tableBody.innerHTML = '<tr><td colspan="5">Cargando...</td></tr>';
+    const response = await fetchApi('/api/workouts/records');
+    if (!response.ok) {
+      const err = await response.json();
+      throw new Error(err.error || 'No se pudieron cargar los records');
+    }
+
+    const records = await response.json(); // Array de PersonalRecordResponseDTO
+    tableBody.innerHTML = '';
+    if (!records || records.length === 0) {
+      tableBody.innerHTML = '<tr><td colspan="5">Aún no tienes records registrados.</td></tr>';
+      return;
+    }
+
+    // Más recientes primero
+    records.slice().reverse().forEach(record => {
+      const row = document.createElement('tr');
+      row.innerHTML = `
+        <td>${new Date(record.date).toLocaleDateString('es-ES')}</td>
+        <td>${record.exercise_name || 'N/D'}</td>
+        <td>${RECORD_LABELS[record.type] || record.type}</td>
+        <td>${formatRecord(record)}</td>
+        <td>${record.previous_value ? record.previous_value.toFixed(1) : '-'}</td>
+      `;
+      tableBody.appendChild(row);
+    });
+  } catch (error) {
+    console.error('Error al cargar records:', error);
+    tableBody.innerHTML = `<tr><td colspan="5" class="text-danger">Error</td></tr>`;
+  }
+}
+
+/**
+ * Da formato al valor de un record según su tipo.
+ */
+function formatRecord(record) {
+  switch (record.type) {
+    case 'most_reps':
+      return `${record.repetitions} reps con ${record.weight} kg`;
+    case 'highest_volume':
+      return `${record.value.toFixed(1)} kg totales`;
+    default:
+      return `${record.value.toFixed(1)} kg (${record.weight} kg x ${record.repetitions})`;
+  }
+}
+
+
 // --- Inicialización ---
-document.addEventListener('DOMContentLoaded', loadStats);
\ No newline at end of file
+document.addEventListener('DOMContentLoaded', () => {
+  loadStats();
+  loadRecords();
+});
\ No newline at end of file
@@ -42,7 +42,7 @@ async function loadRecords() {
 
   try {
     // 1. Llama al endpoint GetWorkouts
-    const response = await fetchApi('/api/workouts');
+    const response = await fetchApi('/api/workouts?limit=100'); // los 100 mas recientes
 
     if (!response.ok) {
       if (response.status === 404) {
@@ -53,7 +53,7 @@ async function loadRecords() {
       throw new Error(err.error || 'No se pudo cargar el historial.');
     }
 
-    const records = await response.json(); // Array de WorkoutResponseDTO
+    const records = (await response.json()).data; // pagina de WorkoutResponseDTO
 
     // 2. Renderizar la tabla
     tableBody.innerHTML = '';
@@ -127,10 +127,162 @@ async function handleDeleteRecord(workoutId) {
   }
 }
 
+const CALENDAR_STATUS = {
+  done: '<span class="badge text-bg-success">Hecho</span>',
+  missed: '<span class="badge text-bg-danger">Perdido</span>',
+  upcoming: '<span class="badge text-bg-secondary">Próximo</span>'
+};
+
+/**
+ * Carga el calendario del mes actual (planificado vs realizado).
+ */
+async function loadCalendar() {
+  const tableBody = document.getElementById('calendar-table-body');
+  const adherenceEl = document.getElementById('calendar_adherence');
+  if (!tableBody) return;
+
+  try {
+    const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    const response = await fetchApi(`/api/schedules/calendar?tz=${encodeURIComponent(tz)}`);
+    if (!response.ok) {
+      const err = await response.json();
+      throw new Error(err.error || 'No se pudo cargar el calendario.');
+    }
+
+    const calendar = await response.json(); // CalendarDTO
+    adherenceEl.textContent = calendar.planned_sessions > 0
+      ? `Cumplimiento del plan: ${Math.round(calendar.adherence * 100)}% (${calendar.completed_planned}/${calendar.planned_sessions})`
+      : 'Sin sesiones planificadas este mes.';
+
+    tableBody.innerHTML = '';
+    if (!calendar.days || calendar.days.length === 0) {
+      tableBody.innerHTML = '<tr><td colspan="4">No hay actividad planificada ni registrada este mes.</td></tr>';
+      return;
+    }
+    calendar.days.forEach(day => {
+      const row = document.createElement('tr');
+      row.innerHTML = `
+        <td>${day.date}</td>
+        <td>${day.planned.map(p => p.routine_name).join(', ') || '-'}</td>
+        <td>${day.completed.map(c => c.routine_name).join(', ') || '-'}</td>
+        <td>${CALENDAR_STATUS[day.status] || day.status}</td>
+      `;
+      tableBody.appendChild(row);
+    });
+  } catch (error) {
+    console.error('Error al cargar calendario:', error);
+    tableBody.innerHTML = `<tr><td colspan="4" class="text-danger">Error al cargar.</td></tr>`;
+  }
+}
+
+/**
+ * Muestra la URL de suscripción del calendario (.ics) si el usuario ya generó una.
+ */
+async function loadCalendarFeed() {
+  const input = document.getElementById('calendar_feed_url');
+  if (!input) return;
+
+  const response = await fetchApi('/api/schedules/feed');
+  input.value = response.ok ? `${window.location.origin}${(await response.json()).url}` : '';
+}
+
+/**
+ * Genera (o regenera) el enlace secreto; el anterior deja de funcionar.
+ */
+async function handleRotateFeed() {
+  if (document.getElementById('calendar_feed_url').value &&
+    !confirm('Se generará un enlace nuevo y el actual dejará de funcionar. ¿Continuar?')) {
+    return;
+  }
+  const response = await fetchApi('/api/schedules/feed', { method: 'POST' });
+  if (!response.ok) {
+    const err = await response.json();
+    document.getElementById('error_msg').textContent = err.error || 'No se pudo generar el enlace.';
+    return;
+  }
+  loadCalendarFeed();
+}
+
+async function handleRevokeFeed() {
+  if (!confirm('¿Revocar el enlace del calendario? Las suscripciones existentes dejarán de actualizarse.')) {
+    return;
+  }
+  await fetchApi('/api/schedules/feed', { method: 'DELETE' });
+  loadCalendarFeed();
+}
+
+// --- Importación de historial de otras aplicaciones ---
+
+// asignaciones manuales elegidas en la vista previa: nombre en el CSV -> ID de ejercicio ("" = ignorar)
+const historyMapping = {};
+
+async function handleHistoryImport(dryRun) {
+  const file = document.getElementById('history_file').files[0];
+  const resultEl = document.getElementById('history_result');
+  if (!file) {
+    resultEl.innerHTML = '<p class="text-danger">Elige el archivo CSV exportado de tu aplicación.</p>';
+    return;
+  }
+
+  // igual que al importar rutinas: el archivo va en el cuerpo y las opciones en la URL
+  const params = new URLSearchParams({
+    dry_run: dryRun,
+    unit: document.getElementById('history_unit').value,
+    tz: Intl.DateTimeFormat().resolvedOptions().timeZone
+  });
+  const dateFormat = document.getElementById('history_date_format').value;
+  if (dateFormat) params.set('date_format', dateFormat);
+  Object.entries(historyMapping).forEach(([name, id]) => params.set(`mapping[${name}]`, id));
+
+  const response = await fetchApi(`/api/workouts/import?${params}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'text/csv' },
+    body: await file.text()
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    resultEl.innerHTML = `<p class="text-danger">${data.error || 'No se pudo importar el historial.'}</p>`;
+    return;
+  }
+
+  const unmatched = data.exercises.filter(e => e.matched_by === 'unmatched');
+  resultEl.innerHTML = `
+    <p>${dryRun ? 'Se importarán' : 'Se importaron'} ${data.workouts} entrenamientos (${data.sets} series)
+      ${data.duplicates ? ` · ${data.duplicates} ya estaban importados` : ''}</p>
+    ${data.exercises.map(e => `
+      <div class="small mb-1">
+        <strong>${e.name}</strong> (${e.rows} series) →
+        ${e.matched_by === 'unmatched'
+          ? `<select class="form-select form-select-sm d-inline-block w-auto history-mapping" data-name="${e.name}">
+              <option value="">Ignorar</option>
+              ${(e.candidates || []).map(c => `<option value="${c.exercise_id}">${c.name}</option>`).join('')}
+            </select>`
+          : e.matched_by === 'skipped' ? 'ignorado' : `${e.exercise_name} (${e.matched_by})`}
+      </div>`).join('')}
+    ${(data.errors || []).map(r => `<div class="text-warning small">Línea ${r.line}: ${r.reason}</div>`).join('')}`;
+
+  // los nombres sin asignar arrancan ignorados hasta que se elija un candidato
+  unmatched.forEach(e => { historyMapping[e.name] = ''; });
+  resultEl.querySelectorAll('.history-mapping').forEach(select => {
+    select.addEventListener('change', () => { historyMapping[select.dataset.name] = select.value; });
+  });
+
+  document.getElementById('btn_history_confirm').classList.toggle('d-none', !dryRun || data.workouts + unmatched.length === 0);
+  if (!dryRun) {
+    loadRecords();
+  }
+}
+
 // --- Inicialización ---
 document.addEventListener('DOMContentLoaded', () => {
   //Cargar los registros al iniciar
   loadRecords();
+  loadCalendar();
+  loadCalendarFeed();
+  document.getElementById('btn_feed_rotate').addEventListener('click', handleRotateFeed);
+  document.getElementById('btn_feed_revoke').addEventListener('click', handleRevokeFeed);
+  document.getElementById('btn_history_preview').addEventListener('click', () => handleHistoryImport(true));
+  document.getElementById('btn_history_confirm').addEventListener('click', () => handleHistoryImport(false));
 
   //Usar delegación de eventos para los botones de eliminar
   const tableBody = document.getElementById('record-table-body');
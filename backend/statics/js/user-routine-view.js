@@ -98,19 +98,42 @@ async function loadRoutineView() {
         // Esperamos a que todas las llamadas a GetExcerciseByID terminen
         const exercisesWithDetails = await Promise.all(exerciseDetailPromises);
 
-        // Renderizar los ejercicios en tarjetas
+        // Superseries y circuitos: las entradas de un grupo vienen seguidas y comparten el descanso
+        const groups = {};
+        (routine.Groups || []).forEach((g, i) => { groups[g.id] = { ...g, label: String.fromCharCode(65 + i) }; });
+
+        // Sugerencias de progresion (solo llegan al creador), por entrada
+        const suggestions = {};
+        (routine.Suggestions || []).forEach(s => { suggestions[s.entry_id] = s; });
+
+        // Renderizar los ejercicios en tarjetas, en el orden de la rutina
         exercisesWithDetails.forEach(ex => {
+            const suggestion = suggestions[ex.entry_id];
+            const group = groups[ex.group_id];
+            const groupBadge = group
+                ? `<span class="badge text-bg-info mb-2">${GROUP_LABELS[group.type] || group.type} ${group.label}` +
+                  `${group.rounds ? ` · ${group.rounds} vueltas` : ''} · descanso ${group.rest_seconds}s</span>`
+                : '';
             const card = document.createElement('div');
             card.className = 'col-md-6 col-lg-4 mb-3';
             card.innerHTML = `
                 <div class="card h-100 shadow-sm">
                     <div class="card-body">
-                        <h5 class="card-title text-primary">${ex.Name}</h5>
+                        ${groupBadge}
+                        <h5 class="card-title text-primary">${ex.position + 1}. ${ex.Name}</h5>
                         <ul class="list-group list-group-flush">
-                            <li class="list-group-item"><strong>Series:</strong> ${ex.series}</li>
-                            <li class="list-group-item"><strong>Repeticiones:</strong> ${ex.repetitions}</li>
-                            <li class="list-group-item"><strong>Peso:</strong> ${ex.weight} kg</li>
+                            ${(ex.sets || []).map(s => `<li class="list-group-item">${describeSet(s)}</li>`).join('')}
+                            ${ex.cardio ? `<li class="list-group-item">${describeCardio(ex.cardio)}</li>` : ''}
+                            ${ex.hold_seconds ? `<li class="list-group-item"><strong>Mantener:</strong> ${ex.series} x ${ex.hold_seconds}s</li>` : ''}
+                            ${ex.rest_seconds ? `<li class="list-group-item"><strong>Descanso:</strong> ${ex.rest_seconds}s</li>` : ''}
+                            ${ex.tempo ? `<li class="list-group-item"><strong>Tempo:</strong> ${ex.tempo}</li>` : ''}
                         </ul>
+                        ${suggestion ? `
+                        <div class="alert alert-success mt-3 mb-0 p-2 small">
+                            <strong>Próxima sesión:</strong> ${suggestion.suggested}<br>
+                            <span class="text-muted">${suggestion.reason}</span><br>
+                            <button type="button" class="btn btn-success btn-sm mt-2 btn-accept-suggestion" data-entry="${suggestion.entry_id}">Aceptar</button>
+                        </div>` : ''}
                     </div>
                 </div>
             `;
@@ -124,6 +147,145 @@ async function loadRoutineView() {
     }
 }
 
+const SET_LABELS = {
+    warmup: 'Calentamiento',
+    working: 'Trabajo',
+    drop: 'Drop set'
+};
+
+/**
+ * Texto de un bloque de series prescritas, ej. "Trabajo: 3 x 8-12 @ 60 kg · RPE 8".
+ */
+function describeSet(s) {
+    const reps = s.max_reps > s.min_reps ? `${s.min_reps}-${s.max_reps}` : s.min_reps;
+    let text = `<strong>${SET_LABELS[s.type] || s.type}:</strong> ${s.count} x ${reps} @ ${s.weight} kg`;
+    if (s.rpe) text += ` · RPE ${s.rpe}`;
+    if (s.drops) text += ` · ${s.drops} bajada(s)`;
+    if (s.rest_seconds) text += ` · ${s.rest_seconds}s descanso`;
+    return text;
+}
+
+/**
+ * Texto de una prescripción de cardio, ej. "20 min · 5 km · zona 2".
+ */
+function describeCardio(c) {
+    const parts = [];
+    if (c.duration_seconds) parts.push(`${Math.round(c.duration_seconds / 60)} min`);
+    if (c.distance_meters) parts.push(`${c.distance_meters / 1000} km`);
+    if (c.pace_seconds_per_km) parts.push(`ritmo ${Math.floor(c.pace_seconds_per_km / 60)}:${String(c.pace_seconds_per_km % 60).padStart(2, '0')}/km`);
+    if (c.heart_rate_zone) parts.push(`zona ${c.heart_rate_zone}`);
+    return `<strong>Cardio:</strong> ${parts.join(' · ')}`;
+}
+
+const GROUP_LABELS = {
+    superset: 'Superserie',
+    giant_set: 'Serie gigante',
+    circuit: 'Circuito'
+};
+
+const DIFF_LABELS = {
+    name_changed: d => `Nombre: "${d.from}" → "${d.to}"`,
+    exercise_added: d => `Ejercicio agregado (${d.exercise_id})`,
+    exercise_removed: d => `Ejercicio eliminado (${d.exercise_id})`,
+    exercise_changed: d => `${d.field} de ${d.exercise_id}: ${d.from} → ${d.to}`,
+    order_changed: () => 'Orden de los ejercicios modificado',
+    group_added: d => `${GROUP_LABELS[d.to] || d.to} creado`,
+    group_removed: d => `${GROUP_LABELS[d.from] || d.from} eliminado`
+};
+
+/**
+ * Carga el historial de versiones de la rutina. Solo el creador recibe respuesta OK.
+ */
+async function loadVersions(routineId) {
+    const response = await fetchApi(`/api/routines/${routineId}/versions`);
+    if (!response.ok) return;
+
+    const versions = await response.json(); // []RoutineVersionDTO, de la mas vieja a la mas nueva
+    if (versions.length === 0) return;
+
+    const current = versions[versions.length - 1].version;
+    const tableBody = document.getElementById('versions-table-body');
+    tableBody.innerHTML = '';
+    versions.slice().reverse().forEach(v => {
+        const changes = v.diff.map(d => (DIFF_LABELS[d.type] || (() => d.type))(d)).join('<br>') || '-';
+        const row = document.createElement('tr');
+        row.innerHTML = `
+            <td>v${v.version}${v.restored_from ? ` (restaura v${v.restored_from})` : ''}</td>
+            <td>${new Date(v.creation_date).toLocaleString('es-ES')}</td>
+            <td>${changes}</td>
+            <td>${v.version === current ? '<span class="badge text-bg-primary">Actual</span>' :
+                `<button type="button" class="btn btn-outline-secondary btn-sm btn-rollback" data-version="${v.version}">Restaurar</button>`}</td>
+        `;
+        tableBody.appendChild(row);
+    });
+    document.getElementById('versions-card').classList.remove('d-none');
+}
+
+async function handleRollback(routineId, version) {
+    if (!confirm(`¿Restaurar la rutina a la versión ${version}? Se guardará como una versión nueva.`)) {
+        return;
+    }
+    const response = await fetchApi(`/api/routines/${routineId}/versions/${version}/rollback`, { method: 'POST' });
+    if (!response.ok) {
+        const err = await response.json();
+        document.getElementById('error_msg').textContent = err.error || 'No se pudo restaurar la versión.';
+        return;
+    }
+    loadRoutineView();
+    loadVersions(routineId);
+}
+
+/**
+ * Aplica a la rutina la sugerencia de progresion de una entrada.
+ */
+async function handleAcceptSuggestion(routineId, entryId) {
+    const response = await fetchApi(`/api/routines/${routineId}/exercises/${entryId}`, {
+        method: 'PUT',
+        body: JSON.stringify({ accept_suggestion: true })
+    });
+    if (!response.ok) {
+        const err = await response.json();
+        document.getElementById('error_msg').textContent = err.error || 'No se pudo aplicar la sugerencia.';
+        return;
+    }
+    loadRoutineView();
+    loadVersions(routineId);
+}
+
+/**
+ * Copia la rutina a la cuenta del usuario y abre la copia.
+ */
+async function handleClone(routineId) {
+    const name = prompt('Nombre para la copia (vacío = automático):', '');
+    if (name === null) return;
+
+    const response = await fetchApi(`/api/routines/${routineId}/clone`, {
+        method: 'POST',
+        body: JSON.stringify({ name: name.trim() })
+    });
+    const data = await response.json();
+    if (!response.ok) {
+        document.getElementById('error_msg').textContent = data.error || 'No se pudo copiar la rutina.';
+        return;
+    }
+    window.location.href = `user-routine-view.html?id=${data.ID}`;
+}
+
 // --- Inicialización ---
-document.addEventListener('DOMContentLoaded', loadRoutineView);
+document.addEventListener('DOMContentLoaded', () => {
+    loadRoutineView();
+
+    const routineId = new URLSearchParams(window.location.search).get('id');
+    if (!routineId) return;
+    loadVersions(routineId);
+    document.getElementById('btn_clone').addEventListener('click', () => handleClone(routineId));
+    document.getElementById('versions-table-body').addEventListener('click', (event) => {
+        const button = event.target.closest('.btn-rollback');
+        if (button) handleRollback(routineId, button.dataset.version);
+    });
+    document.getElementById('exercise-list-container').addEventListener('click', (event) => {
+        const button = event.target.closest('.btn-accept-suggestion');
+        if (button) handleAcceptSuggestion(routineId, button.dataset.entry);
+    });
+});
 
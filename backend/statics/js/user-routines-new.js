@@ -44,7 +44,8 @@ async function handleSaveRoutine() {
   }
 
   const payload = {
-    name: routineName
+    name: routineName,
+    visibility: document.getElementById('routine_visibility').value
   };
 
   try {
@@ -73,7 +73,7 @@ async function loadRoutines() {
   tableBody.innerHTML = '<tr><td colspan="4">Cargando tus rutinas...</td></tr>';
 
   try {
-    const response = await fetchApi('/api/routines');
+    const response = await fetchApi('/api/routines?limit=100');
     if (!response.ok) {
       // Manejo de respuesta 204 (No Content) o 404 (Not Found)
       if (response.status === 204 || response.status === 404) {
@@ -84,7 +84,7 @@ async function loadRoutines() {
       throw new Error(errData.error || 'No se pudieron cargar las rutinas');
     }
 
-    const routines = await response.json();
+    const routines = (await response.json()).data; // respuesta paginada
 
     if (!routines) {
       tableBody.innerHTML = '<tr><td colspan="4">Aún no has creado ninguna rutina.</td></tr>';
@@ -208,10 +208,115 @@ async function handleFinishRoutine(routineId, routineName) {
 }
 
 
+/**
+ * Busca en la biblioteca de rutinas públicas (las destacadas aparecen primero).
+ */
+async function loadLibrary() {
+  const tableBody = document.getElementById('library-table-body');
+  const params = new URLSearchParams();
+  const q = document.getElementById('library_q').value.trim();
+  const muscle = document.getElementById('library_muscle').value.trim();
+  const category = document.getElementById('library_category').value;
+  if (q) params.set('q', q);
+  if (muscle) params.set('muscle_group', muscle);
+  if (category) params.set('category', category);
+
+  tableBody.innerHTML = '<tr><td colspan="4">Buscando...</td></tr>';
+  try {
+    const response = await fetchApi(`/api/routines/public?${params.toString()}`);
+    if (!response.ok) {
+      const err = await response.json();
+      throw new Error(err.error || 'No se pudo cargar la biblioteca');
+    }
+    const routines = await response.json(); // []PublicRoutineDTO
+
+    tableBody.innerHTML = '';
+    if (routines.length === 0) {
+      tableBody.innerHTML = '<tr><td colspan="4">No hay rutinas públicas que coincidan.</td></tr>';
+      return;
+    }
+    routines.forEach(routine => {
+      const row = document.createElement('tr');
+      row.innerHTML = `
+        <td>${routine.Featured ? '<span class="badge text-bg-warning me-1">Destacada</span>' : ''}<strong>${routine.Name}</strong></td>
+        <td>${routine.MuscleGroups.join(', ') || '-'}</td>
+        <td>${routine.ExcerciseList ? routine.ExcerciseList.length : 0}</td>
+        <td><a href="user-routine-view.html?id=${routine.ID}" class="btn btn-outline-info btn-sm">Ver / Copiar</a></td>
+      `;
+      tableBody.appendChild(row);
+    });
+  } catch (error) {
+    console.error('Error al cargar la biblioteca:', error);
+    tableBody.innerHTML = '<tr><td colspan="4" class="text-danger">Error al cargar la biblioteca.</td></tr>';
+  }
+}
+
+/**
+ * Descarga todas las rutinas del usuario en JSON o CSV.
+ */
+async function handleExport(format) {
+  const response = await fetchApi(`/api/routines/export?format=${format}`);
+  if (!response.ok) {
+    const err = await response.json();
+    document.getElementById('error_msg').textContent = err.error || 'No se pudieron exportar las rutinas.';
+    return;
+  }
+  const blob = await response.blob();
+  const link = document.createElement('a');
+  link.href = URL.createObjectURL(blob);
+  link.download = `rutinas.${format}`;
+  link.click();
+  URL.revokeObjectURL(link.href);
+}
+
+/**
+ * Envía el archivo elegido a la importación. Con dryRun solo muestra qué se importaría y qué ejercicios no se encontraron.
+ */
+async function handleImport(dryRun) {
+  const file = document.getElementById('import_file').files[0];
+  const resultEl = document.getElementById('import_result');
+  if (!file) {
+    resultEl.innerHTML = '<p class="text-danger">Elige un archivo JSON o CSV.</p>';
+    return;
+  }
+  const format = file.name.toLowerCase().endsWith('.csv') ? 'csv' : 'json';
+
+  const response = await fetchApi(`/api/routines/import?format=${format}&dry_run=${dryRun}`, {
+    method: 'POST',
+    headers: { 'Content-Type': format === 'csv' ? 'text/csv' : 'application/json' },
+    body: await file.text()
+  });
+  const data = await response.json();
+  if (!response.ok) {
+    resultEl.innerHTML = `<p class="text-danger">${data.error || 'No se pudo leer el archivo.'}</p>`;
+    return;
+  }
+
+  const STATUS = { ready: 'Se importará', created: 'Importada', skipped: 'No se importa' };
+  resultEl.innerHTML = data.routines.map(r => `
+    <div class="mb-2">
+      <strong>${r.name}</strong> · ${STATUS[r.status] || r.status} (${r.exercises} ejercicios)
+      ${(r.errors || []).map(e => `<div class="text-danger small">${e}</div>`).join('')}
+      ${(r.unmatched || []).map(u => `<div class="text-warning small">Fila ${u.position + 1}: ${u.exercise_name || u.exercise_id} — ${u.reason}</div>`).join('')}
+    </div>`).join('');
+
+  // el boton de confirmar solo aparece si la vista previa tiene algo para importar
+  document.getElementById('btn_import_confirm').classList.toggle('d-none', !dryRun || data.created === 0);
+  if (!dryRun) {
+    loadRoutines();
+  }
+}
+
 // --- Inicialización ---
 document.addEventListener('DOMContentLoaded', () => {
   // 1. Cargar las rutinas al iniciar
   loadRoutines();
+  loadLibrary();
+  document.getElementById('btn_library_search').addEventListener('click', loadLibrary);
+  document.getElementById('btn_export_json').addEventListener('click', () => handleExport('json'));
+  document.getElementById('btn_export_csv').addEventListener('click', () => handleExport('csv'));
+  document.getElementById('btn_import_preview').addEventListener('click', () => handleImport(true));
+  document.getElementById('btn_import_confirm').addEventListener('click', () => handleImport(false));
 
   // 2. Escuchar clics en la tabla para los botones de eliminar
   const tableBody = document.getElementById('routines-table-body');
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateSecureToken genera un token aleatorio en hexadecimal para URLs secretas (ej. feed de calendario)
+func GenerateSecureToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// EscapeICalText escapa un texto para usarlo como valor en un archivo iCalendar (RFC 5545)
+func EscapeICalText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// FoldICalLine corta las lineas de mas de 75 octetos como pide el RFC 5545, sin partir caracteres UTF-8
+func FoldICalLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var folded strings.Builder
+	current := 0
+	for _, r := range line {
+		size := len(string(r))
+		if current+size > limit {
+			folded.WriteString("\r\n ")
+			current = 1 // el espacio de continuacion cuenta
+		}
+		folded.WriteRune(r)
+		current += size
+	}
+	return folded.String()
+}
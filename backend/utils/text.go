@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// NormalizeText pasa a minusculas, quita acentos y signos y deja las palabras separadas por un espacio, para
+// comparar nombres escritos de distinta forma ("Press Banca (Barra)" -> "press banca barra")
+func NormalizeText(text string) string {
+	text = accentReplacer.Replace(strings.ToLower(text))
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Similarity compara dos textos ya normalizados y devuelve un puntaje entre 0 y 1: el mayor entre la similitud por
+// distancia de edicion (errores de tipeo) y la proporcion de palabras compartidas (palabras en otro orden)
+func Similarity(a string, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	edit := 1 - float64(Levenshtein(a, b))/float64(max(len(ra), len(rb)))
+
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	inB := make(map[string]bool, len(wordsB))
+	for _, w := range wordsB {
+		inB[w] = true
+	}
+	common := 0
+	for _, w := range wordsA {
+		if inB[w] {
+			common++
+			delete(inB, w)
+		}
+	}
+	words := 2 * float64(common) / float64(len(wordsA)+len(wordsB))
+
+	return max(edit, words)
+}
+
+// Levenshtein es la cantidad minima de letras a insertar, borrar o cambiar para pasar de un texto al otro
+func Levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}